@@ -0,0 +1,342 @@
+// Package charrow converts ch-go columns to Apache Arrow records and back.
+//
+// Types are mapped as follows:
+//
+//	Int8..Int64, UInt8..UInt64  Int8..Int64, Uint8..Uint64
+//	Float32, Float64            Float32, Float64
+//	Bool                        Boolean
+//	String                      String
+//	FixedString(N)              FixedSizeBinary(N)
+//	UUID                        FixedSizeBinary(16), or arrow.uuid extension on insertion
+//	Date, Date32                Date32
+//	DateTime                    Timestamp(s)
+//	DateTime64(P)               Timestamp(s, ms, us or ns), P is rounded up
+//	Decimal(P, S)               Decimal32, Decimal64, Decimal128 or Decimal256(P, S)
+//	Nullable(T)                 T with validity bitmap
+//	LowCardinality(T)           Dictionary(Int32, T)
+//	Array(T)                    List(T)
+//	Map(K, V)                   Map(K, V)
+//	Tuple(T1, T2, ...)          Struct(T1, T2, ...)
+//
+// Timezones of DateTime and DateTime64 are preserved. Other types are not
+// supported.
+//
+// Fixed-size columns are shared with Arrow arrays without copying when
+// memory layout allows it.
+package charrow
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// DataType returns Arrow type of ClickHouse column type.
+func DataType(t proto.ColumnType) (arrow.DataType, error) {
+	typ, err := proto.ParseType(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse type")
+	}
+	return dataType(typ)
+}
+
+// Field returns Arrow field of ClickHouse column.
+func Field(name string, t proto.ColumnType) (arrow.Field, error) {
+	typ, err := proto.ParseType(t)
+	if err != nil {
+		return arrow.Field{}, errors.Wrap(err, "parse type")
+	}
+	dt, err := dataType(typ)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+	return arrow.Field{Name: name, Type: dt, Nullable: nullable(typ)}, nil
+}
+
+// nullable reports whether values of t can be NULL.
+func nullable(t proto.Type) bool {
+	switch t.Name {
+	case proto.ColumnTypeNullable:
+		return true
+	case proto.ColumnTypeLowCardinality:
+		return nullable(t.Elems[0])
+	default:
+		return false
+	}
+}
+
+// timeUnit returns Arrow time unit and its precision that can hold
+// values of DateTime64 with precision p.
+func timeUnit(p int) (arrow.TimeUnit, int) {
+	switch {
+	case p == 0:
+		return arrow.Second, 0
+	case p <= 3:
+		return arrow.Millisecond, 3
+	case p <= 6:
+		return arrow.Microsecond, 6
+	default:
+		return arrow.Nanosecond, 9
+	}
+}
+
+// decimalSize returns size in bytes of decimal with precision p.
+func decimalSize(p int) int {
+	switch {
+	case p < 10:
+		return 4
+	case p < 19:
+		return 8
+	case p < 39:
+		return 16
+	default:
+		return 32
+	}
+}
+
+func dataType(t proto.Type) (arrow.DataType, error) {
+	switch t.Name {
+	case proto.ColumnTypeInt8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case proto.ColumnTypeInt16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case proto.ColumnTypeInt32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case proto.ColumnTypeInt64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case proto.ColumnTypeUInt8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case proto.ColumnTypeUInt16:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case proto.ColumnTypeUInt32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case proto.ColumnTypeUInt64:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case proto.ColumnTypeFloat32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case proto.ColumnTypeFloat64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case proto.ColumnTypeBool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case proto.ColumnTypeString:
+		return arrow.BinaryTypes.String, nil
+	case proto.ColumnTypeFixedString:
+		return &arrow.FixedSizeBinaryType{ByteWidth: t.Size}, nil
+	case proto.ColumnTypeUUID:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 16}, nil
+	case proto.ColumnTypeDate, proto.ColumnTypeDate32:
+		return arrow.FixedWidthTypes.Date32, nil
+	case proto.ColumnTypeDateTime:
+		return &arrow.TimestampType{Unit: arrow.Second, TimeZone: t.Timezone}, nil
+	case proto.ColumnTypeDateTime64:
+		unit, _ := timeUnit(t.Precision)
+		return &arrow.TimestampType{Unit: unit, TimeZone: t.Timezone}, nil
+	case proto.ColumnTypeDecimal, proto.ColumnTypeDecimal32, proto.ColumnTypeDecimal64,
+		proto.ColumnTypeDecimal128, proto.ColumnTypeDecimal256:
+		p, s := int32(t.Precision), int32(t.Scale)
+		switch decimalSize(t.Precision) {
+		case 4:
+			return &arrow.Decimal32Type{Precision: p, Scale: s}, nil
+		case 8:
+			return &arrow.Decimal64Type{Precision: p, Scale: s}, nil
+		case 16:
+			return &arrow.Decimal128Type{Precision: p, Scale: s}, nil
+		default:
+			return &arrow.Decimal256Type{Precision: p, Scale: s}, nil
+		}
+	case proto.ColumnTypeNullable:
+		return dataType(t.Elems[0])
+	case proto.ColumnTypeLowCardinality:
+		elem := t.Elems[0]
+		if elem.Name == proto.ColumnTypeNullable {
+			elem = elem.Elems[0]
+		}
+		v, err := dataType(elem)
+		if err != nil {
+			return nil, errors.Wrap(err, "low cardinality")
+		}
+		return &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: v}, nil
+	case proto.ColumnTypeArray:
+		v, err := dataType(t.Elems[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "array")
+		}
+		return arrow.ListOfField(arrow.Field{Name: "item", Type: v, Nullable: nullable(t.Elems[0])}), nil
+	case proto.ColumnTypeMap:
+		k, err := dataType(t.Elems[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "map key")
+		}
+		v, err := dataType(t.Elems[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "map value")
+		}
+		return arrow.MapOfFields(
+			arrow.Field{Type: k},
+			arrow.Field{Type: v, Nullable: nullable(t.Elems[1])},
+		), nil
+	case proto.ColumnTypeTuple:
+		fields := make([]arrow.Field, len(t.Elems))
+		for i, e := range t.Elems {
+			v, err := dataType(e)
+			if err != nil {
+				return nil, errors.Wrapf(err, "tuple [%d]", i)
+			}
+			// ClickHouse names elements of unnamed tuples by position.
+			name := strconv.Itoa(i + 1)
+			if i < len(t.Names) && t.Names[i] != "" {
+				name = t.Names[i]
+			}
+			fields[i] = arrow.Field{Name: name, Type: v, Nullable: nullable(e)}
+		}
+		return arrow.StructOf(fields...), nil
+	default:
+		return nil, errors.Errorf("type %s is not supported", t)
+	}
+}
+
+// ColumnType returns ClickHouse column type of Arrow field.
+func ColumnType(f arrow.Field) (proto.ColumnType, error) {
+	return columnType(f.Type, f.Nullable)
+}
+
+func columnType(dt arrow.DataType, null bool) (proto.ColumnType, error) {
+	var t proto.ColumnType
+	switch dt := dt.(type) {
+	case *arrow.Int8Type:
+		t = proto.ColumnTypeInt8
+	case *arrow.Int16Type:
+		t = proto.ColumnTypeInt16
+	case *arrow.Int32Type:
+		t = proto.ColumnTypeInt32
+	case *arrow.Int64Type:
+		t = proto.ColumnTypeInt64
+	case *arrow.Uint8Type:
+		t = proto.ColumnTypeUInt8
+	case *arrow.Uint16Type:
+		t = proto.ColumnTypeUInt16
+	case *arrow.Uint32Type:
+		t = proto.ColumnTypeUInt32
+	case *arrow.Uint64Type:
+		t = proto.ColumnTypeUInt64
+	case *arrow.Float32Type:
+		t = proto.ColumnTypeFloat32
+	case *arrow.Float64Type:
+		t = proto.ColumnTypeFloat64
+	case *arrow.BooleanType:
+		t = proto.ColumnTypeBool
+	case *arrow.StringType, *arrow.LargeStringType, *arrow.BinaryType, *arrow.LargeBinaryType:
+		t = proto.ColumnTypeString
+	case *arrow.FixedSizeBinaryType:
+		t = proto.ColumnTypeFixedString.With(strconv.Itoa(dt.ByteWidth))
+	case *arrow.Date32Type:
+		t = proto.ColumnTypeDate32
+	case *arrow.TimestampType:
+		switch dt.Unit {
+		case arrow.Second:
+			t = proto.ColumnTypeDateTime
+		case arrow.Millisecond:
+			t = proto.ColumnTypeDateTime64.With("3")
+		case arrow.Microsecond:
+			t = proto.ColumnTypeDateTime64.With("6")
+		default:
+			t = proto.ColumnTypeDateTime64.With("9")
+		}
+		if dt.TimeZone != "" {
+			t = withTimezone(t, dt.TimeZone)
+		}
+	case arrow.DecimalType:
+		t = proto.ColumnTypeDecimal.With(
+			strconv.Itoa(int(dt.GetPrecision())),
+			strconv.Itoa(int(dt.GetScale())),
+		)
+	case arrow.ExtensionType:
+		if dt.ExtensionName() != "arrow.uuid" {
+			return "", errors.Errorf("extension type %s is not supported", dt.ExtensionName())
+		}
+		t = proto.ColumnTypeUUID
+	case *arrow.DictionaryType:
+		v, err := columnType(dt.ValueType, null)
+		if err != nil {
+			return "", errors.Wrap(err, "dictionary")
+		}
+		return proto.ColumnTypeLowCardinality.Sub(v), nil
+	case *arrow.MapType:
+		k, err := columnType(dt.KeyType(), false)
+		if err != nil {
+			return "", errors.Wrap(err, "map key")
+		}
+		v, err := columnType(dt.ItemType(), dt.ItemField().Nullable)
+		if err != nil {
+			return "", errors.Wrap(err, "map value")
+		}
+		return proto.ColumnTypeMap.With(string(k), string(v)), nil
+	case *arrow.ListType:
+		return arrayType(dt.ElemField())
+	case *arrow.LargeListType:
+		return arrayType(dt.ElemField())
+	case *arrow.StructType:
+		var (
+			elems = make([]string, dt.NumFields())
+			named bool
+		)
+		for i, f := range dt.Fields() {
+			v, err := columnType(f.Type, f.Nullable)
+			if err != nil {
+				return "", errors.Wrapf(err, "struct field %q", f.Name)
+			}
+			elems[i] = string(v)
+			// Fields named by position are elements of unnamed tuple.
+			named = named || f.Name != strconv.Itoa(i+1)
+		}
+		if named {
+			for i, f := range dt.Fields() {
+				elems[i] = quoteName(f.Name) + " " + elems[i]
+			}
+		}
+		return proto.ColumnTypeTuple.With(elems...), nil
+	default:
+		return "", errors.Errorf("type %s is not supported", dt)
+	}
+	if null {
+		t = proto.ColumnTypeNullable.Sub(t)
+	}
+	return t, nil
+}
+
+func arrayType(elem arrow.Field) (proto.ColumnType, error) {
+	v, err := columnType(elem.Type, elem.Nullable)
+	if err != nil {
+		return "", errors.Wrap(err, "list")
+	}
+	return proto.ColumnTypeArray.Sub(v), nil
+}
+
+// withTimezone sets timezone of DateTime or DateTime64 type.
+func withTimezone(t proto.ColumnType, tz string) proto.ColumnType {
+	quoted := "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(tz) + "'"
+	if t == proto.ColumnTypeDateTime {
+		return t.With(quoted)
+	}
+	return proto.ColumnType(strings.TrimSuffix(string(t), ")") + ", " + quoted + ")")
+}
+
+// quoteName quotes name of tuple element if it is not identifier.
+func quoteName(s string) string {
+	ident := s != ""
+	for i, c := range s {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		ident = false
+		break
+	}
+	if ident {
+		return s
+	}
+	return "`" + strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(s) + "`"
+}
@@ -0,0 +1,170 @@
+package charrow
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestDataType(t *testing.T) {
+	for _, tt := range []struct {
+		Type  proto.ColumnType
+		Arrow string
+	}{
+		{Type: "Int8", Arrow: "int8"},
+		{Type: "UInt64", Arrow: "uint64"},
+		{Type: "Float32", Arrow: "float32"},
+		{Type: "Bool", Arrow: "bool"},
+		{Type: "String", Arrow: "utf8"},
+		{Type: "FixedString(4)", Arrow: "fixed_size_binary[4]"},
+		{Type: "UUID", Arrow: "fixed_size_binary[16]"},
+		{Type: "Date", Arrow: "date32"},
+		{Type: "Date32", Arrow: "date32"},
+		{Type: "DateTime", Arrow: "timestamp[s]"},
+		{Type: "DateTime('Europe/Moscow')", Arrow: "timestamp[s, tz=Europe/Moscow]"},
+		{Type: "DateTime64(3, 'UTC')", Arrow: "timestamp[ms, tz=UTC]"},
+		{Type: "DateTime64(5)", Arrow: "timestamp[us]"},
+		{Type: "DateTime64(9)", Arrow: "timestamp[ns]"},
+		{Type: "Decimal(9, 2)", Arrow: "decimal32(9, 2)"},
+		{Type: "Decimal64(4)", Arrow: "decimal64(18, 4)"},
+		{Type: "Decimal(38, 10)", Arrow: "decimal(38, 10)"},
+		{Type: "Decimal256(20)", Arrow: "decimal256(76, 20)"},
+		{Type: "Nullable(String)", Arrow: "utf8"},
+		{Type: "LowCardinality(String)", Arrow: "dictionary<values=utf8, indices=int32, ordered=false>"},
+		{Type: "LowCardinality(Nullable(String))", Arrow: "dictionary<values=utf8, indices=int32, ordered=false>"},
+		{Type: "Array(Nullable(Int32))", Arrow: "list<item: int32, nullable>"},
+		{Type: "Array(Array(String))", Arrow: "list<item: list<item: utf8>>"},
+		{Type: "Map(String, Nullable(UInt64))", Arrow: "map<utf8, uint64, items_nullable>"},
+		{Type: "Tuple(Int8, String)", Arrow: "struct<1: int8, 2: utf8>"},
+		{Type: "Tuple(a Int8, b Nullable(String))", Arrow: "struct<a: int8, b: utf8 nullable>"},
+	} {
+		t.Run(tt.Type.String(), func(t *testing.T) {
+			dt, err := DataType(tt.Type)
+			require.NoError(t, err)
+			require.Equal(t, tt.Arrow, dt.String())
+		})
+	}
+	for _, typ := range []proto.ColumnType{
+		"Int128",
+		"Enum8('a' = 1)",
+		"Array(IPv4)",
+		"Variant(String, UInt64)",
+	} {
+		t.Run(typ.String(), func(t *testing.T) {
+			_, err := DataType(typ)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	f, err := Field("v", "LowCardinality(Nullable(String))")
+	require.NoError(t, err)
+	require.True(t, f.Nullable)
+	require.Equal(t, "v", f.Name)
+
+	f, err = Field("v", "Array(Nullable(String))")
+	require.NoError(t, err)
+	require.False(t, f.Nullable)
+}
+
+func TestColumnType(t *testing.T) {
+	for _, tt := range []struct {
+		Field arrow.Field
+		Type  proto.ColumnType
+	}{
+		{Field: arrow.Field{Type: arrow.PrimitiveTypes.Int64}, Type: "Int64"},
+		{Field: arrow.Field{Type: arrow.PrimitiveTypes.Uint8, Nullable: true}, Type: "Nullable(UInt8)"},
+		{Field: arrow.Field{Type: arrow.BinaryTypes.LargeString}, Type: "String"},
+		{Field: arrow.Field{Type: arrow.BinaryTypes.Binary}, Type: "String"},
+		{Field: arrow.Field{Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}}, Type: "FixedString(8)"},
+		{Field: arrow.Field{Type: arrow.FixedWidthTypes.Date32}, Type: "Date32"},
+		{Field: arrow.Field{Type: &arrow.TimestampType{Unit: arrow.Second}}, Type: "DateTime"},
+		{
+			Field: arrow.Field{Type: &arrow.TimestampType{Unit: arrow.Second, TimeZone: "Europe/Moscow"}},
+			Type:  "DateTime('Europe/Moscow')",
+		},
+		{
+			Field: arrow.Field{Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}},
+			Type:  "DateTime64(6, 'UTC')",
+		},
+		{Field: arrow.Field{Type: &arrow.TimestampType{Unit: arrow.Nanosecond}}, Type: "DateTime64(9)"},
+		{Field: arrow.Field{Type: &arrow.Decimal128Type{Precision: 5, Scale: 2}}, Type: "Decimal(5, 2)"},
+		{
+			Field: arrow.Field{
+				Type:     &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int8, ValueType: arrow.BinaryTypes.String},
+				Nullable: true,
+			},
+			Type: "LowCardinality(Nullable(String))",
+		},
+		{Field: arrow.Field{Type: arrow.ListOf(arrow.PrimitiveTypes.Int32)}, Type: "Array(Nullable(Int32))"},
+		{Field: arrow.Field{Type: arrow.LargeListOfNonNullable(arrow.BinaryTypes.String)}, Type: "Array(String)"},
+		{
+			Field: arrow.Field{Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Float64)},
+			Type:  "Map(String, Nullable(Float64))",
+		},
+		{
+			Field: arrow.Field{Type: arrow.StructOf(
+				arrow.Field{Name: "1", Type: arrow.PrimitiveTypes.Int8},
+				arrow.Field{Name: "2", Type: arrow.BinaryTypes.String},
+			)},
+			Type: "Tuple(Int8, String)",
+		},
+		{
+			Field: arrow.Field{Type: arrow.StructOf(
+				arrow.Field{Name: "a", Type: arrow.PrimitiveTypes.Int8},
+				arrow.Field{Name: "b c", Type: arrow.BinaryTypes.String, Nullable: true},
+			)},
+			Type: "Tuple(a Int8, `b c` Nullable(String))",
+		},
+	} {
+		t.Run(tt.Type.String(), func(t *testing.T) {
+			typ, err := ColumnType(tt.Field)
+			require.NoError(t, err)
+			require.Equal(t, tt.Type, typ)
+			_, err = proto.ParseType(typ)
+			require.NoError(t, err)
+		})
+	}
+	for _, dt := range []arrow.DataType{
+		arrow.FixedWidthTypes.Float16,
+		arrow.FixedWidthTypes.Date64,
+		arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Int8),
+		arrow.ListOf(arrow.FixedWidthTypes.Duration_s),
+	} {
+		t.Run(dt.String(), func(t *testing.T) {
+			_, err := ColumnType(arrow.Field{Type: dt})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestColumnTypeRoundTrip(t *testing.T) {
+	for _, typ := range []proto.ColumnType{
+		"Int32",
+		"String",
+		"Nullable(Float64)",
+		"FixedString(3)",
+		"Date32",
+		"DateTime('UTC')",
+		"DateTime64(3)",
+		"Decimal(20, 4)",
+		"LowCardinality(String)",
+		"LowCardinality(Nullable(String))",
+		"Array(Array(Nullable(String)))",
+		"Map(String, Array(UInt64))",
+		"Tuple(UInt8, Nullable(String))",
+		"Tuple(a UInt8, b Map(String, String))",
+	} {
+		t.Run(typ.String(), func(t *testing.T) {
+			f, err := Field("v", typ)
+			require.NoError(t, err)
+			got, err := ColumnType(f)
+			require.NoError(t, err)
+			require.Equal(t, typ, got)
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package charrow
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Input returns columns of record for insertion.
+//
+// Call Column.Set for each column to insert next record, e.g. in OnInput.
+func Input(rec arrow.RecordBatch) (proto.Input, error) {
+	input := make(proto.Input, rec.NumCols())
+	for i, f := range rec.Schema().Fields() {
+		c, err := NewColumn(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %q", f.Name)
+		}
+		if err := c.Set(rec.Column(i)); err != nil {
+			input.Reset()
+			return nil, errors.Wrapf(err, "field %q", f.Name)
+		}
+		input[i] = proto.InputColumn{Name: f.Name, Data: c}
+	}
+	return input, nil
+}
+
+// Column is proto.ColInput of Arrow array.
+//
+// Array is encoded on Set. Fixed-size values are written directly from
+// array memory, so array is retained until Reset or next Set.
+type Column struct {
+	field arrow.Field
+	typ   proto.ColumnType
+	t     proto.Type
+
+	arr   arrow.Array
+	state []byte
+	parts [][]byte
+}
+
+// Compile-time assertions for Column.
+var (
+	_ proto.ColInput     = (*Column)(nil)
+	_ proto.StateEncoder = (*Column)(nil)
+	_ proto.Resettable   = (*Column)(nil)
+)
+
+// NewColumn returns Column for arrays of field.
+func NewColumn(f arrow.Field) (*Column, error) {
+	typ, err := ColumnType(f)
+	if err != nil {
+		return nil, err
+	}
+	t, err := proto.ParseType(typ)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse type")
+	}
+	return &Column{field: f, typ: typ, t: t}, nil
+}
+
+// Set encodes arr as column data.
+func (c *Column) Set(arr arrow.Array) error {
+	if !arrow.TypeEqual(arr.DataType(), c.field.Type) {
+		return errors.Errorf("got %s, expected %s", arr.DataType(), c.field.Type)
+	}
+	c.Reset()
+
+	var e encoder
+	e.encodeState(c.t)
+	c.state = e.buf
+	e.buf = nil
+	if err := e.encode(c.t, arr); err != nil {
+		return err
+	}
+	c.parts = e.finish()
+	arr.Retain()
+	c.arr = arr
+	return nil
+}
+
+// Type returns ClickHouse type of column.
+func (c *Column) Type() proto.ColumnType { return c.typ }
+
+// Rows returns count of rows.
+func (c *Column) Rows() int {
+	if c.arr == nil {
+		return 0
+	}
+	return c.arr.Len()
+}
+
+// EncodeState implements proto.StateEncoder.
+func (c *Column) EncodeState(b *proto.Buffer) {
+	b.Buf = append(b.Buf, c.state...)
+}
+
+// EncodeColumn implements proto.ColInput.
+func (c *Column) EncodeColumn(b *proto.Buffer) {
+	for _, p := range c.parts {
+		b.Buf = append(b.Buf, p...)
+	}
+}
+
+// WriteColumn implements proto.ColInput.
+func (c *Column) WriteColumn(w *proto.Writer) {
+	for _, p := range c.parts {
+		w.ChainWrite(p)
+	}
+}
+
+// Reset releases array.
+func (c *Column) Reset() {
+	if c.arr != nil {
+		c.arr.Release()
+		c.arr = nil
+	}
+	c.state = nil
+	c.parts = nil
+}
+
+// encoder writes arrays in Native format.
+type encoder struct {
+	parts [][]byte
+	buf   []byte // not yet in parts
+}
+
+// share appends b to encoded data without copying.
+func (e *encoder) share(b []byte) {
+	if len(e.buf) > 0 {
+		e.parts = append(e.parts, e.buf)
+		e.buf = nil
+	}
+	e.parts = append(e.parts, b)
+}
+
+func (e *encoder) putUInt64(v uint64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+}
+
+// finish returns encoded data and resets encoder.
+func (e *encoder) finish() [][]byte {
+	parts := e.parts
+	if len(e.buf) > 0 {
+		parts = append(parts, e.buf)
+	}
+	e.parts, e.buf = nil, nil
+	return parts
+}
+
+// encodeState writes serialization state prefix of column.
+func (e *encoder) encodeState(t proto.Type) {
+	switch t.Name {
+	case proto.ColumnTypeLowCardinality:
+		// Shared dictionaries with additional keys.
+		e.putUInt64(1)
+	case proto.ColumnTypeArray, proto.ColumnTypeNullable, proto.ColumnTypeMap, proto.ColumnTypeTuple:
+		for _, elem := range t.Elems {
+			e.encodeState(elem)
+		}
+	}
+}
+
+// encode writes values of arr as column of type t.
+func (e *encoder) encode(t proto.Type, arr arrow.Array) error {
+	if arr.NullN() > 0 && !nullable(t) {
+		return errors.Errorf("%s: unexpected NULL", t)
+	}
+	return e.values(t, arr)
+}
+
+// fixedValues returns memory of fixed-size values of arr.
+func fixedValues(arr arrow.Array, size int) []byte {
+	data := arr.Data()
+	if len(data.Buffers()) < 2 || data.Buffers()[1] == nil {
+		return nil
+	}
+	return data.Buffers()[1].Bytes()[data.Offset()*size : (data.Offset()+data.Len())*size]
+}
+
+// values writes values of arr, ignoring NULL values.
+func (e *encoder) values(t proto.Type, arr arrow.Array) error {
+	switch t.Name {
+	case proto.ColumnTypeDecimal, proto.ColumnTypeDecimal32, proto.ColumnTypeDecimal64,
+		proto.ColumnTypeDecimal128, proto.ColumnTypeDecimal256:
+		var (
+			n = decimalSize(t.Precision)
+			w = arr.DataType().(arrow.DecimalType).BitWidth() / 8
+			v = fixedValues(arr, w)
+		)
+		if n == w {
+			e.share(v)
+			return nil
+		}
+		// Truncating two's complement little-endian value that fits into
+		// smaller decimal by precision.
+		for i := 0; i < len(v); i += w {
+			e.buf = append(e.buf, v[i:i+n]...)
+		}
+		return nil
+	}
+	if n := size(t); n > 0 {
+		e.share(fixedValues(arr, n))
+		return nil
+	}
+	switch t.Name {
+	case proto.ColumnTypeBool:
+		a := arr.(*array.Boolean)
+		for i := range a.Len() {
+			var v byte
+			if a.Value(i) {
+				v = 1
+			}
+			e.buf = append(e.buf, v)
+		}
+	case proto.ColumnTypeString:
+		for i := range arr.Len() {
+			var v []byte
+			switch a := arr.(type) {
+			case *array.String:
+				v = []byte(a.Value(i))
+			case *array.LargeString:
+				v = []byte(a.Value(i))
+			case *array.Binary:
+				v = a.Value(i)
+			case *array.LargeBinary:
+				v = a.Value(i)
+			}
+			e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+			e.buf = append(e.buf, v...)
+		}
+	case proto.ColumnTypeUUID:
+		// Native format stores UUID as two little-endian 64-bit halves.
+		v := fixedValues(arr.(array.ExtensionArray).Storage(), 16)
+		for i := 0; i < len(v); i += 8 {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, binary.BigEndian.Uint64(v[i:]))
+		}
+	case proto.ColumnTypeDateTime:
+		a := arr.(*array.Timestamp)
+		for i := range a.Len() {
+			v := int64(a.Value(i))
+			if a.IsValid(i) && (v < 0 || v > math.MaxUint32) {
+				return errors.Errorf("datetime: %d is out of range", v)
+			}
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v))
+		}
+	case proto.ColumnTypeNullable:
+		for i := range arr.Len() {
+			var v byte
+			if arr.IsNull(i) {
+				v = 1
+			}
+			e.buf = append(e.buf, v)
+		}
+		return e.values(t.Elems[0], arr)
+	case proto.ColumnTypeLowCardinality:
+		return e.lowCardinality(t, arr.(*array.Dictionary))
+	case proto.ColumnTypeArray:
+		a := arr.(array.ListLike)
+		return e.nested(a, func(start, end int64) error {
+			v := array.NewSlice(a.ListValues(), start, end)
+			defer v.Release()
+			return e.encode(t.Elems[0], v)
+		})
+	case proto.ColumnTypeMap:
+		a := arr.(*array.Map)
+		return e.nested(a, func(start, end int64) error {
+			k := array.NewSlice(a.Keys(), start, end)
+			defer k.Release()
+			if err := e.encode(t.Elems[0], k); err != nil {
+				return err
+			}
+			v := array.NewSlice(a.Items(), start, end)
+			defer v.Release()
+			return e.encode(t.Elems[1], v)
+		})
+	case proto.ColumnTypeTuple:
+		a := arr.(*array.Struct)
+		for i, elem := range t.Elems {
+			if err := e.encode(elem, a.Field(i)); err != nil {
+				return errors.Wrapf(err, "tuple [%d]", i)
+			}
+		}
+	default:
+		return errors.Errorf("type %s is not supported", t)
+	}
+	return nil
+}
+
+// nested writes offsets of Array or Map and range of values that
+// rows refer to.
+func (e *encoder) nested(arr array.ListLike, values func(start, end int64) error) error {
+	if arr.Len() == 0 {
+		return values(0, 0)
+	}
+	first, _ := arr.ValueOffsets(0)
+	var last int64
+	for i := range arr.Len() {
+		_, last = arr.ValueOffsets(i)
+		e.putUInt64(uint64(last - first))
+	}
+	return values(first, last)
+}
+
+// lowCardinality writes dictionary as single granule with additional keys.
+func (e *encoder) lowCardinality(t proto.Type, arr *array.Dictionary) error {
+	if arr.Len() == 0 {
+		return nil
+	}
+	var (
+		elem = t.Elems[0]
+		null = elem.Name == proto.ColumnTypeNullable
+		dict = arr.Dictionary()
+		n    = dict.Len()
+	)
+	if null {
+		// First key is reserved for NULL.
+		elem = elem.Elems[0]
+		n++
+	}
+	var key, keySize uint64
+	switch {
+	case n <= math.MaxUint8+1:
+		key, keySize = 0, 1
+	case n <= math.MaxUint16+1:
+		key, keySize = 1, 2
+	default:
+		key, keySize = 2, 4
+	}
+	const (
+		hasAdditionalKeys    = 1 << 9
+		needUpdateDictionary = 1 << 10
+	)
+	e.putUInt64(hasAdditionalKeys | needUpdateDictionary | key)
+	e.putUInt64(uint64(n))
+	if null {
+		e.buf = append(e.buf, zero(elem)...)
+	}
+	if err := e.values(elem, dict); err != nil {
+		return errors.Wrap(err, "dictionary")
+	}
+	e.putUInt64(uint64(arr.Len()))
+	for i := range arr.Len() {
+		var k uint64
+		switch idx := arr.GetValueIndex(i); {
+		case arr.IsNull(i) || dict.IsNull(idx):
+			if !null {
+				return errors.Errorf("%s: unexpected NULL", t)
+			}
+		case null:
+			k = uint64(idx) + 1
+		default:
+			k = uint64(idx)
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], k)
+		e.buf = append(e.buf, b[:keySize]...)
+	}
+	return nil
+}
+
+// zero returns default value of type t in Native format.
+func zero(t proto.Type) []byte {
+	switch t.Name {
+	case proto.ColumnTypeString, proto.ColumnTypeBool:
+		return []byte{0}
+	case proto.ColumnTypeDate:
+		return make([]byte, 2)
+	case proto.ColumnTypeDateTime:
+		return make([]byte, 4)
+	case proto.ColumnTypeUUID:
+		return make([]byte, 16)
+	default:
+		return make([]byte, size(t))
+	}
+}
@@ -0,0 +1,294 @@
+package charrow
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/extensions"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// native encodes column in Native format and decodes it back.
+func native(t *testing.T, c *Column) arrow.Array {
+	t.Helper()
+
+	var b proto.Buffer
+	c.EncodeState(&b)
+	c.EncodeColumn(&b)
+	d := &decoder{buf: b.Buf}
+	require.NoError(t, d.state(c.t))
+	data, err := d.data(c.t, c.Rows())
+	require.NoError(t, err)
+	require.Empty(t, d.buf)
+	defer data.Release()
+	return array.MakeFromData(data)
+}
+
+func TestInput(t *testing.T) {
+	var (
+		ids     proto.ColUInt64
+		names   proto.ColStr
+		comment = new(proto.ColStr).Nullable()
+		tags    = new(proto.ColStr).LowCardinality()
+		values  = new(proto.ColInt32).Array()
+		attrs   = proto.NewMap[string, uint64](new(proto.ColStr), new(proto.ColUInt64))
+		pair    = proto.ColTuple{new(proto.ColInt8), new(proto.ColStr)}
+		ts      = new(proto.ColDateTime64).WithPrecision(proto.PrecisionMilli).WithLocation(time.UTC)
+		created = &proto.ColDateTime{Location: time.UTC}
+		day     proto.ColDate32
+		hash    = new(proto.ColFixedStr)
+		ok      proto.ColBool
+		price   = new(proto.ColAuto)
+	)
+	hash.SetSize(2)
+	require.NoError(t, price.Infer("Decimal(9, 2)"))
+	start := time.Date(2024, 3, 1, 10, 20, 30, 400_000_000, time.UTC)
+	for i, s := range []string{"foo", "bar", "baz"} {
+		ids.Append(uint64(i + 1))
+		names.Append(s)
+		if i == 1 {
+			comment.Append(proto.Null[string]())
+		} else {
+			comment.Append(proto.NewNullable(s + "!"))
+		}
+		tags.Append([]string{"a", "b", "a"}[i])
+		values.Append(make([]int32, i))
+		attrs.Append(map[string]uint64{s: uint64(i)})
+		pair[0].(*proto.ColInt8).Append(int8(i))
+		pair[1].(*proto.ColStr).Append(s)
+		ts.Append(start.Add(time.Duration(i) * time.Second))
+		created.Append(start)
+		day.Append(start)
+		hash.Append([]byte(s[:2]))
+		ok.Append(i%2 == 0)
+		price.Data.(*proto.ColDecimal32).Append(proto.Decimal32(1050 + i))
+	}
+	expected := proto.Input{
+		{Name: "id", Data: &ids},
+		{Name: "name", Data: &names},
+		{Name: "comment", Data: comment},
+		{Name: "tag", Data: tags},
+		{Name: "values", Data: values},
+		{Name: "attrs", Data: attrs},
+		{Name: "pair", Data: pair},
+		{Name: "ts", Data: ts},
+		{Name: "created", Data: created},
+		{Name: "day", Data: &day},
+		{Name: "hash", Data: hash},
+		{Name: "ok", Data: &ok},
+		{Name: "price", Data: price},
+	}
+	var results proto.Results
+	for _, c := range expected {
+		results = append(results, proto.ResultColumn{Name: c.Name, Data: c.Data.(proto.ColResult)})
+	}
+	rec, err := Record(results)
+	require.NoError(t, err)
+	defer rec.Release()
+
+	input, err := Input(rec)
+	require.NoError(t, err)
+	defer input.Reset()
+	for i, c := range input {
+		require.Equal(t, expected[i].Data.Type(), c.Data.Type(), c.Name)
+	}
+
+	// Converted record is encoded exactly as original columns.
+	block := proto.Block{Columns: len(input), Rows: 3}
+	var want, got proto.Buffer
+	require.NoError(t, block.EncodeBlock(&want, proto.Version, expected))
+	require.NoError(t, block.EncodeBlock(&got, proto.Version, input))
+	require.Equal(t, want.Buf, got.Buf)
+
+	t.Run("Write", func(t *testing.T) {
+		var out bytes.Buffer
+		w := proto.NewWriter(&out, new(proto.Buffer))
+		require.NoError(t, block.WriteBlock(w, proto.Version, input))
+		_, err := w.Flush()
+		require.NoError(t, err)
+		require.Equal(t, want.Buf, out.Bytes())
+	})
+	t.Run("Set", func(t *testing.T) {
+		ids[0] = 100
+		next, err := Record(results)
+		require.NoError(t, err)
+		defer next.Release()
+
+		c := input[0].Data.(*Column)
+		require.NoError(t, c.Set(next.Column(0)))
+		require.Equal(t, "[100 2 3]", native(t, c).String())
+		require.Error(t, c.Set(next.Column(1)))
+	})
+}
+
+func TestColumn(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.DefaultAllocator)
+	defer mem.AssertSize(t, 0)
+
+	for _, tt := range []struct {
+		Name     string
+		Field    arrow.Field
+		Append   func(b array.Builder)
+		Expected string
+	}{
+		{
+			Name:  "LowCardinality(Nullable(String))",
+			Field: arrow.Field{Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int16, ValueType: arrow.BinaryTypes.String}, Nullable: true},
+			Append: func(b array.Builder) {
+				v := b.(*array.BinaryDictionaryBuilder)
+				require.NoError(t, v.AppendString("a"))
+				v.AppendNull()
+				require.NoError(t, v.AppendString("b"))
+				require.NoError(t, v.AppendString("a"))
+			},
+			// First dictionary value is reserved for NULL.
+			Expected: `{ dictionary: ["" "a" "b"]
+  indices: [1 (null) 2 1] }`,
+		},
+		{
+			Name:  "Nullable(String) of binary",
+			Field: arrow.Field{Type: arrow.BinaryTypes.Binary, Nullable: true},
+			Append: func(b array.Builder) {
+				v := b.(*array.BinaryBuilder)
+				v.Append([]byte("foo"))
+				v.AppendNull()
+			},
+			Expected: `["foo" (null)]`,
+		},
+		{
+			Name:  "Decimal(5, 2) of Decimal128",
+			Field: arrow.Field{Type: &arrow.Decimal128Type{Precision: 5, Scale: 2}},
+			Append: func(b array.Builder) {
+				v := b.(*array.Decimal128Builder)
+				v.Append(decimal128.FromI64(-12345))
+				v.Append(decimal128.FromI64(99))
+			},
+			Expected: `[-123.45 0.99]`,
+		},
+		{
+			Name:  "Array(Array(Nullable(Int8))) of large list",
+			Field: arrow.Field{Type: arrow.LargeListOfNonNullable(arrow.ListOf(arrow.PrimitiveTypes.Int8))},
+			Append: func(b array.Builder) {
+				v := b.(*array.LargeListBuilder)
+				elem := v.ValueBuilder().(*array.ListBuilder)
+				values := elem.ValueBuilder().(*array.Int8Builder)
+				v.Append(true)
+				elem.Append(true)
+				values.AppendValues([]int8{1, 2}, nil)
+				elem.Append(true)
+				values.AppendNull()
+				v.Append(true)
+			},
+			Expected: `[[[1 2] [(null)]] []]`,
+		},
+		{
+			Name:  "Tuple(a UInt8, b Nullable(String))",
+			Field: arrow.Field{Type: arrow.StructOf(arrow.Field{Name: "a", Type: arrow.PrimitiveTypes.Uint8}, arrow.Field{Name: "b", Type: arrow.BinaryTypes.String, Nullable: true})},
+			Append: func(b array.Builder) {
+				v := b.(*array.StructBuilder)
+				v.AppendValues([]bool{true, true})
+				v.FieldBuilder(0).(*array.Uint8Builder).AppendValues([]uint8{1, 2}, nil)
+				v.FieldBuilder(1).(*array.StringBuilder).AppendValues([]string{"x", ""}, []bool{true, false})
+			},
+			Expected: `{[1 2] ["x" (null)]}`,
+		},
+		{
+			Name:  "Map(String, Nullable(Bool))",
+			Field: arrow.Field{Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.FixedWidthTypes.Boolean)},
+			Append: func(b array.Builder) {
+				v := b.(*array.MapBuilder)
+				v.Append(true)
+				v.KeyBuilder().(*array.StringBuilder).AppendValues([]string{"x", "y"}, nil)
+				v.ItemBuilder().(*array.BooleanBuilder).AppendValues([]bool{true, false}, []bool{true, false})
+				v.Append(true)
+			},
+			Expected: `[{["x" "y"] [true (null)]} {[] []}]`,
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			b := array.NewBuilder(mem, tt.Field.Type)
+			defer b.Release()
+			tt.Append(b)
+			arr := b.NewArray()
+			defer arr.Release()
+
+			c, err := NewColumn(tt.Field)
+			require.NoError(t, err)
+			require.Equal(t, tt.Name[:len(c.Type())], string(c.Type()))
+			require.NoError(t, c.Set(arr))
+			defer c.Reset()
+			require.Equal(t, tt.Expected, native(t, c).String())
+		})
+	}
+	t.Run("UUID", func(t *testing.T) {
+		b := extensions.NewUUIDBuilder(mem)
+		defer b.Release()
+		v := uuid.MustParse("bc0d55c6-5f1d-4a5b-9a88-8a8e2d7c1e2f")
+		b.Append(v)
+		arr := b.NewArray()
+		defer arr.Release()
+
+		c, err := NewColumn(arrow.Field{Type: arr.DataType()})
+		require.NoError(t, err)
+		require.Equal(t, proto.ColumnTypeUUID, c.Type())
+		require.NoError(t, c.Set(arr))
+		defer c.Reset()
+
+		var buf proto.Buffer
+		c.EncodeColumn(&buf)
+		var col proto.ColUUID
+		require.NoError(t, col.DecodeColumn(buf.Reader(), 1))
+		require.Equal(t, v, col[0])
+	})
+	t.Run("Slice", func(t *testing.T) {
+		b := array.NewListBuilder(mem, arrow.BinaryTypes.String)
+		defer b.Release()
+		values := b.ValueBuilder().(*array.StringBuilder)
+		for _, row := range [][]string{{"a"}, {"b", "c"}, {}, {"d"}} {
+			b.Append(true)
+			values.AppendValues(row, nil)
+		}
+		arr := b.NewArray()
+		defer arr.Release()
+		slice := array.NewSlice(arr, 1, 3)
+		defer slice.Release()
+
+		c, err := NewColumn(arrow.Field{Type: arr.DataType()})
+		require.NoError(t, err)
+		require.NoError(t, c.Set(slice))
+		defer c.Reset()
+		require.Equal(t, `[["b" "c"] []]`, native(t, c).String())
+	})
+	t.Run("UnexpectedNull", func(t *testing.T) {
+		b := array.NewInt64Builder(mem)
+		defer b.Release()
+		b.AppendNull()
+		arr := b.NewArray()
+		defer arr.Release()
+
+		c, err := NewColumn(arrow.Field{Type: arr.DataType()})
+		require.NoError(t, err)
+		require.ErrorContains(t, c.Set(arr), "unexpected NULL")
+		require.Zero(t, c.Rows())
+	})
+	t.Run("DateTimeOutOfRange", func(t *testing.T) {
+		dt := &arrow.TimestampType{Unit: arrow.Second}
+		b := array.NewTimestampBuilder(mem, dt)
+		defer b.Release()
+		b.Append(-1)
+		arr := b.NewArray()
+		defer arr.Release()
+
+		c, err := NewColumn(arrow.Field{Type: dt})
+		require.NoError(t, err)
+		require.ErrorContains(t, c.Set(arr), "out of range")
+	})
+}
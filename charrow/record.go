@@ -0,0 +1,553 @@
+package charrow
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/bitutil"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Record converts decoded block to Arrow record batch.
+//
+// Fixed-size columns, String and FixedString share memory with results, so
+// record is valid only until results are reset, e.g. on decoding of next
+// block. Release record when done.
+func Record(results proto.Results) (arrow.RecordBatch, error) {
+	var (
+		fields = make([]arrow.Field, len(results))
+		cols   = make([]arrow.Array, len(results))
+	)
+	defer func() {
+		for _, c := range cols {
+			if c != nil {
+				c.Release()
+			}
+		}
+	}()
+	for i, c := range results {
+		arr, t, err := convert(c.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q", c.Name)
+		}
+		cols[i] = arr
+		fields[i] = arrow.Field{Name: c.Name, Type: arr.DataType(), Nullable: nullable(t)}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	return array.NewRecordBatch(schema, cols, int64(results.Rows())), nil
+}
+
+// Array converts column to Arrow array, see Record.
+func Array(col proto.ColResult) (arrow.Array, error) {
+	arr, _, err := convert(col)
+	return arr, err
+}
+
+func convert(col proto.ColResult) (arrow.Array, proto.Type, error) {
+	t, err := proto.ParseType(col.Type())
+	if err != nil {
+		return nil, t, errors.Wrap(err, "parse type")
+	}
+	if v, ok := col.(*proto.ColAuto); ok {
+		col = v.Data
+	}
+	dt, err := dataType(t)
+	if err != nil {
+		return nil, t, err
+	}
+	data, err := shared(col, dt, t)
+	if err != nil {
+		return nil, t, err
+	}
+	if data == nil {
+		// Layout differs, converting from Native encoding of column.
+		if data, err = decode(col, t); err != nil {
+			return nil, t, err
+		}
+	}
+	defer data.Release()
+	return array.MakeFromData(data), t, nil
+}
+
+// shared returns array data that shares memory with column, or nil if
+// memory layout of column is not compatible with Arrow.
+func shared(col proto.ColResult, dt arrow.DataType, t proto.Type) (arrow.ArrayData, error) {
+	switch c := col.(type) {
+	case *proto.ColInt8:
+		return fixed(dt, *c), nil
+	case *proto.ColInt16:
+		return fixed(dt, *c), nil
+	case *proto.ColInt32:
+		return fixed(dt, *c), nil
+	case *proto.ColInt64:
+		return fixed(dt, *c), nil
+	case *proto.ColUInt8:
+		return fixed(dt, *c), nil
+	case *proto.ColUInt16:
+		return fixed(dt, *c), nil
+	case *proto.ColUInt32:
+		return fixed(dt, *c), nil
+	case *proto.ColUInt64:
+		return fixed(dt, *c), nil
+	case *proto.ColFloat32:
+		return fixed(dt, *c), nil
+	case *proto.ColFloat64:
+		return fixed(dt, *c), nil
+	case *proto.ColDate32:
+		return fixed(dt, *c), nil
+	case *proto.ColDecimal32:
+		return fixed(dt, *c), nil
+	case *proto.ColDecimal64:
+		return fixed(dt, *c), nil
+	case *proto.ColDecimal128:
+		// Both are two's complement little-endian 128-bit integers.
+		return fixed(dt, *c), nil
+	case *proto.ColDecimal256:
+		return fixed(dt, *c), nil
+	case *proto.ColUUID:
+		// uuid.UUID is in RFC 4122 byte order.
+		return fixed(dt, *c), nil
+	case *proto.ColDateTime64:
+		if _, p := timeUnit(t.Precision); p != t.Precision {
+			return nil, nil
+		}
+		return fixed(dt, c.Data), nil
+	case *proto.ColFixedStr:
+		return array.NewData(dt, c.Rows(), []*memory.Buffer{nil, memory.NewBufferBytes(c.Buf)}, nil, 0, 0), nil
+	case *proto.ColStr:
+		offsets := make([]int32, len(c.Pos)+1)
+		for i, p := range c.Pos {
+			if i == 0 {
+				offsets[0] = int32(p.Start)
+			} else if p.Start != c.Pos[i-1].End {
+				// Rows are not contiguous.
+				return nil, nil
+			}
+			if p.End > math.MaxInt32 {
+				return nil, errors.New("string data is too large")
+			}
+			offsets[i+1] = int32(p.End)
+		}
+		buffers := []*memory.Buffer{nil, memory.NewBufferBytes(bytesOf(offsets)), memory.NewBufferBytes(c.Buf)}
+		return array.NewData(dt, c.Rows(), buffers, nil, 0, 0), nil
+	default:
+		return nil, nil
+	}
+}
+
+// fixed returns array data that shares memory with v.
+func fixed[T any](dt arrow.DataType, v []T) arrow.ArrayData {
+	return array.NewData(dt, len(v), []*memory.Buffer{nil, memory.NewBufferBytes(bytesOf(v))}, nil, 0, 0)
+}
+
+// bytesOf returns memory of v as byte slice.
+func bytesOf[T any](v []T) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(v))), len(v)*int(unsafe.Sizeof(v[0]))) // #nosec G103
+}
+
+// decode converts column by decoding its Native encoding.
+func decode(col proto.ColResult, t proto.Type) (arrow.ArrayData, error) {
+	in, ok := col.(proto.ColInput)
+	if !ok {
+		return nil, errors.Errorf("can't encode %T", col)
+	}
+	if v, ok := in.(proto.Preparable); ok {
+		if err := v.Prepare(); err != nil {
+			return nil, errors.Wrap(err, "prepare")
+		}
+	}
+	var b proto.Buffer
+	if v, ok := in.(proto.StateEncoder); ok {
+		v.EncodeState(&b)
+	}
+	in.EncodeColumn(&b)
+
+	d := &decoder{buf: b.Buf}
+	if err := d.state(t); err != nil {
+		return nil, errors.Wrap(err, "state")
+	}
+	data, err := d.data(t, in.Rows())
+	if err != nil {
+		return nil, err
+	}
+	if len(d.buf) != 0 {
+		data.Release()
+		return nil, errors.Errorf("%d bytes left after decoding", len(d.buf))
+	}
+	return data, nil
+}
+
+// decoder reads array data from column in Native format.
+//
+// Fixed-size values are not copied, so buf should not be reused.
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || n > len(d.buf) {
+		return nil, errors.Errorf("need %d bytes, got %d", n, len(d.buf))
+	}
+	v := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	b, err := d.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// count reads row count of nested column.
+func (d *decoder) count() (int, error) {
+	v, err := d.uint64()
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxInt32 {
+		return 0, errors.Errorf("%d rows is too many", v)
+	}
+	return int(v), nil
+}
+
+// offsets reads Array or Map offsets and returns them as Arrow offsets.
+func (d *decoder) offsets(rows int) ([]int32, error) {
+	b, err := d.take(rows * 8)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make([]int32, rows+1)
+	for i := range rows {
+		v := binary.LittleEndian.Uint64(b[i*8:])
+		if v > math.MaxInt32 || int32(v) < offsets[i] {
+			return nil, errors.Errorf("invalid offset %d", v)
+		}
+		offsets[i+1] = int32(v)
+	}
+	return offsets, nil
+}
+
+// state reads serialization state prefix of column.
+func (d *decoder) state(t proto.Type) error {
+	switch t.Name {
+	case proto.ColumnTypeLowCardinality:
+		v, err := d.uint64()
+		if err != nil {
+			return errors.Wrap(err, "key serialization version")
+		}
+		if v != 1 {
+			return errors.Errorf("unsupported key serialization version %d", v)
+		}
+	case proto.ColumnTypeArray, proto.ColumnTypeNullable, proto.ColumnTypeMap, proto.ColumnTypeTuple:
+		for _, e := range t.Elems {
+			if err := d.state(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// size returns size of value of type t if it is the same in Native format
+// and Arrow.
+func size(t proto.Type) int {
+	switch t.Name {
+	case proto.ColumnTypeInt8, proto.ColumnTypeUInt8:
+		return 1
+	case proto.ColumnTypeInt16, proto.ColumnTypeUInt16:
+		return 2
+	case proto.ColumnTypeInt32, proto.ColumnTypeUInt32, proto.ColumnTypeFloat32, proto.ColumnTypeDate32:
+		return 4
+	case proto.ColumnTypeInt64, proto.ColumnTypeUInt64, proto.ColumnTypeFloat64:
+		return 8
+	case proto.ColumnTypeFixedString:
+		return t.Size
+	case proto.ColumnTypeDecimal, proto.ColumnTypeDecimal32, proto.ColumnTypeDecimal64,
+		proto.ColumnTypeDecimal128, proto.ColumnTypeDecimal256:
+		return decimalSize(t.Precision)
+	case proto.ColumnTypeDateTime64:
+		if _, p := timeUnit(t.Precision); p == t.Precision {
+			return 8
+		}
+	}
+	return 0
+}
+
+// data reads rows of type t.
+func (d *decoder) data(t proto.Type, rows int) (arrow.ArrayData, error) {
+	dt, err := dataType(t)
+	if err != nil {
+		return nil, err
+	}
+	if n := size(t); n > 0 {
+		b, err := d.take(rows * n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", t)
+		}
+		return array.NewData(dt, rows, []*memory.Buffer{nil, memory.NewBufferBytes(b)}, nil, 0, 0), nil
+	}
+	values := func(v []byte) arrow.ArrayData {
+		return array.NewData(dt, rows, []*memory.Buffer{nil, memory.NewBufferBytes(v)}, nil, 0, 0)
+	}
+	switch t.Name {
+	case proto.ColumnTypeBool:
+		b, err := d.take(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "bool")
+		}
+		v := make([]byte, bitutil.BytesForBits(int64(rows)))
+		for i, x := range b {
+			if x != 0 {
+				bitutil.SetBit(v, i)
+			}
+		}
+		return values(v), nil
+	case proto.ColumnTypeDate:
+		b, err := d.take(rows * 2)
+		if err != nil {
+			return nil, errors.Wrap(err, "date")
+		}
+		v := make([]int32, rows)
+		for i := range v {
+			v[i] = int32(binary.LittleEndian.Uint16(b[i*2:]))
+		}
+		return values(bytesOf(v)), nil
+	case proto.ColumnTypeDateTime:
+		b, err := d.take(rows * 4)
+		if err != nil {
+			return nil, errors.Wrap(err, "datetime")
+		}
+		v := make([]int64, rows)
+		for i := range v {
+			v[i] = int64(binary.LittleEndian.Uint32(b[i*4:]))
+		}
+		return values(bytesOf(v)), nil
+	case proto.ColumnTypeDateTime64:
+		// Scaling values to precision of time unit.
+		b, err := d.take(rows * 8)
+		if err != nil {
+			return nil, errors.Wrap(err, "datetime64")
+		}
+		_, p := timeUnit(t.Precision)
+		scale := int64(math.Pow10(p - t.Precision))
+		v := make([]int64, rows)
+		for i := range v {
+			v[i] = int64(binary.LittleEndian.Uint64(b[i*8:])) * scale
+		}
+		return values(bytesOf(v)), nil
+	case proto.ColumnTypeUUID:
+		// Native format stores UUID as two little-endian 64-bit halves.
+		b, err := d.take(rows * 16)
+		if err != nil {
+			return nil, errors.Wrap(err, "uuid")
+		}
+		v := make([]byte, len(b))
+		for i := 0; i < len(b); i += 8 {
+			binary.BigEndian.PutUint64(v[i:], binary.LittleEndian.Uint64(b[i:]))
+		}
+		return values(v), nil
+	case proto.ColumnTypeString:
+		offsets := make([]int32, rows+1)
+		var v []byte
+		for i := range rows {
+			n, k := binary.Uvarint(d.buf)
+			if k <= 0 {
+				return nil, errors.New("string: invalid length")
+			}
+			d.buf = d.buf[k:]
+			b, err := d.take(int(min(n, math.MaxInt32)))
+			if err != nil {
+				return nil, errors.Wrap(err, "string")
+			}
+			v = append(v, b...)
+			if len(v) > math.MaxInt32 {
+				return nil, errors.New("string data is too large")
+			}
+			offsets[i+1] = int32(len(v))
+		}
+		buffers := []*memory.Buffer{nil, memory.NewBufferBytes(bytesOf(offsets)), memory.NewBufferBytes(v)}
+		return array.NewData(dt, rows, buffers, nil, 0, 0), nil
+	case proto.ColumnTypeNullable:
+		b, err := d.take(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "null map")
+		}
+		v, err := d.data(t.Elems[0], rows)
+		if err != nil {
+			return nil, err
+		}
+		defer v.Release()
+		bitmap, nulls := validity(b)
+		buffers := append([]*memory.Buffer{bitmap}, v.Buffers()[1:]...)
+		return array.NewData(dt, rows, buffers, v.Children(), nulls, 0), nil
+	case proto.ColumnTypeLowCardinality:
+		return d.lowCardinality(t, dt.(*arrow.DictionaryType), rows)
+	case proto.ColumnTypeArray:
+		offsets, err := d.offsets(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "array offsets")
+		}
+		v, err := d.data(t.Elems[0], int(offsets[rows]))
+		if err != nil {
+			return nil, err
+		}
+		defer v.Release()
+		buffers := []*memory.Buffer{nil, memory.NewBufferBytes(bytesOf(offsets))}
+		return array.NewData(dt, rows, buffers, []arrow.ArrayData{v}, 0, 0), nil
+	case proto.ColumnTypeMap:
+		offsets, err := d.offsets(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "map offsets")
+		}
+		n := int(offsets[rows])
+		k, err := d.data(t.Elems[0], n)
+		if err != nil {
+			return nil, err
+		}
+		defer k.Release()
+		v, err := d.data(t.Elems[1], n)
+		if err != nil {
+			return nil, err
+		}
+		defer v.Release()
+		entries := array.NewData(dt.(*arrow.MapType).Elem(), n, []*memory.Buffer{nil}, []arrow.ArrayData{k, v}, 0, 0)
+		defer entries.Release()
+		buffers := []*memory.Buffer{nil, memory.NewBufferBytes(bytesOf(offsets))}
+		return array.NewData(dt, rows, buffers, []arrow.ArrayData{entries}, 0, 0), nil
+	case proto.ColumnTypeTuple:
+		children := make([]arrow.ArrayData, 0, len(t.Elems))
+		defer func() {
+			for _, c := range children {
+				c.Release()
+			}
+		}()
+		for _, e := range t.Elems {
+			v, err := d.data(e, rows)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, v)
+		}
+		return array.NewData(dt, rows, []*memory.Buffer{nil}, children, 0, 0), nil
+	default:
+		return nil, errors.Errorf("type %s is not supported", t)
+	}
+}
+
+// lowCardinality reads LowCardinality column that is encoded as single
+// granule with additional keys, as ch-go encodes it.
+func (d *decoder) lowCardinality(t proto.Type, dt *arrow.DictionaryType, rows int) (arrow.ArrayData, error) {
+	elem := t.Elems[0]
+	null := elem.Name == proto.ColumnTypeNullable
+	if null {
+		// Dictionary is not nullable, first key is NULL.
+		elem = elem.Elems[0]
+	}
+	if rows == 0 {
+		dict, err := d.data(elem, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer dict.Release()
+		return array.NewDataWithDictionary(dt, 0, []*memory.Buffer{nil, nil}, 0, 0, dict.(*array.Data)), nil
+	}
+	meta, err := d.uint64()
+	if err != nil {
+		return nil, errors.Wrap(err, "meta")
+	}
+	const (
+		needGlobalDictionary = 1 << 8
+		hasAdditionalKeys    = 1 << 9
+	)
+	if meta&needGlobalDictionary != 0 || meta&hasAdditionalKeys == 0 {
+		return nil, errors.Errorf("low cardinality: unsupported meta %#x", meta)
+	}
+	if meta&0xff > 3 {
+		return nil, errors.Errorf("low cardinality: invalid key type %d", meta&0xff)
+	}
+	keySize := 1 << (meta & 0xff)
+	n, err := d.count()
+	if err != nil {
+		return nil, errors.Wrap(err, "dictionary rows")
+	}
+	dict, err := d.data(elem, n)
+	if err != nil {
+		return nil, err
+	}
+	defer dict.Release()
+	if k, err := d.uint64(); err != nil || k != uint64(rows) {
+		return nil, errors.Errorf("low cardinality: got %d keys, expected %d", k, rows)
+	}
+	b, err := d.take(rows * keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "keys")
+	}
+	var (
+		keys   = make([]int32, rows)
+		bitmap []byte
+		nulls  int
+	)
+	for i := range keys {
+		var k uint64
+		switch keySize {
+		case 1:
+			k = uint64(b[i])
+		case 2:
+			k = uint64(binary.LittleEndian.Uint16(b[i*2:]))
+		case 4:
+			k = uint64(binary.LittleEndian.Uint32(b[i*4:]))
+		default:
+			k = binary.LittleEndian.Uint64(b[i*8:])
+		}
+		if k >= uint64(n) {
+			return nil, errors.Errorf("low cardinality: key %d out of range", k)
+		}
+		keys[i] = int32(k)
+		if null && k == 0 {
+			if bitmap == nil {
+				bitmap = make([]byte, bitutil.BytesForBits(int64(rows)))
+				bitutil.SetBitsTo(bitmap, 0, int64(rows), true)
+			}
+			bitutil.ClearBit(bitmap, i)
+			nulls++
+		}
+	}
+	var validity *memory.Buffer
+	if bitmap != nil {
+		validity = memory.NewBufferBytes(bitmap)
+	}
+	buffers := []*memory.Buffer{validity, memory.NewBufferBytes(bytesOf(keys))}
+	return array.NewDataWithDictionary(dt, rows, buffers, nulls, 0, dict.(*array.Data)), nil
+}
+
+// validity returns Arrow validity bitmap and count of NULL values of
+// ClickHouse null map.
+func validity(nullMap []byte) (*memory.Buffer, int) {
+	var (
+		v     = make([]byte, bitutil.BytesForBits(int64(len(nullMap))))
+		nulls int
+	)
+	for i, x := range nullMap {
+		if x != 0 {
+			nulls++
+			continue
+		}
+		bitutil.SetBit(v, i)
+	}
+	if nulls == 0 {
+		return nil, 0
+	}
+	return memory.NewBufferBytes(v), nulls
+}
@@ -0,0 +1,189 @@
+package charrow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestRecord(t *testing.T) {
+	var (
+		ids     proto.ColUInt64
+		names   proto.ColStr
+		comment = new(proto.ColStr).Nullable()
+		tags    = new(proto.ColStr).LowCardinality()
+		values  = new(proto.ColInt32).Array()
+		attrs   = proto.NewMap[string, uint64](new(proto.ColStr), new(proto.ColUInt64))
+		pair    = proto.ColTuple{new(proto.ColInt8), new(proto.ColStr)}
+		ts      = new(proto.ColDateTime64).WithPrecision(proto.PrecisionMilli).WithLocation(time.UTC)
+		created = &proto.ColDateTime{Location: time.UTC}
+		day     proto.ColDate
+		id      proto.ColUUID
+		hash    = new(proto.ColFixedStr)
+		ok      proto.ColBool
+		price   = new(proto.ColAuto)
+	)
+	hash.SetSize(2)
+	require.NoError(t, price.Infer("Decimal(9, 2)"))
+	start := time.Date(2024, 3, 1, 10, 20, 30, 400_000_000, time.UTC)
+	for i, s := range []string{"foo", "bar", "baz"} {
+		ids.Append(uint64(i + 1))
+		names.Append(s)
+		if i == 1 {
+			comment.Append(proto.Null[string]())
+		} else {
+			comment.Append(proto.NewNullable(s + "!"))
+		}
+		tags.Append([]string{"a", "b", "a"}[i])
+		values.Append(make([]int32, i))
+		attrs.Append(map[string]uint64{s: uint64(i)})
+		pair[0].(*proto.ColInt8).Append(int8(i))
+		pair[1].(*proto.ColStr).Append(s)
+		ts.Append(start.Add(time.Duration(i) * time.Second))
+		created.Append(start)
+		day.Append(start)
+		id.Append(uuid.MustParse("bc0d55c6-5f1d-4a5b-9a88-8a8e2d7c1e2f"))
+		hash.Append([]byte(s[:2]))
+		ok.Append(i%2 == 0)
+		price.Data.(*proto.ColDecimal32).Append(proto.Decimal32(1050 + i))
+	}
+	results := proto.Results{
+		{Name: "id", Data: &ids},
+		{Name: "name", Data: &names},
+		{Name: "comment", Data: comment},
+		{Name: "tag", Data: tags},
+		{Name: "values", Data: values},
+		{Name: "attrs", Data: attrs},
+		{Name: "pair", Data: pair},
+		{Name: "ts", Data: ts},
+		{Name: "created", Data: created},
+		{Name: "day", Data: &day},
+		{Name: "uuid", Data: &id},
+		{Name: "hash", Data: hash},
+		{Name: "ok", Data: &ok},
+		{Name: "price", Data: price},
+	}
+	rec, err := Record(results)
+	require.NoError(t, err)
+	defer rec.Release()
+
+	require.Equal(t, int64(3), rec.NumRows())
+	require.Equal(t, `schema:
+  fields: 14
+    - id: type=uint64
+    - name: type=utf8
+    - comment: type=utf8, nullable
+    - tag: type=dictionary<values=utf8, indices=int32, ordered=false>
+    - values: type=list<item: int32>
+    - attrs: type=map<utf8, uint64, items_non_nullable>
+    - pair: type=struct<1: int8, 2: utf8>
+    - ts: type=timestamp[ms, tz=UTC]
+    - created: type=timestamp[s, tz=UTC]
+    - day: type=date32
+    - uuid: type=fixed_size_binary[16]
+    - hash: type=fixed_size_binary[2]
+    - ok: type=bool
+    - price: type=decimal32(9, 2)`, rec.Schema().String())
+	for i, expected := range []string{
+		`[1 2 3]`,
+		`["foo" "bar" "baz"]`,
+		`["foo!" (null) "baz!"]`,
+		`{ dictionary: ["a" "b"]
+  indices: [0 1 0] }`,
+		`[[] [0] [0 0]]`,
+		`[{["foo"] [0]} {["bar"] [1]} {["baz"] [2]}]`,
+		`{[0 1 2] ["foo" "bar" "baz"]}`,
+		`[1709288430400 1709288431400 1709288432400]`,
+		`[1709288430 1709288430 1709288430]`,
+		`[2024-03-01 2024-03-01 2024-03-01]`,
+		"", // uuid
+		"", // hash
+		`[true false true]`,
+		`[10.5 10.51 10.52]`,
+	} {
+		if expected == "" {
+			continue
+		}
+		require.Equal(t, expected, rec.Column(i).String(), "%s", rec.ColumnName(i))
+	}
+	require.Equal(t, id[0][:], rec.Column(10).(*array.FixedSizeBinary).Value(2))
+	require.Equal(t, []byte("ba"), rec.Column(11).(*array.FixedSizeBinary).Value(2))
+
+	t.Run("Shared", func(t *testing.T) {
+		// Fixed-size and string columns share memory with results.
+		ids[0] = 100
+		names.Buf[0] = 'F'
+		require.Equal(t, uint64(100), rec.Column(0).(*array.Uint64).Value(0))
+		require.Equal(t, "Foo", rec.Column(1).(*array.String).Value(0))
+	})
+}
+
+func TestRecordAuto(t *testing.T) {
+	// Encoding block and decoding it with inferred columns, as when
+	// reading query result.
+	var (
+		input = proto.Input{
+			{Name: "s", Data: proto.ColStr{Buf: []byte("ab"), Pos: []proto.Position{{Start: 0, End: 1}, {Start: 1, End: 2}}}},
+			{Name: "arr", Data: proto.NewArray[string](new(proto.ColStr).LowCardinality())},
+			{Name: "m", Data: proto.NewMap[string, int64](new(proto.ColStr), new(proto.ColInt64))},
+			{Name: "t", Data: proto.ColTuple{new(proto.ColInt64), new(proto.ColStr).Nullable()}},
+			{Name: "dt", Data: new(proto.ColDateTime64).WithPrecision(proto.Precision(5))},
+		}
+		arr = input[1].Data.(*proto.ColArr[string])
+		m   = input[2].Data.(*proto.ColMap[string, int64])
+		tup = input[3].Data.(proto.ColTuple)
+		dt  = input[4].Data.(*proto.ColDateTime64)
+	)
+	arr.Append([]string{"x", "y"})
+	arr.Append([]string{"y"})
+	m.Append(map[string]int64{"k": 1})
+	m.Append(map[string]int64{})
+	tup[0].(*proto.ColInt64).Append(10)
+	tup[0].(*proto.ColInt64).Append(20)
+	tup[1].(*proto.ColNullable[string]).Append(proto.Null[string]())
+	tup[1].(*proto.ColNullable[string]).Append(proto.NewNullable("v"))
+	dt.AppendRaw(100_001)
+	dt.AppendRaw(1)
+
+	var buf proto.Buffer
+	require.NoError(t, proto.Block{Columns: len(input), Rows: 2}.EncodeRawBlock(&buf, proto.Version, input))
+	var (
+		results proto.Results
+		block   proto.Block
+	)
+	require.NoError(t, block.DecodeRawBlock(buf.Reader(), proto.Version, results.Auto()))
+
+	rec, err := Record(results)
+	require.NoError(t, err)
+	defer rec.Release()
+	require.Equal(t, `schema:
+  fields: 5
+    - s: type=utf8
+    - arr: type=list<item: dictionary<values=utf8, indices=int32, ordered=false>>
+    - m: type=map<utf8, int64, items_non_nullable>
+    - t: type=struct<1: int64, 2: utf8 nullable>
+    - dt: type=timestamp[us]`, rec.Schema().String())
+	for i, expected := range []string{
+		`["a" "b"]`,
+		`[{ dictionary: ["x" "y"]
+  indices: [0 1] } { dictionary: ["x" "y"]
+  indices: [1] }]`,
+		`[{["k"] [1]} {[] []}]`,
+		`{[10 20] [(null) "v"]}`,
+		`[1000010 10]`, // scaled to microseconds,
+	} {
+		require.Equal(t, expected, rec.Column(i).String(), "%s", rec.ColumnName(i))
+	}
+}
+
+func TestRecordUnsupported(t *testing.T) {
+	col := new(proto.ColInt128)
+	col.Append(proto.Int128{Low: 1})
+	_, err := Record(proto.Results{{Name: "v", Data: col}})
+	require.ErrorContains(t, err, `column "v"`)
+}
@@ -0,0 +1,472 @@
+package chformat
+
+import (
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-faster/errors"
+	"github.com/google/uuid"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// target is column prepared for appending parsed values.
+type target struct {
+	kind kind
+	typ  proto.Type
+
+	append  reflect.Value    // Append method of scalar
+	arg     reflect.Type     // of Append
+	loc     *time.Location   // of DateTime or DateTime64
+	nulls   *proto.ColUInt8  // of nullable
+	offsets *proto.ColUInt64 // of array or map
+	data    column           // of array or map keys, to count elements
+	elems   []*target        // nullable value, array element, map key and value, or tuple elements
+	names   []string         // of named tuple
+}
+
+// newTarget prepares column of type t.
+func newTarget(col column, t proto.Type) (*target, error) {
+	col = unwrap(col)
+	switch t.Name {
+	case proto.ColumnTypeLowCardinality, proto.ColumnTypeSimpleAggregateFunction:
+		return newTarget(col, t.Elems[len(t.Elems)-1])
+	case proto.ColumnTypeNullable:
+		nulls, ok := fieldPtr(col, "Nulls").(*proto.ColUInt8)
+		if !ok {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		elem, err := elemTarget(col, "Values", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		return &target{kind: kindNullable, typ: t, nulls: nulls, elems: []*target{elem}}, nil
+	case proto.ColumnTypeArray:
+		offsets, ok := fieldPtr(col, "Offsets").(*proto.ColUInt64)
+		if !ok {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		elem, err := elemTarget(col, "Data", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		data, _ := structField(col, "Data").(column)
+		return &target{kind: kindArray, typ: t, offsets: offsets, data: data, elems: []*target{elem}}, nil
+	case proto.ColumnTypeMap:
+		offsets, ok := fieldPtr(col, "Offsets").(*proto.ColUInt64)
+		if !ok || len(t.Elems) != 2 {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		k, err := elemTarget(col, "Keys", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		v, err := elemTarget(col, "Values", t.Elems[1])
+		if err != nil {
+			return nil, err
+		}
+		data, _ := structField(col, "Keys").(column)
+		return &target{kind: kindMap, typ: t, offsets: offsets, data: data, elems: []*target{k, v}}, nil
+	case proto.ColumnTypeTuple:
+		tuple, ok := col.(proto.ColTuple)
+		if !ok {
+			if p, isPtr := col.(*proto.ColTuple); isPtr {
+				tuple, ok = *p, true
+			}
+		}
+		if !ok || len(tuple) != len(t.Elems) {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		v := &target{kind: kindTuple, typ: t, names: t.Names}
+		for i, c := range tuple {
+			elem, err := newTarget(c, t.Elems[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "[%d]", i)
+			}
+			v.elems = append(v.elems, elem)
+		}
+		return v, nil
+	}
+	v := &target{kind: kindScalar, typ: t, loc: time.Local}
+	if t.Timezone != "" {
+		loc, err := time.LoadLocation(t.Timezone)
+		if err != nil {
+			return nil, errors.Wrap(err, "timezone")
+		}
+		v.loc = loc
+	}
+	v.append = reflect.ValueOf(col).MethodByName("Append")
+	if !v.append.IsValid() || v.append.Type().NumIn() != 1 {
+		return nil, errors.Errorf("unsupported %T column for %s", col, t)
+	}
+	v.arg = v.append.Type().In(0)
+	return v, nil
+}
+
+func elemTarget(col column, name string, t proto.Type) (*target, error) {
+	c, ok := structField(col, name).(column)
+	if !ok {
+		return nil, errors.Errorf("unsupported %T column for %s", col, t)
+	}
+	v, err := newTarget(c, t)
+	if err != nil {
+		return nil, errors.Wrap(err, name)
+	}
+	return v, nil
+}
+
+// fieldPtr returns pointer to exported struct field of column, if column
+// is pointer to struct.
+func fieldPtr(col column, name string) any {
+	v := reflect.ValueOf(col)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.Elem().FieldByName(name)
+	if !f.IsValid() || !f.CanAddr() || !f.CanInterface() {
+		return nil
+	}
+	return f.Addr().Interface()
+}
+
+// width returns count of CSV fields of value, as tuple elements are
+// separate fields.
+func (t *target) width() int {
+	if t.kind != kindTuple {
+		return 1
+	}
+	var n int
+	for _, e := range t.elems {
+		n += e.width()
+	}
+	return n
+}
+
+// appendField appends parsed value.
+func (t *target) appendField(f field) error {
+	if f.kind == fieldDefault {
+		return t.appendDefault()
+	}
+	switch {
+	case f.kind == fieldEscaped && t.kind == kindScalar:
+		f = field{kind: fieldText, text: unescapeTSV(f.text)}
+	case (f.kind == fieldText || f.kind == fieldEscaped) && t.kind != kindScalar && t.kind != kindNullable:
+		// Text representation of nested value, e.g. in TabSeparated.
+		v, err := parseText(f.text)
+		if err != nil {
+			return err
+		}
+		f = v
+	}
+	switch t.kind {
+	case kindNullable:
+		if f.kind == fieldNull {
+			t.nulls.Append(1)
+			return t.elems[0].appendDefault()
+		}
+		if err := t.elems[0].appendField(f); err != nil {
+			return err
+		}
+		t.nulls.Append(0)
+		return nil
+	case kindArray:
+		if f.kind != fieldList {
+			return errors.Errorf("%s: array expected", t.typ)
+		}
+		for _, e := range f.elems {
+			if err := t.elems[0].appendField(e); err != nil {
+				return err
+			}
+		}
+		t.offsets.Append(uint64(t.data.Rows()))
+		return nil
+	case kindMap:
+		if f.kind != fieldObject {
+			return errors.Errorf("%s: map expected", t.typ)
+		}
+		for i, e := range f.elems {
+			if err := t.elems[0].appendField(f.keys[i]); err != nil {
+				return errors.Wrap(err, "key")
+			}
+			if err := t.elems[1].appendField(e); err != nil {
+				return err
+			}
+		}
+		t.offsets.Append(uint64(t.data.Rows()))
+		return nil
+	case kindTuple:
+		return t.appendTuple(f)
+	}
+	if f.kind != fieldText {
+		if f.kind == fieldNull {
+			return errors.Errorf("%s: unexpected NULL", t.typ)
+		}
+		return errors.Errorf("%s: unexpected nested value", t.typ)
+	}
+	v, err := parseScalar(t.typ, t.arg, t.loc, f.text)
+	if err != nil {
+		return err
+	}
+	t.append.Call([]reflect.Value{v})
+	return nil
+}
+
+func (t *target) appendTuple(f field) error {
+	switch f.kind {
+	case fieldList:
+		if len(f.elems) != len(t.elems) {
+			return errors.Errorf("%s: got %d elements", t.typ, len(f.elems))
+		}
+		for i, e := range t.elems {
+			if err := e.appendField(f.elems[i]); err != nil {
+				return errors.Wrapf(err, "[%d]", i)
+			}
+		}
+		return nil
+	case fieldObject:
+		// Named tuple as JSON object.
+		if t.names == nil {
+			return errors.Errorf("%s: unexpected object", t.typ)
+		}
+		for i, e := range t.elems {
+			elem := field{kind: fieldDefault}
+			for j, k := range f.keys {
+				if k.text == t.names[i] {
+					elem = f.elems[j]
+				}
+			}
+			if err := e.appendField(elem); err != nil {
+				return errors.Wrap(err, t.names[i])
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("%s: tuple expected", t.typ)
+	}
+}
+
+// appendDefault appends default value of type.
+func (t *target) appendDefault() error {
+	switch t.kind {
+	case kindNullable:
+		t.nulls.Append(1)
+		return t.elems[0].appendDefault()
+	case kindArray, kindMap:
+		t.offsets.Append(uint64(t.data.Rows()))
+		return nil
+	case kindTuple:
+		for _, e := range t.elems {
+			if err := e.appendDefault(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	v := reflect.Zero(t.arg)
+	switch t.typ.Name {
+	case proto.ColumnTypeFixedString:
+		if t.arg.Kind() == reflect.Slice {
+			v = reflect.ValueOf(make([]byte, t.typ.Size))
+		}
+	case proto.ColumnTypeEnum8, proto.ColumnTypeEnum16:
+		if len(t.typ.Enum) > 0 {
+			var err error
+			if v, err = parseScalar(t.typ, t.arg, t.loc, t.typ.Enum[0].Name); err != nil {
+				return err
+			}
+		}
+	}
+	t.append.Call([]reflect.Value{v})
+	return nil
+}
+
+var (
+	typeTime    = reflect.TypeOf(time.Time{})
+	typeUUID    = reflect.TypeOf(uuid.UUID{})
+	typeIPv4    = reflect.TypeOf(proto.IPv4(0))
+	typeIPv6    = reflect.TypeOf(proto.IPv6{})
+	typeInt128  = reflect.TypeOf(proto.Int128{})
+	typeUInt128 = reflect.TypeOf(proto.UInt128{})
+	typeInt256  = reflect.TypeOf(proto.Int256{})
+	typeUInt256 = reflect.TypeOf(proto.UInt256{})
+	typeDec32   = reflect.TypeOf(proto.Decimal32(0))
+	typeDec64   = reflect.TypeOf(proto.Decimal64(0))
+	typeDec128  = reflect.TypeOf(proto.Decimal128{})
+	typeDec256  = reflect.TypeOf(proto.Decimal256{})
+	typeEnum8   = reflect.TypeOf(proto.Enum8(0))
+	typeEnum16  = reflect.TypeOf(proto.Enum16(0))
+)
+
+// parseScalar parses text representation of value of type t into value of
+// Go type typ.
+func parseScalar(t proto.Type, typ reflect.Type, loc *time.Location, s string) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	switch typ {
+	case typeTime:
+		tm, err := parseTime(t, loc, s)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return v, nil
+	case typeUUID:
+		u, err := uuid.Parse(s)
+		if err != nil {
+			return v, errors.Wrap(err, "uuid")
+		}
+		v.Set(reflect.ValueOf(u))
+		return v, nil
+	case typeIPv4, typeIPv6:
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return v, errors.Wrap(err, "ip")
+		}
+		if typ == typeIPv6 {
+			v.Set(reflect.ValueOf(proto.ToIPv6(ip)))
+			return v, nil
+		}
+		if !ip.Is4() {
+			return v, errors.Errorf("%q is not IPv4", s)
+		}
+		v.Set(reflect.ValueOf(proto.ToIPv4(ip)))
+		return v, nil
+	case typeInt128, typeUInt128, typeInt256, typeUInt256, typeDec32, typeDec64, typeDec128, typeDec256:
+		n, err := parseBig(t, s)
+		if err != nil {
+			return v, err
+		}
+		setBig(v, n)
+		return v, nil
+	case typeEnum8, typeEnum16:
+		for _, e := range t.Enum {
+			if e.Name == s {
+				v.SetInt(int64(e.Value))
+				return v, nil
+			}
+		}
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "true", "1":
+			v.SetBool(true)
+		case "false", "0":
+		default:
+			return v, errors.Errorf("invalid bool %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(n)
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() != reflect.Uint8 {
+			return v, errors.Errorf("unsupported %s", typ)
+		}
+		size := t.Size
+		if typ.Kind() == reflect.Array {
+			size = typ.Len()
+		}
+		if size > 0 && len(s) > size {
+			return v, errors.Errorf("%s: too long value %q", t, s)
+		}
+		b := []byte(s)
+		if size > 0 {
+			// FixedString is padded by zero bytes.
+			b = append(b, make([]byte, size-len(s))...)
+		}
+		if typ.Kind() == reflect.Array {
+			reflect.Copy(v, reflect.ValueOf(b))
+		} else {
+			v.SetBytes(b)
+		}
+	default:
+		return v, errors.Errorf("unsupported %s", typ)
+	}
+	return v, nil
+}
+
+// parseTime parses Date, Date32, DateTime or DateTime64 value, or
+// unix timestamp for DateTime.
+func parseTime(t proto.Type, loc *time.Location, s string) (time.Time, error) {
+	switch t.Name {
+	case proto.ColumnTypeDate, proto.ColumnTypeDate32:
+		v, err := time.ParseInLocation(proto.DateLayout, s, time.UTC)
+		if err != nil {
+			return v, errors.Wrap(err, "date")
+		}
+		return v, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0).In(loc), nil
+	}
+	layout := "2006-01-02 15:04:05"
+	if strings.IndexByte(s, '.') > 0 {
+		layout += ".999999999"
+	}
+	v, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return v, errors.Wrap(err, "datetime")
+	}
+	return v, nil
+}
+
+// parseBig parses integer or decimal with scale of t as big.Int.
+func parseBig(t proto.Type, s string) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > t.Scale {
+		frac = frac[:t.Scale]
+	}
+	frac += strings.Repeat("0", t.Scale-len(frac))
+	n, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return nil, errors.Errorf("%s: invalid value %q", t, s)
+	}
+	return n, nil
+}
+
+// setBig sets integer or decimal value v from n.
+func setBig(v reflect.Value, n *big.Int) {
+	switch v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		v.SetInt(n.Int64())
+		return
+	}
+	// Two's complement of n in little-endian 64-bit words.
+	words := v.Type().Size() / 8
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(words*64))
+	u := new(big.Int).Mod(n, mod)
+	mask := new(big.Int).SetUint64(^uint64(0))
+	var w []uint64
+	for i := uint(0); i < uint(words); i++ {
+		w = append(w, new(big.Int).And(new(big.Int).Rsh(u, i*64), mask).Uint64())
+	}
+	switch words {
+	case 2:
+		v.Set(reflect.ValueOf(proto.UInt128{Low: w[0], High: w[1]}).Convert(v.Type()))
+	case 4:
+		v.Set(reflect.ValueOf(proto.UInt256{
+			Low:  proto.UInt128{Low: w[0], High: w[1]},
+			High: proto.UInt128{Low: w[2], High: w[3]},
+		}).Convert(v.Type()))
+	}
+}
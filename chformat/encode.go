@@ -0,0 +1,183 @@
+package chformat
+
+// mode of value serialization.
+type mode byte
+
+const (
+	modeEscaped mode = iota // TabSeparated
+	modeQuoted              // values of arrays, maps and tuples
+	modeCSV
+	modeJSON
+	modeRaw // Pretty
+)
+
+// encoder appends values of prepared columns.
+type encoder struct {
+	scratch []byte
+}
+
+// appendValue appends i-th row of v.
+func (e *encoder) appendValue(b []byte, v *value, i int, m mode) []byte {
+	switch v.kind {
+	case kindNullable:
+		if v.nulls[i] == 1 {
+			return appendNull(b, m)
+		}
+		return e.appendValue(b, v.elems[0], i, m)
+	case kindArray, kindMap:
+		if m == modeCSV {
+			// Arrays and maps are CSV strings with text representation.
+			start := len(b)
+			b = e.appendValue(b, v, i, modeQuoted)
+			e.scratch = append(e.scratch[:0], b[start:]...)
+			return appendCSV(b[:start], e.scratch)
+		}
+		start, end := v.bounds(i)
+		if v.kind == kindArray {
+			return e.appendArray(b, v.elems[0], start, end, m)
+		}
+		return e.appendMap(b, v.elems[0], v.elems[1], start, end, m)
+	case kindTuple:
+		return e.appendTuple(b, v, i, m)
+	default:
+		return e.appendScalar(b, v, i, m)
+	}
+}
+
+// bounds returns range of elements of i-th array or map.
+func (v *value) bounds(i int) (start, end int) {
+	if i > 0 {
+		start = int(v.offsets[i-1])
+	}
+	return start, int(v.offsets[i])
+}
+
+func (e *encoder) appendArray(b []byte, elem *value, start, end int, m mode) []byte {
+	if m != modeJSON {
+		m = modeQuoted
+	}
+	b = append(b, '[')
+	for j := start; j < end; j++ {
+		if j > start {
+			b = append(b, ',')
+		}
+		b = e.appendValue(b, elem, j, m)
+	}
+	return append(b, ']')
+}
+
+func (e *encoder) appendMap(b []byte, k, v *value, start, end int, m mode) []byte {
+	if m != modeJSON {
+		m = modeQuoted
+	}
+	b = append(b, '{')
+	for j := start; j < end; j++ {
+		if j > start {
+			b = append(b, ',')
+		}
+		if m == modeJSON {
+			// Keys of JSON objects are always strings.
+			b = e.appendKey(b, k, j)
+		} else {
+			b = e.appendValue(b, k, j, m)
+		}
+		b = append(b, ':')
+		b = e.appendValue(b, v, j, m)
+	}
+	return append(b, '}')
+}
+
+// appendKey appends JSON object key from text representation of value.
+func (e *encoder) appendKey(b []byte, v *value, i int) []byte {
+	start := len(b)
+	b = e.appendValue(b, v, i, modeRaw)
+	e.scratch = append(e.scratch[:0], b[start:]...)
+	return appendJSON(b[:start], e.scratch)
+}
+
+func (e *encoder) appendTuple(b []byte, v *value, i int, m mode) []byte {
+	switch m {
+	case modeCSV:
+		// Elements are separate CSV fields.
+		for j, elem := range v.elems {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = e.appendValue(b, elem, i, m)
+		}
+		return b
+	case modeJSON:
+		if v.names == nil {
+			b = append(b, '[')
+		} else {
+			b = append(b, '{')
+		}
+		for j, elem := range v.elems {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			if v.names != nil {
+				b = appendJSON(b, []byte(v.names[j]))
+				b = append(b, ':')
+			}
+			b = e.appendValue(b, elem, i, m)
+		}
+		if v.names == nil {
+			return append(b, ']')
+		}
+		return append(b, '}')
+	default:
+		b = append(b, '(')
+		for j, elem := range v.elems {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = e.appendValue(b, elem, i, modeQuoted)
+		}
+		return append(b, ')')
+	}
+}
+
+func (e *encoder) appendScalar(b []byte, v *value, i int, m mode) []byte {
+	start := len(b)
+	b, k := appendScalar(b, v.typ, v.loc, v.row(i))
+	switch {
+	case k == scalarFloat && m == modeJSON:
+		if s := string(b[start:]); s == "nan" || s == "inf" || s == "-inf" {
+			return append(b[:start], "null"...)
+		}
+		return b
+	case k == scalarInt64 && m == modeJSON:
+		// Integers are digits only, no escaping is needed.
+		b = append(b[:start+1], b[start:]...)
+		b[start] = '"'
+		return append(b, '"')
+	case k != scalarText || m == modeRaw:
+		return b
+	}
+	e.scratch = append(e.scratch[:0], b[start:]...)
+	b = b[:start]
+	switch m {
+	case modeEscaped:
+		return appendEscaped(b, e.scratch)
+	case modeQuoted:
+		return appendQuoted(b, e.scratch)
+	case modeCSV:
+		return appendCSV(b, e.scratch)
+	default:
+		return appendJSON(b, e.scratch)
+	}
+}
+
+func appendNull(b []byte, m mode) []byte {
+	switch m {
+	case modeQuoted:
+		return append(b, "NULL"...)
+	case modeJSON:
+		return append(b, "null"...)
+	case modeRaw:
+		return append(b, "ᴺᵁᴸᴸ"...)
+	default:
+		return append(b, `\N`...)
+	}
+}
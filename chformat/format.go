@@ -0,0 +1,28 @@
+// Package chformat implements ClickHouse text formats for ch columns, to
+// write query results and to read input of INSERT queries.
+package chformat
+
+// Format is name of ClickHouse format, so it can be used in FORMAT clause.
+type Format string
+
+// Supported formats.
+//
+// Pretty is table like one of Pretty format, but output is not byte-exact,
+// e.g. control characters are escaped.
+const (
+	TabSeparated          Format = "TabSeparated"
+	TabSeparatedWithNames Format = "TabSeparatedWithNames"
+	CSV                   Format = "CSV"
+	CSVWithNames          Format = "CSVWithNames"
+	JSONEachRow           Format = "JSONEachRow"
+	Pretty                Format = "Pretty"
+)
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// withNames reports whether format starts with header of column names.
+func (f Format) withNames() bool {
+	return f == TabSeparatedWithNames || f == CSVWithNames
+}
@@ -0,0 +1,349 @@
+package chformat
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+type fieldKind byte
+
+const (
+	fieldText    fieldKind = iota // scalar or text representation of nested value
+	fieldEscaped                  // TabSeparated field, escaped if scalar
+	fieldDefault                  // omitted value
+	fieldNull
+	fieldList   // array or tuple
+	fieldObject // map or named tuple
+)
+
+// field is parsed value, before conversion to column type.
+type field struct {
+	kind  fieldKind
+	text  string
+	keys  []field // of object
+	elems []field // of list or object
+}
+
+// parseText parses text representation of value, e.g. ['a','b'] or
+// {'k':(1,NULL)}.
+func parseText(s string) (field, error) {
+	p := textParser{s: s}
+	f, err := p.value()
+	if err != nil {
+		return field{}, errors.Wrapf(err, "parse %q", s)
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return field{}, errors.Errorf("parse %q: unexpected %q at %d", s, p.s[p.pos:], p.pos)
+	}
+	return f, nil
+}
+
+type textParser struct {
+	s   string
+	pos int
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *textParser) value() (field, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return field{}, io.ErrUnexpectedEOF
+	}
+	switch c := p.s[p.pos]; c {
+	case '[':
+		p.pos++
+		elems, _, err := p.list(']', false)
+		return field{kind: fieldList, elems: elems}, err
+	case '(':
+		p.pos++
+		elems, _, err := p.list(')', false)
+		return field{kind: fieldList, elems: elems}, err
+	case '{':
+		p.pos++
+		elems, keys, err := p.list('}', true)
+		return field{kind: fieldObject, keys: keys, elems: elems}, err
+	case '\'', '"':
+		s, err := p.quoted(c)
+		return field{kind: fieldText, text: s}, err
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && !strings.ContainsRune(",:])} \t\n", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		s := p.s[start:p.pos]
+		if s == "" {
+			return field{}, errors.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+		}
+		if strings.EqualFold(s, "NULL") {
+			return field{kind: fieldNull}, nil
+		}
+		return field{kind: fieldText, text: s}, nil
+	}
+}
+
+// list parses elements until end, with keys if object is set.
+func (p *textParser) list(end byte, object bool) (elems, keys []field, err error) {
+	for i := 0; ; i++ {
+		p.skipSpace()
+		if p.pos < len(p.s) && p.s[p.pos] == end && i == 0 {
+			p.pos++
+			return elems, keys, nil
+		}
+		if object {
+			k, err := p.value()
+			if err != nil {
+				return nil, nil, err
+			}
+			if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != ':' {
+				return nil, nil, errors.Errorf("expected ':' at %d", p.pos)
+			}
+			p.pos++
+			keys = append(keys, k)
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		elems = append(elems, v)
+		if p.skipSpace(); p.pos >= len(p.s) {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case end:
+			p.pos++
+			return elems, keys, nil
+		default:
+			return nil, nil, errors.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+		}
+	}
+}
+
+// quoted parses string literal, where quote is escaped by backslash or
+// doubled.
+func (p *textParser) quoted(quote byte) (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			n := unescape(&b, p.s[p.pos:])
+			p.pos += n
+		case c == quote && p.pos+1 < len(p.s) && p.s[p.pos+1] == quote:
+			b.WriteByte(quote)
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errors.Errorf("unterminated string at %d", start)
+}
+
+// unescape writes character of escape sequence at start of s, returning
+// length of sequence.
+func unescape(b *strings.Builder, s string) int {
+	if len(s) < 2 {
+		b.WriteString(s)
+		return len(s)
+	}
+	switch s[1] {
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case '0':
+		b.WriteByte(0)
+	case 'a':
+		b.WriteByte('\a')
+	case 'v':
+		b.WriteByte('\v')
+	case 'x':
+		if len(s) >= 4 {
+			if v, err := strconv.ParseUint(s[2:4], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				return 4
+			}
+		}
+		b.WriteByte('x')
+	default:
+		// Other characters are escaped as is, e.g. \\ or \'.
+		b.WriteByte(s[1])
+	}
+	return 2
+}
+
+// unescapeTSV returns value of escaped scalar of TabSeparated field.
+func unescapeTSV(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '\\' {
+			i += unescape(&b, s[i:])
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// tsvField returns TabSeparated field.
+//
+// Text of nested values is not escaped, so field is unescaped only if it
+// is scalar.
+func tsvField(s string) field {
+	if s == `\N` {
+		return field{kind: fieldNull}
+	}
+	return field{kind: fieldEscaped, text: s}
+}
+
+// readTSV reads fields of TabSeparated row.
+func readTSV(r *bufio.Reader, fields []field) ([]field, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	fields = fields[:0]
+	for {
+		i := strings.IndexByte(line, '\t')
+		if i < 0 {
+			return append(fields, tsvField(line)), nil
+		}
+		fields = append(fields, tsvField(line[:i]))
+		line = line[i+1:]
+	}
+}
+
+// readCSV reads fields of CSV row.
+//
+// Unquoted \N is NULL and unquoted empty field is default value.
+func readCSV(r *bufio.Reader, fields []field) ([]field, error) {
+	fields = fields[:0]
+	var b strings.Builder
+	for {
+		c, err := r.ReadByte()
+		if errors.Is(err, io.EOF) && len(fields) == 0 {
+			return nil, io.EOF
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		b.Reset()
+		quoted := err == nil && c == '"'
+		if quoted {
+			for {
+				c, err := r.ReadByte()
+				if errors.Is(err, io.EOF) {
+					return nil, errors.Wrap(io.ErrUnexpectedEOF, "quoted field")
+				}
+				if err != nil {
+					return nil, errors.Wrap(err, "quoted field")
+				}
+				if c == '"' {
+					if next, err := r.Peek(1); err == nil && next[0] == '"' {
+						_, _ = r.ReadByte()
+					} else {
+						break
+					}
+				}
+				b.WriteByte(c)
+			}
+			c, err = r.ReadByte()
+		}
+		for err == nil && c != ',' && c != '\n' {
+			b.WriteByte(c)
+			c, err = r.ReadByte()
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		s := b.String()
+		if c == '\n' || err != nil {
+			s = strings.TrimSuffix(s, "\r")
+		}
+		switch {
+		case quoted:
+			fields = append(fields, field{kind: fieldText, text: s})
+		case s == `\N`:
+			fields = append(fields, field{kind: fieldNull})
+		case s == "":
+			fields = append(fields, field{kind: fieldDefault})
+		default:
+			fields = append(fields, field{kind: fieldText, text: s})
+		}
+		if err != nil || c == '\n' {
+			return fields, nil
+		}
+	}
+}
+
+// readJSON reads value from JSON decoder, keeping order of object keys.
+func readJSON(d *json.Decoder) (field, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return field{}, err
+	}
+	switch v := tok.(type) {
+	case nil:
+		return field{kind: fieldNull}, nil
+	case bool:
+		return field{kind: fieldText, text: strconv.FormatBool(v)}, nil
+	case json.Number:
+		return field{kind: fieldText, text: v.String()}, nil
+	case string:
+		return field{kind: fieldText, text: v}, nil
+	case json.Delim:
+		f := field{kind: fieldList}
+		if v == '{' {
+			f.kind = fieldObject
+		}
+		for d.More() {
+			if f.kind == fieldObject {
+				k, err := d.Token()
+				if err != nil {
+					return field{}, err
+				}
+				f.keys = append(f.keys, field{kind: fieldText, text: k.(string)})
+			}
+			e, err := readJSON(d)
+			if err != nil {
+				return field{}, err
+			}
+			f.elems = append(f.elems, e)
+		}
+		// Closing delimiter.
+		if _, err := d.Token(); err != nil {
+			return field{}, err
+		}
+		return f, nil
+	default:
+		return field{}, errors.Errorf("unexpected %v", tok)
+	}
+}
@@ -0,0 +1,141 @@
+package chformat
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// appendPretty appends block as table like one of Pretty format, without
+// colors.
+//
+// Output is not exactly the same as of ClickHouse: control characters are
+// escaped like in TabSeparated, so they don't break the table, and width of
+// value is number of runes, so wide and combining characters are misaligned.
+func (w *Writer) appendPretty(b []byte, names []string, rows int) []byte {
+	if rows == 0 {
+		return b
+	}
+	var (
+		cells  = make([][]byte, 0, rows*len(w.cols))
+		widths = make([]int, len(w.cols))
+		right  = make([]bool, len(w.cols))
+		buf    []byte
+	)
+	header := make([][]byte, len(names))
+	for j, name := range names {
+		header[j] = appendControlEscaped(nil, []byte(name))
+		widths[j] = utf8.RuneCount(header[j])
+		right[j] = w.cols[j].alignRight()
+	}
+	for i := 0; i < rows; i++ {
+		for j, v := range w.cols {
+			start := len(buf)
+			buf = w.enc.appendValue(buf, v, i, modeRaw)
+			if bytes.ContainsAny(buf[start:], prettyEscaped) {
+				w.enc.scratch = append(w.enc.scratch[:0], buf[start:]...)
+				buf = appendControlEscaped(buf[:start], w.enc.scratch)
+			}
+			cells = append(cells, buf[start:len(buf):len(buf)])
+			if n := utf8.RuneCount(cells[len(cells)-1]); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	b = appendLine(b, widths, "┏", "┳", "┓", "━")
+	b = append(b, "┃"...)
+	for j := range names {
+		b = appendCell(b, header[j], widths[j], right[j])
+		b = append(b, "┃"...)
+	}
+	b = append(b, '\n')
+	b = appendLine(b, widths, "┡", "╇", "┩", "━")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b = appendLine(b, widths, "├", "┼", "┤", "─")
+		}
+		b = append(b, "│"...)
+		for j := range w.cols {
+			b = appendCell(b, cells[i*len(w.cols)+j], widths[j], right[j])
+			b = append(b, "│"...)
+		}
+		b = append(b, '\n')
+	}
+	return appendLine(b, widths, "└", "┴", "┘", "─")
+}
+
+// prettyEscaped are control characters that are escaped in Pretty-like
+// table, as they break it.
+const prettyEscaped = "\b\f\n\r\t\x00"
+
+// appendControlEscaped appends s with control characters escaped like in
+// TabSeparated, other characters are appended as is.
+func appendControlEscaped(b, s []byte) []byte {
+	for _, c := range s {
+		if strings.IndexByte(prettyEscaped, c) >= 0 {
+			b = appendEscaped(b, []byte{c})
+			continue
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func appendLine(b []byte, widths []int, left, mid, right, fill string) []byte {
+	b = append(b, left...)
+	for j, w := range widths {
+		if j > 0 {
+			b = append(b, mid...)
+		}
+		for k := 0; k < w+2; k++ {
+			b = append(b, fill...)
+		}
+	}
+	b = append(b, right...)
+	return append(b, '\n')
+}
+
+func appendCell(b, s []byte, width int, right bool) []byte {
+	pad := width - utf8.RuneCount(s)
+	b = append(b, ' ')
+	if right {
+		b = appendSpaces(b, pad)
+	}
+	b = append(b, s...)
+	if !right {
+		b = appendSpaces(b, pad)
+	}
+	return append(b, ' ')
+}
+
+func appendSpaces(b []byte, n int) []byte {
+	for ; n > 0; n-- {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// alignRight reports whether values are numbers, which are aligned to the
+// right in Pretty format.
+func (v *value) alignRight() bool {
+	if v.kind == kindNullable {
+		return v.elems[0].alignRight()
+	}
+	if v.kind != kindScalar {
+		return false
+	}
+	switch v.typ.Name {
+	case proto.ColumnTypeInt8, proto.ColumnTypeInt16, proto.ColumnTypeInt32, proto.ColumnTypeInt64,
+		proto.ColumnTypeInt128, proto.ColumnTypeInt256,
+		proto.ColumnTypeUInt8, proto.ColumnTypeUInt16, proto.ColumnTypeUInt32, proto.ColumnTypeUInt64,
+		proto.ColumnTypeUInt128, proto.ColumnTypeUInt256,
+		proto.ColumnTypeFloat32, proto.ColumnTypeFloat64, proto.ColumnTypeBFloat16, proto.ColumnTypeDecimal,
+		proto.ColumnTypeDecimal32, proto.ColumnTypeDecimal64, proto.ColumnTypeDecimal128, proto.ColumnTypeDecimal256:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,277 @@
+package chformat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// DefaultBlockRows is default count of rows in block of Reader.
+const DefaultBlockRows = 65536
+
+// Reader reads rows in Format into input columns block by block.
+//
+// Columns are usually proto.ColAuto, inferred by client from column types
+// that server sends for INSERT query, so Reader is used as Query.OnInput:
+//
+//	input := proto.Input{
+//		{Name: "id", Data: new(proto.ColAuto)},
+//		{Name: "name", Data: new(proto.ColAuto)},
+//	}
+//	r := chformat.NewReader(file, chformat.CSV, input)
+//	err := conn.Do(ctx, ch.Query{
+//		Body:    input.Into("table"),
+//		Input:   input,
+//		OnInput: r.OnInput,
+//	})
+//
+// Values are parsed per column type like ClickHouse does with default
+// settings. DateTime without timezone in type is parsed in local timezone.
+//
+// Fields of TabSeparated and CSV rows match input columns by position,
+// unless format has header with names. Omitted columns are filled with
+// default values.
+type Reader struct {
+	format    Format
+	input     proto.Input
+	blockRows int
+
+	r    *bufio.Reader
+	json *json.Decoder
+
+	targets []*target // of input columns
+	index   []int     // of input columns by field position
+	seen    []bool    // input columns that are set in row
+	width   int       // count of fields in row
+	fields  []field
+	row     int
+	eof     bool
+}
+
+// NewReader returns new Reader of format f that fills columns of input.
+func NewReader(r io.Reader, f Format, input proto.Input) *Reader {
+	br := bufio.NewReader(r)
+	d := json.NewDecoder(br)
+	d.UseNumber()
+	return &Reader{
+		format:    f,
+		input:     input,
+		blockRows: DefaultBlockRows,
+		r:         br,
+		json:      d,
+	}
+}
+
+// WithBlockRows sets maximum count of rows in block.
+func (r *Reader) WithBlockRows(n int) *Reader {
+	r.blockRows = n
+	return r
+}
+
+// OnInput resets input columns and reads next block of rows into them.
+//
+// Returns io.EOF if there are no more rows, so it can be used as
+// Query.OnInput.
+func (r *Reader) OnInput(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.input.Reset()
+	if r.eof {
+		return io.EOF
+	}
+	if r.targets == nil {
+		if err := r.prepare(); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < r.blockRows; i++ {
+		err := r.readRow()
+		if errors.Is(err, io.EOF) {
+			r.eof = true
+			if i == 0 {
+				return io.EOF
+			}
+			// Tail of input is returned with nil, to return io.EOF
+			// on next call.
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "row %d", r.row+1)
+		}
+		r.row++
+	}
+	return nil
+}
+
+// prepare columns of input, reading header if any.
+func (r *Reader) prepare() error {
+	switch r.format {
+	case TabSeparated, TabSeparatedWithNames, CSV, CSVWithNames, JSONEachRow:
+	default:
+		return errors.Errorf("unsupported format %q", r.format)
+	}
+	targets := make([]*target, 0, len(r.input))
+	for _, c := range r.input {
+		t, err := proto.ParseType(c.Data.Type())
+		if err != nil {
+			return errors.Wrapf(err, "column %q", c.Name)
+		}
+		v, err := newTarget(c.Data, t)
+		if err != nil {
+			return errors.Wrapf(err, "column %q", c.Name)
+		}
+		targets = append(targets, v)
+	}
+	r.seen = make([]bool, len(targets))
+	r.index = r.index[:0]
+	if !r.format.withNames() {
+		for i := range r.input {
+			r.index = append(r.index, i)
+		}
+		r.targets = targets
+		r.setWidth()
+		return nil
+	}
+	fields, err := r.readFields()
+	if err != nil {
+		return errors.Wrap(err, "header")
+	}
+	for _, f := range fields {
+		name := f.text
+		if f.kind == fieldEscaped {
+			name = unescapeTSV(name)
+		}
+		i := r.column(name)
+		if i < 0 {
+			return errors.Errorf("header: unknown column %q", name)
+		}
+		r.index = append(r.index, i)
+	}
+	r.targets = targets
+	r.setWidth()
+	return nil
+}
+
+func (r *Reader) setWidth() {
+	r.width = 0
+	for _, i := range r.index {
+		if r.format == CSV || r.format == CSVWithNames {
+			r.width += r.targets[i].width()
+		} else {
+			r.width++
+		}
+	}
+}
+
+// column returns index of input column by name.
+func (r *Reader) column(name string) int {
+	for i, c := range r.input {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Reader) readFields() ([]field, error) {
+	var err error
+	switch r.format {
+	case TabSeparated, TabSeparatedWithNames:
+		r.fields, err = readTSV(r.r, r.fields)
+	default:
+		r.fields, err = readCSV(r.r, r.fields)
+	}
+	return r.fields, err
+}
+
+func (r *Reader) readRow() error {
+	for i := range r.seen {
+		r.seen[i] = false
+	}
+	if r.format == JSONEachRow {
+		if err := r.readJSONRow(); err != nil {
+			return err
+		}
+	} else if err := r.readTextRow(); err != nil {
+		return err
+	}
+	for i, ok := range r.seen {
+		if ok {
+			continue
+		}
+		if err := r.targets[i].appendDefault(); err != nil {
+			return errors.Wrapf(err, "column %q", r.input[i].Name)
+		}
+	}
+	return nil
+}
+
+func (r *Reader) readTextRow() error {
+	fields, err := r.readFields()
+	if err != nil {
+		return err
+	}
+	if len(fields) != r.width {
+		return errors.Errorf("got %d fields, expected %d", len(fields), r.width)
+	}
+	csv := r.format == CSV || r.format == CSVWithNames
+	for _, i := range r.index {
+		t := r.targets[i]
+		var f field
+		if csv {
+			f = nestCSV(t, &fields)
+		} else {
+			f, fields = fields[0], fields[1:]
+		}
+		if err := t.appendField(f); err != nil {
+			return errors.Wrapf(err, "column %q", r.input[i].Name)
+		}
+		r.seen[i] = true
+	}
+	return nil
+}
+
+// nestCSV takes fields of t from CSV row, as tuple elements are separate
+// fields.
+func nestCSV(t *target, fields *[]field) field {
+	if t.kind != kindTuple {
+		f := (*fields)[0]
+		*fields = (*fields)[1:]
+		return f
+	}
+	v := field{kind: fieldList}
+	for _, e := range t.elems {
+		v.elems = append(v.elems, nestCSV(e, fields))
+	}
+	return v
+}
+
+func (r *Reader) readJSONRow() error {
+	row, err := readJSON(r.json)
+	if err != nil {
+		return err
+	}
+	if row.kind != fieldObject {
+		return errors.New("object expected")
+	}
+	for j, k := range row.keys {
+		i := r.column(k.text)
+		if i < 0 {
+			return errors.Errorf("unknown column %q", k.text)
+		}
+		if r.seen[i] {
+			return errors.Errorf("duplicate column %q", k.text)
+		}
+		if err := r.targets[i].appendField(row.elems[j]); err != nil {
+			return errors.Wrapf(err, "column %q", k.text)
+		}
+		r.seen[i] = true
+	}
+	return nil
+}
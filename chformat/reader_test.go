@@ -0,0 +1,163 @@
+package chformat
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// autoInput returns input of inferred columns, like client does for INSERT.
+func autoInput(t *testing.T, names []string, types []proto.ColumnType) proto.Input {
+	t.Helper()
+	var input proto.Input
+	for i, name := range names {
+		col := new(proto.ColAuto)
+		require.NoError(t, col.Infer(types[i]))
+		input = append(input, proto.InputColumn{Name: name, Data: col})
+	}
+	return input
+}
+
+// readAll reads all blocks, formatting them as TabSeparated.
+func readAll(t *testing.T, r *Reader, input proto.Input) (string, int) {
+	t.Helper()
+	var (
+		out    bytes.Buffer
+		blocks int
+	)
+	w := NewWriter(&out, TabSeparated)
+	for {
+		err := r.OnInput(context.Background())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, w.WriteInput(input))
+		blocks++
+	}
+	return out.String(), blocks
+}
+
+func TestReader(t *testing.T) {
+	names := []string{"id", "s", "d", "t", "e", "n", "arr", "m"}
+	types := []proto.ColumnType{
+		"UInt64",
+		"String",
+		"Decimal(18, 2)",
+		"DateTime('UTC')",
+		"Enum8('a' = 1, 'b' = 2)",
+		"Nullable(String)",
+		"Array(String)",
+		"Map(String, UInt8)",
+	}
+	var want bytes.Buffer
+	require.NoError(t, NewWriter(&want, TabSeparated).WriteResults(testResults()))
+
+	for _, f := range []Format{
+		TabSeparated,
+		TabSeparatedWithNames,
+		CSV,
+		CSVWithNames,
+		JSONEachRow,
+	} {
+		t.Run(f.String(), func(t *testing.T) {
+			var data bytes.Buffer
+			require.NoError(t, NewWriter(&data, f).WriteResults(testResults()))
+
+			input := autoInput(t, names, types)
+			got, blocks := readAll(t, NewReader(&data, f, input).WithBlockRows(1), input)
+			require.Equal(t, want.String(), got)
+			require.Equal(t, 2, blocks)
+		})
+	}
+}
+
+func TestReader_Nested(t *testing.T) {
+	names := []string{"tuple", "arr", "fixed", "big"}
+	types := []proto.ColumnType{
+		"Tuple(a String, b Nullable(Int8))",
+		"Array(Array(Nullable(UInt8)))",
+		"FixedString(4)",
+		"Int128",
+	}
+	for _, tt := range []struct {
+		Format Format
+		Input  string
+	}{
+		{Format: TabSeparated, Input: "('x',NULL)\t[[1,NULL],[]]\tab\t-170141183460469231731687303715884105728\n"},
+		{Format: CSV, Input: "\"x\",\\N,\"[[1,NULL],[]]\",ab,-170141183460469231731687303715884105728\r\n"},
+		{Format: JSONEachRow, Input: `{"tuple":{"a":"x"},"arr":[[1,null],[]],"fixed":"ab","big":"-170141183460469231731687303715884105728"}`},
+	} {
+		t.Run(tt.Format.String(), func(t *testing.T) {
+			input := autoInput(t, names, types)
+			got, _ := readAll(t, NewReader(strings.NewReader(tt.Input), tt.Format, input), input)
+			require.Equal(t, "('x',NULL)\t[[1,NULL],[]]\tab\\0\\0\t-170141183460469231731687303715884105728\n", got)
+		})
+	}
+}
+
+func TestReader_Defaults(t *testing.T) {
+	input := autoInput(t,
+		[]string{"a", "b", "c"},
+		[]proto.ColumnType{"UInt8", "Nullable(String)", "Array(String)"},
+	)
+	got, _ := readAll(t, NewReader(strings.NewReader(`{"a":1}`+"\n"+`{"c":["x"],"a":2}`), JSONEachRow, input), input)
+	require.Equal(t, "1\t\\N\t[]\n2\t\\N\t['x']\n", got)
+
+	input = autoInput(t,
+		[]string{"a", "b"},
+		[]proto.ColumnType{"UInt8", "String"},
+	)
+	got, _ = readAll(t, NewReader(strings.NewReader("\"b\"\n\"x\"\n"), CSVWithNames, input), input)
+	require.Equal(t, "0\tx\n", got)
+}
+
+func TestReader_Error(t *testing.T) {
+	for _, tt := range []struct {
+		Format Format
+		Input  string
+	}{
+		{Format: TabSeparated, Input: "1\t2\n3\n"},
+		{Format: TabSeparated, Input: "1\tx\n"},
+		{Format: CSVWithNames, Input: "a,c\n1,2\n"},
+		{Format: JSONEachRow, Input: `{"c":1}`},
+		{Format: JSONEachRow, Input: `{"a":1,"a":2}`},
+		{Format: CSV, Input: "1,\"2\n"},
+		{Format: "XML", Input: "1,2\n"},
+	} {
+		input := autoInput(t, []string{"a", "b"}, []proto.ColumnType{"UInt8", "UInt8"})
+		r := NewReader(strings.NewReader(tt.Input), tt.Format, input)
+		var err error
+		for err == nil {
+			err = r.OnInput(context.Background())
+		}
+		require.NotErrorIs(t, err, io.EOF, "%s: %q", tt.Format, tt.Input)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	f, err := parseText(`{'k\'s':[1, NULL], '':(2,'a''b')}`)
+	require.NoError(t, err)
+	require.Equal(t, field{
+		kind: fieldObject,
+		keys: []field{
+			{kind: fieldText, text: "k's"},
+			{kind: fieldText, text: ""},
+		},
+		elems: []field{
+			{kind: fieldList, elems: []field{{kind: fieldText, text: "1"}, {kind: fieldNull}}},
+			{kind: fieldList, elems: []field{{kind: fieldText, text: "2"}, {kind: fieldText, text: "a'b"}}},
+		},
+	}, f)
+
+	for _, s := range []string{"", "[1", "[1,]", "'a", "(1) 2", "{1}"} {
+		_, err := parseText(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,327 @@
+package chformat
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// scalarKind describes how scalar is represented in formats.
+type scalarKind byte
+
+const (
+	scalarText   scalarKind = iota // quoted in nested values, CSV and JSON
+	scalarNumber                   // never quoted
+	scalarFloat                    // never quoted, but can be nan or inf
+	scalarInt64                    // quoted in JSON, as 64-bit integers are not precise there
+)
+
+// appendScalar appends text representation of v of type t.
+func appendScalar(b []byte, t proto.Type, loc *time.Location, v any) ([]byte, scalarKind) {
+	switch v := v.(type) {
+	case string:
+		return append(b, v...), scalarText
+	case []byte:
+		return append(b, v...), scalarText
+	case bool:
+		return strconv.AppendBool(b, v), scalarNumber
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10), scalarNumber
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10), scalarNumber
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), scalarNumber
+	case int64:
+		return strconv.AppendInt(b, v, 10), scalarInt64
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10), scalarNumber
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10), scalarNumber
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10), scalarNumber
+	case uint64:
+		return strconv.AppendUint(b, v, 10), scalarInt64
+	case float32:
+		return appendFloat(b, float64(v), 32), scalarFloat
+	case float64:
+		return appendFloat(b, v, 64), scalarFloat
+	case proto.Int128:
+		return int128(v).Append(b, 10), scalarInt64
+	case proto.UInt128:
+		return uint128(v).Append(b, 10), scalarInt64
+	case proto.Int256:
+		return int256(v).Append(b, 10), scalarInt64
+	case proto.UInt256:
+		return uint256(v).Append(b, 10), scalarInt64
+	case proto.Decimal32:
+		return appendDecimal(b, big.NewInt(int64(v)), t.Scale), scalarNumber
+	case proto.Decimal64:
+		return appendDecimal(b, big.NewInt(int64(v)), t.Scale), scalarNumber
+	case proto.Decimal128:
+		return appendDecimal(b, int128(proto.Int128(v)), t.Scale), scalarNumber
+	case proto.Decimal256:
+		return appendDecimal(b, int256(proto.Int256(v)), t.Scale), scalarNumber
+	case proto.Enum8:
+		return appendEnum(b, t, int(v)), scalarText
+	case proto.Enum16:
+		return appendEnum(b, t, int(v)), scalarText
+	case time.Time:
+		return appendTime(b, t, loc, v), scalarText
+	case proto.Date:
+		return appendTime(b, t, loc, v.Time()), scalarText
+	case proto.Date32:
+		return appendTime(b, t, loc, v.Time()), scalarText
+	case proto.DateTime:
+		return appendTime(b, t, loc, v.Time()), scalarText
+	case proto.DateTime64:
+		return appendTime(b, t, loc, v.Time(proto.Precision(t.Precision))), scalarText
+	case uuid.UUID:
+		return append(b, v.String()...), scalarText
+	case fmt.Stringer:
+		return append(b, v.String()...), scalarText
+	}
+	if a, ok := fixedBytes(v); ok {
+		return append(b, a...), scalarText
+	}
+	return fmt.Append(b, v), scalarText
+}
+
+// fixedBytes returns bytes of [N]byte value, e.g. of ColFixedStr16.
+func fixedBytes(v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case [8]byte:
+		return v[:], true
+	case [16]byte:
+		return v[:], true
+	case [32]byte:
+		return v[:], true
+	case [64]byte:
+		return v[:], true
+	case [128]byte:
+		return v[:], true
+	case [256]byte:
+		return v[:], true
+	case [512]byte:
+		return v[:], true
+	default:
+		return nil, false
+	}
+}
+
+// appendFloat appends shortest representation of f, like ClickHouse does:
+// exponent is used for values less than 1e-6 or not less than 1e21.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, "nan"...)
+	case math.IsInf(f, 1):
+		return append(b, "inf"...)
+	case math.IsInf(f, -1):
+		return append(b, "-inf"...)
+	}
+	if f == 0 || math.Abs(f) >= 1e-6 && math.Abs(f) < 1e21 {
+		return strconv.AppendFloat(b, f, 'f', -1, bits)
+	}
+	// Converting 1.5e-07 to 1.5e-7.
+	e := strconv.AppendFloat(nil, f, 'e', -1, bits)
+	for i := len(e) - 1; i > 0; i-- {
+		if e[i] != 'e' {
+			continue
+		}
+		b = append(b, e[:i+1]...)
+		exp := e[i+1:]
+		if exp[0] == '-' {
+			b = append(b, '-')
+		}
+		exp = exp[1:]
+		for len(exp) > 1 && exp[0] == '0' {
+			exp = exp[1:]
+		}
+		return append(b, exp...)
+	}
+	return append(b, e...)
+}
+
+// appendDecimal appends v scaled by scale, without trailing zeros.
+func appendDecimal(b []byte, v *big.Int, scale int) []byte {
+	if v.Sign() < 0 {
+		b = append(b, '-')
+		v = new(big.Int).Neg(v)
+	}
+	s := v.Text(10)
+	if scale <= 0 {
+		return append(b, s...)
+	}
+	for len(s) <= scale {
+		s = "0" + s
+	}
+	whole, frac := s[:len(s)-scale], s[len(s)-scale:]
+	for frac != "" && frac[len(frac)-1] == '0' {
+		frac = frac[:len(frac)-1]
+	}
+	b = append(b, whole...)
+	if frac != "" {
+		b = append(b, '.')
+		b = append(b, frac...)
+	}
+	return b
+}
+
+func uint128(v proto.UInt128) *big.Int {
+	r := new(big.Int).SetUint64(v.High)
+	r.Lsh(r, 64)
+	return r.Or(r, new(big.Int).SetUint64(v.Low))
+}
+
+func int128(v proto.Int128) *big.Int {
+	r := uint128(proto.UInt128(v))
+	if int64(v.High) < 0 {
+		r.Sub(r, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return r
+}
+
+func uint256(v proto.UInt256) *big.Int {
+	r := uint128(v.High)
+	r.Lsh(r, 128)
+	return r.Or(r, uint128(v.Low))
+}
+
+func int256(v proto.Int256) *big.Int {
+	r := uint256(proto.UInt256(v))
+	if int64(v.High.High) < 0 {
+		r.Sub(r, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return r
+}
+
+func appendEnum(b []byte, t proto.Type, v int) []byte {
+	for _, e := range t.Enum {
+		if e.Value == v {
+			return append(b, e.Name...)
+		}
+	}
+	return strconv.AppendInt(b, int64(v), 10)
+}
+
+// appendTime appends Date, Date32, DateTime or DateTime64 value.
+//
+// Timezone of column type is used if set, otherwise timezone of value.
+func appendTime(b []byte, t proto.Type, loc *time.Location, v time.Time) []byte {
+	switch t.Name {
+	case proto.ColumnTypeDate, proto.ColumnTypeDate32:
+		// Dates are stored as days since epoch.
+		return v.UTC().AppendFormat(b, proto.DateLayout)
+	}
+	if loc != nil {
+		v = v.In(loc)
+	}
+	b = v.AppendFormat(b, "2006-01-02 15:04:05")
+	if t.Name == proto.ColumnTypeDateTime64 && t.Precision > 0 {
+		b = append(b, '.')
+		frac := strconv.AppendInt(nil, int64(v.Nanosecond()), 10)
+		for i := len(frac); i < 9; i++ {
+			b = append(b, '0')
+		}
+		b = append(b, frac...)
+		b = b[:len(b)-(9-t.Precision)]
+	}
+	return b
+}
+
+// appendEscaped appends s with escaping of TabSeparated format.
+func appendEscaped(b, s []byte) []byte {
+	for _, c := range s {
+		switch c {
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		case 0:
+			b = append(b, '\\', '0')
+		case '\\', '\'':
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// appendQuoted appends s as quoted string literal, e.g. 'it\'s'.
+func appendQuoted(b, s []byte) []byte {
+	b = append(b, '\'')
+	b = appendEscaped(b, s)
+	return append(b, '\'')
+}
+
+// appendCSV appends s as CSV string, doubling quotes.
+func appendCSV(b, s []byte) []byte {
+	b = append(b, '"')
+	for _, c := range s {
+		if c == '"' {
+			b = append(b, '"')
+		}
+		b = append(b, c)
+	}
+	return append(b, '"')
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSON appends s as JSON string.
+//
+// Forward slashes are escaped, like ClickHouse does by default.
+func appendJSON(b, s []byte) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '"', '\\', '/':
+			b = append(b, '\\', c)
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			if c < 0x20 {
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+				break
+			}
+			if c >= utf8.RuneSelf {
+				// Line and paragraph separators are invalid in JavaScript strings.
+				r, size := utf8.DecodeRune(s[i:])
+				if r == '\u2028' || r == '\u2029' {
+					b = append(b, `\u202`...)
+					b = append(b, hex[r&0xf])
+				} else {
+					b = append(b, s[i:i+size]...)
+				}
+				i += size
+				continue
+			}
+			b = append(b, c)
+		}
+		i++
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,192 @@
+package chformat
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// column is common part of proto.ColInput and proto.ColResult.
+type column interface {
+	Type() proto.ColumnType
+	Rows() int
+}
+
+type kind byte
+
+const (
+	kindScalar kind = iota
+	kindNullable
+	kindArray
+	kindMap
+	kindTuple
+)
+
+// value is column prepared for formatting.
+type value struct {
+	kind kind
+	typ  proto.Type // of scalar
+
+	row     func(i int) any // of scalar
+	loc     *time.Location  // of DateTime or DateTime64 with timezone
+	nulls   proto.ColUInt8  // of nullable
+	offsets proto.ColUInt64 // of array or map
+	elems   []*value        // nullable value, array element, map key and value, or tuple elements
+	names   []string        // of named tuple
+}
+
+// newValue prepares column of type t.
+func newValue(col column, t proto.Type) (*value, error) {
+	col = unwrap(col)
+	switch t.Name {
+	case proto.ColumnTypeLowCardinality, proto.ColumnTypeSimpleAggregateFunction:
+		// Transparent for formatting.
+		return newValue(col, t.Elems[len(t.Elems)-1])
+	case proto.ColumnTypeNullable:
+		nulls, ok := structField(col, "Nulls").(proto.ColUInt8)
+		if !ok {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		elem, err := elemValue(col, "Values", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		return &value{kind: kindNullable, nulls: nulls, elems: []*value{elem}}, nil
+	case proto.ColumnTypeArray:
+		offsets, ok := structField(col, "Offsets").(proto.ColUInt64)
+		if !ok {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		elem, err := elemValue(col, "Data", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		return &value{kind: kindArray, offsets: offsets, elems: []*value{elem}}, nil
+	case proto.ColumnTypeMap:
+		offsets, ok := structField(col, "Offsets").(proto.ColUInt64)
+		if !ok || len(t.Elems) != 2 {
+			return nil, errors.Errorf("unsupported %T column for %s", col, t)
+		}
+		k, err := elemValue(col, "Keys", t.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		v, err := elemValue(col, "Values", t.Elems[1])
+		if err != nil {
+			return nil, err
+		}
+		return &value{kind: kindMap, offsets: offsets, elems: []*value{k, v}}, nil
+	case proto.ColumnTypeTuple:
+		return newTuple(col, t)
+	}
+	v := &value{
+		kind: kindScalar,
+		typ:  t,
+	}
+	if t.Timezone != "" {
+		loc, err := time.LoadLocation(t.Timezone)
+		if err != nil {
+			return nil, errors.Wrap(err, "timezone")
+		}
+		v.loc = loc
+	}
+	m := reflect.ValueOf(col).MethodByName("Row")
+	if !m.IsValid() || m.Type().NumIn() != 1 || m.Type().NumOut() != 1 {
+		return nil, errors.Errorf("unsupported %T column for %s", col, t)
+	}
+	v.row = func(i int) any {
+		return m.Call([]reflect.Value{reflect.ValueOf(i)})[0].Interface()
+	}
+	return v, nil
+}
+
+func newTuple(col column, t proto.Type) (*value, error) {
+	tuple, ok := col.(proto.ColTuple)
+	if !ok {
+		if p, isPtr := col.(*proto.ColTuple); isPtr {
+			tuple, ok = *p, true
+		}
+	}
+	if !ok || len(tuple) != len(t.Elems) {
+		return nil, errors.Errorf("unsupported %T column for %s", col, t)
+	}
+	v := &value{kind: kindTuple, names: t.Names}
+	for i, c := range tuple {
+		elem, err := newValue(c, t.Elems[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		v.elems = append(v.elems, elem)
+	}
+	if v.names == nil {
+		// Names can be set by columns only, e.g. by ColNamed.
+		names := make([]string, 0, len(tuple))
+		for _, c := range tuple {
+			n, ok := c.(interface{ ColumnName() string })
+			if !ok || n.ColumnName() == "" {
+				names = nil
+				break
+			}
+			names = append(names, n.ColumnName())
+		}
+		v.names = names
+	}
+	return v, nil
+}
+
+func elemValue(col column, name string, t proto.Type) (*value, error) {
+	c, ok := structField(col, name).(column)
+	if !ok {
+		return nil, errors.Errorf("unsupported %T column for %s", col, t)
+	}
+	v, err := newValue(c, t)
+	if err != nil {
+		return nil, errors.Wrap(err, name)
+	}
+	return v, nil
+}
+
+// structField returns value of exported struct field of column, if any.
+func structField(col column, name string) any {
+	v := reflect.Indirect(reflect.ValueOf(col))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName(name)
+	if !f.IsValid() || !f.CanInterface() {
+		return nil
+	}
+	return f.Interface()
+}
+
+// unwrap returns underlying column of wrappers like ColAuto, ColNamed or
+// column returned by proto.Alias.
+func unwrap(col column) column {
+	for {
+		if c, ok := col.(*proto.ColAuto); ok && c.Data != nil {
+			col = c.Data
+			continue
+		}
+		v := reflect.Indirect(reflect.ValueOf(col))
+		if v.Kind() != reflect.Struct {
+			return col
+		}
+		var inner column
+		for _, name := range []string{"Column", "ColumnOf"} {
+			f, ok := v.Type().FieldByName(name)
+			if !ok || !f.Anonymous || len(f.Index) != 1 {
+				continue
+			}
+			if c, ok := v.FieldByIndex(f.Index).Interface().(column); ok {
+				inner = c
+			}
+		}
+		if inner == nil {
+			return col
+		}
+		col = inner
+	}
+}
@@ -0,0 +1,152 @@
+package chformat
+
+import (
+	"io"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Writer writes blocks of columns in Format.
+//
+// Values are formatted per column type like ClickHouse does with default
+// settings, e.g. DateTime in timezone of column, Decimal with scale and
+// Enum as names.
+type Writer struct {
+	w      io.Writer
+	format Format
+	header bool // written
+
+	enc  encoder
+	buf  []byte
+	cols []*value
+}
+
+// NewWriter returns new Writer of format f.
+func NewWriter(w io.Writer, f Format) *Writer {
+	return &Writer{w: w, format: f}
+}
+
+// WriteResults writes block of result columns, e.g. in Query.OnResult.
+func (w *Writer) WriteResults(cols proto.Results) error {
+	names := make([]string, len(cols))
+	data := make([]column, len(cols))
+	for i, c := range cols {
+		names[i], data[i] = c.Name, c.Data
+	}
+	return w.write(names, data)
+}
+
+// WriteInput writes block of input columns.
+func (w *Writer) WriteInput(cols proto.Input) error {
+	names := make([]string, len(cols))
+	data := make([]column, len(cols))
+	for i, c := range cols {
+		names[i], data[i] = c.Name, c.Data
+	}
+	return w.write(names, data)
+}
+
+func (w *Writer) write(names []string, data []column) error {
+	rows, err := w.prepare(names, data)
+	if err != nil {
+		return err
+	}
+	b := w.buf[:0]
+	switch w.format {
+	case TabSeparated, TabSeparatedWithNames:
+		if w.format.withNames() && !w.header {
+			for i, name := range names {
+				if i > 0 {
+					b = append(b, '\t')
+				}
+				b = appendEscaped(b, []byte(name))
+			}
+			b = append(b, '\n')
+		}
+		b = w.appendRows(b, rows, modeEscaped, '\t')
+	case CSV, CSVWithNames:
+		if w.format.withNames() && !w.header {
+			for i, name := range names {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				b = appendCSV(b, []byte(name))
+			}
+			b = append(b, '\n')
+		}
+		b = w.appendRows(b, rows, modeCSV, ',')
+	case JSONEachRow:
+		for i := 0; i < rows; i++ {
+			b = append(b, '{')
+			for j, v := range w.cols {
+				if j > 0 {
+					b = append(b, ',')
+				}
+				b = appendJSON(b, []byte(names[j]))
+				b = append(b, ':')
+				b = w.enc.appendValue(b, v, i, modeJSON)
+			}
+			b = append(b, '}', '\n')
+		}
+	case Pretty:
+		b = w.appendPretty(b, names, rows)
+	}
+	w.header = true
+	w.buf = b
+	if len(b) == 0 {
+		return nil
+	}
+	if _, err := w.w.Write(b); err != nil {
+		return errors.Wrap(err, "write")
+	}
+	return nil
+}
+
+// prepare columns of block, returning count of rows.
+func (w *Writer) prepare(names []string, data []column) (int, error) {
+	switch w.format {
+	case TabSeparated, TabSeparatedWithNames, CSV, CSVWithNames, JSONEachRow, Pretty:
+	default:
+		return 0, errors.Errorf("unsupported format %q", w.format)
+	}
+	w.cols = w.cols[:0]
+	rows := -1
+	for i, c := range data {
+		if c == nil {
+			return 0, errors.Errorf("column %q: no data", names[i])
+		}
+		if rows == -1 {
+			rows = c.Rows()
+		} else if c.Rows() != rows {
+			return 0, errors.Errorf("column %q: %d rows, expected %d", names[i], c.Rows(), rows)
+		}
+		t, err := proto.ParseType(c.Type())
+		if err != nil {
+			return 0, errors.Wrapf(err, "column %q", names[i])
+		}
+		v, err := newValue(c, t)
+		if err != nil {
+			return 0, errors.Wrapf(err, "column %q", names[i])
+		}
+		w.cols = append(w.cols, v)
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	return rows, nil
+}
+
+func (w *Writer) appendRows(b []byte, rows int, m mode, delim byte) []byte {
+	for i := 0; i < rows; i++ {
+		for j, v := range w.cols {
+			if j > 0 {
+				b = append(b, delim)
+			}
+			b = w.enc.appendValue(b, v, i, m)
+		}
+		b = append(b, '\n')
+	}
+	return b
+}
@@ -0,0 +1,217 @@
+package chformat
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func testResults() proto.Results {
+	var (
+		id   proto.ColUInt64
+		str  proto.ColStr
+		dec  proto.ColDecimal64
+		ts   = &proto.ColDateTime{Location: time.UTC}
+		enum proto.ColEnum8
+		null = proto.NewColNullable[string](new(proto.ColStr))
+		arr  = proto.NewArray[string](new(proto.ColStr))
+		m    = proto.NewMap[string, uint8](new(proto.ColStr), new(proto.ColUInt8))
+	)
+	id.AppendArr([]uint64{1, math.MaxUint64})
+	str.AppendArr([]string{"it's\ttab", `a"b/c`})
+	dec.AppendArr([]proto.Decimal64{150, -5})
+	ts.Append(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	ts.Append(time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC))
+	enum.AppendArr([]proto.Enum8{1, 2})
+	null.Append(proto.NewNullable("x"))
+	null.Append(proto.Null[string]())
+	arr.Append([]string{"a", "b'c"})
+	arr.Append(nil)
+	m.AppendKV([]proto.KV[string, uint8]{{Key: "k", Value: 1}, {Key: "j", Value: 2}})
+	m.AppendKV(nil)
+	return proto.Results{
+		{Name: "id", Data: &id},
+		{Name: "s", Data: &str},
+		{Name: "d", Data: proto.Alias(&dec, "Decimal(18, 2)")},
+		{Name: "t", Data: ts},
+		{Name: "e", Data: proto.Alias(&enum, "Enum8('a' = 1, 'b' = 2)")},
+		{Name: "n", Data: null},
+		{Name: "arr", Data: arr},
+		{Name: "m", Data: m},
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for _, tt := range []struct {
+		Format Format
+		Output string
+	}{
+		{
+			Format: TabSeparated,
+			Output: "1\tit\\'s\\ttab\t1.5\t2024-01-02 03:04:05\ta\tx\t['a','b\\'c']\t{'k':1,'j':2}\n" +
+				"18446744073709551615\ta\"b/c\t-0.05\t2024-12-31 23:59:59\tb\t\\N\t[]\t{}\n",
+		},
+		{
+			Format: TabSeparatedWithNames,
+			Output: "id\ts\td\tt\te\tn\tarr\tm\n" +
+				"1\tit\\'s\\ttab\t1.5\t2024-01-02 03:04:05\ta\tx\t['a','b\\'c']\t{'k':1,'j':2}\n" +
+				"18446744073709551615\ta\"b/c\t-0.05\t2024-12-31 23:59:59\tb\t\\N\t[]\t{}\n",
+		},
+		{
+			Format: CSV,
+			Output: "1,\"it's\ttab\",1.5,\"2024-01-02 03:04:05\",\"a\",\"x\",\"['a','b\\'c']\",\"{'k':1,'j':2}\"\n" +
+				"18446744073709551615,\"a\"\"b/c\",-0.05,\"2024-12-31 23:59:59\",\"b\",\\N,\"[]\",\"{}\"\n",
+		},
+		{
+			Format: CSVWithNames,
+			Output: "\"id\",\"s\",\"d\",\"t\",\"e\",\"n\",\"arr\",\"m\"\n" +
+				"1,\"it's\ttab\",1.5,\"2024-01-02 03:04:05\",\"a\",\"x\",\"['a','b\\'c']\",\"{'k':1,'j':2}\"\n" +
+				"18446744073709551615,\"a\"\"b/c\",-0.05,\"2024-12-31 23:59:59\",\"b\",\\N,\"[]\",\"{}\"\n",
+		},
+		{
+			Format: JSONEachRow,
+			Output: `{"id":"1","s":"it's\ttab","d":1.5,"t":"2024-01-02 03:04:05","e":"a","n":"x","arr":["a","b'c"],"m":{"k":1,"j":2}}` + "\n" +
+				`{"id":"18446744073709551615","s":"a\"b\/c","d":-0.05,"t":"2024-12-31 23:59:59","e":"b","n":null,"arr":[],"m":{}}` + "\n",
+		},
+		{
+			Format: Pretty,
+			Output: "" +
+				"┏━━━━━━━━━━━━━━━━━━━━━━┳━━━━━━━━━━━┳━━━━━━━┳━━━━━━━━━━━━━━━━━━━━━┳━━━┳━━━━━━┳━━━━━━━━━━━━━━┳━━━━━━━━━━━━━━━┓\n" +
+				"┃                   id ┃ s         ┃     d ┃ t                   ┃ e ┃ n    ┃ arr          ┃ m             ┃\n" +
+				"┡━━━━━━━━━━━━━━━━━━━━━━╇━━━━━━━━━━━╇━━━━━━━╇━━━━━━━━━━━━━━━━━━━━━╇━━━╇━━━━━━╇━━━━━━━━━━━━━━╇━━━━━━━━━━━━━━━┩\n" +
+				"│                    1 │ it's\\ttab │   1.5 │ 2024-01-02 03:04:05 │ a │ x    │ ['a','b\\'c'] │ {'k':1,'j':2} │\n" +
+				"├──────────────────────┼───────────┼───────┼─────────────────────┼───┼──────┼──────────────┼───────────────┤\n" +
+				"│ 18446744073709551615 │ a\"b/c     │ -0.05 │ 2024-12-31 23:59:59 │ b │ ᴺᵁᴸᴸ │ []           │ {}            │\n" +
+				"└──────────────────────┴───────────┴───────┴─────────────────────┴───┴──────┴──────────────┴───────────────┘\n",
+		},
+	} {
+		t.Run(tt.Format.String(), func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewWriter(&out, tt.Format)
+			require.NoError(t, w.WriteResults(testResults()))
+			require.Equal(t, tt.Output, out.String())
+		})
+	}
+}
+
+func TestWriter_Header(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		data proto.ColUInt8
+	)
+	w := NewWriter(&out, TabSeparatedWithNames)
+	results := proto.Results{{Name: "v", Data: &data}}
+
+	// Header is written once, even for empty block.
+	require.NoError(t, w.WriteResults(results))
+	data.Append(1)
+	require.NoError(t, w.WriteResults(results))
+	data.Reset()
+	data.Append(2)
+	require.NoError(t, w.WriteResults(results))
+	require.Equal(t, "v\n1\n2\n", out.String())
+}
+
+func TestWriter_Nested(t *testing.T) {
+	var out bytes.Buffer
+	tuple := proto.ColTuple{
+		proto.Named[string](new(proto.ColStr), "a"),
+		proto.Named[int64](new(proto.ColInt64), "b"),
+	}
+	tuple[0].(*proto.ColNamed[string]).Append("x")
+	tuple[1].(*proto.ColNamed[int64]).Append(-1)
+	unnamed := proto.ColTuple{new(proto.ColStr), new(proto.ColFloat64)}
+	unnamed[0].(*proto.ColStr).Append("y")
+	unnamed[1].(*proto.ColFloat64).Append(math.NaN())
+	lc := proto.NewLowCardinality[string](new(proto.ColStr))
+	lc.Append("lc")
+	results := proto.Results{
+		{Name: "named", Data: tuple},
+		{Name: "unnamed", Data: unnamed},
+		{Name: "lc", Data: lc},
+	}
+
+	for _, tt := range []struct {
+		Format Format
+		Output string
+	}{
+		{Format: TabSeparated, Output: "('x',-1)\t('y',nan)\tlc\n"},
+		{Format: CSV, Output: "\"x\",-1,\"y\",nan,\"lc\"\n"},
+		{Format: JSONEachRow, Output: `{"named":{"a":"x","b":"-1"},"unnamed":["y",null],"lc":"lc"}` + "\n"},
+	} {
+		t.Run(tt.Format.String(), func(t *testing.T) {
+			out.Reset()
+			require.NoError(t, NewWriter(&out, tt.Format).WriteResults(results))
+			require.Equal(t, tt.Output, out.String())
+		})
+	}
+}
+
+func TestWriter_Error(t *testing.T) {
+	var (
+		out bytes.Buffer
+		a   proto.ColUInt8
+		b   proto.ColUInt8
+	)
+	a.Append(1)
+	require.Error(t, NewWriter(&out, "XML").WriteResults(proto.Results{{Name: "a", Data: &a}}))
+	require.Error(t, NewWriter(&out, CSV).WriteResults(proto.Results{
+		{Name: "a", Data: &a},
+		{Name: "b", Data: &b},
+	}))
+}
+
+func TestAppendFloat(t *testing.T) {
+	for _, tt := range []struct {
+		Value  float64
+		Output string
+	}{
+		{0, "0"},
+		{0.1, "0.1"},
+		{-1.5, "-1.5"},
+		{1e-6, "0.000001"},
+		{1e-7, "1e-7"},
+		{1.5e-10, "1.5e-10"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e21"},
+		{math.Inf(1), "inf"},
+		{math.Inf(-1), "-inf"},
+		{math.NaN(), "nan"},
+	} {
+		require.Equal(t, tt.Output, string(appendFloat(nil, tt.Value, 64)))
+	}
+	require.Equal(t, "0.1", string(appendFloat(nil, float64(float32(0.1)), 32)))
+}
+
+func TestAppendScalar(t *testing.T) {
+	dec, err := proto.ParseType("Decimal(38, 3)")
+	require.NoError(t, err)
+	dt64, err := proto.ParseType("DateTime64(3, 'UTC')")
+	require.NoError(t, err)
+	for _, tt := range []struct {
+		Type   proto.Type
+		Value  any
+		Output string
+	}{
+		{Type: dec, Value: proto.Decimal128(proto.Int128FromInt(-1234)), Output: "-1.234"},
+		{Type: dec, Value: proto.Decimal128(proto.Int128FromInt(1000)), Output: "1"},
+		{Type: dec, Value: proto.Decimal128(proto.Int128FromInt(5)), Output: "0.005"},
+		{Value: proto.Int128FromInt(-42), Output: "-42"},
+		{Value: proto.UInt256{High: proto.UInt128{Low: 1}}, Output: "340282366920938463463374607431768211456"},
+		{Type: dt64, Value: time.Date(2024, 1, 2, 3, 4, 5, 67e6, time.UTC), Output: "2024-01-02 03:04:05.067"},
+		{Value: proto.IPv4(0x7f000001), Output: "127.0.0.1"},
+		{Value: [8]byte{'a', 'b'}, Output: "ab\x00\x00\x00\x00\x00\x00"},
+	} {
+		out, _ := appendScalar(nil, tt.Type, time.UTC, tt.Value)
+		require.Equal(t, tt.Output, string(out))
+	}
+}
+
+func TestAppendJSON(t *testing.T) {
+	require.Equal(t, `"a\"\\\/\n\u0001\u2028é"`, string(appendJSON(nil, []byte("a\"\\/\n\x01\u2028é"))))
+}
@@ -0,0 +1,56 @@
+package ch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/chformat"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestClient_Format(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	conn := Conn(t)
+	require.NoError(t, conn.Do(ctx, Query{
+		Body: "CREATE TABLE test_table (id UInt64, name String, tags Array(String), v Nullable(Decimal(9, 2))) ENGINE = MergeTree ORDER BY id",
+	}), "create table")
+
+	const data = "id,name,tags,v\n" +
+		"1,\"it's\",\"['a','b']\",1.5\n" +
+		"2,\"b\"\"c\",\"[]\",\\N\n" +
+		"3,c,\"['d']\",-0.25\n"
+	input := proto.Input{
+		{Name: "id", Data: new(proto.ColAuto)},
+		{Name: "name", Data: new(proto.ColAuto)},
+		{Name: "tags", Data: new(proto.ColAuto)},
+		{Name: "v", Data: new(proto.ColAuto)},
+	}
+	r := chformat.NewReader(strings.NewReader(data), chformat.CSVWithNames, input).WithBlockRows(2)
+	require.NoError(t, conn.Do(ctx, Query{
+		Body:    input.Into("test_table"),
+		Input:   input,
+		OnInput: r.OnInput,
+	}), "insert")
+
+	var (
+		out     bytes.Buffer
+		results proto.Results
+	)
+	w := chformat.NewWriter(&out, chformat.CSVWithNames)
+	require.NoError(t, conn.Do(ctx, Query{
+		Body:   "SELECT * FROM test_table ORDER BY id",
+		Result: results.Auto(),
+		OnResult: func(ctx context.Context, block proto.Block) error {
+			return w.WriteResults(results)
+		},
+	}), "select")
+	require.Equal(t, "\"id\",\"name\",\"tags\",\"v\"\n"+
+		"1,\"it's\",\"['a','b']\",1.5\n"+
+		"2,\"b\"\"c\",\"[]\",\\N\n"+
+		"3,\"c\",\"['d']\",-0.25\n", out.String())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/puddle/v2"
 
 	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
 )
 
 // Client is an acquired *ch.Client from a Pool.
@@ -39,6 +40,11 @@ func (c *Client) Ping(ctx context.Context) error {
 	return c.client().Ping(ctx)
 }
 
+// TablesStatus requests status of tables, see ch.Client.TablesStatus.
+func (c *Client) TablesStatus(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+	return c.client().TablesStatus(ctx, tables)
+}
+
 func (c *Client) Close() error {
 	var err error
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
 
 	"github.com/jackc/puddle/v2"
 )
@@ -144,6 +145,18 @@ func (p *Pool) Ping(ctx context.Context) error {
 	return c.Ping(ctx)
 }
 
+// TablesStatus requests status of tables on connection from pool, see
+// ch.Client.TablesStatus.
+func (p *Pool) TablesStatus(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+	c, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Release()
+
+	return c.TablesStatus(ctx, tables)
+}
+
 func (p *Pool) backgroundHealthCheck() {
 	defer p.wg.Done()
 	ticker := time.NewTicker(p.options.HealthCheckPeriod)
@@ -0,0 +1,201 @@
+package chpool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// ReplicaOptions for Replicas.
+type ReplicaOptions struct {
+	// Tables to check replication delay of, like tables of Distributed query.
+	Tables []proto.TableName
+	// MaxDelay is maximum replication delay of replica, like
+	// max_replica_delay_for_distributed_queries setting.
+	//
+	// Stale replicas are used only if there are no other available replicas,
+	// like with fallback_to_stale_replicas_for_distributed_queries setting.
+	MaxDelay time.Duration
+	// CheckPeriod is period of replication delay checks.
+	CheckPeriod time.Duration
+}
+
+// Defaults for replicas.
+const (
+	DefaultMaxReplicaDelay    = time.Minute * 5
+	DefaultReplicaCheckPeriod = time.Second * 10
+)
+
+func (o *ReplicaOptions) setDefaults() {
+	if o.MaxDelay == 0 {
+		o.MaxDelay = DefaultMaxReplicaDelay
+	}
+	if o.CheckPeriod == 0 {
+		o.CheckPeriod = DefaultReplicaCheckPeriod
+	}
+}
+
+// replicaStatus is result of last replica check.
+type replicaStatus struct {
+	delay time.Duration
+	err   error
+}
+
+// Replicas routes queries to pools of replicas, avoiding replicas with
+// replication delay of tables above threshold, as reported by
+// TablesStatus request.
+//
+// Read-only status of tables is not taken into account, as queries are
+// not distinguished from inserts, so insert can be routed to replica
+// with read-only table and fail with TABLE_IS_READ_ONLY.
+type Replicas struct {
+	pools   []*Pool
+	options ReplicaOptions
+
+	mux    sync.Mutex
+	status []replicaStatus
+	next   int
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewReplicas returns Replicas of pools, checking replication delay of
+// each replica.
+//
+// Pools are closed on Close.
+func NewReplicas(ctx context.Context, pools []*Pool, opt ReplicaOptions) (*Replicas, error) {
+	if len(pools) == 0 {
+		return nil, errors.New("no replicas")
+	}
+	opt.setDefaults()
+	r := &Replicas{
+		pools:     pools,
+		options:   opt,
+		status:    make([]replicaStatus, len(pools)),
+		closeChan: make(chan struct{}),
+	}
+	r.check(ctx)
+
+	r.wg.Add(1)
+	go r.backgroundCheck()
+
+	return r, nil
+}
+
+func (r *Replicas) backgroundCheck() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.options.CheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closeChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.options.CheckPeriod)
+			r.check(ctx)
+			cancel()
+		}
+	}
+}
+
+// check updates status of all replicas.
+func (r *Replicas) check(ctx context.Context) {
+	status := make([]replicaStatus, len(r.pools))
+	var wg sync.WaitGroup
+	for i, p := range r.pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tables, err := p.TablesStatus(ctx, r.options.Tables)
+			if err != nil {
+				status[i].err = err
+				return
+			}
+			for _, t := range tables {
+				if !t.Replicated {
+					continue
+				}
+				if d := time.Duration(t.AbsoluteDelay) * time.Second; d > status[i].delay {
+					status[i].delay = d
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	r.mux.Lock()
+	r.status = status
+	r.mux.Unlock()
+}
+
+// Delay returns replication delay of i-th replica from last check, or error
+// if replica is not available.
+func (r *Replicas) Delay(i int) (time.Duration, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	s := r.status[i]
+	return s.delay, s.err
+}
+
+// pool returns pool of replica to use.
+//
+// Available replicas with delay not exceeding MaxDelay are used in
+// round-robin, otherwise least stale available replica is used. If there
+// are no available replicas, all of them are tried in round-robin.
+func (r *Replicas) pool() *Pool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	n := len(r.pools)
+	start := r.next
+	r.next = (r.next + 1) % n
+
+	stale := -1
+	for j := 0; j < n; j++ {
+		i := (start + j) % n
+		s := r.status[i]
+		if s.err != nil {
+			continue
+		}
+		if s.delay <= r.options.MaxDelay {
+			return r.pools[i]
+		}
+		if stale < 0 || s.delay < r.status[stale].delay {
+			stale = i
+		}
+	}
+	if stale >= 0 {
+		return r.pools[stale]
+	}
+
+	return r.pools[start]
+}
+
+// Acquire connection from pool of replica.
+func (r *Replicas) Acquire(ctx context.Context) (*Client, error) {
+	return r.pool().Acquire(ctx)
+}
+
+// Do executes query on connection from pool of replica.
+func (r *Replicas) Do(ctx context.Context, q ch.Query) (err error) {
+	return r.pool().Do(ctx, q)
+}
+
+// Close replicas and their pools.
+func (r *Replicas) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+		r.wg.Wait()
+		for _, p := range r.pools {
+			p.Close()
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package chpool
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/internal/ztest"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// replica starts server that reports replication delay of tables.
+func replica(t *testing.T, delay *atomic.Uint32) *Pool {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	s := ch.NewServer(ch.ServerOptions{
+		TablesStatus: func(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+			var result []proto.TableStatus
+			for _, table := range tables {
+				result = append(result, proto.TableStatus{
+					Table:         table,
+					Replicated:    true,
+					AbsoluteDelay: delay.Load(),
+				})
+			}
+			return result, nil
+		},
+	})
+	go func() { _ = s.Serve(ln) }()
+
+	p, err := Dial(context.Background(), Options{
+		ClientOptions: ch.Options{
+			Logger:  ztest.NewLogger(t),
+			Address: ln.Addr().String(),
+		},
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestReplicas(t *testing.T) {
+	ctx := context.Background()
+	var (
+		fresh  atomic.Uint32
+		stale  atomic.Uint32
+		tables = []proto.TableName{{Database: "default", Table: "events"}}
+	)
+	fresh.Store(1)
+	stale.Store(600)
+	r, err := NewReplicas(ctx, []*Pool{
+		replica(t, &stale),
+		replica(t, &fresh),
+	}, ReplicaOptions{
+		Tables:      tables,
+		MaxDelay:    time.Minute,
+		CheckPeriod: time.Millisecond * 50,
+	})
+	require.NoError(t, err)
+	t.Cleanup(r.Close)
+
+	delay, err := r.Delay(0)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute*10, delay)
+
+	// Reports delay of replica that handles request.
+	replicaDelay := func() uint32 {
+		t.Helper()
+		c, err := r.Acquire(ctx)
+		require.NoError(t, err)
+		defer c.Release()
+		status, err := c.TablesStatus(ctx, tables)
+		require.NoError(t, err)
+		require.Len(t, status, 1)
+		return status[0].AbsoluteDelay
+	}
+	for i := 0; i < 4; i++ {
+		require.Equal(t, uint32(1), replicaDelay())
+	}
+
+	// Least stale replica is used if all replicas are stale.
+	fresh.Store(120)
+	require.Eventually(t, func() bool {
+		d, err := r.Delay(1)
+		return err == nil && d == time.Minute*2
+	}, time.Second*5, time.Millisecond*10)
+	for i := 0; i < 4; i++ {
+		require.Equal(t, uint32(120), replicaDelay())
+	}
+
+	// Delays are refreshed in background.
+	fresh.Store(0)
+	stale.Store(0)
+	require.Eventually(t, func() bool {
+		d, err := r.Delay(0)
+		return err == nil && d == 0
+	}, time.Second*5, time.Millisecond*10)
+	require.Eventually(t, func() bool {
+		d, err := r.Delay(1)
+		return err == nil && d == 0
+	}, time.Second*5, time.Millisecond*10)
+	for i := 0; i < 4; i++ {
+		require.Equal(t, uint32(0), replicaDelay())
+	}
+
+	_, err = NewReplicas(ctx, nil, ReplicaOptions{})
+	require.Error(t, err)
+}
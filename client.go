@@ -54,6 +54,20 @@ type Client struct {
 	// see encodeBlock.
 	compressor  *compress.Writer
 	compression proto.Compression
+	// compressed writes blocks to frames, which compresses them to
+	// writer, see writeCompressed.
+	compressed *proto.Writer
+	frames     *compress.StreamWriter
+
+	// Chunking capabilities and negotiated chunked framing of packets.
+	chunkedSend proto.Chunking
+	chunkedRecv proto.Chunking
+	sendChunked bool
+
+	// writeMux serializes writes to conn, so packets, e.g. Cancel, are not
+	// written in the middle of other packet, like data block that is
+	// flushed by parts, see encodeBlock.
+	writeMux sync.Mutex
 
 	settings []Setting
 
@@ -276,6 +290,9 @@ func (c *Client) packet(ctx context.Context) (proto.ServerCode, error) {
 	return code, nil
 }
 
+// flushBuf writes b to connection as single packet.
+//
+// The c.writeMux should be held.
 func (c *Client) flushBuf(ctx context.Context, b *proto.Buffer) error {
 	defer b.Reset()
 	if err := ctx.Err(); err != nil {
@@ -285,6 +302,13 @@ func (c *Client) flushBuf(ctx context.Context, b *proto.Buffer) error {
 		// Nothing to flush.
 		return nil
 	}
+	data := b.Buf
+	if c.sendChunked {
+		var framed proto.Buffer
+		framed.PutChunk(data)
+		framed.PutChunkEnd()
+		data = framed.Buf
+	}
 	if deadline, ok := ctx.Deadline(); ok {
 		if err := c.conn.SetWriteDeadline(deadline); err != nil {
 			return errors.Wrap(err, "set write deadline")
@@ -292,11 +316,11 @@ func (c *Client) flushBuf(ctx context.Context, b *proto.Buffer) error {
 		// Reset deadline.
 		defer func() { _ = c.conn.SetWriteDeadline(time.Time{}) }()
 	}
-	n, err := c.conn.Write(b.Buf)
+	n, err := c.conn.Write(data)
 	if err != nil {
 		return errors.Wrap(err, "write")
 	}
-	if n != len(b.Buf) {
+	if n != len(data) {
 		return errors.Wrap(io.ErrShortWrite, "wrote less than expected")
 	}
 	if ce := c.lg.Check(zap.DebugLevel, "Buffer flush"); ce != nil {
@@ -309,6 +333,8 @@ func (c *Client) flush(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return errors.Wrap(err, "context")
 	}
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
 	if deadline, ok := ctx.Deadline(); ok {
 		if err := c.conn.SetWriteDeadline(deadline); err != nil {
 			return errors.Wrap(err, "set write deadline")
@@ -326,10 +352,12 @@ func (c *Client) flush(ctx context.Context) error {
 	return nil
 }
 
+// encode encodes packet.
 func (c *Client) encode(v proto.AwareEncoder) {
 	c.writer.ChainBuffer(func(b *proto.Buffer) {
 		v.EncodeAware(b, c.protocolVersion)
 	})
+	c.writer.FinishChunk()
 }
 
 //go:generate go run github.com/dmarkham/enumer -transform upper -type Compression -trimprefix Compression -output compression_enum.go
@@ -393,6 +421,14 @@ type Options struct {
 	// SSH authentication.
 	SSHSigner cryptossh.Signer
 
+	// ChunkedSend and ChunkedRecv are capabilities of chunked framing of
+	// packets sent and received by client, negotiated with server, like
+	// proto_caps setting of clickhouse-client.
+	//
+	// Default to proto.ChunkingNotChunkedOptional, so server can choose.
+	ChunkedSend proto.Chunking
+	ChunkedRecv proto.Chunking
+
 	meter  metric.Meter
 	tracer trace.Tracer
 }
@@ -415,6 +451,12 @@ func (o *Options) setDefaults() {
 	if o.ProtocolVersion == 0 {
 		o.ProtocolVersion = proto.Version
 	}
+	if o.ChunkedSend == "" {
+		o.ChunkedSend = proto.ChunkingNotChunkedOptional
+	}
+	if o.ChunkedRecv == "" {
+		o.ChunkedRecv = proto.ChunkingNotChunkedOptional
+	}
 	if o.HandshakeTimeout == 0 {
 		o.HandshakeTimeout = DefaultHandshakeTimeout
 	}
@@ -556,7 +598,13 @@ func ConnectWithBuffer(ctx context.Context, conn net.Conn, opt Options, buf *pro
 			Password:        opt.Password,
 		},
 		sshSigner: opt.SSHSigner,
+
+		chunkedSend: opt.ChunkedSend,
+		chunkedRecv: opt.ChunkedRecv,
 	}
+	c.frames = compress.NewStreamWriter(c.writer, c.compressor, compress.DefaultFrameSize)
+	c.compressed = proto.NewWriter(c.frames, new(proto.Buffer))
+	c.compressed.SetFlushSize(compress.DefaultFrameSize)
 
 	handshakeCtx, cancel := context.WithTimeout(ctx, opt.HandshakeTimeout)
 	defer cancel()
@@ -0,0 +1,96 @@
+package compress
+
+import (
+	"io"
+
+	"github.com/go-faster/errors"
+)
+
+// DefaultFrameSize is default maximum size of uncompressed data in single
+// frame of StreamWriter, like max_compress_block_size setting of ClickHouse.
+const DefaultFrameSize = 1024 * 1024 // 1MB
+
+// StreamWriter compresses stream of data into frames of bounded size,
+// writing them to underlying writer, like CompressedWriteBuffer of
+// ClickHouse.
+//
+// Frames are decoded by Reader as single stream, so data can be split
+// between frames arbitrarily.
+type StreamWriter struct {
+	w    io.Writer
+	c    *Writer
+	buf  []byte
+	size int
+}
+
+// NewStreamWriter creates new StreamWriter that compresses data with c
+// into frames of at most size bytes of uncompressed data.
+//
+// Uses DefaultFrameSize if size is not positive.
+func NewStreamWriter(w io.Writer, c *Writer, size int) *StreamWriter {
+	if size <= 0 {
+		size = DefaultFrameSize
+	}
+	if size > maxDataSize {
+		size = maxDataSize
+	}
+	return &StreamWriter{
+		w:    w,
+		c:    c,
+		size: size,
+	}
+}
+
+// Write implements io.Writer.
+//
+// Data is buffered until frame is full, call Flush to write rest of data.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if len(s.buf) == 0 && len(p) >= s.size {
+			// Compressing full frame directly, without copying.
+			if err := s.writeFrame(p[:s.size]); err != nil {
+				return n, err
+			}
+			n += s.size
+			p = p[s.size:]
+			continue
+		}
+		k := min(s.size-len(s.buf), len(p))
+		s.buf = append(s.buf, p[:k]...)
+		n += k
+		p = p[k:]
+		if len(s.buf) == s.size {
+			if err := s.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush writes buffered data as frame, if any.
+func (s *StreamWriter) Flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	err := s.writeFrame(s.buf)
+	s.buf = s.buf[:0]
+	return err
+}
+
+// Reset discards buffered data and switches to writing to w.
+func (s *StreamWriter) Reset(w io.Writer) {
+	s.w = w
+	s.buf = s.buf[:0]
+}
+
+func (s *StreamWriter) writeFrame(data []byte) error {
+	if err := s.c.Compress(data); err != nil {
+		return errors.Wrap(err, "compress")
+	}
+	if _, err := s.w.Write(s.c.Data); err != nil {
+		return errors.Wrap(err, "write")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package compress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriter(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.New(rand.NewSource(1)).Read(data)
+
+	for _, m := range MethodValues() {
+		t.Run(m.String(), func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewStreamWriter(&out, NewWriter(LevelZero, m), 64)
+			for _, chunk := range [][]byte{
+				data[:10], data[10:100], data[100:164], data[164:900], data[900:],
+			} {
+				n, err := w.Write(chunk)
+				require.NoError(t, err)
+				require.Equal(t, len(chunk), n)
+			}
+			require.NoError(t, w.Flush())
+			require.NoError(t, w.Flush()) // no-op
+
+			// Checking that frames are bounded.
+			var frames int
+			for b := out.Bytes(); len(b) > 0; frames++ {
+				require.LessOrEqual(t, int(binary.LittleEndian.Uint32(b[hDataSize:])), 64)
+				b = b[checksumSize+int(binary.LittleEndian.Uint32(b[hRawSize:])):]
+			}
+			require.Equal(t, (len(data)+63)/64, frames)
+
+			got := make([]byte, len(data))
+			_, err := io.ReadFull(NewReader(&out), got)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		})
+	}
+	t.Run("Reset", func(t *testing.T) {
+		var a, b bytes.Buffer
+		w := NewStreamWriter(&a, NewWriter(LevelZero, LZ4), 0)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		w.Reset(&b)
+		require.NoError(t, w.Flush())
+		require.Zero(t, a.Len())
+		require.Zero(t, b.Len())
+	})
+}
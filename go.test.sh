@@ -7,3 +7,6 @@ go test --timeout 5m ./...
 
 echo "test -race"
 go test --timeout 5m -race ./...
+
+echo "test charrow"
+(cd charrow && GOWORK=off go test --timeout 5m -race ./...)
@@ -17,12 +17,38 @@ import (
 	"github.com/ClickHouse/ch-go/proto"
 )
 
-func (c *Client) encodeAddendum() {
-	if proto.FeatureQuotaKey.In(c.protocolVersion) {
-		c.writer.ChainBuffer(func(b *proto.Buffer) {
-			b.PutString(c.quotaKey)
-		})
+// negotiateChunked negotiates chunked framing of packets with server.
+func (c *Client) negotiateChunked() (send, recv bool, err error) {
+	if !proto.FeatureChunkedPackets.In(c.protocolVersion) {
+		return false, false, nil
+	}
+	if !c.chunkedSend.Valid() || !c.chunkedRecv.Valid() {
+		return false, false, errors.Errorf("invalid chunking %q/%q", c.chunkedSend, c.chunkedRecv)
+	}
+	if send, err = c.chunkedSend.Negotiate(c.server.ChunkedRecv); err != nil {
+		return false, false, errors.Wrap(err, "send")
+	}
+	if recv, err = c.chunkedRecv.Negotiate(c.server.ChunkedSend); err != nil {
+		return false, false, errors.Wrap(err, "recv")
 	}
+	return send, recv, nil
+}
+
+func chunking(chunked bool) proto.Chunking {
+	if chunked {
+		return proto.ChunkingChunked
+	}
+	return proto.ChunkingNotChunked
+}
+
+func (c *Client) encodeAddendum(send, recv bool) {
+	c.writer.ChainBuffer(func(b *proto.Buffer) {
+		proto.Addendum{
+			QuotaKey:    c.quotaKey,
+			ChunkedSend: chunking(send),
+			ChunkedRecv: chunking(recv),
+		}.EncodeAware(b, c.protocolVersion)
+	})
 }
 
 func (c *Client) performSSHAuthentication(ctx context.Context) error {
@@ -166,13 +192,28 @@ func (c *Client) handshake(ctx context.Context) error {
 				otelch.ProtocolVersion(c.protocolVersion),
 			)
 		}
+		send, recv, err := c.negotiateChunked()
+		if err != nil {
+			return errors.Wrap(err, "negotiate chunked packets")
+		}
 		if proto.FeatureAddendum.In(c.protocolVersion) {
 			c.lg.Debug("Writing addendum")
-			c.encodeAddendum()
+			c.encodeAddendum(send, recv)
 			if err := c.flush(wgCtx); err != nil {
 				return errors.Wrap(err, "flush")
 			}
 		}
+		// Packets after addendum are framed.
+		if send {
+			c.sendChunked = true
+			c.writer.EnableChunked()
+		}
+		if recv {
+			c.reader.EnableChunked()
+		}
+		if send || recv {
+			c.lg.Debug("Chunked packets", zap.Bool("send", send), zap.Bool("recv", recv))
+		}
 
 		return nil
 	})
@@ -0,0 +1,483 @@
+package ch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// HTTPOptions for HTTPClient. Zero value is valid.
+type HTTPOptions struct {
+	Logger   *zap.Logger  // defaults to Nop.
+	Address  string       // http://127.0.0.1:8123
+	Database string       // "default"
+	User     string       // "default"
+	Password string       // blank string by default
+	QuotaKey string       // blank string by default
+	Settings []Setting    // none by default
+	Client   *http.Client // defaults to http.DefaultClient
+}
+
+// Defaults for HTTP interface.
+const (
+	DefaultHTTPPort = 8123
+)
+
+func (o *HTTPOptions) setDefaults() {
+	if o.Logger == nil {
+		o.Logger = zap.NewNop()
+	}
+	if o.Address == "" {
+		o.Address = "http://" + DefaultHost + ":" + strconv.Itoa(DefaultHTTPPort)
+	}
+	if o.Database == "" {
+		o.Database = DefaultDatabase
+	}
+	if o.User == "" {
+		o.User = DefaultUser
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+}
+
+// HTTPClient executes queries over ClickHouse HTTP interface, using Native
+// format for data.
+//
+// Same Query can be used with Client and HTTPClient, with following
+// limitations of HTTP interface:
+//
+//   - Input columns are not inferred from table, so types of Input
+//     columns should be set explicitly;
+//   - Progress is reported from X-ClickHouse-Progress headers, which are
+//     received before result;
+//   - Profile, profile events, logs, external data, totals and extremes
+//     are not supported.
+//
+// Safe for concurrent use.
+type HTTPClient struct {
+	lg       *zap.Logger
+	url      *url.URL
+	client   *http.Client
+	database string
+	user     string
+	password string
+	quotaKey string
+	settings []Setting
+}
+
+// NewHTTPClient returns new HTTPClient.
+func NewHTTPClient(opt HTTPOptions) (*HTTPClient, error) {
+	opt.setDefaults()
+	u, err := url.Parse(opt.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse address")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unexpected scheme %q of %q", u.Scheme, opt.Address)
+	}
+	return &HTTPClient{
+		lg:       opt.Logger,
+		url:      u,
+		client:   opt.Client,
+		database: opt.Database,
+		user:     opt.User,
+		password: opt.Password,
+		quotaKey: opt.QuotaKey,
+		settings: opt.Settings,
+	}, nil
+}
+
+func (c *HTTPClient) request(ctx context.Context, method, path string, params url.Values, body io.Reader) (*http.Response, error) {
+	u := *c.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = params.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "request")
+	}
+	req.Header.Set("X-ClickHouse-User", c.user)
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	req.Header.Set("X-ClickHouse-Database", c.database)
+	if c.quotaKey != "" {
+		req.Header.Set("X-ClickHouse-Quota", c.quotaKey)
+	}
+	return c.client.Do(req)
+}
+
+// Ping server.
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	resp, err := c.request(ctx, http.MethodGet, "/ping", nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "ping")
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return httpException(resp)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// httpValues returns URL parameters of query.
+func (c *HTTPClient) httpValues(q Query) url.Values {
+	v := url.Values{}
+	v.Set("query_id", q.QueryID)
+	// Result is always encoded in Native format, if query has no FORMAT.
+	v.Set("default_format", "Native")
+	if q.OnProgress != nil {
+		v.Set("send_progress_in_http_headers", "1")
+	}
+	if q.QuotaKey != "" {
+		v.Set("quota_key", q.QuotaKey)
+	}
+	for _, s := range c.settings {
+		v.Set(s.Key, s.Value)
+	}
+	for _, s := range q.Settings {
+		v.Set(s.Key, s.Value)
+	}
+	for _, p := range q.Parameters {
+		v.Set("param_"+p.Key, httpParameter(p.Value))
+	}
+	return v
+}
+
+// httpParameter converts quoted literal of Parameters to value that is
+// expected by HTTP interface.
+func httpParameter(v string) string {
+	if len(v) < 2 || v[0] != '\'' || v[len(v)-1] != '\'' {
+		return v
+	}
+	return strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(v[1 : len(v)-1])
+}
+
+var insertValues = regexp.MustCompile(`(?i)\s+VALUES\s*$`)
+
+// httpInsertBody returns INSERT query with FORMAT Native clause, replacing
+// trailing VALUES, like in Input.Into.
+func httpInsertBody(body string) string {
+	body = strings.TrimSpace(body)
+	body = insertValues.ReplaceAllString(body, "")
+	return body + " FORMAT Native"
+}
+
+// Do performs Query over HTTP interface.
+//
+// Query body is sent as request body, or, if Input is set, as "query"
+// parameter with Input streamed as request body in Native format.
+func (c *HTTPClient) Do(ctx context.Context, q Query) (err error) {
+	if len(q.ExternalData) > 0 {
+		return errors.New("external data is not supported over HTTP")
+	}
+	if q.Totals != nil || q.Extremes != nil || q.OnTotals != nil || q.OnExtremes != nil {
+		return errors.New("totals and extremes are not supported over HTTP")
+	}
+	if q.QueryID == "" {
+		q.QueryID = uuid.New().String()
+	}
+	lg := q.Logger
+	if lg == nil {
+		lg = c.lg.With(zap.String("query_id", q.QueryID))
+	}
+	if ce := lg.Check(zap.DebugLevel, "Do"); ce != nil {
+		ce.Write(zap.String("query", q.Body))
+	}
+
+	var (
+		params    = c.httpValues(q)
+		body      io.Reader
+		pr        *io.PipeReader
+		inputDone chan struct{}
+		inputErr  error
+	)
+	if len(q.Input) > 0 {
+		params.Set("query", httpInsertBody(q.Body))
+		var pw *io.PipeWriter
+		pr, pw = io.Pipe()
+		inputDone = make(chan struct{})
+		go func() {
+			defer close(inputDone)
+			inputErr = c.sendInput(ctx, lg, pw, q)
+			_ = pw.CloseWithError(inputErr)
+		}()
+		defer func() {
+			// Unblocking input if request is done before it.
+			_ = pr.Close()
+			<-inputDone
+			if inputErr != nil && !errors.Is(inputErr, io.ErrClosedPipe) && err == nil {
+				err = errors.Wrap(inputErr, "send input")
+			}
+		}()
+		body = pr
+	} else {
+		body = strings.NewReader(q.Body)
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, "/", params, body)
+	if err != nil {
+		if inputDone != nil {
+			_ = pr.Close()
+			<-inputDone
+			if inputErr != nil && !errors.Is(inputErr, io.ErrClosedPipe) {
+				// Request is aborted by input.
+				return errors.Wrap(inputErr, "send input")
+			}
+		}
+		return errors.Wrap(err, "do")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := httpProgress(ctx, q, resp.Header); err != nil {
+		return errors.Wrap(err, "progress")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return httpException(resp)
+	}
+	if q.Result == nil {
+		if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+			return errors.Wrap(err, "read")
+		}
+		return nil
+	}
+
+	var (
+		tail     httpTail
+		r        = proto.NewNativeReader(io.TeeReader(resp.Body, &tail))
+		onResult = resultHandler(q)
+	)
+	for {
+		block, err := r.ReadBlock(q.Result)
+		if errors.Is(err, io.EOF) {
+			if resp.Trailer.Get(httpExceptionCodeHeader) != "" {
+				// Exception is sent after complete block.
+				return httpBodyException(resp, &tail)
+			}
+			return nil
+		}
+		if err != nil {
+			// Exception can be sent in the middle of response, so data
+			// can't be decoded.
+			if e := httpBodyException(resp, &tail); e != nil {
+				return e
+			}
+			return errors.Wrap(err, "read block")
+		}
+		if ce := lg.Check(zap.DebugLevel, "Block"); ce != nil {
+			ce.Write(
+				zap.Int("rows", block.Rows),
+				zap.Int("columns", block.Columns),
+			)
+		}
+		if err := onResult(ctx, block); err != nil {
+			return errors.Wrap(err, "handler")
+		}
+	}
+}
+
+// sendInput writes Input blocks to w, like Client does.
+func (c *HTTPClient) sendInput(ctx context.Context, lg *zap.Logger, w io.Writer, q Query) error {
+	var (
+		nw = proto.NewNativeWriter(w)
+		f  = q.OnInput
+	)
+	if f != nil && q.Input[0].Data.Rows() == 0 {
+		// Fetching initial input if no rows provided.
+		if err := f(ctx); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil // initial input was blank
+			}
+			return errors.Wrap(err, "input")
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "context")
+		}
+		if err := nw.WriteBlock(q.Input); err != nil {
+			return errors.Wrap(err, "write block")
+		}
+		if f == nil {
+			// No callback, single block.
+			return nil
+		}
+		if err := f(ctx); err != nil {
+			if errors.Is(err, io.EOF) {
+				if tailRows := q.Input[0].Data.Rows(); tailRows > 0 {
+					// Write data tail on next tick and break.
+					if ce := lg.Check(zap.DebugLevel, "Writing tail of input data (not empty and io.EOF)"); ce != nil {
+						ce.Write(zap.Int("rows", tailRows))
+					}
+					f = nil
+					continue
+				}
+				return nil
+			}
+			// ClickHouse server persists blocks after receive.
+			return errors.Wrap(err, "next input (server already persisted previous blocks)")
+		}
+	}
+}
+
+// httpProgressValue is value of X-ClickHouse-Progress and
+// X-ClickHouse-Summary headers.
+//
+// Values are cumulative and encoded as strings.
+type httpProgressValue struct {
+	ReadRows     uint64 `json:"read_rows,string"`
+	ReadBytes    uint64 `json:"read_bytes,string"`
+	TotalRows    uint64 `json:"total_rows_to_read,string"`
+	WrittenRows  uint64 `json:"written_rows,string"`
+	WrittenBytes uint64 `json:"written_bytes,string"`
+	ElapsedNs    uint64 `json:"elapsed_ns,string"`
+}
+
+// httpProgress calls OnProgress with difference of each progress header.
+func httpProgress(ctx context.Context, q Query, h http.Header) error {
+	if q.OnProgress == nil {
+		return nil
+	}
+	values := h.Values("X-ClickHouse-Progress")
+	values = append(values, h.Values("X-ClickHouse-Summary")...)
+	var last httpProgressValue
+	for _, s := range values {
+		var v httpProgressValue
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return errors.Wrapf(err, "decode %q", s)
+		}
+		p := proto.Progress{
+			Rows:       progressDelta(v.ReadRows, last.ReadRows),
+			Bytes:      progressDelta(v.ReadBytes, last.ReadBytes),
+			TotalRows:  progressDelta(v.TotalRows, last.TotalRows),
+			WroteRows:  progressDelta(v.WrittenRows, last.WrittenRows),
+			WroteBytes: progressDelta(v.WrittenBytes, last.WrittenBytes),
+			ElapsedNs:  progressDelta(v.ElapsedNs, last.ElapsedNs),
+		}
+		last = v
+		if p == (proto.Progress{}) {
+			continue
+		}
+		if err := q.OnProgress(ctx, p); err != nil {
+			return errors.Wrap(err, "handler")
+		}
+	}
+	return nil
+}
+
+func progressDelta(a, b uint64) uint64 {
+	if a < b {
+		return 0
+	}
+	return a - b
+}
+
+// maxHTTPExceptionSize limits size of exception message read from response.
+const maxHTTPExceptionSize = 1 << 20
+
+// httpExceptionCodeHeader is header or trailer with exception code.
+const httpExceptionCodeHeader = "X-ClickHouse-Exception-Code"
+
+// httpException returns error of failed response.
+//
+// Response body is like "Code: 60. DB::Exception: Table doesn't exist.
+// (UNKNOWN_TABLE) (version 24.1.1.1)".
+func httpException(resp *http.Response) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPExceptionSize))
+	if err != nil {
+		return errors.Wrap(err, "read exception")
+	}
+	msg := strings.TrimSpace(string(data))
+	code, err := strconv.Atoi(resp.Header.Get(httpExceptionCodeHeader))
+	if err != nil {
+		return errors.Errorf("http status %d: %s", resp.StatusCode, msg)
+	}
+	return newHTTPException(code, msg)
+}
+
+// httpBodyException returns exception that is sent by server in the middle
+// of response body, after status 200 and some data is already sent, or nil.
+//
+// Such exception is written as text to the end of body, and, if server
+// supports it, its code is sent in trailer.
+func httpBodyException(resp *http.Response, tail *httpTail) error {
+	// Trailer is available only after body is read.
+	if _, err := io.Copy(tail, io.LimitReader(resp.Body, maxHTTPExceptionSize)); err != nil {
+		return errors.Wrap(err, "read exception")
+	}
+	data := tail.Bytes()
+	idx := bytes.LastIndex(data, []byte("Code: "))
+	var msg string
+	if idx >= 0 {
+		msg = strings.TrimSpace(string(data[idx:]))
+	}
+	if code, err := strconv.Atoi(resp.Trailer.Get(httpExceptionCodeHeader)); err == nil {
+		return newHTTPException(code, msg)
+	}
+	if idx < 0 || !strings.Contains(msg, "DB::Exception") {
+		return nil
+	}
+	v, _, _ := strings.Cut(strings.TrimPrefix(msg, "Code: "), ".")
+	code, err := strconv.Atoi(v)
+	if err != nil {
+		return nil
+	}
+	return newHTTPException(code, msg)
+}
+
+// newHTTPException parses exception message of HTTP interface, stripping
+// code, name and version, so Message is like one received over TCP.
+func newHTTPException(code int, msg string) *Exception {
+	e := &Exception{
+		Code: proto.Error(code),
+	}
+	if _, v, ok := strings.Cut(msg, ". "); ok && strings.HasPrefix(msg, "Code: ") {
+		msg = v
+	}
+	if name, v, ok := strings.Cut(msg, ": "); ok && strings.Contains(name, "::") && !strings.Contains(name, " ") {
+		e.Name = name
+		msg = v
+	}
+	if i := strings.LastIndex(msg, " (version "); i >= 0 && strings.HasSuffix(msg, ")") {
+		msg = msg[:i]
+	}
+	msg = strings.TrimSuffix(msg, " ("+e.Code.String()+")")
+	e.Message = msg
+	return e
+}
+
+// httpTail is io.Writer that keeps last maxHTTPExceptionSize bytes written
+// to it, so exception at the end of response body can be found.
+type httpTail struct {
+	buf []byte
+}
+
+func (t *httpTail) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > 2*maxHTTPExceptionSize {
+		// Amortizing copying by discarding in batches.
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-maxHTTPExceptionSize:]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns tail of written data.
+func (t *httpTail) Bytes() []byte {
+	if len(t.buf) > maxHTTPExceptionSize {
+		return t.buf[len(t.buf)-maxHTTPExceptionSize:]
+	}
+	return t.buf
+}
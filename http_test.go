@@ -0,0 +1,216 @@
+package ch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func newHTTPClient(t *testing.T, h http.HandlerFunc) *HTTPClient {
+	t.Helper()
+	s := httptest.NewServer(h)
+	t.Cleanup(s.Close)
+	c, err := NewHTTPClient(HTTPOptions{
+		Address:  s.URL,
+		Password: "secret",
+		Settings: []Setting{{Key: "max_threads", Value: "2"}},
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestHTTPClient_Select(t *testing.T) {
+	ctx := context.Background()
+	c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if string(body) != "SELECT number FROM numbers({n:UInt8})" ||
+			r.Header.Get("X-ClickHouse-Key") != "secret" ||
+			r.Header.Get("X-ClickHouse-User") != DefaultUser ||
+			r.URL.Query().Get("query_id") != "id" ||
+			r.URL.Query().Get("max_threads") != "2" ||
+			r.URL.Query().Get("param_n") != "it's" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Add("X-ClickHouse-Progress", `{"read_rows":"2","read_bytes":"16","total_rows_to_read":"4"}`)
+		w.Header().Add("X-ClickHouse-Progress", `{"read_rows":"4","read_bytes":"32","total_rows_to_read":"4"}`)
+		w.Header().Add("X-ClickHouse-Summary", `{"read_rows":"4","read_bytes":"32","total_rows_to_read":"4","elapsed_ns":"100"}`)
+		nw := proto.NewNativeWriter(w)
+		for _, v := range [][]uint64{{0, 1}, {2, 3}} {
+			_ = nw.WriteBlock(proto.Input{{Name: "number", Data: proto.ColUInt64(v)}})
+		}
+	})
+	var (
+		data     proto.ColUInt64
+		got      []uint64
+		progress []proto.Progress
+	)
+	require.NoError(t, c.Do(ctx, Query{
+		Body:       "SELECT number FROM numbers({n:UInt8})",
+		QueryID:    "id",
+		Parameters: Parameters(map[string]any{"n": `it\'s`}),
+		Result: proto.Results{
+			{Name: "number", Data: &data},
+		},
+		OnResult: func(ctx context.Context, block proto.Block) error {
+			got = append(got, data...)
+			return nil
+		},
+		OnProgress: func(ctx context.Context, p proto.Progress) error {
+			progress = append(progress, p)
+			return nil
+		},
+	}))
+	require.Equal(t, []uint64{0, 1, 2, 3}, got)
+	require.Equal(t, []proto.Progress{
+		{Rows: 2, Bytes: 16, TotalRows: 4},
+		{Rows: 2, Bytes: 16},
+		{ElapsedNs: 100},
+	}, progress)
+}
+
+func TestHTTPClient_Insert(t *testing.T) {
+	ctx := context.Background()
+	var rows []uint64
+	c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("query"); q != `INSERT INTO "t" ("id") FORMAT Native` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var results proto.Results
+		nr := proto.NewNativeReader(r.Body)
+		for {
+			if _, err := nr.ReadBlock(results.Auto()); err != nil {
+				if !errors.Is(err, io.EOF) {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				return
+			}
+			rows = append(rows, *results[0].Data.(*proto.ColUInt64)...)
+		}
+	})
+	var (
+		id    proto.ColUInt64
+		input = proto.Input{{Name: "id", Data: &id}}
+		block int
+	)
+	require.NoError(t, c.Do(ctx, Query{
+		Body:  input.Into("t"),
+		Input: input,
+		OnInput: func(ctx context.Context) error {
+			input.Reset()
+			if block == 3 {
+				return io.EOF
+			}
+			id.Append(uint64(block))
+			block++
+			return nil
+		},
+	}))
+	require.Equal(t, []uint64{0, 1, 2}, rows)
+
+	inputErr := errors.New("input failed")
+	err := c.Do(ctx, Query{
+		Body:  input.Into("t"),
+		Input: input,
+		OnInput: func(ctx context.Context) error {
+			return inputErr
+		},
+	})
+	require.ErrorIs(t, err, inputErr)
+}
+
+func TestHTTPClient_Exception(t *testing.T) {
+	ctx := context.Background()
+	c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ClickHouse-Exception-Code", "60")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "Code: 60. DB::Exception: Table default.t does not exist. (UNKNOWN_TABLE) (version 24.1.1.1)\n")
+	})
+	err := c.Do(ctx, Query{Body: "SELECT * FROM t"})
+	require.True(t, IsErr(err, proto.ErrUnknownTable))
+	e, ok := AsException(err)
+	require.True(t, ok)
+	require.Equal(t, "DB::Exception", e.Name)
+	require.Equal(t, "Table default.t does not exist.", e.Message)
+	require.Error(t, c.Ping(ctx))
+}
+
+func TestHTTPClient_ExceptionInBody(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		Name    string
+		Trailer bool
+		Message string
+	}{
+		{Name: "Text", Message: "Memory limit exceeded."},
+		{Name: "Trailer", Trailer: true, Message: "Memory limit exceeded."},
+		{Name: "TrailerOnly", Trailer: true},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.Trailer {
+					w.Header().Set("Trailer", "X-ClickHouse-Exception-Code")
+				}
+				nw := proto.NewNativeWriter(w)
+				_ = nw.WriteBlock(proto.Input{{Name: "number", Data: proto.ColUInt64{0, 1}}})
+				if tt.Message != "" {
+					_, _ = io.WriteString(w, "Code: 241. DB::Exception: "+tt.Message+" (MEMORY_LIMIT_EXCEEDED) (version 24.1.1.1)\n")
+				}
+				if tt.Trailer {
+					w.Header().Set("X-ClickHouse-Exception-Code", "241")
+				}
+			})
+			var data proto.ColUInt64
+			err := c.Do(ctx, Query{
+				Body:   "SELECT number FROM numbers(10)",
+				Result: proto.Results{{Name: "number", Data: &data}},
+			})
+			require.True(t, IsErr(err, proto.ErrMemoryLimitExceeded), "%+v", err)
+			e, ok := AsException(err)
+			require.True(t, ok)
+			require.Equal(t, tt.Message, e.Message)
+		})
+	}
+}
+
+func TestHTTPClient_TotalsExtremes(t *testing.T) {
+	ctx := context.Background()
+	c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+	handler := func(ctx context.Context, b proto.Block) error { return nil }
+	for _, q := range []Query{
+		{Body: "SELECT 1", Totals: proto.Results{}},
+		{Body: "SELECT 1", OnTotals: handler},
+		{Body: "SELECT 1", Extremes: proto.Results{}},
+		{Body: "SELECT 1", OnExtremes: handler},
+	} {
+		require.ErrorContains(t, c.Do(ctx, q), "not supported over HTTP")
+	}
+}
+
+func TestHTTPClient_Ping(t *testing.T) {
+	c := newHTTPClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, "Ok.\n")
+	})
+	require.NoError(t, c.Ping(context.Background()))
+
+	_, err := NewHTTPClient(HTTPOptions{Address: "127.0.0.1:8123"})
+	require.Error(t, err)
+}
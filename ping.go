@@ -39,6 +39,7 @@ func (c *Client) Ping(ctx context.Context) (err error) {
 	c.writer.ChainBuffer(func(b *proto.Buffer) {
 		b.Encode(proto.ClientCodePing)
 	})
+	c.writer.FinishChunk()
 	if err := c.flush(ctx); err != nil {
 		return errors.Wrap(err, "flush")
 	}
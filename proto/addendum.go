@@ -0,0 +1,48 @@
+package proto
+
+import "github.com/go-faster/errors"
+
+// Addendum is sent by client after ServerHello, if FeatureAddendum is
+// supported.
+type Addendum struct {
+	QuotaKey string
+
+	// ChunkedSend and ChunkedRecv are negotiated chunking of packets sent
+	// and received by client, ChunkingChunked or ChunkingNotChunked.
+	ChunkedSend Chunking
+	ChunkedRecv Chunking
+}
+
+// EncodeAware encodes addendum.
+func (a Addendum) EncodeAware(b *Buffer, version int) {
+	if FeatureQuotaKey.In(version) {
+		b.PutString(a.QuotaKey)
+	}
+	if FeatureChunkedPackets.In(version) {
+		b.PutString(string(a.ChunkedSend))
+		b.PutString(string(a.ChunkedRecv))
+	}
+}
+
+// DecodeAware decodes addendum.
+func (a *Addendum) DecodeAware(r *Reader, version int) error {
+	if FeatureQuotaKey.In(version) {
+		v, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "quota key")
+		}
+		a.QuotaKey = v
+	}
+	if FeatureChunkedPackets.In(version) {
+		send, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "chunked send")
+		}
+		recv, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "chunked recv")
+		}
+		a.ChunkedSend, a.ChunkedRecv = Chunking(send), Chunking(recv)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package proto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddendum_EncodeAware(t *testing.T) {
+	v := Addendum{
+		QuotaKey:    "key",
+		ChunkedSend: ChunkingChunked,
+		ChunkedRecv: ChunkingNotChunked,
+	}
+	var b Buffer
+	v.EncodeAware(&b, Version)
+	Gold(t, v)
+
+	t.Run("Decode", func(t *testing.T) {
+		var dec Addendum
+		requireDecode(t, b.Buf, aware(&dec))
+		require.Equal(t, v, dec)
+		requireNoShortRead(t, b.Buf, aware(&dec))
+	})
+	t.Run("NotChunked", func(t *testing.T) {
+		version := FeatureChunkedPackets.Version() - 1
+		var b Buffer
+		v.EncodeAware(&b, version)
+		var dec Addendum
+		require.NoError(t, dec.DecodeAware(NewReader(bytes.NewReader(b.Buf)), version))
+		require.Equal(t, Addendum{QuotaKey: v.QuotaKey}, dec)
+	})
+}
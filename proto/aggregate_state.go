@@ -0,0 +1,691 @@
+package proto
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/go-faster/errors"
+)
+
+// AggregateState is typed state of aggregate function, encoded in the same
+// binary format as ClickHouse uses for AggregateFunction columns.
+//
+// DecodeAggregateState overwrites state, so it can be reused.
+type AggregateState interface {
+	DecodeAggregateState(r *Reader) error
+	EncodeAggregateState(b *Buffer)
+}
+
+// Compile-time assertions for aggregate states.
+var (
+	_ AggregateState = (*CountState)(nil)
+	_ AggregateState = (*SumState[uint64])(nil)
+	_ AggregateState = (*AvgState[float64])(nil)
+	_ AggregateState = (*MinState[string])(nil)
+	_ AggregateState = (*MaxState[int32])(nil)
+	_ AggregateState = (*UniqExactState[UInt128])(nil)
+	_ AggregateState = (*UniqState)(nil)
+	_ AggregateState = (*TDigestState)(nil)
+)
+
+// aggregateNumber is type of numeric state value.
+type aggregateNumber interface {
+	int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64 | float32 | float64
+}
+
+// aggregateValue is type of state value that has fixed size encoding.
+type aggregateValue interface {
+	aggregateNumber | UInt128
+}
+
+// aggregateOrdered is type of min and max state value.
+type aggregateOrdered interface {
+	aggregateNumber | string
+}
+
+func putAggregateValue[T aggregateValue](b *Buffer, v T) {
+	switch v := any(v).(type) {
+	case int8:
+		b.PutInt8(v)
+	case int16:
+		b.PutInt16(v)
+	case int32:
+		b.PutInt32(v)
+	case int64:
+		b.PutInt64(v)
+	case uint8:
+		b.PutUInt8(v)
+	case uint16:
+		b.PutUInt16(v)
+	case uint32:
+		b.PutUInt32(v)
+	case uint64:
+		b.PutUInt64(v)
+	case float32:
+		b.PutFloat32(v)
+	case float64:
+		b.PutFloat64(v)
+	case UInt128:
+		b.PutUInt128(v)
+	}
+}
+
+func readAggregateValue[T aggregateValue](r *Reader) (T, error) {
+	var (
+		v   T
+		res any
+		err error
+	)
+	switch any(v).(type) {
+	case int8:
+		res, err = r.Int8()
+	case int16:
+		res, err = r.Int16()
+	case int32:
+		res, err = r.Int32()
+	case int64:
+		res, err = r.Int64()
+	case uint8:
+		res, err = r.UInt8()
+	case uint16:
+		res, err = r.UInt16()
+	case uint32:
+		res, err = r.UInt32()
+	case uint64:
+		res, err = r.UInt64()
+	case float32:
+		res, err = r.Float32()
+	case float64:
+		res, err = r.Float64()
+	case UInt128:
+		res, err = r.UInt128()
+	}
+	if err != nil {
+		return v, err
+	}
+	return res.(T), nil
+}
+
+// CountState is state of count.
+type CountState struct {
+	Count uint64
+}
+
+// Merge adds count of other state.
+func (s *CountState) Merge(o CountState) { s.Count += o.Count }
+
+// DecodeAggregateState implements AggregateState.
+func (s *CountState) DecodeAggregateState(r *Reader) error {
+	v, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "count")
+	}
+	s.Count = v
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s CountState) EncodeAggregateState(b *Buffer) {
+	b.PutUVarInt(s.Count)
+}
+
+// SumState is state of sum.
+//
+// Sum of unsigned integers is uint64, of signed integers is int64 and of
+// floats is float64.
+type SumState[T int64 | uint64 | float64] struct {
+	Sum T
+}
+
+// Add value to sum.
+func (s *SumState[T]) Add(v T) { s.Sum += v }
+
+// Merge adds sum of other state.
+func (s *SumState[T]) Merge(o SumState[T]) { s.Sum += o.Sum }
+
+// DecodeAggregateState implements AggregateState.
+func (s *SumState[T]) DecodeAggregateState(r *Reader) error {
+	v, err := readAggregateValue[T](r)
+	if err != nil {
+		return errors.Wrap(err, "sum")
+	}
+	s.Sum = v
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s SumState[T]) EncodeAggregateState(b *Buffer) {
+	putAggregateValue(b, s.Sum)
+}
+
+// AvgState is state of avg, i.e. sum of values and their count.
+//
+// Numerator type is chosen like in SumState.
+type AvgState[T int64 | uint64 | float64] struct {
+	Numerator   T
+	Denominator uint64
+}
+
+// Add value.
+func (s *AvgState[T]) Add(v T) {
+	s.Numerator += v
+	s.Denominator++
+}
+
+// Merge other state.
+func (s *AvgState[T]) Merge(o AvgState[T]) {
+	s.Numerator += o.Numerator
+	s.Denominator += o.Denominator
+}
+
+// Value returns average value, which is NaN for empty state.
+func (s AvgState[T]) Value() float64 {
+	return float64(s.Numerator) / float64(s.Denominator)
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *AvgState[T]) DecodeAggregateState(r *Reader) error {
+	v, err := readAggregateValue[T](r)
+	if err != nil {
+		return errors.Wrap(err, "numerator")
+	}
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "denominator")
+	}
+	s.Numerator = v
+	s.Denominator = n
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s AvgState[T]) EncodeAggregateState(b *Buffer) {
+	putAggregateValue(b, s.Numerator)
+	b.PutUVarInt(s.Denominator)
+}
+
+// singleValue is state of min or max.
+type singleValue[T aggregateOrdered] struct {
+	Value T
+	Has   bool // false if no values were added
+}
+
+func (s *singleValue[T]) decode(r *Reader) error {
+	var zero T
+	if _, ok := any(zero).(string); ok {
+		// String value has size with trailing zero byte or -1 instead
+		// of has flag.
+		n, err := r.Int32()
+		if err != nil {
+			return errors.Wrap(err, "size")
+		}
+		s.Value = zero
+		if s.Has = n >= 0; !s.Has {
+			return nil
+		}
+		if n == 0 {
+			return errors.New("invalid size 0")
+		}
+		buf, err := r.ReadRaw(int(n))
+		if err != nil {
+			return errors.Wrap(err, "value")
+		}
+		s.Value = any(string(buf[:n-1])).(T)
+		return nil
+	}
+	has, err := r.Bool()
+	if err != nil {
+		return errors.Wrap(err, "has")
+	}
+	s.Value = zero
+	if s.Has = has; !has {
+		return nil
+	}
+	switch any(zero).(type) {
+	case int8:
+		s.Value, err = decodeAggregateOrdered[T, int8](r)
+	case int16:
+		s.Value, err = decodeAggregateOrdered[T, int16](r)
+	case int32:
+		s.Value, err = decodeAggregateOrdered[T, int32](r)
+	case int64:
+		s.Value, err = decodeAggregateOrdered[T, int64](r)
+	case uint8:
+		s.Value, err = decodeAggregateOrdered[T, uint8](r)
+	case uint16:
+		s.Value, err = decodeAggregateOrdered[T, uint16](r)
+	case uint32:
+		s.Value, err = decodeAggregateOrdered[T, uint32](r)
+	case uint64:
+		s.Value, err = decodeAggregateOrdered[T, uint64](r)
+	case float32:
+		s.Value, err = decodeAggregateOrdered[T, float32](r)
+	case float64:
+		s.Value, err = decodeAggregateOrdered[T, float64](r)
+	}
+	if err != nil {
+		return errors.Wrap(err, "value")
+	}
+	return nil
+}
+
+func decodeAggregateOrdered[T aggregateOrdered, V aggregateValue](r *Reader) (T, error) {
+	v, err := readAggregateValue[V](r)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return any(v).(T), nil
+}
+
+func (s singleValue[T]) encode(b *Buffer) {
+	switch v := any(s.Value).(type) {
+	case string:
+		if !s.Has {
+			b.PutInt32(-1)
+			return
+		}
+		b.PutInt32(int32(len(v) + 1))
+		b.Buf = append(b.Buf, v...)
+		b.PutByte(0)
+		return
+	}
+	b.PutBool(s.Has)
+	if !s.Has {
+		return
+	}
+	switch v := any(s.Value).(type) {
+	case int8:
+		putAggregateValue(b, v)
+	case int16:
+		putAggregateValue(b, v)
+	case int32:
+		putAggregateValue(b, v)
+	case int64:
+		putAggregateValue(b, v)
+	case uint8:
+		putAggregateValue(b, v)
+	case uint16:
+		putAggregateValue(b, v)
+	case uint32:
+		putAggregateValue(b, v)
+	case uint64:
+		putAggregateValue(b, v)
+	case float32:
+		putAggregateValue(b, v)
+	case float64:
+		putAggregateValue(b, v)
+	}
+}
+
+// MinState is state of min.
+//
+// Dates are represented by underlying integers, e.g. DateTime is uint32.
+type MinState[T aggregateOrdered] singleValue[T]
+
+// Add value.
+func (s *MinState[T]) Add(v T) {
+	if !s.Has || v < s.Value {
+		s.Value, s.Has = v, true
+	}
+}
+
+// Merge other state.
+func (s *MinState[T]) Merge(o MinState[T]) {
+	if o.Has {
+		s.Add(o.Value)
+	}
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *MinState[T]) DecodeAggregateState(r *Reader) error {
+	return (*singleValue[T])(s).decode(r)
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s MinState[T]) EncodeAggregateState(b *Buffer) {
+	singleValue[T](s).encode(b)
+}
+
+// MaxState is state of max.
+//
+// Dates are represented by underlying integers, e.g. DateTime is uint32.
+type MaxState[T aggregateOrdered] singleValue[T]
+
+// Add value.
+func (s *MaxState[T]) Add(v T) {
+	if !s.Has || v > s.Value {
+		s.Value, s.Has = v, true
+	}
+}
+
+// Merge other state.
+func (s *MaxState[T]) Merge(o MaxState[T]) {
+	if o.Has {
+		s.Add(o.Value)
+	}
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *MaxState[T]) DecodeAggregateState(r *Reader) error {
+	return (*singleValue[T])(s).decode(r)
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s MaxState[T]) EncodeAggregateState(b *Buffer) {
+	singleValue[T](s).encode(b)
+}
+
+// UniqExactState is state of uniqExact, i.e. set of unique values.
+//
+// Values of numeric arguments are stored as is, while values of other types,
+// like String, are stored as 128-bit SipHash of value.
+type UniqExactState[T aggregateValue] struct {
+	Values []T
+
+	set map[T]struct{}
+}
+
+func (s *UniqExactState[T]) init() {
+	if s.set != nil && len(s.set) == len(s.Values) {
+		return
+	}
+	s.set = make(map[T]struct{}, len(s.Values))
+	for _, v := range s.Values {
+		s.set[v] = struct{}{}
+	}
+}
+
+// Add value if it is not present.
+func (s *UniqExactState[T]) Add(v T) {
+	s.init()
+	if _, ok := s.set[v]; ok {
+		return
+	}
+	s.set[v] = struct{}{}
+	s.Values = append(s.Values, v)
+}
+
+// Merge other state.
+func (s *UniqExactState[T]) Merge(o UniqExactState[T]) {
+	for _, v := range o.Values {
+		s.Add(v)
+	}
+}
+
+// Count returns count of unique values.
+func (s UniqExactState[T]) Count() uint64 {
+	return uint64(len(s.Values))
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *UniqExactState[T]) DecodeAggregateState(r *Reader) error {
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "size")
+	}
+	if err := checkRows(int(n)); err != nil {
+		return errors.Wrap(err, "size")
+	}
+	s.Values = s.Values[:0]
+	s.set = nil
+	for i := 0; i < int(n); i++ {
+		v, err := readAggregateValue[T](r)
+		if err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+		s.Values = append(s.Values, v)
+	}
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s UniqExactState[T]) EncodeAggregateState(b *Buffer) {
+	b.PutUVarInt(uint64(len(s.Values)))
+	for _, v := range s.Values {
+		putAggregateValue(b, v)
+	}
+}
+
+// Limits of UniquesHashSet.
+const (
+	uniqMaxSizeDegree = 17
+	uniqMaxSize       = 1 << (uniqMaxSizeDegree - 1)
+)
+
+// UniqState is state of uniq, i.e. UniquesHashSet of 32-bit hashes of
+// values, which is adaptive sample of hashes that are divisible by
+// 2^SkipDegree.
+//
+// Values can't be added directly, because hashes depend on argument type,
+// but states can be merged.
+type UniqState struct {
+	SkipDegree uint8
+	Hashes     []uint32
+}
+
+func (s UniqState) good(h uint32) bool {
+	return h == (h>>s.SkipDegree)<<s.SkipDegree
+}
+
+// filter removes hashes that are not good for current skip degree.
+func (s *UniqState) filter() {
+	hashes := s.Hashes[:0]
+	for _, h := range s.Hashes {
+		if s.good(h) {
+			hashes = append(hashes, h)
+		}
+	}
+	s.Hashes = hashes
+}
+
+// Merge other state.
+func (s *UniqState) Merge(o UniqState) {
+	if o.SkipDegree > s.SkipDegree {
+		s.SkipDegree = o.SkipDegree
+		s.filter()
+	}
+	set := make(map[uint32]struct{}, len(s.Hashes))
+	for _, h := range s.Hashes {
+		set[h] = struct{}{}
+	}
+	for _, h := range o.Hashes {
+		if _, ok := set[h]; ok || !s.good(h) {
+			continue
+		}
+		set[h] = struct{}{}
+		s.Hashes = append(s.Hashes, h)
+	}
+	for len(s.Hashes) > uniqMaxSize {
+		s.SkipDegree++
+		s.filter()
+	}
+}
+
+var uniqCRC32Table = crc32.MakeTable(crc32.Castagnoli)
+
+// intHashCRC32 is CRC32-C of x with initial value of all ones and without
+// final inversion, as computed by SSE4.2 instruction.
+func intHashCRC32(x uint64) uint32 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	return ^crc32.Update(0, uniqCRC32Table, buf[:])
+}
+
+// Count returns estimated count of unique values.
+func (s UniqState) Count() uint64 {
+	n := uint64(len(s.Hashes))
+	if s.SkipDegree == 0 {
+		return n
+	}
+	mask := uint64(1)<<s.SkipDegree - 1
+	res := n<<s.SkipDegree + uint64(intHashCRC32(n))&mask
+
+	// Correction of systematic error due to collisions of 32-bit hashes.
+	const p32 = float64(1 << 32)
+	return uint64(math.Round(p32 * (math.Log(p32) - math.Log(p32-float64(res)))))
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *UniqState) DecodeAggregateState(r *Reader) error {
+	skip, err := r.UInt8()
+	if err != nil {
+		return errors.Wrap(err, "skip degree")
+	}
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "size")
+	}
+	if n > uniqMaxSize {
+		return errors.Errorf("size %d is too large", n)
+	}
+	s.SkipDegree = skip
+	s.Hashes = s.Hashes[:0]
+	for i := 0; i < int(n); i++ {
+		h, err := r.UInt32()
+		if err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+		s.Hashes = append(s.Hashes, h)
+	}
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s UniqState) EncodeAggregateState(b *Buffer) {
+	b.PutUInt8(s.SkipDegree)
+	b.PutUVarInt(uint64(len(s.Hashes)))
+	for _, h := range s.Hashes {
+		b.PutUInt32(h)
+	}
+}
+
+// TDigestCentroid is centroid of t-digest.
+type TDigestCentroid struct {
+	Mean  float32
+	Count float32
+}
+
+// TDigestState is state of quantileTDigest and quantilesTDigest, including
+// weighted variants, i.e. t-digest centroids sorted by mean.
+//
+// Centroids are not compressed on Add and Merge, server compresses them on
+// next aggregation.
+type TDigestState struct {
+	Centroids []TDigestCentroid
+}
+
+func (s *TDigestState) sort() {
+	sort.SliceStable(s.Centroids, func(i, j int) bool {
+		return s.Centroids[i].Mean < s.Centroids[j].Mean
+	})
+}
+
+// Add value with weight.
+func (s *TDigestState) Add(v, weight float32) {
+	idx := sort.Search(len(s.Centroids), func(i int) bool {
+		return s.Centroids[i].Mean > v
+	})
+	s.Centroids = append(s.Centroids, TDigestCentroid{})
+	copy(s.Centroids[idx+1:], s.Centroids[idx:])
+	s.Centroids[idx] = TDigestCentroid{Mean: v, Count: weight}
+}
+
+// Merge other state.
+func (s *TDigestState) Merge(o TDigestState) {
+	s.Centroids = append(s.Centroids, o.Centroids...)
+	s.sort()
+}
+
+// Count returns total weight of values.
+func (s TDigestState) Count() float64 {
+	var n float64
+	for _, c := range s.Centroids {
+		n += float64(c.Count)
+	}
+	return n
+}
+
+// Quantile returns approximate quantile of level in [0, 1] range, which is
+// zero for empty state.
+func (s TDigestState) Quantile(level float64) float64 {
+	if len(s.Centroids) == 0 {
+		return 0
+	}
+	first := s.Centroids[0]
+	if len(s.Centroids) == 1 {
+		return float64(first.Mean)
+	}
+	var (
+		x         = level * s.Count()
+		prevX     float64
+		sum       float64
+		prevMean  = float64(first.Mean)
+		prevCount = float64(first.Count)
+	)
+	for _, c := range s.Centroids {
+		currentX := sum + float64(c.Count)*0.5
+		if currentX >= x {
+			// Special handling of singletons.
+			left := prevX
+			if prevCount == 1 {
+				left += 0.5
+			}
+			right := currentX
+			if c.Count == 1 {
+				right -= 0.5
+			}
+			switch {
+			case x <= left:
+				return prevMean
+			case x >= right:
+				return float64(c.Mean)
+			default:
+				return prevMean + (x-left)*(float64(c.Mean)-prevMean)/(right-left)
+			}
+		}
+		sum += float64(c.Count)
+		prevMean = float64(c.Mean)
+		prevCount = float64(c.Count)
+		prevX = currentX
+	}
+	return float64(s.Centroids[len(s.Centroids)-1].Mean)
+}
+
+// DecodeAggregateState implements AggregateState.
+func (s *TDigestState) DecodeAggregateState(r *Reader) error {
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "size")
+	}
+	if err := checkRows(int(n)); err != nil {
+		return errors.Wrap(err, "size")
+	}
+	s.Centroids = s.Centroids[:0]
+	for i := 0; i < int(n); i++ {
+		mean, err := r.Float32()
+		if err != nil {
+			return errors.Wrapf(err, "[%d]: mean", i)
+		}
+		count, err := r.Float32()
+		if err != nil {
+			return errors.Wrapf(err, "[%d]: count", i)
+		}
+		if math.IsNaN(float64(mean)) || !(count > 0) {
+			return errors.Errorf("[%d]: invalid centroid", i)
+		}
+		s.Centroids = append(s.Centroids, TDigestCentroid{Mean: mean, Count: count})
+	}
+	return nil
+}
+
+// EncodeAggregateState implements AggregateState.
+func (s TDigestState) EncodeAggregateState(b *Buffer) {
+	b.PutUVarInt(uint64(len(s.Centroids)))
+	for _, c := range s.Centroids {
+		b.PutFloat32(c.Mean)
+		b.PutFloat32(c.Count)
+	}
+}
@@ -0,0 +1,121 @@
+package proto
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateState(t *testing.T) {
+	minStr := new(MinState[string])
+	minStr.Add("foo")
+	minStr.Add("bar")
+	for _, tt := range []struct {
+		Name  string
+		State AggregateState
+		Hex   []byte
+	}{
+		{Name: "Count", State: &CountState{Count: 300}, Hex: []byte{0xac, 0x02}},
+		{Name: "SumUInt64", State: &SumState[uint64]{Sum: 5}, Hex: []byte{5, 0, 0, 0, 0, 0, 0, 0}},
+		{Name: "SumInt64", State: &SumState[int64]{Sum: -1}},
+		{Name: "SumFloat64", State: &SumState[float64]{Sum: 1.5}},
+		{Name: "Avg", State: &AvgState[uint64]{Numerator: 10, Denominator: 4}, Hex: []byte{10, 0, 0, 0, 0, 0, 0, 0, 4}},
+		{Name: "MinStr", State: minStr, Hex: []byte{4, 0, 0, 0, 'b', 'a', 'r', 0}},
+		{Name: "MinStrEmpty", State: &MinState[string]{}, Hex: []byte{0xff, 0xff, 0xff, 0xff}},
+		{Name: "MaxUInt16", State: &MaxState[uint16]{Value: 2, Has: true}, Hex: []byte{1, 2, 0}},
+		{Name: "MaxEmpty", State: &MaxState[float32]{}, Hex: []byte{0}},
+		{Name: "UniqExact", State: &UniqExactState[uint32]{Values: []uint32{1, 2}}, Hex: []byte{2, 1, 0, 0, 0, 2, 0, 0, 0}},
+		{Name: "UniqExactHash", State: &UniqExactState[UInt128]{Values: []UInt128{{Low: 1, High: 2}}}},
+		{Name: "Uniq", State: &UniqState{SkipDegree: 1, Hashes: []uint32{2}}, Hex: []byte{1, 1, 2, 0, 0, 0}},
+		{Name: "TDigest", State: &TDigestState{Centroids: []TDigestCentroid{{Mean: 1, Count: 2}}}},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			var b Buffer
+			tt.State.EncodeAggregateState(&b)
+			if tt.Hex != nil {
+				require.Equal(t, tt.Hex, b.Buf)
+			}
+			dec := reflect.New(reflect.TypeOf(tt.State).Elem()).Interface().(AggregateState)
+			r := NewReader(bytes.NewReader(b.Buf))
+			require.NoError(t, dec.DecodeAggregateState(r))
+			require.Equal(t, tt.State, dec)
+
+			for i := range b.Buf {
+				r := NewReader(bytes.NewReader(b.Buf[:i]))
+				require.Error(t, dec.DecodeAggregateState(r), "short read")
+			}
+		})
+	}
+}
+
+func TestAggregateState_Merge(t *testing.T) {
+	t.Run("MinMax", func(t *testing.T) {
+		var a, b MinState[int32]
+		a.Add(10)
+		b.Add(-5)
+		a.Merge(b)
+		a.Merge(MinState[int32]{})
+		require.Equal(t, MinState[int32]{Value: -5, Has: true}, a)
+
+		var c, d MaxState[int32]
+		c.Add(10)
+		d.Add(-5)
+		c.Merge(d)
+		require.Equal(t, MaxState[int32]{Value: 10, Has: true}, c)
+	})
+	t.Run("Avg", func(t *testing.T) {
+		var a, b AvgState[int64]
+		a.Add(1)
+		b.Add(2)
+		b.Add(6)
+		a.Merge(b)
+		require.Equal(t, 3.0, a.Value())
+	})
+	t.Run("UniqExact", func(t *testing.T) {
+		var a, b UniqExactState[uint64]
+		a.Add(1)
+		a.Add(1)
+		b.Add(1)
+		b.Add(2)
+		a.Merge(b)
+		require.Equal(t, []uint64{1, 2}, a.Values)
+		require.Equal(t, uint64(2), a.Count())
+	})
+	t.Run("Uniq", func(t *testing.T) {
+		a := UniqState{Hashes: []uint32{1, 2, 3}}
+		a.Merge(UniqState{Hashes: []uint32{3, 4}})
+		require.Equal(t, uint64(4), a.Count())
+
+		// Hashes that are not divisible by 2^SkipDegree are dropped.
+		a.Merge(UniqState{SkipDegree: 1, Hashes: []uint32{6}})
+		require.Equal(t, UniqState{SkipDegree: 1, Hashes: []uint32{2, 4, 6}}, a)
+		require.InDelta(t, 6, a.Count(), 1)
+
+		var big UniqState
+		for i := uint32(0); i < 2*uniqMaxSize+2; i++ {
+			big.Hashes = append(big.Hashes, i)
+		}
+		a.Merge(big)
+		require.Equal(t, uint8(2), a.SkipDegree)
+		require.Len(t, a.Hashes, uniqMaxSize/2+1)
+		require.InDelta(t, 2*uniqMaxSize, a.Count(), 10)
+	})
+	t.Run("TDigest", func(t *testing.T) {
+		var a, b TDigestState
+		require.Zero(t, a.Quantile(0.5))
+		for i := 1; i <= 100; i++ {
+			if i%2 == 0 {
+				a.Add(float32(i), 1)
+			} else {
+				b.Add(float32(i), 1)
+			}
+		}
+		a.Merge(b)
+		require.Equal(t, 100.0, a.Count())
+		require.Equal(t, 50.0, a.Quantile(0.5))
+		require.Equal(t, 1.0, a.Quantile(0))
+		require.Equal(t, 100.0, a.Quantile(1))
+	})
+}
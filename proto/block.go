@@ -184,10 +184,15 @@ func (b Block) EncodeRawBlock(buf *Buffer, version int, input []InputColumn) err
 }
 
 func (b Block) WriteBlock(w *Writer, version int, input []InputColumn) error {
+	if FeatureBlockInfo.In(version) {
+		w.ChainBuffer(b.Info.Encode)
+	}
+	return b.WriteRawBlock(w, version, input)
+}
+
+// WriteRawBlock writes block without block info, like EncodeRawBlock.
+func (b Block) WriteRawBlock(w *Writer, version int, input []InputColumn) error {
 	w.ChainBuffer(func(buf *Buffer) {
-		if FeatureBlockInfo.In(version) {
-			b.Info.Encode(buf)
-		}
 		buf.PutInt(b.Columns)
 		buf.PutInt(b.Rows)
 	})
@@ -211,6 +216,9 @@ func (b Block) WriteBlock(w *Writer, version int, input []InputColumn) error {
 			w.ChainBuffer(v.EncodeState)
 		}
 		col.Data.WriteColumn(w)
+		if err := w.flushIfNeeded(); err != nil {
+			return errors.Wrapf(err, "flush %q", col.Name)
+		}
 	}
 	return nil
 }
@@ -281,18 +289,12 @@ func (b *Block) DecodeRawBlock(r *Reader, version int, target Result) error {
 				return errors.Wrapf(err, "column [%d] name", i)
 			}
 			// Type.
-			if _, err := r.Str(); err != nil {
+			t, err := r.Str()
+			if err != nil {
 				return errors.Wrapf(err, "column [%d] type", i)
 			}
-			if FeatureCustomSerialization.In(version) {
-				// Custom serialization flag.
-				v, err := r.Bool()
-				if err != nil {
-					return errors.Wrapf(err, "column [%d] custom serialization flag", i)
-				}
-				if v {
-					return errors.Errorf("column [%d] has custom serialization (not supported)", i)
-				}
+			if _, err := decodeColumnHeader(r, version, ColumnType(t)); err != nil {
+				return errors.Wrapf(err, "column [%d]", i)
 			}
 		}
 		return nil
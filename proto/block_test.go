@@ -139,3 +139,49 @@ func TestBlock_EncodeBlock(t *testing.T) {
 		}
 	})
 }
+
+// writesCounter counts writes.
+type writesCounter struct {
+	Buffer
+	writes int
+}
+
+func (w *writesCounter) Write(p []byte) (int, error) {
+	w.writes++
+	w.PutRaw(p)
+	return len(p), nil
+}
+
+func TestBlock_WriteBlock(t *testing.T) {
+	v := Block{
+		Info: BlockInfo{
+			BucketNum: -1,
+		},
+		Columns: 2,
+		Rows:    3,
+	}
+	input := []InputColumn{
+		{Name: "name", Data: &ColStr{}},
+		{Name: "users", Data: ColUInt64{5467267, 175676, 956105}},
+	}
+	for _, s := range []string{"foo", "bar", "baz"} {
+		input[0].Data.(*ColStr).Append(s)
+	}
+	var expected Buffer
+	require.NoError(t, v.EncodeBlock(&expected, Version, input))
+
+	for _, flushSize := range []int{0, 1} {
+		var out writesCounter
+		w := NewWriter(&out, new(Buffer))
+		w.SetFlushSize(flushSize)
+		require.NoError(t, v.WriteBlock(w, Version, input))
+		if flushSize == 0 {
+			require.Zero(t, out.writes, "should not flush")
+		} else {
+			require.NotZero(t, out.writes, "should flush between columns")
+		}
+		_, err := w.Flush()
+		require.NoError(t, err)
+		require.Equal(t, expected.Buf, out.Buf)
+	}
+}
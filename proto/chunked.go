@@ -0,0 +1,114 @@
+package proto
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// Chunking is capability of chunked framing of packets in one direction,
+// negotiated in handshake, like proto_caps setting of ClickHouse.
+//
+// In chunked framing, each packet is sent as sequence of chunks prefixed
+// by UInt32 size, terminated by empty chunk.
+type Chunking string
+
+// Possible chunking capabilities.
+const (
+	ChunkingNotChunked         Chunking = "notchunked"
+	ChunkingNotChunkedOptional Chunking = "notchunked_optional"
+	ChunkingChunked            Chunking = "chunked"
+	ChunkingChunkedOptional    Chunking = "chunked_optional"
+)
+
+// Chunked reports whether chunked framing is preferred.
+func (c Chunking) Chunked() bool {
+	return strings.HasPrefix(string(c), string(ChunkingChunked))
+}
+
+// Optional reports whether other side can choose framing.
+func (c Chunking) Optional() bool {
+	return strings.HasSuffix(string(c), "_optional")
+}
+
+// Valid reports whether c is known chunking capability.
+func (c Chunking) Valid() bool {
+	switch c {
+	case ChunkingNotChunked, ChunkingNotChunkedOptional, ChunkingChunked, ChunkingChunkedOptional:
+		return true
+	default:
+		return false
+	}
+}
+
+// Negotiate reports whether chunked framing should be used, where c is
+// local capability and remote is capability of other side for opposite
+// direction, e.g. local send and remote receive.
+func (c Chunking) Negotiate(remote Chunking) (bool, error) {
+	if !remote.Valid() {
+		return false, errors.Errorf("unknown chunking %q", remote)
+	}
+	switch {
+	case remote.Optional():
+		return c.Chunked(), nil
+	case c.Optional():
+		return remote.Chunked(), nil
+	case c.Chunked() != remote.Chunked():
+		return false, errors.Errorf("incompatible chunking: %q, other side requires %q", c, remote)
+	default:
+		return remote.Chunked(), nil
+	}
+}
+
+// PutChunk appends data as single chunk of chunked packet.
+func (b *Buffer) PutChunk(data []byte) {
+	b.PutUInt32(uint32(len(data)))
+	b.PutRaw(data)
+}
+
+// PutChunkEnd appends empty chunk that terminates chunked packet.
+func (b *Buffer) PutChunkEnd() {
+	b.PutUInt32(0)
+}
+
+// chunkedReader reads data of chunked packets, skipping chunk headers.
+//
+// Reads as is until enabled.
+type chunkedReader struct {
+	r       io.Reader
+	enabled bool
+
+	left   uint32 // bytes left in current chunk
+	header [4]byte
+	n      int // bytes of header read, to resume after timeout
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if !c.enabled {
+		return c.r.Read(p)
+	}
+	for c.left == 0 {
+		// Reading next chunk header, empty chunks terminate packets.
+		n, err := c.r.Read(c.header[c.n:])
+		c.n += n
+		if c.n == len(c.header) {
+			c.n = 0
+			c.left = binary.LittleEndian.Uint32(c.header[:])
+			continue
+		}
+		if err != nil {
+			if c.n > 0 && errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	if len(p) > int(c.left) {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= uint32(n)
+	return n, err
+}
@@ -0,0 +1,168 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/go-faster/errors"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestChunking_Negotiate(t *testing.T) {
+	for _, tt := range []struct {
+		Local, Remote Chunking
+		Chunked       bool
+		Error         bool
+	}{
+		{Local: ChunkingNotChunked, Remote: ChunkingNotChunked},
+		{Local: ChunkingChunked, Remote: ChunkingChunked, Chunked: true},
+		{Local: ChunkingChunked, Remote: ChunkingNotChunked, Error: true},
+		{Local: ChunkingNotChunked, Remote: ChunkingChunked, Error: true},
+		{Local: ChunkingChunked, Remote: ChunkingNotChunkedOptional, Chunked: true},
+		{Local: ChunkingNotChunkedOptional, Remote: ChunkingChunked, Chunked: true},
+		{Local: ChunkingChunkedOptional, Remote: ChunkingNotChunked},
+		{Local: ChunkingChunkedOptional, Remote: ChunkingNotChunkedOptional, Chunked: true},
+		{Local: ChunkingNotChunked, Remote: "bad", Error: true},
+	} {
+		chunked, err := tt.Local.Negotiate(tt.Remote)
+		if tt.Error {
+			require.Error(t, err, "%s %s", tt.Local, tt.Remote)
+			continue
+		}
+		require.NoError(t, err, "%s %s", tt.Local, tt.Remote)
+		require.Equal(t, tt.Chunked, chunked, "%s %s", tt.Local, tt.Remote)
+	}
+}
+
+func TestWriter_Chunked(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, new(Buffer))
+	w.EnableChunked()
+
+	// Single packet.
+	w.ChainBuffer(func(b *Buffer) {
+		ClientCodePing.Encode(b)
+	})
+	w.FinishChunk()
+	// Packet that is flushed in the middle.
+	data := []byte("Hello, world!")
+	w.ChainBuffer(func(b *Buffer) {
+		ClientCodeData.Encode(b)
+		b.PutString("table")
+	})
+	w.ChainWrite(data)
+	_, err := w.Flush()
+	require.NoError(t, err)
+	w.ChainWrite(nil)
+	w.ChainBuffer(func(b *Buffer) {
+		b.PutUInt64(42)
+	})
+	w.FinishChunk()
+	// Terminator without data is not written.
+	w.FinishChunk()
+	_, err = w.Flush()
+	require.NoError(t, err)
+
+	var expected Buffer
+	expected.PutChunk([]byte{byte(ClientCodePing)})
+	expected.PutChunkEnd()
+	{
+		var chunk Buffer
+		ClientCodeData.Encode(&chunk)
+		chunk.PutString("table")
+		chunk.PutRaw(data)
+		expected.PutChunk(chunk.Buf)
+	}
+	{
+		var chunk Buffer
+		chunk.PutUInt64(42)
+		expected.PutChunk(chunk.Buf)
+	}
+	expected.PutChunkEnd()
+	require.Equal(t, expected.Buf, out.Bytes())
+	gold.Bytes(t, out.Bytes(), "chunked_packets")
+
+	t.Run("Read", func(t *testing.T) {
+		for _, r := range []io.Reader{
+			bytes.NewReader(out.Bytes()),
+			iotest.OneByteReader(bytes.NewReader(out.Bytes())),
+			iotest.HalfReader(bytes.NewReader(out.Bytes())),
+		} {
+			rd := NewReader(r)
+			rd.EnableChunked()
+
+			code, err := rd.UVarInt()
+			require.NoError(t, err)
+			require.Equal(t, uint64(ClientCodePing), code)
+
+			code, err = rd.UVarInt()
+			require.NoError(t, err)
+			require.Equal(t, uint64(ClientCodeData), code)
+			table, err := rd.Str()
+			require.NoError(t, err)
+			require.Equal(t, "table", table)
+			got, err := rd.ReadRaw(len(data))
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+			v, err := rd.UInt64()
+			require.NoError(t, err)
+			require.Equal(t, uint64(42), v)
+
+			_, err = rd.UVarInt()
+			require.ErrorIs(t, err, io.EOF)
+		}
+	})
+}
+
+// timeoutReader returns timeout error before each read.
+type timeoutReader struct {
+	r       io.Reader
+	timeout bool
+}
+
+func (t *timeoutReader) Read(p []byte) (int, error) {
+	t.timeout = !t.timeout
+	if t.timeout {
+		return 0, iotest.ErrTimeout
+	}
+	return t.r.Read(p)
+}
+
+func TestChunkedReader(t *testing.T) {
+	var b Buffer
+	b.PutChunk([]byte("foo"))
+	b.PutChunkEnd()
+	b.PutChunk([]byte("bar"))
+	b.PutChunk([]byte("baz"))
+	b.PutChunkEnd()
+
+	r := &chunkedReader{
+		r:       &timeoutReader{r: iotest.OneByteReader(bytes.NewReader(b.Buf))},
+		enabled: true,
+	}
+	var got []byte
+	buf := make([]byte, 2)
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if errors.Is(err, iotest.ErrTimeout) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, "foobarbaz", string(got))
+
+	t.Run("UnexpectedEOF", func(t *testing.T) {
+		r := &chunkedReader{r: bytes.NewReader(b.Buf[:2]), enabled: true}
+		_, err := io.ReadAll(r)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
@@ -0,0 +1,15 @@
+CREATE TABLE events
+(
+    id UInt64,
+    ts DateTime64(3, 'Europe/Berlin'),
+    created DateTime('UTC'),
+    updated Nullable(DateTime64(6, 'Asia/Tokyo')),
+    day Date,
+    user_id UUID,
+    status Enum8('ok' = 1, 'fail' = 2),
+    price Decimal(18, 4),
+    tags Array(LowCardinality(String)),
+    attrs Map(String, UInt32),
+    day_of_week MATERIALIZED toDayOfWeek(ts),
+    ts_alias ALIAS ts
+) ENGINE = MergeTree ORDER BY id
@@ -0,0 +1,60 @@
+package example
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestEvents(t *testing.T) {
+	events := NewEvents()
+	for _, c := range []struct {
+		Got      proto.ColumnType
+		Expected proto.ColumnType
+	}{
+		{events.Ts.Type(), "DateTime64(3, 'Europe/Berlin')"},
+		{events.Created.Type(), "DateTime('UTC')"},
+		{events.Updated.Type(), "Nullable(DateTime64(6, 'Asia/Tokyo'))"},
+		{events.Status.Type(), "Enum8('ok' = 1, 'fail' = 2)"},
+	} {
+		require.Equal(t, c.Expected, c.Got)
+	}
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	row := EventsRow{
+		ID:      1,
+		Ts:      ts,
+		Created: ts.Truncate(time.Second),
+		Updated: proto.NewNullable(ts),
+		Day:     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		UserID:  uuid.New(),
+		Status:  "fail",
+		Price:   proto.Decimal64(10050),
+		Tags:    []string{"a", "b"},
+		Attrs:   map[string]uint32{"k": 1},
+	}
+	events.Append(row)
+
+	var buf proto.Buffer
+	input := events.Input()
+	block := proto.Block{Columns: len(input), Rows: events.Rows()}
+	require.NoError(t, block.EncodeRawBlock(&buf, proto.Version, input))
+
+	decoded := NewEvents()
+	require.NoError(t, block.DecodeRawBlock(buf.Reader(), proto.Version, decoded.Result()))
+	require.Equal(t, 1, decoded.Rows())
+	got := decoded.Row(0)
+	require.Equal(t, "Europe/Berlin", got.Ts.Location().String())
+	require.True(t, row.Ts.Equal(got.Ts))
+	require.True(t, row.Created.Equal(got.Created))
+	require.True(t, row.Updated.Value.Equal(got.Updated.Value))
+	require.Equal(t, row.UserID, got.UserID)
+	require.Equal(t, row.Status, got.Status)
+	require.Equal(t, row.Price, got.Price)
+	require.Equal(t, row.Tags, got.Tags)
+	require.Equal(t, row.Attrs, got.Attrs)
+}
@@ -0,0 +1,5 @@
+// Package example contains columns generated by ch-gen-table from
+// events.sql, so generated code is compiled and tested.
+package example
+
+//go:generate go run github.com/ClickHouse/ch-go/proto/cmd/ch-gen-table -i events.sql -o events_gen.go -package example
@@ -0,0 +1,193 @@
+// Binary ch-gen-table generates struct of typed columns for table.
+//
+// Table schema is read from CREATE TABLE statement or from result of
+// DESCRIBE TABLE in TabSeparated format, e.g.:
+//
+//	clickhouse-client -q "DESCRIBE TABLE events" | go run ./proto/cmd/ch-gen-table -type Events -package db
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+//go:embed table.go.tmpl
+var tableTemplate string
+
+// Table is template data.
+type Table struct {
+	Package string
+	Type    string
+	Table   string
+	Fields  []Field
+
+	Time, UUID, Infer bool
+}
+
+// Field of generated struct.
+type Field struct {
+	GoColumn
+
+	Name       string
+	ColumnName string
+	Type       proto.ColumnType
+}
+
+// initialisms that are upper-cased in Go names.
+var initialisms = map[string]bool{
+	"api":  true,
+	"http": true,
+	"id":   true,
+	"ip":   true,
+	"json": true,
+	"sql":  true,
+	"ttl":  true,
+	"uri":  true,
+	"url":  true,
+	"utc":  true,
+	"uuid": true,
+}
+
+// goName converts ClickHouse name to exported Go identifier.
+func goName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "C" + name
+	}
+	return name
+}
+
+// Generate returns formatted Go source of typed columns for schema.
+func Generate(schema *Schema, pkg, typeName string) ([]byte, error) {
+	if typeName == "" {
+		if schema.Table == "" {
+			return nil, errors.New("type name is required")
+		}
+		typeName = goName(schema.Table)
+	}
+	table := Table{
+		Package: pkg,
+		Type:    typeName,
+		Table:   schema.Table,
+	}
+	if table.Table == "" {
+		table.Table = typeName
+	}
+	names := map[string]int{}
+	for _, c := range schema.Columns {
+		t, err := proto.ParseType(c.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q", c.Name)
+		}
+		col, err := NewGoColumn(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q", c.Name)
+		}
+		if col.Alias && col.Prepare {
+			return nil, errors.Errorf("column %q: type %q is not supported", c.Name, t.String())
+		}
+		f := Field{
+			GoColumn:   col,
+			Name:       goName(c.Name),
+			ColumnName: c.Name,
+			Type:       t.ColumnType(),
+		}
+		if n := names[f.Name]; n > 0 {
+			names[f.Name]++
+			f.Name = fmt.Sprintf("%s%d", f.Name, n+1)
+		} else {
+			names[f.Name] = 1
+		}
+		table.Time = table.Time || col.Time
+		table.UUID = table.UUID || col.UUID
+		table.Infer = table.Infer || col.Infer
+		table.Fields = append(table.Fields, f)
+	}
+
+	tpl, err := template.New("table").Parse(tableTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+	out := new(bytes.Buffer)
+	if err := tpl.Execute(out, table); err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+	data, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format")
+	}
+	return data, nil
+}
+
+func run() error {
+	var (
+		input    = flag.String("i", "", "file with CREATE TABLE or DESCRIBE TABLE result, stdin if blank")
+		output   = flag.String("o", "", "output file, stdout if blank")
+		pkg      = flag.String("package", os.Getenv("GOPACKAGE"), "package name")
+		typeName = flag.String("type", "", "name of generated type, derived from table name if blank")
+	)
+	flag.Parse()
+	if *pkg == "" {
+		return errors.New("package name is required")
+	}
+
+	var (
+		raw []byte
+		err error
+	)
+	if *input == "" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return errors.Wrap(err, "read")
+	}
+	schema, err := ParseSchema(string(raw))
+	if err != nil {
+		return errors.Wrap(err, "parse schema")
+	}
+	data, err := Generate(schema, *pkg, *typeName)
+	if err != nil {
+		return errors.Wrap(err, "generate")
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		return errors.Wrap(err, "write")
+	}
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+		os.Exit(2)
+	}
+}
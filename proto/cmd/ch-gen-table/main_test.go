@@ -0,0 +1,195 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestParseSchema(t *testing.T) {
+	expected := &Schema{
+		Table: "events",
+		Columns: []Column{
+			{Name: "id", Type: "UInt64"},
+			{Name: "ts", Type: "DateTime64(3, 'UTC')"},
+			{Name: "level", Type: "LowCardinality(String)"},
+			{Name: "status", Type: "Enum8('a,b' = 1, 'c' = 2)"},
+			{Name: "comment", Type: "Nullable(String)"},
+			{Name: "big value", Type: "INT UNSIGNED"},
+		},
+	}
+	t.Run("Create", func(t *testing.T) {
+		s, err := ParseSchema(`-- Events.
+CREATE TABLE IF NOT EXISTS db.events ON CLUSTER c
+(
+    id UInt64 CODEC(Delta, ZSTD),
+    ts DateTime64(3, 'UTC') DEFAULT now64(3),
+    level LowCardinality(String) COMMENT 'log level, (see docs)',
+    /* Status. */
+    status Enum8('a,b' = 1, 'c' = 2),
+    day UInt8 MATERIALIZED toDayOfWeek(ts),
+    ts_alias DateTime ALIAS ts,
+    day_of_week MATERIALIZED toDayOfWeek(ts),
+    ts_copy ALIAS ts,
+    comment String NULL,
+    ` + "`big value`" + ` INT UNSIGNED NOT NULL,
+    INDEX idx level TYPE set(0) GRANULARITY 1,
+    PRIMARY KEY (id)
+) ENGINE = MergeTree ORDER BY id`)
+		require.NoError(t, err)
+		require.Equal(t, expected, s)
+	})
+	t.Run("Describe", func(t *testing.T) {
+		s, err := ParseSchema(strings.Join([]string{
+			"name\ttype\tdefault_type\tdefault_expression\tcomment\tcodec_expression\tttl_expression",
+			"id\tUInt64\t\t\t\tDelta(8), ZSTD(1)\t",
+			"ts\tDateTime64(3, \\'UTC\\')\tDEFAULT\tnow64(3)\t\t\t",
+			"level\tLowCardinality(String)\t\t\tlog level, (see docs)\t\t",
+			"status\tEnum8(\\'a,b\\' = 1, \\'c\\' = 2)\t\t\t\t\t",
+			"day\tUInt8\tMATERIALIZED\ttoDayOfWeek(ts)\t\t\t",
+			"comment\tNullable(String)\t\t\t\t\t",
+			"big value\tINT UNSIGNED\t\t\t\t\t",
+		}, "\n"))
+		require.NoError(t, err)
+		expected := *expected
+		expected.Table = ""
+		require.Equal(t, &expected, s)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"CREATE TABLE t AS other ENGINE = Memory()",
+			"CREATE TABLE t (id UInt64",
+			"CREATE TABLE t (id DEFAULT 1) ENGINE = Memory",
+			"CREATE TABLE t (id String DEFAULT 'a) ENGINE = Memory",
+			"CREATE TABLE t (day UInt8 MATERIALIZED 1) ENGINE = Memory",
+			"id",
+		} {
+			_, err := ParseSchema(s)
+			require.Error(t, err, "%s", s)
+		}
+		_, err := ParseSchema("CREATE TABLE t (id UInt64, v DEFAULT 1) ENGINE = Memory")
+		require.ErrorContains(t, err, `column "v DEFAULT 1": type is required`)
+	})
+}
+
+func TestGoName(t *testing.T) {
+	for in, out := range map[string]string{
+		"id":          "ID",
+		"user_id":     "UserID",
+		"big value":   "BigValue",
+		"nested.a_b":  "NestedAB",
+		"2fa":         "C2fa",
+		"_":           "C",
+		"camelCase":   "CamelCase",
+		"remote_addr": "RemoteAddr",
+	} {
+		require.Equal(t, out, goName(in), in)
+	}
+}
+
+func TestNewGoColumn(t *testing.T) {
+	for _, tt := range []struct {
+		Type   proto.ColumnType
+		Column string
+		Elem   string
+		New    string
+		Infer  bool
+	}{
+		{
+			Type:   "UInt64",
+			Column: "*proto.ColUInt64",
+			Elem:   "uint64",
+			New:    "new(proto.ColUInt64)",
+		},
+		{
+			Type:   "DateTime64(9, 'UTC')",
+			Column: "*proto.ColDateTime64",
+			Elem:   "time.Time",
+			New:    "new(proto.ColDateTime64).WithPrecision(9)",
+			Infer:  true,
+		},
+		{
+			Type:   "DateTime64(3)",
+			Column: "*proto.ColDateTime64",
+			Elem:   "time.Time",
+			New:    "new(proto.ColDateTime64).WithPrecision(3)",
+		},
+		{
+			Type:   "Array(DateTime('Europe/Berlin'))",
+			Column: "*proto.ColArr[time.Time]",
+			Elem:   "[]time.Time",
+			New:    "proto.NewArray[time.Time](new(proto.ColDateTime))",
+			Infer:  true,
+		},
+		{
+			Type:   "Array(LowCardinality(String))",
+			Column: "*proto.ColArr[string]",
+			Elem:   "[]string",
+			New:    "proto.NewArray[string](proto.NewLowCardinality[string](new(proto.ColStr)))",
+		},
+		{
+			Type:   "Map(String, Array(FixedString(2)))",
+			Column: "*proto.ColMap[string, [][]byte]",
+			Elem:   "map[string][][]byte",
+			New:    "proto.NewMap[string, [][]byte](new(proto.ColStr), proto.NewArray[[]byte](&proto.ColFixedStr{Size: 2}))",
+		},
+		{
+			Type:   "Nullable(Decimal(9, 2))",
+			Column: "*proto.ColNullable[proto.Decimal32]",
+			Elem:   "proto.Nullable[proto.Decimal32]",
+			New:    "proto.NewColNullable[proto.Decimal32](new(proto.ColDecimal32))",
+		},
+	} {
+		typ, err := proto.ParseType(tt.Type)
+		require.NoError(t, err)
+		c, err := NewGoColumn(typ)
+		require.NoError(t, err)
+		require.Equal(t, tt.Column, c.Column, "%s", tt.Type)
+		require.Equal(t, tt.Elem, c.Elem, "%s", tt.Type)
+		require.Equal(t, tt.New, c.New, "%s", tt.Type)
+		require.Equal(t, tt.Infer, c.Infer, "%s", tt.Type)
+	}
+	for _, s := range []proto.ColumnType{
+		"Tuple(String, UInt8)",
+		"JSON",
+		"LowCardinality(Nullable(String))",
+		"Nullable(Enum8('a' = 1))",
+		"DateTime('Mars/Olympus')",
+		"LowCardinality(DateTime('UTC'))",
+		"Nothing",
+	} {
+		typ, err := proto.ParseType(s)
+		require.NoError(t, err)
+		_, err = NewGoColumn(typ)
+		require.Error(t, err, "%s", s)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	// Generated code is compiled and tested in example package.
+	const dir = "internal/example"
+	schema, err := os.ReadFile(filepath.Join(dir, "events.sql"))
+	require.NoError(t, err)
+	s, err := ParseSchema(string(schema))
+	require.NoError(t, err)
+	data, err := Generate(s, "example", "")
+	require.NoError(t, err)
+	expected, err := os.ReadFile(filepath.Join(dir, "events_gen.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(data), "run go generate ./%s", dir)
+
+	s.Table = ""
+	_, err = Generate(s, "db", "")
+	require.Error(t, err, "type name is required")
+
+	_, err = Generate(&Schema{Columns: []Column{
+		{Name: "v", Type: "Map(LowCardinality(String), Decimal(9, 2))"},
+	}}, "db", "Table")
+	require.Error(t, err)
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Column of table schema.
+type Column struct {
+	Name string
+	Type proto.ColumnType
+}
+
+// Schema of table.
+type Schema struct {
+	Table   string // table name without database, can be blank
+	Columns []Column
+}
+
+// ParseSchema parses CREATE TABLE statement or DESCRIBE TABLE result in
+// TabSeparated format.
+func ParseSchema(s string) (*Schema, error) {
+	s = strings.TrimSpace(stripComments(s))
+	if s == "" {
+		return nil, errors.New("empty schema")
+	}
+	var (
+		schema *Schema
+		err    error
+	)
+	if w, _ := nextWord(s); strings.EqualFold(w, "CREATE") || strings.EqualFold(w, "ATTACH") {
+		schema, err = parseCreate(s)
+	} else {
+		schema, err = parseDescribe(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.Columns) == 0 {
+		return nil, errors.New("no columns")
+	}
+	return schema, nil
+}
+
+// parseDescribe parses result of DESCRIBE TABLE in TabSeparated or
+// TabSeparatedWithNames format.
+//
+// Columns are name, type, default_type and so on.
+func parseDescribe(s string) (*Schema, error) {
+	schema := &Schema{}
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 {
+			return nil, errors.Errorf("line %d: expected at least 2 tab-separated fields", line)
+		}
+		if line == 1 && fields[0] == "name" && fields[1] == "type" {
+			// Header of TabSeparatedWithNames.
+			continue
+		}
+		if len(fields) > 2 && !insertable(fields[2]) {
+			continue
+		}
+		schema.Columns = append(schema.Columns, Column{
+			Name: unescapeTSV(fields[0]),
+			Type: proto.ColumnType(unescapeTSV(fields[1])),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "scan")
+	}
+	return schema, nil
+}
+
+// insertable reports whether column with such default kind is both
+// returned by "SELECT *" and accepted by INSERT.
+func insertable(defaultKind string) bool {
+	switch strings.ToUpper(defaultKind) {
+	case "MATERIALIZED", "ALIAS", "EPHEMERAL":
+		return false
+	default:
+		return true
+	}
+}
+
+func unescapeTSV(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func parseCreate(s string) (*Schema, error) {
+	// CREATE [OR REPLACE] [TEMPORARY] TABLE [IF NOT EXISTS] [db.]name
+	// [ON CLUSTER cluster] (columns...) ENGINE = ...
+	rest := s
+	for {
+		w, tail := nextWord(rest)
+		if w == "" {
+			return nil, errors.New("expected TABLE keyword")
+		}
+		rest = tail
+		if strings.EqualFold(w, "TABLE") {
+			break
+		}
+	}
+	if w, tail := nextWord(rest); strings.EqualFold(w, "IF") {
+		rest = tail
+		for _, kw := range []string{"NOT", "EXISTS"} {
+			w, tail := nextWord(rest)
+			if !strings.EqualFold(w, kw) {
+				return nil, errors.Errorf("expected %s, got %q", kw, w)
+			}
+			rest = tail
+		}
+	}
+	name, rest, err := parseIdent(rest)
+	if err != nil {
+		return nil, errors.Wrap(err, "table name")
+	}
+	for strings.HasPrefix(rest, ".") {
+		if name, rest, err = parseIdent(rest[1:]); err != nil {
+			return nil, errors.Wrap(err, "table name")
+		}
+	}
+	schema := &Schema{Table: name}
+
+	start := strings.IndexByte(rest, '(')
+	if start < 0 {
+		return nil, errors.New("no column list")
+	}
+	if w, _ := nextWord(rest[:start]); w != "" && !strings.EqualFold(w, "ON") {
+		return nil, errors.Errorf("unexpected %q before column list", w)
+	}
+	elems, err := splitList(rest[start+1:])
+	if err != nil {
+		return nil, errors.Wrap(err, "column list")
+	}
+	for _, elem := range elems {
+		switch w, _ := nextWord(elem); strings.ToUpper(w) {
+		case "INDEX", "CONSTRAINT", "PROJECTION", "PRIMARY":
+			continue
+		}
+		col, ok, err := parseColumn(elem)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %q", elem)
+		}
+		if ok {
+			schema.Columns = append(schema.Columns, col)
+		}
+	}
+	return schema, nil
+}
+
+// parseColumn parses column declaration:
+//
+//	name [type] [NULL|NOT NULL] [DEFAULT|MATERIALIZED|EPHEMERAL|ALIAS expr] [CODEC(...)] ...
+//
+// Reports false if column is not insertable.
+func parseColumn(s string) (Column, bool, error) {
+	name, rest, err := parseIdent(s)
+	if err != nil {
+		return Column{}, false, err
+	}
+	var (
+		typ      strings.Builder
+		nullable bool
+		ok       = true
+	)
+Scan:
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		w, tail := nextWord(rest)
+		switch strings.ToUpper(w) {
+		case "NULL":
+			nullable = true
+			break Scan
+		case "NOT":
+			if n, _ := nextWord(tail); strings.EqualFold(n, "NULL") {
+				break Scan
+			}
+		case "MATERIALIZED", "ALIAS", "EPHEMERAL":
+			ok = false
+			break Scan
+		case "DEFAULT", "CODEC", "TTL", "COMMENT", "SETTINGS", "STATISTICS", "PRIMARY":
+			break Scan
+		}
+		n, err := typeToken(rest)
+		if err != nil {
+			return Column{}, false, err
+		}
+		if typ.Len() > 0 && rest[0] != '(' {
+			typ.WriteByte(' ')
+		}
+		typ.WriteString(rest[:n])
+		rest = rest[n:]
+	}
+	if typ.Len() == 0 {
+		if !ok {
+			// Type of expression, but column is skipped anyway.
+			return Column{}, false, nil
+		}
+		return Column{}, false, errors.New("type is required, type of default expression can't be inferred")
+	}
+	t := proto.ColumnType(typ.String())
+	if nullable {
+		t = proto.ColumnTypeNullable.Sub(t)
+	}
+	return Column{Name: name, Type: t}, ok, nil
+}
+
+// typeToken returns length of next token of type: word, quoted literal or
+// balanced parenthesized group.
+func typeToken(s string) (int, error) {
+	switch s[0] {
+	case '(':
+		n, err := closing(s[1:])
+		if err != nil {
+			return 0, err
+		}
+		return n + 2, nil
+	case '\'', '"', '`':
+		n, err := quoted(s)
+		if err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	w, _ := nextWord(s)
+	if w == "" {
+		return 0, errors.Errorf("unexpected %q", s[:1])
+	}
+	return len(w), nil
+}
+
+// splitList splits comma-separated list until closing parenthesis.
+func splitList(s string) ([]string, error) {
+	end, err := closing(s)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		elems []string
+		depth int
+		last  int
+	)
+	for i := 0; i < end; i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"', '`':
+			n, err := quoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			i += n - 1
+		case ',':
+			if depth == 0 {
+				elems = append(elems, strings.TrimSpace(s[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	elems = append(elems, strings.TrimSpace(s[last:end]))
+	return elems, nil
+}
+
+// closing returns index of parenthesis that closes already opened one.
+func closing(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		case '\'', '"', '`':
+			n, err := quoted(s[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n - 1
+		}
+	}
+	return 0, errors.New("unbalanced parenthesis")
+}
+
+// quoted returns length of quoted literal at the start of s, including
+// quotes.
+func quoted(s string) (int, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			if i+1 < len(s) && s[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, errors.Errorf("unterminated %c", q)
+}
+
+// parseIdent parses optionally quoted identifier.
+func parseIdent(s string) (name, rest string, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", "", errors.New("expected identifier")
+	}
+	if q := s[0]; q == '`' || q == '"' {
+		n, err := quoted(s)
+		if err != nil {
+			return "", "", err
+		}
+		v := s[1 : n-1]
+		v = strings.ReplaceAll(v, string([]byte{q, q}), string(q))
+		v = strings.ReplaceAll(v, `\`+string(q), string(q))
+		return v, s[n:], nil
+	}
+	end := 0
+	for end < len(s) && isIdent(s[end]) {
+		end++
+	}
+	if end == 0 {
+		return "", "", errors.Errorf("unexpected %q", s[:1])
+	}
+	return s[:end], s[end:], nil
+}
+
+// nextWord returns next identifier-like word and rest of s.
+func nextWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && isIdent(s[end]) {
+		end++
+	}
+	return s[:end], s[end:]
+}
+
+func isIdent(c byte) bool {
+	return c == '_' ||
+		'a' <= c && c <= 'z' ||
+		'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9'
+}
+
+// stripComments removes SQL comments.
+func stripComments(s string) string {
+	if !strings.Contains(s, "--") && !strings.Contains(s, "/*") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' || s[i] == '"' || s[i] == '`':
+			n, err := quoted(s[i:])
+			if err != nil {
+				// Will be reported by parser.
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(s[i : i+n])
+			i += n - 1
+		case strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end - 1
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			b.WriteByte(' ')
+			i += end + 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// GoColumn is Go representation of ClickHouse column type.
+type GoColumn struct {
+	Column string // type of column, e.g. "*proto.ColArr[string]"
+	Elem   string // type of row value, e.g. "[]string"
+	New    string // expression that creates column
+
+	// Alias is set if type of column differs from ClickHouse type and
+	// column should be aliased on insert.
+	Alias bool
+	// Prepare is set if column should be prepared or inferred before
+	// encoding, so it can't be aliased.
+	Prepare bool
+	// Infer is set if column should be inferred from type, e.g. enum or
+	// timezone of DateTime.
+	Infer      bool
+	Time, UUID bool // whether time or uuid package is required
+}
+
+func simpleColumn(name, elem string) GoColumn {
+	return GoColumn{
+		Column: "*proto." + name,
+		Elem:   elem,
+		New:    "new(proto." + name + ")",
+	}
+}
+
+var simpleColumns = map[proto.ColumnType]GoColumn{
+	proto.ColumnTypeInt8:       simpleColumn("ColInt8", "int8"),
+	proto.ColumnTypeInt16:      simpleColumn("ColInt16", "int16"),
+	proto.ColumnTypeInt32:      simpleColumn("ColInt32", "int32"),
+	proto.ColumnTypeInt64:      simpleColumn("ColInt64", "int64"),
+	proto.ColumnTypeInt128:     simpleColumn("ColInt128", "proto.Int128"),
+	proto.ColumnTypeInt256:     simpleColumn("ColInt256", "proto.Int256"),
+	proto.ColumnTypeUInt8:      simpleColumn("ColUInt8", "uint8"),
+	proto.ColumnTypeUInt16:     simpleColumn("ColUInt16", "uint16"),
+	proto.ColumnTypeUInt32:     simpleColumn("ColUInt32", "uint32"),
+	proto.ColumnTypeUInt64:     simpleColumn("ColUInt64", "uint64"),
+	proto.ColumnTypeUInt128:    simpleColumn("ColUInt128", "proto.UInt128"),
+	proto.ColumnTypeUInt256:    simpleColumn("ColUInt256", "proto.UInt256"),
+	proto.ColumnTypeFloat32:    simpleColumn("ColFloat32", "float32"),
+	proto.ColumnTypeFloat64:    simpleColumn("ColFloat64", "float64"),
+	proto.ColumnTypeString:     simpleColumn("ColStr", "string"),
+	proto.ColumnTypeBool:       simpleColumn("ColBool", "bool"),
+	proto.ColumnTypeIPv4:       simpleColumn("ColIPv4", "proto.IPv4"),
+	proto.ColumnTypeIPv6:       simpleColumn("ColIPv6", "proto.IPv6"),
+	proto.ColumnTypeDecimal32:  simpleColumn("ColDecimal32", "proto.Decimal32"),
+	proto.ColumnTypeDecimal64:  simpleColumn("ColDecimal64", "proto.Decimal64"),
+	proto.ColumnTypeDecimal128: simpleColumn("ColDecimal128", "proto.Decimal128"),
+	proto.ColumnTypeDecimal256: simpleColumn("ColDecimal256", "proto.Decimal256"),
+}
+
+// decimalColumn returns fixed-width decimal type for precision.
+func decimalColumn(precision int) proto.ColumnType {
+	switch {
+	case precision <= 9:
+		return proto.ColumnTypeDecimal32
+	case precision <= 18:
+		return proto.ColumnTypeDecimal64
+	case precision <= 38:
+		return proto.ColumnTypeDecimal128
+	default:
+		return proto.ColumnTypeDecimal256
+	}
+}
+
+// NewGoColumn returns Go representation of ClickHouse type.
+func NewGoColumn(t proto.Type) (GoColumn, error) {
+	switch t.Name {
+	case proto.ColumnTypeDate, proto.ColumnTypeDate32:
+		c := simpleColumn("Col"+string(t.Name), "time.Time")
+		c.Time = true
+		return c, nil
+	case proto.ColumnTypeDateTime:
+		c := simpleColumn("ColDateTime", "time.Time")
+		c.Time = true
+		if err := checkTimezone(&c, t); err != nil {
+			return GoColumn{}, err
+		}
+		return c, nil
+	case proto.ColumnTypeDateTime64:
+		c := GoColumn{
+			Column: "*proto.ColDateTime64",
+			Elem:   "time.Time",
+			New:    fmt.Sprintf("new(proto.ColDateTime64).WithPrecision(%d)", t.Precision),
+			Time:   true,
+		}
+		if err := checkTimezone(&c, t); err != nil {
+			return GoColumn{}, err
+		}
+		return c, nil
+	case proto.ColumnTypeUUID:
+		c := simpleColumn("ColUUID", "uuid.UUID")
+		c.UUID = true
+		return c, nil
+	case proto.ColumnTypeFixedString:
+		return GoColumn{
+			Column: "*proto.ColFixedStr",
+			Elem:   "[]byte",
+			New:    fmt.Sprintf("&proto.ColFixedStr{Size: %d}", t.Size),
+		}, nil
+	case proto.ColumnTypeEnum8, proto.ColumnTypeEnum16:
+		c := simpleColumn("ColEnum", "string")
+		c.Prepare = true
+		c.Infer = true
+		return c, nil
+	case proto.ColumnTypeDecimal:
+		c := simpleColumns[decimalColumn(t.Precision)]
+		c.Alias = true
+		return c, nil
+	case proto.ColumnTypeDecimal32, proto.ColumnTypeDecimal64,
+		proto.ColumnTypeDecimal128, proto.ColumnTypeDecimal256:
+		c := simpleColumns[t.Name]
+		c.Alias = true // scale is not part of column type
+		return c, nil
+	case proto.ColumnTypeLowCardinality:
+		if t.Elems[0].Name == proto.ColumnTypeNullable {
+			return GoColumn{}, errors.Errorf("type %q is not supported", t.String())
+		}
+		elem, err := NewGoColumn(t.Elems[0])
+		if err != nil {
+			return GoColumn{}, err
+		}
+		if elem.Infer {
+			// LowCardinality does not propagate Infer.
+			return GoColumn{}, errors.Errorf("type %q is not supported", t.String())
+		}
+		c := wrapColumn(elem, "ColLowCardinality", "NewLowCardinality", elem.Elem)
+		c.Prepare = true
+		return c, nil
+	case proto.ColumnTypeArray:
+		elem, err := NewGoColumn(t.Elems[0])
+		if err != nil {
+			return GoColumn{}, err
+		}
+		return wrapColumn(elem, "ColArr", "NewArray", "[]"+elem.Elem), nil
+	case proto.ColumnTypeNullable:
+		elem, err := NewGoColumn(t.Elems[0])
+		if err != nil {
+			return GoColumn{}, err
+		}
+		if elem.Prepare {
+			// Nullable does not propagate Prepare and Infer.
+			return GoColumn{}, errors.Errorf("type %q is not supported", t.String())
+		}
+		return wrapColumn(elem, "ColNullable", "NewColNullable", "proto.Nullable["+elem.Elem+"]"), nil
+	case proto.ColumnTypeMap:
+		k, err := NewGoColumn(t.Elems[0])
+		if err != nil {
+			return GoColumn{}, errors.Wrap(err, "key")
+		}
+		v, err := NewGoColumn(t.Elems[1])
+		if err != nil {
+			return GoColumn{}, errors.Wrap(err, "value")
+		}
+		params := k.Elem + ", " + v.Elem
+		return GoColumn{
+			Column:  "*proto.ColMap[" + params + "]",
+			Elem:    "map[" + k.Elem + "]" + v.Elem,
+			New:     "proto.NewMap[" + params + "](" + k.New + ", " + v.New + ")",
+			Alias:   k.Alias || v.Alias,
+			Prepare: k.Prepare || v.Prepare,
+			Infer:   k.Infer || v.Infer,
+			Time:    k.Time || v.Time,
+			UUID:    k.UUID || v.UUID,
+		}, nil
+	}
+	if c, ok := simpleColumns[t.Name]; ok && len(t.Params) == 0 {
+		return c, nil
+	}
+	return GoColumn{}, errors.Errorf("type %q is not supported", t.String())
+}
+
+// checkTimezone checks timezone of DateTime or DateTime64 type, setting
+// Infer if it is set, because location can't be set by expression.
+func checkTimezone(c *GoColumn, t proto.Type) error {
+	if t.Timezone == "" {
+		// Timezone is inferred from server.
+		return nil
+	}
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return errors.Wrapf(err, "type %q", t.String())
+	}
+	c.Infer = true
+	return nil
+}
+
+// wrapColumn wraps elem into generic column.
+func wrapColumn(elem GoColumn, col, constructor, rowType string) GoColumn {
+	elem.Column = "*proto." + col + "[" + elem.Elem + "]"
+	elem.New = "proto." + constructor + "[" + elem.Elem + "](" + elem.New + ")"
+	elem.Elem = rowType
+	return elem
+}
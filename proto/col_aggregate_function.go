@@ -0,0 +1,342 @@
+package proto
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColAggregateFunction.
+var (
+	_ ColInput         = (*ColAggregateFunction)(nil)
+	_ ColResult        = (*ColAggregateFunction)(nil)
+	_ Column           = (*ColAggregateFunction)(nil)
+	_ ColumnOf[[]byte] = (*ColAggregateFunction)(nil)
+	_ Inferable        = (*ColAggregateFunction)(nil)
+)
+
+// AggregateFunction is signature of aggregate function in
+// AggregateFunction(name(params), args...) type.
+type AggregateFunction struct {
+	Name    string
+	Params  []string     // e.g. levels of quantiles
+	Args    []ColumnType // argument types
+	Version int          // state serialization version, 0 if not set
+}
+
+// ParseAggregateFunction parses signature of AggregateFunction type.
+func ParseAggregateFunction(t ColumnType) (AggregateFunction, error) {
+	var f AggregateFunction
+	typ, err := ParseType(t)
+	if err != nil {
+		return f, err
+	}
+	if typ.Name != ColumnTypeAggregateFunction {
+		return f, errors.Errorf("%s is not aggregate function", t)
+	}
+	params := typ.Params
+	if len(params) > 0 && params[0].Kind == TypeParamNumber {
+		// Optional version is first parameter.
+		v, err := strconv.Atoi(params[0].Value)
+		if err != nil {
+			return f, errors.Wrap(err, "version")
+		}
+		f.Version = v
+		params = params[1:]
+	}
+	if len(params) == 0 {
+		return f, errors.New("function name is missing")
+	}
+	fn := params[0]
+	if fn.Kind != TypeParamType || fn.Name != "" {
+		return f, errors.Errorf("invalid function %s", fn)
+	}
+	f.Name = string(fn.Type.Name)
+	for _, p := range fn.Type.Params {
+		f.Params = append(f.Params, p.String())
+	}
+	for _, p := range params[1:] {
+		if p.Kind != TypeParamType {
+			return f, errors.Errorf("invalid argument %s", p)
+		}
+		f.Args = append(f.Args, p.Type.ColumnType())
+	}
+	return f, nil
+}
+
+// Type returns AggregateFunction type of signature.
+func (f AggregateFunction) Type() ColumnType {
+	var params []string
+	if f.Version != 0 {
+		params = append(params, strconv.Itoa(f.Version))
+	}
+	fn := f.Name
+	if len(f.Params) > 0 {
+		fn += "(" + strings.Join(f.Params, ", ") + ")"
+	}
+	params = append(params, fn)
+	for _, a := range f.Args {
+		params = append(params, a.String())
+	}
+	return ColumnTypeAggregateFunction.With(params...)
+}
+
+// NewState returns new empty typed state of function.
+//
+// Supported functions are count, sum, avg, min, max, uniq, uniqExact,
+// quantileTDigest and quantilesTDigest, including weighted variants.
+func (f AggregateFunction) NewState() (AggregateState, error) {
+	var arg ColumnType
+	if len(f.Args) > 0 {
+		arg = f.Args[0]
+	}
+	switch f.Name {
+	case "count":
+		return new(CountState), nil
+	case "sum":
+		switch aggregateArgKind(arg) {
+		case 'u':
+			return new(SumState[uint64]), nil
+		case 'i':
+			return new(SumState[int64]), nil
+		case 'f':
+			return new(SumState[float64]), nil
+		}
+	case "avg":
+		switch aggregateArgKind(arg) {
+		case 'u':
+			return new(AvgState[uint64]), nil
+		case 'i':
+			return new(AvgState[int64]), nil
+		case 'f':
+			return new(AvgState[float64]), nil
+		}
+	case "min":
+		if newState, ok := minStates[arg.Base()]; ok {
+			return newState(), nil
+		}
+	case "max":
+		if newState, ok := maxStates[arg.Base()]; ok {
+			return newState(), nil
+		}
+	case "uniq":
+		return new(UniqState), nil
+	case "uniqExact":
+		if len(f.Args) != 1 {
+			break
+		}
+		switch arg.Base() {
+		case ColumnTypeInt8, ColumnTypeUInt8, ColumnTypeBool:
+			return new(UniqExactState[uint8]), nil
+		case ColumnTypeInt16, ColumnTypeUInt16, ColumnTypeDate:
+			return new(UniqExactState[uint16]), nil
+		case ColumnTypeInt32, ColumnTypeUInt32, ColumnTypeFloat32, ColumnTypeDateTime, ColumnTypeDate32, ColumnTypeIPv4:
+			return new(UniqExactState[uint32]), nil
+		case ColumnTypeInt64, ColumnTypeUInt64, ColumnTypeFloat64, ColumnTypeDateTime64:
+			return new(UniqExactState[uint64]), nil
+		case ColumnTypeString, ColumnTypeFixedString, ColumnTypeInt128, ColumnTypeUInt128, ColumnTypeUUID, ColumnTypeIPv6:
+			return new(UniqExactState[UInt128]), nil
+		}
+	case "quantileTDigest", "quantilesTDigest", "quantileTDigestWeighted", "quantilesTDigestWeighted":
+		return new(TDigestState), nil
+	}
+	return nil, errors.Errorf("unsupported aggregate function %s", f.Type().Elem())
+}
+
+// aggregateArgKind returns 'u' for unsigned, 'i' for signed and 'f' for
+// float argument type, or zero for other types.
+func aggregateArgKind(t ColumnType) byte {
+	switch t {
+	case ColumnTypeUInt8, ColumnTypeUInt16, ColumnTypeUInt32, ColumnTypeUInt64:
+		return 'u'
+	case ColumnTypeInt8, ColumnTypeInt16, ColumnTypeInt32, ColumnTypeInt64:
+		return 'i'
+	case ColumnTypeFloat32, ColumnTypeFloat64:
+		return 'f'
+	default:
+		return 0
+	}
+}
+
+// minMaxStates are constructors of min or max states by argument type.
+type minMaxStates map[ColumnType]func() AggregateState
+
+var (
+	minStates = minMaxStates{
+		ColumnTypeInt8:       func() AggregateState { return new(MinState[int8]) },
+		ColumnTypeInt16:      func() AggregateState { return new(MinState[int16]) },
+		ColumnTypeInt32:      func() AggregateState { return new(MinState[int32]) },
+		ColumnTypeInt64:      func() AggregateState { return new(MinState[int64]) },
+		ColumnTypeUInt8:      func() AggregateState { return new(MinState[uint8]) },
+		ColumnTypeUInt16:     func() AggregateState { return new(MinState[uint16]) },
+		ColumnTypeUInt32:     func() AggregateState { return new(MinState[uint32]) },
+		ColumnTypeUInt64:     func() AggregateState { return new(MinState[uint64]) },
+		ColumnTypeFloat32:    func() AggregateState { return new(MinState[float32]) },
+		ColumnTypeFloat64:    func() AggregateState { return new(MinState[float64]) },
+		ColumnTypeDate:       func() AggregateState { return new(MinState[uint16]) },
+		ColumnTypeDate32:     func() AggregateState { return new(MinState[int32]) },
+		ColumnTypeDateTime:   func() AggregateState { return new(MinState[uint32]) },
+		ColumnTypeDateTime64: func() AggregateState { return new(MinState[int64]) },
+		ColumnTypeString:     func() AggregateState { return new(MinState[string]) },
+	}
+	maxStates = minMaxStates{
+		ColumnTypeInt8:       func() AggregateState { return new(MaxState[int8]) },
+		ColumnTypeInt16:      func() AggregateState { return new(MaxState[int16]) },
+		ColumnTypeInt32:      func() AggregateState { return new(MaxState[int32]) },
+		ColumnTypeInt64:      func() AggregateState { return new(MaxState[int64]) },
+		ColumnTypeUInt8:      func() AggregateState { return new(MaxState[uint8]) },
+		ColumnTypeUInt16:     func() AggregateState { return new(MaxState[uint16]) },
+		ColumnTypeUInt32:     func() AggregateState { return new(MaxState[uint32]) },
+		ColumnTypeUInt64:     func() AggregateState { return new(MaxState[uint64]) },
+		ColumnTypeFloat32:    func() AggregateState { return new(MaxState[float32]) },
+		ColumnTypeFloat64:    func() AggregateState { return new(MaxState[float64]) },
+		ColumnTypeDate:       func() AggregateState { return new(MaxState[uint16]) },
+		ColumnTypeDate32:     func() AggregateState { return new(MaxState[int32]) },
+		ColumnTypeDateTime:   func() AggregateState { return new(MaxState[uint32]) },
+		ColumnTypeDateTime64: func() AggregateState { return new(MaxState[int64]) },
+		ColumnTypeString:     func() AggregateState { return new(MaxState[string]) },
+	}
+)
+
+// ColAggregateFunction is AggregateFunction(f, T...) column, which contains
+// intermediate states of aggregate function f, e.g. in AggregatingMergeTree.
+//
+// States are kept as opaque bytes in ClickHouse binary format, so they can
+// be copied between tables with the same function signature. States are
+// not prefixed by size in Native format, so function must be supported by
+// AggregateFunction.NewState to decode column.
+type ColAggregateFunction struct {
+	Function AggregateFunction
+
+	Buf []byte
+	Pos []Position
+
+	state AggregateState // for decoding
+}
+
+// NewAggregateFunction returns new AggregateFunction column of function f.
+func NewAggregateFunction(f AggregateFunction) *ColAggregateFunction {
+	return &ColAggregateFunction{Function: f}
+}
+
+// Type returns AggregateFunction(f, T...).
+func (c ColAggregateFunction) Type() ColumnType {
+	return c.Function.Type()
+}
+
+// Rows returns count of states.
+func (c ColAggregateFunction) Rows() int {
+	return len(c.Pos)
+}
+
+// Row returns encoded state of i-th row.
+func (c ColAggregateFunction) Row(i int) []byte {
+	p := c.Pos[i]
+	return c.Buf[p.Start:p.End]
+}
+
+// Append encoded state.
+//
+// State is not validated.
+func (c *ColAggregateFunction) Append(v []byte) {
+	start := len(c.Buf)
+	c.Buf = append(c.Buf, v...)
+	c.Pos = append(c.Pos, Position{Start: start, End: len(c.Buf)})
+}
+
+// AppendArr appends slice of encoded states.
+func (c *ColAggregateFunction) AppendArr(v [][]byte) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// AppendState appends typed state.
+func (c *ColAggregateFunction) AppendState(s AggregateState) {
+	start := len(c.Buf)
+	b := Buffer{Buf: c.Buf}
+	s.EncodeAggregateState(&b)
+	c.Buf = b.Buf
+	c.Pos = append(c.Pos, Position{Start: start, End: len(c.Buf)})
+}
+
+// State returns typed state of i-th row, see AggregateFunction.NewState.
+func (c ColAggregateFunction) State(i int) (AggregateState, error) {
+	s, err := c.Function.NewState()
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(bytes.NewReader(c.Row(i)))
+	if err := s.DecodeAggregateState(r); err != nil {
+		return nil, errors.Wrapf(err, "[%d]", i)
+	}
+	return s, nil
+}
+
+// Array is helper that creates Array(AggregateFunction(f, T...)).
+func (c *ColAggregateFunction) Array() *ColArr[[]byte] {
+	return &ColArr[[]byte]{
+		Data: c,
+	}
+}
+
+// Infer implements Inferable.
+func (c *ColAggregateFunction) Infer(t ColumnType) error {
+	f, err := ParseAggregateFunction(t)
+	if err != nil {
+		return errors.Wrap(err, "parse")
+	}
+	// Checking that states can be decoded.
+	s, err := f.NewState()
+	if err != nil {
+		return err
+	}
+	c.Function = f
+	c.state = s
+	return nil
+}
+
+// DecodeColumn implements ColResult.
+//
+// States are decoded and encoded back to find their boundaries.
+func (c *ColAggregateFunction) DecodeColumn(r *Reader, rows int) error {
+	if c.state == nil {
+		s, err := c.Function.NewState()
+		if err != nil {
+			return err
+		}
+		c.state = s
+	}
+	b := Buffer{Buf: c.Buf}
+	for i := 0; i < rows; i++ {
+		if err := c.state.DecodeAggregateState(r); err != nil {
+			c.Buf = b.Buf
+			return errors.Wrapf(err, "[%d]", i)
+		}
+		start := len(b.Buf)
+		c.state.EncodeAggregateState(&b)
+		c.Pos = append(c.Pos, Position{Start: start, End: len(b.Buf)})
+	}
+	c.Buf = b.Buf
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColAggregateFunction) Reset() {
+	c.Buf = c.Buf[:0]
+	c.Pos = c.Pos[:0]
+}
+
+// EncodeColumn implements ColInput.
+func (c ColAggregateFunction) EncodeColumn(b *Buffer) {
+	for i := range c.Pos {
+		b.PutRaw(c.Row(i))
+	}
+}
+
+// WriteColumn implements ColInput.
+func (c ColAggregateFunction) WriteColumn(w *Writer) {
+	w.ChainWrite(c.Buf)
+}
@@ -0,0 +1,119 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestParseAggregateFunction(t *testing.T) {
+	for _, tt := range []struct {
+		Type     ColumnType
+		Function AggregateFunction
+	}{
+		{
+			Type:     "AggregateFunction(count)",
+			Function: AggregateFunction{Name: "count"},
+		},
+		{
+			Type:     "AggregateFunction(sum, UInt64)",
+			Function: AggregateFunction{Name: "sum", Args: []ColumnType{"UInt64"}},
+		},
+		{
+			Type: "AggregateFunction(quantiles(0.5, 0.9), Float64)",
+			Function: AggregateFunction{
+				Name:   "quantiles",
+				Params: []string{"0.5", "0.9"},
+				Args:   []ColumnType{"Float64"},
+			},
+		},
+		{
+			Type: "AggregateFunction(1, argMax, String, DateTime('UTC'))",
+			Function: AggregateFunction{
+				Name:    "argMax",
+				Args:    []ColumnType{"String", "DateTime('UTC')"},
+				Version: 1,
+			},
+		},
+	} {
+		t.Run(tt.Type.String(), func(t *testing.T) {
+			f, err := ParseAggregateFunction(tt.Type)
+			require.NoError(t, err)
+			require.Equal(t, tt.Function, f)
+			require.Equal(t, tt.Type, f.Type())
+		})
+	}
+	for _, v := range []ColumnType{
+		"UInt64",
+		"AggregateFunction",
+		"AggregateFunction(1)",
+	} {
+		_, err := ParseAggregateFunction(v)
+		require.Error(t, err, v)
+	}
+}
+
+func TestColAggregateFunction(t *testing.T) {
+	t.Parallel()
+	const rows = 50
+	const typ ColumnType = "AggregateFunction(max, String)"
+	f, err := ParseAggregateFunction(typ)
+	require.NoError(t, err)
+	data := NewAggregateFunction(f)
+	for i := 0; i < rows; i++ {
+		s := new(MaxState[string])
+		if i%5 != 0 {
+			s.Add("foo")
+			s.Add(string(rune('a' + i%26)))
+		}
+		data.AppendState(s)
+	}
+	require.Equal(t, typ, data.Type())
+	require.Equal(t, rows, data.Rows())
+
+	var buf Buffer
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_aggregate_function_max_str")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer(typ))
+		require.NoError(t, dec.Data.DecodeColumn(r, rows))
+		require.Equal(t, data.Buf, dec.Data.(*ColAggregateFunction).Buf)
+		requireEqual[[]byte](t, data, dec.Data.(*ColAggregateFunction))
+
+		s, err := dec.Data.(*ColAggregateFunction).State(1)
+		require.NoError(t, err)
+		require.Equal(t, &MaxState[string]{Value: "foo", Has: true}, s)
+		s, err = dec.Data.(*ColAggregateFunction).State(0)
+		require.NoError(t, err)
+		require.Equal(t, &MaxState[string]{}, s)
+
+		dec.Data.Reset()
+		require.Equal(t, 0, dec.Data.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		dec := NewAggregateFunction(f)
+		require.ErrorIs(t, dec.DecodeColumn(r, rows), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		dec := NewAggregateFunction(f)
+		requireNoShortRead(t, buf.Buf, colAware(dec, rows))
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+	t.Run("Unsupported", func(t *testing.T) {
+		var dec ColAggregateFunction
+		require.Error(t, dec.Infer("AggregateFunction(groupArray, String)"))
+		require.Error(t, dec.Infer("AggregateFunction(sum, String)"))
+		dec.Function = AggregateFunction{Name: "groupArray"}
+		require.Error(t, dec.DecodeColumn(buf.Reader(), 1))
+	})
+}
@@ -2,7 +2,6 @@ package proto
 
 import (
 	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/go-faster/errors"
@@ -21,151 +20,177 @@ func (c *ColAuto) Infer(t ColumnType) error {
 		c.DataType = t // update subtype if needed
 		return nil
 	}
-	if v := inferGenerated(t); v != nil {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "type")
+	}
+	if err := c.infer(t, typ); err != nil {
+		return err
+	}
+	c.DataType = t
+	return nil
+}
+
+// infer initializes Data from parsed type t.
+func (c *ColAuto) infer(raw ColumnType, t Type) error {
+	if v := inferGenerated(t.ColumnType()); v != nil {
 		c.Data = v
-		c.DataType = t
 		return nil
 	}
-	if strings.HasPrefix(t.String(), ColumnTypeInterval.String()) {
+	if strings.HasPrefix(t.Name.String(), ColumnTypeInterval.String()) {
 		v := new(ColInterval)
-		if err := v.Infer(t); err != nil {
+		if err := v.Infer(raw); err != nil {
 			return errors.Wrap(err, "interval")
 		}
 		c.Data = v
-		c.DataType = t
 		return nil
 	}
-	switch t {
+	switch t.Name {
 	case ColumnTypeNothing:
 		c.Data = new(ColNothing)
 	case ColumnTypeString:
 		c.Data = new(ColStr)
 	case ColumnTypeBool:
 		c.Data = new(ColBool)
-	case ColumnTypeDateTime:
-		c.Data = new(ColDateTime)
 	case ColumnTypeDate:
 		c.Data = new(ColDate)
-	case "Map(String, String)":
-		c.Data = NewMap[string, string](new(ColStr), new(ColStr))
 	case ColumnTypeUUID:
 		c.Data = new(ColUUID)
-	default:
-		switch t.Base() {
-		case ColumnTypeArray:
-			inner := new(ColAuto)
-			if err := inner.Infer(t.Elem()); err != nil {
-				return errors.Wrap(err, "array")
-			}
-			innerValue := reflect.ValueOf(inner.Data)
-			arrayMethod := innerValue.MethodByName("Array")
-			if arrayMethod.IsValid() && arrayMethod.Type().NumOut() == 1 {
-				if col, ok := arrayMethod.Call(nil)[0].Interface().(Column); ok {
-					c.Data = col
-					c.DataType = t
-					return nil
-				}
-			}
-		case ColumnTypeNullable:
-			inner := new(ColAuto)
-			if err := inner.Infer(t.Elem()); err != nil {
-				return errors.Wrap(err, "nullable")
-			}
-			innerValue := reflect.ValueOf(inner.Data)
-			nullableMethod := innerValue.MethodByName("Nullable")
-			if nullableMethod.IsValid() && nullableMethod.Type().NumOut() == 1 {
-				if col, ok := nullableMethod.Call(nil)[0].Interface().(Column); ok {
-					c.Data = col
-					c.DataType = t
-					return nil
-				}
+	case ColumnTypePoint:
+		c.Data = new(ColPoint)
+	case ColumnTypeRing:
+		c.Data = new(ColRing)
+	case ColumnTypePolygon:
+		c.Data = new(ColPolygon)
+	case ColumnTypeMultiPolygon:
+		c.Data = new(ColMultiPolygon)
+	case ColumnTypeLineString:
+		c.Data = new(ColLineString)
+	case ColumnTypeMultiLineString:
+		c.Data = new(ColMultiLineString)
+	case ColumnTypeArray, ColumnTypeNullable, ColumnTypeLowCardinality:
+		if len(t.Elems) != 1 {
+			return errors.Errorf("%s: got %d types, expected 1", t.Name, len(t.Elems))
+		}
+		elem := t.Elems[0]
+		if t.Name == ColumnTypeLowCardinality && elem.Name == ColumnTypeNullable {
+			// Dictionary of LowCardinality(Nullable(T)) is encoded as T.
+			if len(elem.Elems) != 1 {
+				return errors.Errorf("%s: got %d types, expected 1", elem.Name, len(elem.Elems))
 			}
-		case ColumnTypeLowCardinality:
 			inner := new(ColAuto)
-			if err := inner.Infer(t.Elem()); err != nil {
-				return errors.Wrap(err, "low cardinality")
-			}
-			innerValue := reflect.ValueOf(inner.Data)
-			lowCardinalityMethod := innerValue.MethodByName("LowCardinality")
-			if lowCardinalityMethod.IsValid() && lowCardinalityMethod.Type().NumOut() == 1 {
-				if col, ok := lowCardinalityMethod.Call(nil)[0].Interface().(Column); ok {
-					c.Data = col
-					c.DataType = t
-					return nil
-				}
-			}
-		case ColumnTypeDateTime:
-			v := new(ColDateTime)
-			if err := v.Infer(t); err != nil {
-				return errors.Wrap(err, "datetime")
+			if err := inner.infer(elem.Elems[0].ColumnType(), elem.Elems[0]); err != nil {
+				return errors.Wrapf(err, "%s", t.Name)
 			}
-			c.Data = v
-			c.DataType = t
+			c.Data = NewLowCardinality[any](colNullableAny{colAny{Column: inner.Data, key: true}})
 			return nil
-		case ColumnTypeDecimal:
-			var prec int
-			precStr, _, _ := strings.Cut(string(t.Elem()), ",")
-			if precStr != "" {
-				var err error
-				precStr = strings.TrimSpace(precStr)
-				prec, err = strconv.Atoi(precStr)
-				if err != nil {
-					return errors.Wrap(err, "decimal")
-				}
-			} else {
-				prec = 10
-			}
-			switch {
-			case prec >= 1 && prec < 10:
-				c.Data = new(ColDecimal32)
-			case prec >= 10 && prec < 19:
-				c.Data = new(ColDecimal64)
-			case prec >= 19 && prec < 39:
-				c.Data = new(ColDecimal128)
-			case prec >= 39 && prec < 77:
-				c.Data = new(ColDecimal256)
-			default:
-				return errors.Errorf("decimal precision %d out of range", prec)
+		}
+		inner := new(ColAuto)
+		if err := inner.infer(elem.ColumnType(), elem); err != nil {
+			return errors.Wrapf(err, "%s", t.Name)
+		}
+		// Calling Array, Nullable or LowCardinality method of inner column.
+		innerValue := reflect.ValueOf(inner.Data)
+		method := innerValue.MethodByName(t.Name.String())
+		if method.IsValid() && method.Type().NumOut() == 1 {
+			if col, ok := method.Call(nil)[0].Interface().(Column); ok {
+				c.Data = col
+				return nil
 			}
-			c.DataType = t
-			return nil
+		}
+		// Columns without such method, e.g. inferred tuples, maps or enums.
+		switch t.Name {
+		case ColumnTypeArray:
+			c.Data = &ColArr[any]{Data: colAny{Column: inner.Data}}
+		case ColumnTypeNullable:
+			c.Data = NewColNullable[any](colAny{Column: inner.Data})
+		default:
+			c.Data = NewLowCardinality[any](colAny{Column: inner.Data, key: true})
+		}
+	case ColumnTypeDecimal, ColumnTypeDecimal32, ColumnTypeDecimal64, ColumnTypeDecimal128, ColumnTypeDecimal256:
+		switch t.decimalWidth() {
 		case ColumnTypeDecimal32:
 			c.Data = new(ColDecimal32)
-			c.DataType = t
-			return nil
 		case ColumnTypeDecimal64:
 			c.Data = new(ColDecimal64)
-			c.DataType = t
-			return nil
 		case ColumnTypeDecimal128:
 			c.Data = new(ColDecimal128)
-			c.DataType = t
-			return nil
-		case ColumnTypeDecimal256:
+		default:
 			c.Data = new(ColDecimal256)
-			c.DataType = t
+		}
+	case ColumnTypeFixedString:
+		v := new(ColFixedStr)
+		v.SetSize(t.Size)
+		c.Data = v
+	case ColumnTypeMap:
+		if len(t.Elems) != 2 {
+			return errors.Errorf("map: got %d types, expected 2", len(t.Elems))
+		}
+		if t.Elems[0].Name == ColumnTypeString && t.Elems[1].Name == ColumnTypeString {
+			c.Data = NewMap[string, string](new(ColStr), new(ColStr))
 			return nil
-		case ColumnTypeEnum8, ColumnTypeEnum16:
-			v := new(ColEnum)
-			if err := v.Infer(t); err != nil {
-				return errors.Wrap(err, "enum")
+		}
+		keys, values := new(ColAuto), new(ColAuto)
+		if err := keys.infer(t.Elems[0].ColumnType(), t.Elems[0]); err != nil {
+			return errors.Wrap(err, "map keys")
+		}
+		if err := values.infer(t.Elems[1].ColumnType(), t.Elems[1]); err != nil {
+			return errors.Wrap(err, "map values")
+		}
+		c.Data = NewMap[any, any](
+			colAny{Column: keys.Data, key: true},
+			colAny{Column: values.Data},
+		)
+	case ColumnTypeTuple:
+		if len(t.Elems) == 0 {
+			return errors.New("tuple: no elements")
+		}
+		var v ColTuple
+		for i, e := range t.Elems {
+			col := new(ColAuto)
+			if err := col.infer(e.ColumnType(), e); err != nil {
+				return errors.Wrapf(err, "tuple [%d]", i)
 			}
-			c.Data = v
-			c.DataType = t
-			return nil
-		case ColumnTypeDateTime64:
-			v := new(ColDateTime64)
-			if err := v.Infer(t); err != nil {
-				return errors.Wrap(err, "datetime64")
+			if t.Names == nil || t.Names[i] == "" {
+				v = append(v, col.Data)
+				continue
 			}
-			c.Data = v
-			c.DataType = t
-			return nil
+			v = append(v, colNamedAny{Column: col.Data, name: t.Names[i]})
+		}
+		c.Data = v
+	default:
+		// Columns that infer parameters themselves.
+		var v interface {
+			Column
+			Inferable
+		}
+		switch t.Name {
+		case ColumnTypeDateTime:
+			v = new(ColDateTime)
+		case ColumnTypeDateTime64:
+			v = new(ColDateTime64)
+		case ColumnTypeEnum8, ColumnTypeEnum16:
+			v = new(ColEnum)
+		case ColumnTypeVariant:
+			v = new(ColVariant)
+		case ColumnTypeNested:
+			v = new(ColNested)
+		case ColumnTypeJSON:
+			v = new(ColJSON)
+		case ColumnTypeDynamic:
+			v = NewDynamic()
+		case ColumnTypeAggregateFunction:
+			v = new(ColAggregateFunction)
+		case ColumnTypeSimpleAggregateFunction:
+			v = new(ColSimpleAggregateFunction)
+		default:
+			return errors.Errorf("automatic column inference not supported for %q", raw)
+		}
+		if err := v.Infer(raw); err != nil {
+			return errors.Wrapf(err, "%s", t.Name)
 		}
-		return errors.Errorf("automatic column inference not supported for %q", t)
+		c.Data = v
 	}
-
-	c.DataType = t
 	return nil
 }
 
@@ -174,6 +199,107 @@ var (
 	_ Inferable = &ColAuto{}
 )
 
+// colAny is ColumnOf[any] adapter of inferred column, which is used to
+// construct generic columns like ColMap for arbitrary types.
+type colAny struct {
+	Column
+
+	// key reports whether rows are used as map keys, so []byte rows,
+	// e.g. of FixedString, are represented as string.
+	key bool
+}
+
+var _ ColumnOf[any] = colAny{}
+
+func (c colAny) Row(i int) any {
+	v := columnRow(c.Column, i)
+	if b, ok := v.([]byte); ok && c.key {
+		return string(b)
+	}
+	return v
+}
+
+func (c colAny) Append(v any) {
+	if s, ok := v.(string); ok && c.key {
+		if col, ok := c.Column.(ColumnOf[[]byte]); ok {
+			col.Append([]byte(s))
+			return
+		}
+	}
+	columnAppend(c.Column, v)
+}
+
+func (c colAny) AppendArr(v []any) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+func (c colAny) Infer(t ColumnType) error {
+	if v, ok := c.Column.(Inferable); ok {
+		return v.Infer(t)
+	}
+	return nil
+}
+
+func (c colAny) Prepare() error {
+	if v, ok := c.Column.(Preparable); ok {
+		return v.Prepare()
+	}
+	return nil
+}
+
+func (c colAny) DecodeState(r *Reader) error {
+	if v, ok := c.Column.(StateDecoder); ok {
+		return v.DecodeState(r)
+	}
+	return nil
+}
+
+func (c colAny) EncodeState(b *Buffer) {
+	if v, ok := c.Column.(StateEncoder); ok {
+		v.EncodeState(b)
+	}
+}
+
+// colNullableAny is ColumnOf[any] adapter of dictionary of
+// LowCardinality(Nullable(T)), which is encoded as T with NULL as first
+// key. Rows are Nullable[any].
+type colNullableAny struct {
+	colAny
+}
+
+var _ lowCardinalityNull[any] = colNullableAny{}
+
+func (c colNullableAny) Type() ColumnType {
+	return ColumnTypeNullable.Sub(c.Column.Type())
+}
+
+func (c colNullableAny) Row(i int) any {
+	return NewNullable(c.colAny.Row(i))
+}
+
+func (c colNullableAny) Append(v any) {
+	if n, ok := v.(Nullable[any]); ok {
+		if !n.Set {
+			columnAppendZero(c.Column)
+			return
+		}
+		v = n.Value
+	}
+	c.colAny.Append(v)
+}
+
+func (c colNullableAny) AppendArr(v []any) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+func (c colNullableAny) null() any {
+	return Null[any]()
+}
+
 func (c ColAuto) Type() ColumnType {
 	return c.DataType
 }
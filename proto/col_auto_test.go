@@ -59,6 +59,42 @@ func TestColAuto_Infer(t *testing.T) {
 		"Decimal256(4)",
 		"Array(Nullable(Int8))",
 		"Nullable(DateTime64(3))",
+		"Variant(String, UInt64)",
+		"Variant(Array(UInt8), Map(String, String), String)",
+		ColumnTypeDynamic,
+		"Dynamic(max_types=10)",
+		ColumnTypeJSON,
+		"JSON(a.b UInt32, SKIP c)",
+		"Nested(a String, b Array(Int64))",
+		ColumnTypePoint,
+		ColumnTypeRing,
+		ColumnTypePolygon,
+		ColumnTypeMultiPolygon,
+		ColumnTypeLineString,
+		ColumnTypeMultiLineString,
+		"Array(Point)",
+		"Array(MultiPolygon)",
+		"AggregateFunction(sum, UInt64)",
+		"AggregateFunction(quantilesTDigest(0.5, 0.9), Float64)",
+		"AggregateFunction(uniqExact, String)",
+		"SimpleAggregateFunction(max, UInt64)",
+		"FixedString(3)",
+		"Map(LowCardinality(String), Array(UInt64))",
+		"Map(UInt8, Tuple(String, Int64))",
+		"Map(FixedString(2), Map(String, Nullable(Int32)))",
+		"Tuple(UInt8, String)",
+		"Tuple(a Int32, b Nullable(String))",
+		"Tuple(`a b` DateTime64(3, 'UTC'), c Map(String, Array(Tuple(UInt8, UInt8))))",
+		"Array(Tuple(String, FixedString(10)))",
+		"SimpleAggregateFunction(groupUniqArrayArray, Array(String))",
+		"Enum8('a, (b)' = 1, 'c\\'' = 2)",
+		"DateTime64(3, 'Europe/Moscow')",
+		"Tuple(a Enum8('x,y' = 1), b DateTime('UTC'))",
+		"LowCardinality(Nullable(String))",
+		"LowCardinality(FixedString(4))",
+		"Nullable(Enum8('a' = 1))",
+		"Array(Nullable(Enum8('a' = 1, 'b' = 2)))",
+		"Map(String, LowCardinality(Nullable(String)))",
 	} {
 		r := AutoResult("foo")
 		require.NoError(t, r.Data.(Inferable).Infer(columnType))
@@ -67,3 +103,116 @@ func TestColAuto_Infer(t *testing.T) {
 		require.Equal(t, 0, r.Data.Rows())
 	}
 }
+
+func TestColAuto_InferAlias(t *testing.T) {
+	for _, tt := range []struct {
+		Type ColumnType
+		Data ColumnType
+	}{
+		{Type: "BIGINT", Data: ColumnTypeInt64},
+		{Type: "INT UNSIGNED", Data: ColumnTypeUInt32},
+		{Type: "Array(VARCHAR(255))", Data: "Array(String)"},
+		{Type: "Nullable(DOUBLE)", Data: "Nullable(Float64)"},
+		{Type: "DECIMAL(18, 4)", Data: ColumnTypeDecimal64},
+	} {
+		r := AutoResult("foo")
+		require.NoError(t, r.Data.(Inferable).Infer(tt.Type))
+		require.Equal(t, tt.Type, r.Data.Type())
+		require.Equal(t, tt.Data, r.Data.(*ColAuto).Data.Type(), "%s", tt.Type)
+	}
+}
+
+func TestColAuto_DecodeComposite(t *testing.T) {
+	const rows = 10
+	var (
+		m = NewMap[string, []uint64](
+			new(ColStr).LowCardinality(),
+			new(ColUInt64).Array(),
+		)
+		tuple = ColTuple{
+			Named[int32](new(ColInt32), "a"),
+			Named[Nullable[string]](new(ColStr).Nullable(), "b"),
+		}
+		fixed ColFixedStr
+	)
+	fixed.SetSize(2)
+	for i := 0; i < rows; i++ {
+		m.Append(map[string][]uint64{
+			"foo": {uint64(i)},
+			"bar": {1, 2, 3},
+		})
+		tuple[0].(*ColNamed[int32]).Append(int32(i))
+		tuple[1].(*ColNamed[Nullable[string]]).Append(NewNullable("v"))
+		fixed.Append([]byte{'a', byte('0' + i)})
+	}
+	for _, tt := range []struct {
+		Type ColumnType
+		Data Column
+	}{
+		{Type: "Map(LowCardinality(String), Array(UInt64))", Data: m},
+		{Type: "Tuple(a Int32, b Nullable(String))", Data: tuple},
+		{Type: "FixedString(2)", Data: &fixed},
+	} {
+		t.Run(tt.Type.String(), func(t *testing.T) {
+			require.Equal(t, tt.Type, tt.Data.Type())
+			if v, ok := tt.Data.(Preparable); ok {
+				require.NoError(t, v.Prepare())
+			}
+			var buf Buffer
+			if v, ok := tt.Data.(StateEncoder); ok {
+				v.EncodeState(&buf)
+			}
+			tt.Data.EncodeColumn(&buf)
+
+			dec := new(ColAuto)
+			require.NoError(t, dec.Infer(tt.Type))
+			r := buf.Reader()
+			if v, ok := dec.Data.(StateDecoder); ok {
+				require.NoError(t, v.DecodeState(r))
+			}
+			require.NoError(t, dec.DecodeColumn(r, rows))
+			require.Equal(t, rows, dec.Rows())
+
+			// Decoded column should be encoded to the same data.
+			var got Buffer
+			if v, ok := dec.Data.(StateEncoder); ok {
+				v.EncodeState(&got)
+			}
+			dec.EncodeColumn(&got)
+			require.Equal(t, buf.Buf, got.Buf)
+		})
+	}
+}
+
+func TestColAuto_LowCardinalityNullable(t *testing.T) {
+	var buf Buffer
+	buf.PutInt64(int64(sharedDictionariesWithAdditionalKeys))
+	buf.PutInt64(cardinalityUpdateAll | int64(KeyUInt8))
+	buf.PutInt64(3)
+	// First key is NULL.
+	for _, s := range []string{"", "foo", "bar"} {
+		buf.PutString(s)
+	}
+	buf.PutInt64(4)
+	buf.PutRaw([]byte{1, 0, 2, 1})
+
+	dec := new(ColAuto)
+	require.NoError(t, dec.Infer("LowCardinality(Nullable(String))"))
+	r := buf.Reader()
+	require.NoError(t, dec.Data.(StateDecoder).DecodeState(r))
+	require.NoError(t, dec.DecodeColumn(r, 4))
+	col := dec.Data.(*ColLowCardinality[any])
+	require.Equal(t, []any{
+		NewNullable[any]("foo"),
+		Null[any](),
+		NewNullable[any]("bar"),
+		NewNullable[any]("foo"),
+	}, col.Values)
+
+	// Encoded with NULL as first key.
+	require.NoError(t, col.Prepare())
+	var got Buffer
+	col.EncodeState(&got)
+	col.EncodeColumn(&got)
+	require.Equal(t, buf.Buf, got.Buf)
+}
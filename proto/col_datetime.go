@@ -1,7 +1,6 @@
 package proto
 
 import (
-	"strings"
 	"time"
 
 	"github.com/go-faster/errors"
@@ -34,14 +33,15 @@ func (c ColDateTime) Type() ColumnType {
 }
 
 func (c *ColDateTime) Infer(t ColumnType) error {
-	sub := t.Elem()
-	if sub == "" {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if typ.Timezone == "" {
 		c.Location = nil
 		return nil
 	}
-	rawLoc := string(sub)
-	rawLoc = strings.Trim(rawLoc, `'`)
-	loc, err := time.LoadLocation(rawLoc)
+	loc, err := time.LoadLocation(typ.Timezone)
 	if err != nil {
 		return errors.Wrap(err, "load location")
 	}
@@ -3,7 +3,6 @@ package proto
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-faster/errors"
@@ -57,25 +56,17 @@ func (c ColDateTime64) Type() ColumnType {
 }
 
 func (c *ColDateTime64) Infer(t ColumnType) error {
-	elem := string(t.Elem())
-	if elem == "" {
-		return errors.Errorf("invalid DateTime64: no elements in %q", t)
-	}
-	pStr, locStr, hasloc := strings.Cut(elem, ",")
-	pStr = strings.Trim(pStr, `' `)
-	locStr = strings.Trim(locStr, `' `)
-	n, err := strconv.ParseUint(pStr, 10, 8)
+	typ, err := ParseType(t)
 	if err != nil {
-		return errors.Wrap(err, "parse precision")
+		return errors.Wrap(err, "parse type")
 	}
-	p := Precision(n)
-	if !p.Valid() {
-		return errors.Errorf("precision %d is invalid", n)
+	if len(typ.Params) == 0 {
+		return errors.Errorf("invalid DateTime64: no elements in %q", t)
 	}
-	c.Precision = p
+	c.Precision = Precision(typ.Precision)
 	c.PrecisionSet = true
-	if hasloc {
-		loc, err := time.LoadLocation(locStr)
+	if typ.Timezone != "" {
+		loc, err := time.LoadLocation(typ.Timezone)
 		if err != nil {
 			return errors.Wrap(err, "invalid location")
 		}
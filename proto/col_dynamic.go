@@ -0,0 +1,452 @@
+package proto
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColDynamic.
+var (
+	_ ColInput               = (*ColDynamic)(nil)
+	_ ColResult              = (*ColDynamic)(nil)
+	_ Column                 = (*ColDynamic)(nil)
+	_ ColumnOf[DynamicValue] = (*ColDynamic)(nil)
+	_ StateEncoder           = (*ColDynamic)(nil)
+	_ StateDecoder           = (*ColDynamic)(nil)
+	_ Inferable              = (*ColDynamic)(nil)
+	_ Preparable             = (*ColDynamic)(nil)
+)
+
+// DynamicSharedVariant is type of Dynamic rows that are stored in shared
+// variant, i.e. rows of types that exceed max_types limit.
+//
+// Such rows are exposed as []byte containing binary encoded type and
+// value.
+const DynamicSharedVariant ColumnType = "SharedVariant"
+
+// Dynamic structure serialization versions.
+const (
+	dynamicSerializationV1 uint64 = 1 // with max_types
+	dynamicSerializationV2 uint64 = 2 // without max_types
+)
+
+// dynamicDefaultMaxTypes is default max_types of Dynamic in ClickHouse.
+const dynamicDefaultMaxTypes = 32
+
+// DynamicValue is row of Dynamic column.
+type DynamicValue struct {
+	Type  ColumnType // ColumnTypeNothing for NULL
+	Value any
+}
+
+// IsNull reports whether value is NULL.
+func (v DynamicValue) IsNull() bool {
+	return v.Type == ColumnTypeNone || v.Type == ColumnTypeNothing
+}
+
+// ColDynamic is Dynamic column, which can store values of any type.
+//
+// Dynamic is Variant with list of types that is sent in each block, plus
+// shared variant for values of types that exceed max_types. Types are
+// inferred with ColAuto on decoding and added on the fly on Append.
+type ColDynamic struct {
+	// Variant holds values. Alternatives are sorted by type name and
+	// include DynamicSharedVariant.
+	Variant ColVariant
+
+	t        ColumnType
+	types    []ColumnType // type of each Variant alternative
+	maxTypes int
+
+	sharedTypes map[ColumnType]*rowBinaryValue // encoders of shared variant values
+	buf         Buffer
+	err         error // of appended values
+}
+
+// NewDynamic returns new Dynamic column.
+func NewDynamic() *ColDynamic {
+	c := new(ColDynamic)
+	c.init()
+	return c
+}
+
+func (c *ColDynamic) init() {
+	if len(c.types) > 0 {
+		return
+	}
+	c.types = []ColumnType{DynamicSharedVariant}
+	c.Variant.Variants = []Column{new(ColStr)}
+}
+
+// Type returns Dynamic or Dynamic(max_types=N).
+func (c ColDynamic) Type() ColumnType {
+	if c.t == "" {
+		return ColumnTypeDynamic
+	}
+	return c.t
+}
+
+// Rows returns rows count.
+func (c ColDynamic) Rows() int {
+	return c.Variant.Rows()
+}
+
+// Types returns types of values that are stored in column, including
+// DynamicSharedVariant.
+func (c ColDynamic) Types() []ColumnType {
+	return c.types
+}
+
+// RowType returns type of i-th row or ColumnTypeNothing for NULL.
+func (c ColDynamic) RowType(i int) ColumnType {
+	d := c.Variant.Discriminator(i)
+	if d == VariantNull {
+		return ColumnTypeNothing
+	}
+	return c.types[d]
+}
+
+// Row returns i-th row.
+func (c ColDynamic) Row(i int) DynamicValue {
+	d := c.Variant.Discriminator(i)
+	if d == VariantNull {
+		return DynamicValue{Type: ColumnTypeNothing}
+	}
+	return DynamicValue{
+		Type:  c.types[d],
+		Value: columnRow(c.Variant.Variants[d], c.Variant.Offset(i)),
+	}
+}
+
+// DynamicRow returns i-th row of c if it is value of ColumnOf[T].
+func DynamicRow[T any](c *ColDynamic, i int) (T, bool) {
+	return VariantRow[T](&c.Variant, i)
+}
+
+// variant returns index of alternative with type t, adding it if needed.
+//
+// Index of DynamicSharedVariant is returned for new types if column
+// already has max_types types.
+func (c *ColDynamic) variant(t ColumnType) (int, error) {
+	c.init()
+	idx := sort.Search(len(c.types), func(i int) bool {
+		return c.types[i] >= t
+	})
+	if idx < len(c.types) && c.types[idx] == t {
+		return idx, nil
+	}
+	if len(c.types)-1 >= c.limit() {
+		return c.variant(DynamicSharedVariant)
+	}
+	col := new(ColAuto)
+	if err := col.Infer(t); err != nil {
+		return 0, errors.Wrapf(err, "infer %q", t)
+	}
+	// Shifting discriminators of existing rows to keep alternatives sorted.
+	for i, d := range c.Variant.Discriminators {
+		if d != VariantNull && int(d) >= idx {
+			c.Variant.Discriminators[i] = d + 1
+		}
+	}
+	c.types = append(c.types[:idx], append([]ColumnType{t}, c.types[idx:]...)...)
+	c.Variant.Variants = append(c.Variant.Variants[:idx], append([]Column{col.Data}, c.Variant.Variants[idx:]...)...)
+	return idx, nil
+}
+
+// limit returns maximum count of types, excluding DynamicSharedVariant.
+func (c *ColDynamic) limit() int {
+	return dynamicLimit(c.maxTypes)
+}
+
+// dynamicLimit returns maximum count of types of Dynamic with max_types,
+// excluding DynamicSharedVariant.
+func dynamicLimit(maxTypes int) int {
+	if maxTypes == 0 {
+		maxTypes = dynamicDefaultMaxTypes
+	}
+	return min(maxTypes, maxVariantTypes-1)
+}
+
+// shared reports whether values of type t are appended to shared variant.
+func (c *ColDynamic) shared(d int, t ColumnType) bool {
+	return c.types[d] == DynamicSharedVariant && t != DynamicSharedVariant
+}
+
+// appendShared appends v of type t to shared variant d as binary encoded
+// type and value.
+func (c *ColDynamic) appendShared(d int, t ColumnType, v any) error {
+	enc, ok := c.sharedTypes[t]
+	if !ok {
+		var err error
+		if enc, err = newRowBinaryValue(t); err != nil {
+			return errors.Wrapf(err, "shared variant %q", t)
+		}
+		if c.sharedTypes == nil {
+			c.sharedTypes = map[ColumnType]*rowBinaryValue{}
+		}
+		c.sharedTypes[t] = enc
+	}
+	c.buf.Reset()
+	if err := putBinaryType(&c.buf, enc.typ); err != nil {
+		return errors.Wrapf(err, "shared variant %q", t)
+	}
+	if err := enc.encode(&c.buf, v); err != nil {
+		return errors.Wrapf(err, "shared variant %q", t)
+	}
+	c.Variant.Variants[d].(*ColStr).AppendBytes(c.buf.Buf)
+	c.Variant.appendDiscriminator(uint8(d))
+	return nil
+}
+
+// fail records error of appended value, which is returned by Prepare.
+func (c *ColDynamic) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// DynamicAppend appends v of type t to c.
+//
+// Panics if column of type t is not ColumnOf[T], see ColDynamic.Append for
+// other errors.
+func DynamicAppend[T any](c *ColDynamic, t ColumnType, v T) {
+	d, err := c.variant(t)
+	if err != nil || c.shared(d, t) {
+		c.Append(DynamicValue{Type: t, Value: v})
+		return
+	}
+	VariantAppend[T](&c.Variant, d, v)
+}
+
+// AppendNull appends NULL row.
+func (c *ColDynamic) AppendNull() {
+	c.init()
+	c.Variant.AppendNull()
+}
+
+// Append value to column.
+//
+// Values of new types are appended to shared variant if column already
+// has max_types types. If type can't be inferred or encoded, NULL is
+// appended instead and error is returned by Prepare.
+//
+// Panics if v.Value can't be appended to column of v.Type.
+func (c *ColDynamic) Append(v DynamicValue) {
+	if v.IsNull() {
+		c.AppendNull()
+		return
+	}
+	d, err := c.variant(v.Type)
+	if err == nil && c.shared(d, v.Type) {
+		if err = c.appendShared(d, v.Type, v.Value); err == nil {
+			return
+		}
+	}
+	if err != nil {
+		c.fail(err)
+		c.AppendNull()
+		return
+	}
+	columnAppend(c.Variant.Variants[d], v.Value)
+	c.Variant.appendDiscriminator(uint8(d))
+}
+
+// AppendArr appends slice of values.
+func (c *ColDynamic) AppendArr(v []DynamicValue) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// Array is helper that creates Array(Dynamic).
+func (c *ColDynamic) Array() *ColArr[DynamicValue] {
+	return &ColArr[DynamicValue]{
+		Data: c,
+	}
+}
+
+// Infer implements Inferable.
+func (c *ColDynamic) Infer(t ColumnType) error {
+	c.init()
+	c.t = t
+	c.maxTypes = 0
+	if t == ColumnTypeDynamic {
+		return nil
+	}
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if len(typ.Params) != 1 || typ.Params[0].Kind != TypeParamSetting || typ.Params[0].Name != "max_types" {
+		return errors.Errorf("invalid dynamic parameters %q", t.Elem())
+	}
+	n, err := strconv.Atoi(typ.Params[0].Value)
+	if err != nil {
+		return errors.Wrap(err, "max_types")
+	}
+	c.maxTypes = n
+	return nil
+}
+
+// Prepare implements Preparable.
+func (c *ColDynamic) Prepare() error {
+	c.init()
+	if c.err != nil {
+		return c.err
+	}
+	for i, v := range c.Variant.Variants {
+		if s, ok := v.(Preparable); ok {
+			if err := s.Prepare(); err != nil {
+				return errors.Wrapf(err, "%s", c.types[i])
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColDynamic) DecodeState(r *Reader) error {
+	version, err := r.UInt64()
+	if err != nil {
+		return errors.Wrap(err, "structure version")
+	}
+	switch version {
+	case dynamicSerializationV1:
+		v, err := r.UVarInt()
+		if err != nil {
+			return errors.Wrap(err, "max types")
+		}
+		c.maxTypes = int(v)
+	case dynamicSerializationV2:
+	default:
+		return errors.Errorf("unsupported dynamic structure version %d", version)
+	}
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "types count")
+	}
+	if n >= uint64(maxVariantTypes) {
+		return errors.Errorf("too many dynamic types: %d", n)
+	}
+	types := make([]ColumnType, 0, n+1)
+	for i := 0; i < int(n); i++ {
+		s, err := r.Str()
+		if err != nil {
+			return errors.Wrapf(err, "type [%d]", i)
+		}
+		types = append(types, ColumnType(s))
+	}
+	// Shared variant is not listed, but takes its place among sorted types.
+	idx := sort.Search(len(types), func(i int) bool {
+		return types[i] >= DynamicSharedVariant
+	})
+	types = append(types[:idx], append([]ColumnType{DynamicSharedVariant}, types[idx:]...)...)
+	if !c.hasTypes(types) {
+		variants := make([]Column, 0, len(types))
+		for i, t := range types {
+			if t == DynamicSharedVariant {
+				variants = append(variants, new(ColStr))
+				continue
+			}
+			col := new(ColAuto)
+			if err := col.Infer(t); err != nil {
+				return errors.Wrapf(err, "type [%d]", i)
+			}
+			variants = append(variants, col.Data)
+		}
+		c.types = types
+		c.Variant.Variants = variants
+	}
+	if err := c.Variant.DecodeState(r); err != nil {
+		return errors.Wrap(err, "variant state")
+	}
+	return nil
+}
+
+func (c *ColDynamic) hasTypes(types []ColumnType) bool {
+	if len(c.types) != len(types) {
+		return false
+	}
+	for i, t := range types {
+		if c.types[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeState implements StateEncoder.
+func (c ColDynamic) EncodeState(b *Buffer) {
+	maxTypes := c.maxTypes
+	if maxTypes == 0 {
+		maxTypes = dynamicDefaultMaxTypes
+	}
+	b.PutUInt64(dynamicSerializationV1)
+	b.PutUVarInt(uint64(maxTypes))
+	var n int
+	for _, t := range c.types {
+		if t != DynamicSharedVariant {
+			n++
+		}
+	}
+	b.PutUVarInt(uint64(n))
+	for _, t := range c.types {
+		if t != DynamicSharedVariant {
+			b.PutString(t.String())
+		}
+	}
+	c.Variant.EncodeState(b)
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColDynamic) DecodeColumn(r *Reader, rows int) error {
+	c.init()
+	if err := c.Variant.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "variant")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+//
+// Types are kept, so column memory can be reused for next block.
+func (c *ColDynamic) Reset() {
+	c.Variant.Reset()
+	c.err = nil
+}
+
+// EncodeColumn implements ColInput.
+func (c ColDynamic) EncodeColumn(b *Buffer) {
+	c.Variant.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColDynamic) WriteColumn(w *Writer) {
+	c.Variant.WriteColumn(w)
+}
+
+// columnRow returns i-th row of column that implements ColumnOf.
+func columnRow(c Column, i int) any {
+	m := reflect.ValueOf(c).MethodByName("Row")
+	if !m.IsValid() {
+		panic(fmt.Sprintf("column %s has no Row method", c.Type()))
+	}
+	return m.Call([]reflect.Value{reflect.ValueOf(i)})[0].Interface()
+}
+
+// columnAppend appends v to column that implements ColumnOf.
+func columnAppend(c Column, v any) {
+	m := reflect.ValueOf(c).MethodByName("Append")
+	if !m.IsValid() || m.Type().NumIn() != 1 {
+		panic(fmt.Sprintf("column %s has no Append method", c.Type()))
+	}
+	val := reflect.ValueOf(v)
+	if want := m.Type().In(0); !val.IsValid() || !val.Type().AssignableTo(want) {
+		panic(fmt.Sprintf("can't append %T to %s column, %s expected", v, c.Type(), want))
+	}
+	m.Call([]reflect.Value{val})
+}
@@ -0,0 +1,169 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestColDynamic(t *testing.T) {
+	t.Parallel()
+	values := []DynamicValue{
+		{Type: ColumnTypeUInt64, Value: uint64(1)},
+		{Type: ColumnTypeString, Value: "foo"},
+		{Type: ColumnTypeNothing},
+		{Type: ColumnTypeArray.Sub(ColumnTypeInt32), Value: []int32{1, 2, 3}},
+		{Type: ColumnTypeString, Value: "bar"},
+		{Type: ColumnTypeUInt64, Value: uint64(2)},
+		{Type: ColumnTypeBool, Value: true},
+	}
+	data := NewDynamic()
+	data.AppendArr(values)
+	require.NoError(t, data.Prepare())
+	require.Equal(t, ColumnTypeDynamic, data.Type())
+	require.Equal(t, []ColumnType{
+		"Array(Int32)", "Bool", "SharedVariant", "String", "UInt64",
+	}, data.Types())
+	for i, v := range values {
+		require.Equal(t, v, data.Row(i))
+	}
+
+	var buf Buffer
+	data.EncodeState(&buf)
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_dynamic")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer(ColumnTypeDynamic))
+		v := dec.Data.(*ColDynamic)
+		require.NoError(t, v.DecodeState(r))
+		require.NoError(t, v.DecodeColumn(r, len(values)))
+		require.Equal(t, data.Types(), v.Types())
+		for i, e := range values {
+			require.Equal(t, e, v.Row(i))
+			require.Equal(t, e.Type, v.RowType(i))
+		}
+		s, ok := DynamicRow[string](v, 1)
+		require.True(t, ok)
+		require.Equal(t, "foo", s)
+		_, ok = DynamicRow[string](v, 0)
+		require.False(t, ok)
+		v.Reset()
+		require.Equal(t, 0, v.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		require.ErrorIs(t, NewDynamic().DecodeState(r), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		requireNoShortRead(t, buf.Buf, dynamicAware{rows: len(values)})
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+}
+
+type dynamicAware struct {
+	rows int
+}
+
+func (a dynamicAware) Decode(r *Reader) error {
+	v := NewDynamic()
+	if err := v.DecodeState(r); err != nil {
+		return err
+	}
+	return v.DecodeColumn(r, a.rows)
+}
+
+func TestColDynamic_DecodeV2(t *testing.T) {
+	var b Buffer
+	b.PutUInt64(dynamicSerializationV2)
+	b.PutUVarInt(1)
+	b.PutString("Int64")
+	b.PutUInt64(variantDiscriminatorsBasic)
+	// Discriminators: Int64, NULL, Int64.
+	b.PutRaw([]byte{0, VariantNull, 0})
+	b.PutInt64(10)
+	b.PutInt64(20)
+
+	v := NewDynamic()
+	require.NoError(t, v.Infer("Dynamic(max_types=8)"))
+	require.Equal(t, ColumnType("Dynamic(max_types=8)"), v.Type())
+	r := b.Reader()
+	require.NoError(t, v.DecodeState(r))
+	require.NoError(t, v.DecodeColumn(r, 3))
+	require.Equal(t, DynamicValue{Type: ColumnTypeInt64, Value: int64(10)}, v.Row(0))
+	require.True(t, v.Row(1).IsNull())
+	require.Equal(t, DynamicValue{Type: ColumnTypeInt64, Value: int64(20)}, v.Row(2))
+}
+
+func TestColDynamic_AppendMismatch(t *testing.T) {
+	v := NewDynamic()
+	require.Panics(t, func() {
+		v.Append(DynamicValue{Type: ColumnTypeString, Value: 1})
+	})
+}
+
+func TestColDynamic_MaxTypes(t *testing.T) {
+	v := NewDynamic()
+	require.NoError(t, v.Infer("Dynamic(max_types=2)"))
+	v.Append(DynamicValue{Type: ColumnTypeUInt64, Value: uint64(1)})
+	v.Append(DynamicValue{Type: ColumnTypeString, Value: "foo"})
+	v.Append(DynamicValue{Type: ColumnTypeInt32, Value: int32(-2)})
+	DynamicAppend[float64](v, ColumnTypeFloat64, 1.5)
+	require.NoError(t, v.Prepare())
+	require.Equal(t, []ColumnType{DynamicSharedVariant, ColumnTypeString, ColumnTypeUInt64}, v.Types())
+	require.Equal(t, DynamicSharedVariant, v.RowType(2))
+
+	// Shared variant values are binary encoded type and value.
+	for i, e := range []struct {
+		Type ColumnType
+		Data []byte
+	}{
+		{Type: ColumnTypeInt32, Data: []byte{0xFE, 0xFF, 0xFF, 0xFF}},
+		{Type: ColumnTypeFloat64, Data: []byte{0, 0, 0, 0, 0, 0, 0xF8, 0x3F}},
+	} {
+		s, ok := DynamicRow[string](v, 2+i)
+		require.True(t, ok)
+		r := (&Buffer{Buf: []byte(s)}).Reader()
+		typ, err := readBinaryType(r)
+		require.NoError(t, err)
+		require.Equal(t, e.Type, typ)
+		data, err := r.ReadRaw(len(e.Data))
+		require.NoError(t, err)
+		require.Equal(t, e.Data, data)
+	}
+
+	var b Buffer
+	v.EncodeState(&b)
+	v.EncodeColumn(&b)
+	dec := NewDynamic()
+	r := b.Reader()
+	require.NoError(t, dec.DecodeState(r))
+	require.NoError(t, dec.DecodeColumn(r, v.Rows()))
+	require.Equal(t, v.Types(), dec.Types())
+	for i := 0; i < v.Rows(); i++ {
+		require.Equal(t, v.Row(i), dec.Row(i))
+	}
+}
+
+func TestColDynamic_AppendInvalidType(t *testing.T) {
+	v := NewDynamic()
+	require.NotPanics(t, func() {
+		v.Append(DynamicValue{Type: "Unknown(1)", Value: 1})
+	})
+	v.Append(DynamicValue{Type: ColumnTypeString, Value: "foo"})
+	require.Equal(t, 2, v.Rows())
+	require.True(t, v.Row(0).IsNull())
+	require.Error(t, v.Prepare())
+
+	v.Reset()
+	v.Append(DynamicValue{Type: ColumnTypeString, Value: "bar"})
+	require.NoError(t, v.Prepare())
+}
@@ -1,17 +1,14 @@
 package proto
 
-import (
-	"strconv"
-	"strings"
-
-	"github.com/go-faster/errors"
-)
+import "github.com/go-faster/errors"
 
 var (
 	_ Column           = (*ColEnum)(nil)
 	_ ColumnOf[string] = (*ColEnum)(nil)
 	_ Inferable        = (*ColEnum)(nil)
 	_ Preparable       = (*ColEnum)(nil)
+
+	_ defaultAppender = (*ColEnum)(nil)
 )
 
 // ColEnum is inference helper for enums.
@@ -32,7 +29,7 @@ type ColEnum struct {
 }
 
 func (e *ColEnum) raw() Column {
-	if e.t.Base() == ColumnTypeEnum8 {
+	if e.base == ColumnTypeEnum8 {
 		return &e.raw8
 	}
 	return &e.raw16
@@ -51,51 +48,55 @@ func (e *ColEnum) AppendArr(vs []string) {
 	e.Values = append(e.Values, vs...)
 }
 
-func (e *ColEnum) parse(t ColumnType) error {
+func (e *ColEnum) parse(t Type) {
 	if e.rawToStr == nil {
 		e.rawToStr = map[int]string{}
 	}
 	if e.strToRaw == nil {
 		e.strToRaw = map[string]int{}
 	}
+	for _, v := range t.Enum {
+		e.strToRaw[v.Name] = v.Value
+		e.rawToStr[v.Value] = v.Name
+	}
+}
 
-	elements := t.Elem().String()
-	for _, elem := range strings.Split(elements, ",") {
-		def := strings.TrimSpace(elem)
-		// 'hello' = 1
-		left, right, hascomma := strings.Cut(def, "=")
-		if !hascomma {
-			return errors.Errorf("bad enum definition %q", def)
-		}
-		left = strings.TrimSpace(left)   // 'hello'
-		right = strings.TrimSpace(right) // 1
-		idx, err := strconv.Atoi(right)
-		if err != nil {
-			return errors.Errorf("bad right side of definition %q", right)
+// defaultValue returns default value of enum, like in sparse columns: the
+// element with zero value, or the element with minimal value if there is
+// no such element.
+func (e *ColEnum) defaultValue() string {
+	if v, ok := e.rawToStr[0]; ok {
+		return v
+	}
+	var (
+		min   int
+		value string
+		found bool
+	)
+	for raw, v := range e.rawToStr {
+		if !found || raw < min {
+			min, value, found = raw, v, true
 		}
-		left = strings.TrimFunc(left, func(c rune) bool {
-			return c == '\''
-		})
-		e.strToRaw[left] = idx
-		e.rawToStr[idx] = left
 	}
-	return nil
+	return value
+}
+
+func (e *ColEnum) appendDefault() {
+	e.Append(e.defaultValue())
 }
 
 func (e *ColEnum) Infer(t ColumnType) error {
-	if !strings.HasPrefix(t.Base().String(), "Enum") {
-		return errors.Errorf("invalid base %q to infer enum", t.Base())
-	}
-	if err := e.parse(t); err != nil {
+	typ, err := ParseType(t)
+	if err != nil {
 		return errors.Wrap(err, "parse type")
 	}
-	base := t.Base()
-	switch base {
+	switch typ.Name {
 	case ColumnTypeEnum8, ColumnTypeEnum16:
-		e.base = base
+		e.base = typ.Name
 	default:
-		return errors.Errorf("invalid base %q", base)
+		return errors.Errorf("invalid base %q to infer enum", typ.Name)
 	}
+	e.parse(typ)
 	e.t = t
 	return nil
 }
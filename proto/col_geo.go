@@ -0,0 +1,423 @@
+package proto
+
+import "github.com/go-faster/errors"
+
+// Geo types that are built from Point.
+//
+// https://clickhouse.com/docs/en/sql-reference/data-types/geo
+// Ring is closed polygon contour without holes, i.e. Array(Point).
+type Ring []Point
+
+// Polygon is polygon with holes, i.e. Array(Ring): first ring is outer contour, next ones are holes.
+type Polygon []Ring
+
+// MultiPolygon consists of multiple polygons, i.e. Array(Polygon).
+type MultiPolygon []Polygon
+
+// LineString is line as sequence of points, i.e. Array(Point).
+type LineString []Point
+
+// MultiLineString consists of multiple lines, i.e. Array(LineString).
+type MultiLineString []LineString
+
+// Compile-time assertions for geo columns.
+var (
+	_ ColumnOf[Ring]             = (*ColRing)(nil)
+	_ Arrayable[Ring]            = (*ColRing)(nil)
+	_ ColumnOf[Polygon]          = (*ColPolygon)(nil)
+	_ Arrayable[Polygon]         = (*ColPolygon)(nil)
+	_ ColumnOf[MultiPolygon]     = (*ColMultiPolygon)(nil)
+	_ Arrayable[MultiPolygon]    = (*ColMultiPolygon)(nil)
+	_ ColumnOf[LineString]       = (*ColLineString)(nil)
+	_ Arrayable[LineString]      = (*ColLineString)(nil)
+	_ ColumnOf[MultiLineString]  = (*ColMultiLineString)(nil)
+	_ Arrayable[MultiLineString] = (*ColMultiLineString)(nil)
+)
+
+// offsetsRange returns [start, end) range of i-th row of array offsets.
+func offsetsRange(offsets ColUInt64, i int) (start, end int) {
+	if i > 0 {
+		start = int(offsets[i-1])
+	}
+	return start, int(offsets[i])
+}
+
+// ColRing is Ring column, i.e. Array(Point) column with
+// shared Points column.
+type ColRing struct {
+	Offsets ColUInt64
+	Points  ColPoint
+}
+
+// Type returns ColumnTypeRing.
+func (c ColRing) Type() ColumnType { return ColumnTypeRing }
+
+// Rows returns rows count.
+func (c ColRing) Rows() int { return c.Offsets.Rows() }
+
+// Row returns i-th row.
+func (c ColRing) Row(i int) Ring {
+	start, end := offsetsRange(c.Offsets, i)
+	v := make(Ring, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		v = append(v, c.Points.Row(idx))
+	}
+	return v
+}
+
+// Append appends new row.
+func (c *ColRing) Append(v Ring) {
+	c.Points.AppendArr(v)
+	c.Offsets.Append(uint64(c.Points.Rows()))
+}
+
+// AppendArr appends slice of rows.
+func (c *ColRing) AppendArr(v []Ring) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColRing) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "points count")
+	}
+	if err := c.Points.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "points")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColRing) Reset() {
+	c.Offsets.Reset()
+	c.Points.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColRing) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.Points.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColRing) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.Points.WriteColumn(w)
+}
+
+// Array is helper that creates Array(Ring).
+func (c *ColRing) Array() *ColArr[Ring] {
+	return &ColArr[Ring]{
+		Data: c,
+	}
+}
+
+// ColPolygon is Polygon column, i.e. Array(Ring) column with
+// shared Rings column.
+type ColPolygon struct {
+	Offsets ColUInt64
+	Rings   ColRing
+}
+
+// Type returns ColumnTypePolygon.
+func (c ColPolygon) Type() ColumnType { return ColumnTypePolygon }
+
+// Rows returns rows count.
+func (c ColPolygon) Rows() int { return c.Offsets.Rows() }
+
+// Row returns i-th row.
+func (c ColPolygon) Row(i int) Polygon {
+	start, end := offsetsRange(c.Offsets, i)
+	v := make(Polygon, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		v = append(v, c.Rings.Row(idx))
+	}
+	return v
+}
+
+// Append appends new row.
+func (c *ColPolygon) Append(v Polygon) {
+	c.Rings.AppendArr(v)
+	c.Offsets.Append(uint64(c.Rings.Rows()))
+}
+
+// AppendArr appends slice of rows.
+func (c *ColPolygon) AppendArr(v []Polygon) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColPolygon) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "rings count")
+	}
+	if err := c.Rings.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "rings")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColPolygon) Reset() {
+	c.Offsets.Reset()
+	c.Rings.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColPolygon) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.Rings.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColPolygon) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.Rings.WriteColumn(w)
+}
+
+// Array is helper that creates Array(Polygon).
+func (c *ColPolygon) Array() *ColArr[Polygon] {
+	return &ColArr[Polygon]{
+		Data: c,
+	}
+}
+
+// ColMultiPolygon is MultiPolygon column, i.e. Array(Polygon) column with
+// shared Polygons column.
+type ColMultiPolygon struct {
+	Offsets  ColUInt64
+	Polygons ColPolygon
+}
+
+// Type returns ColumnTypeMultiPolygon.
+func (c ColMultiPolygon) Type() ColumnType { return ColumnTypeMultiPolygon }
+
+// Rows returns rows count.
+func (c ColMultiPolygon) Rows() int { return c.Offsets.Rows() }
+
+// Row returns i-th row.
+func (c ColMultiPolygon) Row(i int) MultiPolygon {
+	start, end := offsetsRange(c.Offsets, i)
+	v := make(MultiPolygon, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		v = append(v, c.Polygons.Row(idx))
+	}
+	return v
+}
+
+// Append appends new row.
+func (c *ColMultiPolygon) Append(v MultiPolygon) {
+	c.Polygons.AppendArr(v)
+	c.Offsets.Append(uint64(c.Polygons.Rows()))
+}
+
+// AppendArr appends slice of rows.
+func (c *ColMultiPolygon) AppendArr(v []MultiPolygon) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColMultiPolygon) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "polygons count")
+	}
+	if err := c.Polygons.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "polygons")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColMultiPolygon) Reset() {
+	c.Offsets.Reset()
+	c.Polygons.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColMultiPolygon) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.Polygons.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColMultiPolygon) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.Polygons.WriteColumn(w)
+}
+
+// Array is helper that creates Array(MultiPolygon).
+func (c *ColMultiPolygon) Array() *ColArr[MultiPolygon] {
+	return &ColArr[MultiPolygon]{
+		Data: c,
+	}
+}
+
+// ColLineString is LineString column, i.e. Array(Point) column with
+// shared Points column.
+type ColLineString struct {
+	Offsets ColUInt64
+	Points  ColPoint
+}
+
+// Type returns ColumnTypeLineString.
+func (c ColLineString) Type() ColumnType { return ColumnTypeLineString }
+
+// Rows returns rows count.
+func (c ColLineString) Rows() int { return c.Offsets.Rows() }
+
+// Row returns i-th row.
+func (c ColLineString) Row(i int) LineString {
+	start, end := offsetsRange(c.Offsets, i)
+	v := make(LineString, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		v = append(v, c.Points.Row(idx))
+	}
+	return v
+}
+
+// Append appends new row.
+func (c *ColLineString) Append(v LineString) {
+	c.Points.AppendArr(v)
+	c.Offsets.Append(uint64(c.Points.Rows()))
+}
+
+// AppendArr appends slice of rows.
+func (c *ColLineString) AppendArr(v []LineString) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColLineString) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "points count")
+	}
+	if err := c.Points.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "points")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColLineString) Reset() {
+	c.Offsets.Reset()
+	c.Points.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColLineString) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.Points.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColLineString) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.Points.WriteColumn(w)
+}
+
+// Array is helper that creates Array(LineString).
+func (c *ColLineString) Array() *ColArr[LineString] {
+	return &ColArr[LineString]{
+		Data: c,
+	}
+}
+
+// ColMultiLineString is MultiLineString column, i.e. Array(LineString) column with
+// shared LineStrings column.
+type ColMultiLineString struct {
+	Offsets     ColUInt64
+	LineStrings ColLineString
+}
+
+// Type returns ColumnTypeMultiLineString.
+func (c ColMultiLineString) Type() ColumnType { return ColumnTypeMultiLineString }
+
+// Rows returns rows count.
+func (c ColMultiLineString) Rows() int { return c.Offsets.Rows() }
+
+// Row returns i-th row.
+func (c ColMultiLineString) Row(i int) MultiLineString {
+	start, end := offsetsRange(c.Offsets, i)
+	v := make(MultiLineString, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		v = append(v, c.LineStrings.Row(idx))
+	}
+	return v
+}
+
+// Append appends new row.
+func (c *ColMultiLineString) Append(v MultiLineString) {
+	c.LineStrings.AppendArr(v)
+	c.Offsets.Append(uint64(c.LineStrings.Rows()))
+}
+
+// AppendArr appends slice of rows.
+func (c *ColMultiLineString) AppendArr(v []MultiLineString) {
+	for _, e := range v {
+		c.Append(e)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColMultiLineString) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "lineStrings count")
+	}
+	if err := c.LineStrings.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "lineStrings")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColMultiLineString) Reset() {
+	c.Offsets.Reset()
+	c.LineStrings.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColMultiLineString) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.LineStrings.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColMultiLineString) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.LineStrings.WriteColumn(w)
+}
+
+// Array is helper that creates Array(MultiLineString).
+func (c *ColMultiLineString) Array() *ColArr[MultiLineString] {
+	return &ColArr[MultiLineString]{
+		Data: c,
+	}
+}
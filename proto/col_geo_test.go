@@ -0,0 +1,78 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func testColGeo[T any](t *testing.T, name string, data, dec ColumnOf[T], values []T) {
+	t.Helper()
+	data.AppendArr(values)
+	rows := len(values)
+	for i, v := range values {
+		require.Equal(t, v, data.Row(i))
+	}
+
+	var buf Buffer
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		gold.Bytes(t, buf.Buf, name)
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		auto := new(ColAuto)
+		require.NoError(t, auto.Infer(data.Type()))
+		require.NoError(t, auto.DecodeColumn(r, rows))
+		requireEqual[T](t, data, auto.Data.(ColumnOf[T]))
+		auto.Reset()
+		require.Equal(t, 0, auto.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		require.ErrorIs(t, dec.DecodeColumn(r, rows), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		requireNoShortRead(t, buf.Buf, colAware(dec, rows))
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+}
+
+func TestColGeo(t *testing.T) {
+	square := Ring{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 0}}
+	hole := Ring{{X: 4, Y: 4}, {X: 4, Y: 6}, {X: 6, Y: 6}, {X: 6, Y: 4}}
+	line := LineString{{X: 1, Y: 2}, {X: 3, Y: 4.5}}
+	t.Run("Ring", func(t *testing.T) {
+		testColGeo[Ring](t, "col_geo_ring", new(ColRing), new(ColRing), []Ring{
+			square, hole, {},
+		})
+	})
+	t.Run("Polygon", func(t *testing.T) {
+		testColGeo[Polygon](t, "col_geo_polygon", new(ColPolygon), new(ColPolygon), []Polygon{
+			{square, hole}, {square}, {},
+		})
+	})
+	t.Run("MultiPolygon", func(t *testing.T) {
+		testColGeo[MultiPolygon](t, "col_geo_multi_polygon", new(ColMultiPolygon), new(ColMultiPolygon), []MultiPolygon{
+			{{square, hole}, {hole}}, {}, {{square}},
+		})
+	})
+	t.Run("LineString", func(t *testing.T) {
+		testColGeo[LineString](t, "col_geo_line_string", new(ColLineString), new(ColLineString), []LineString{
+			line, {}, {{X: -1, Y: -1}},
+		})
+	})
+	t.Run("MultiLineString", func(t *testing.T) {
+		testColGeo[MultiLineString](t, "col_geo_multi_line_string", new(ColMultiLineString), new(ColMultiLineString), []MultiLineString{
+			{line, line}, {}, {{}},
+		})
+	})
+	t.Run("Type", func(t *testing.T) {
+		require.Equal(t, ColumnType("Array(Polygon)"), new(ColPolygon).Array().Type())
+		require.Equal(t, ColumnType("Array(Point)"), new(ColPoint).Array().Type())
+	})
+}
@@ -70,7 +70,11 @@ type ColInterval struct {
 }
 
 func (c *ColInterval) Infer(t ColumnType) error {
-	scale, err := IntervalScaleString(t.String())
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	scale, err := IntervalScaleString(typ.Name.String())
 	if err != nil {
 		return errors.Wrap(err, "scale")
 	}
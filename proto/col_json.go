@@ -0,0 +1,438 @@
+package proto
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColJSON.
+var (
+	_ ColInput     = (*ColJSON)(nil)
+	_ ColResult    = (*ColJSON)(nil)
+	_ Column       = (*ColJSON)(nil)
+	_ StateEncoder = (*ColJSON)(nil)
+	_ StateDecoder = (*ColJSON)(nil)
+	_ Inferable    = (*ColJSON)(nil)
+	_ Preparable   = (*ColJSON)(nil)
+)
+
+// JSON object serialization versions.
+const (
+	jsonSerializationV1     uint64 = 0 // with max_dynamic_paths
+	jsonSerializationString        = JSONStringSerializationVersion
+	jsonSerializationV2     uint64 = 2 // without max_dynamic_paths
+)
+
+// jsonDefaultMaxDynamicPaths is default max_dynamic_paths of JSON in
+// ClickHouse.
+const jsonDefaultMaxDynamicPaths = 1024
+
+// ColJSON is JSON column in native object serialization, i.e. when
+// "output_format_native_write_json_as_string" is disabled.
+// See ColJSONStr for string serialization.
+//
+// JSON object is stored as set of paths:
+//   - typed paths, declared in type, e.g. JSON(a.b UInt32), each is column
+//     of declared type;
+//   - dynamic paths, discovered in data, each is Dynamic column;
+//   - shared data, i.e. paths that exceed max_dynamic_paths, stored as
+//     Array(Tuple(path String, value String)) with binary encoded values.
+type ColJSON struct {
+	t ColumnType
+
+	typedPaths   []string
+	typed        []Column
+	dynamicPaths []string
+	dynamic      []*ColDynamic
+
+	// Shared data, Array(Tuple(String, String)).
+	SharedOffsets ColUInt64
+	SharedPaths   ColStr
+	SharedValues  ColStr
+
+	maxDynamicPaths int
+	maxDynamicTypes int
+}
+
+// Type returns JSON column type.
+func (c ColJSON) Type() ColumnType {
+	if c.t == "" {
+		return ColumnTypeJSON
+	}
+	return c.t
+}
+
+// Rows returns rows count.
+func (c ColJSON) Rows() int {
+	return c.SharedOffsets.Rows()
+}
+
+// TypedPaths returns sorted list of typed paths.
+func (c ColJSON) TypedPaths() []string {
+	return c.typedPaths
+}
+
+// DynamicPaths returns sorted list of dynamic paths.
+func (c ColJSON) DynamicPaths() []string {
+	return c.dynamicPaths
+}
+
+func searchPath(paths []string, path string) (int, bool) {
+	idx := sort.SearchStrings(paths, path)
+	return idx, idx < len(paths) && paths[idx] == path
+}
+
+// TypedPath returns column of typed path.
+//
+// For example, column of "a.b" path of JSON(a.b Int64) is *ColInt64.
+func (c ColJSON) TypedPath(path string) (Column, bool) {
+	idx, ok := searchPath(c.typedPaths, path)
+	if !ok {
+		return nil, false
+	}
+	return c.typed[idx], true
+}
+
+// DynamicPath returns column of dynamic path.
+func (c ColJSON) DynamicPath(path string) (*ColDynamic, bool) {
+	idx, ok := searchPath(c.dynamicPaths, path)
+	if !ok {
+		return nil, false
+	}
+	return c.dynamic[idx], true
+}
+
+// JSONPathRow returns value of path in i-th row of c if it is T.
+//
+// Reports false if path is missing, NULL or is not T. Paths from shared
+// data are not looked up.
+func JSONPathRow[T any](c *ColJSON, path string, i int) (T, bool) {
+	if col, ok := c.TypedPath(path); ok {
+		if v, ok := col.(ColumnOf[T]); ok {
+			return v.Row(i), true
+		}
+		var zero T
+		return zero, false
+	}
+	if col, ok := c.DynamicPath(path); ok {
+		return DynamicRow[T](col, i)
+	}
+	var zero T
+	return zero, false
+}
+
+// Row returns i-th row as map of paths to values.
+//
+// NULL dynamic paths are omitted. Values from shared data are []byte
+// with binary encoded type and value.
+func (c ColJSON) Row(i int) map[string]any {
+	m := make(map[string]any)
+	for j, p := range c.typedPaths {
+		m[p] = columnRow(c.typed[j], i)
+	}
+	for j, p := range c.dynamicPaths {
+		if v := c.dynamic[j].Row(i); !v.IsNull() {
+			m[p] = v.Value
+		}
+	}
+	var start int
+	if i > 0 {
+		start = int(c.SharedOffsets[i-1])
+	}
+	for j := start; j < int(c.SharedOffsets[i]); j++ {
+		m[c.SharedPaths.Row(j)] = c.SharedValues.RowBytes(j)
+	}
+	return m
+}
+
+// Append appends row of paths to values.
+//
+// Values of typed paths must match column type, missing typed paths are
+// set to zero value. Other paths are appended as dynamic paths of
+// DynamicValue.Type, missing dynamic paths are NULL.
+//
+// Panics if value can't be appended.
+func (c *ColJSON) Append(v map[string]DynamicValue) {
+	rows := c.Rows()
+	for p := range v {
+		if _, ok := searchPath(c.typedPaths, p); ok {
+			continue
+		}
+		if _, ok := searchPath(c.dynamicPaths, p); !ok {
+			c.addDynamicPath(p, rows)
+		}
+	}
+	for j, p := range c.typedPaths {
+		e, ok := v[p]
+		if !ok {
+			columnAppendZero(c.typed[j])
+			continue
+		}
+		columnAppend(c.typed[j], e.Value)
+	}
+	for j, p := range c.dynamicPaths {
+		e, ok := v[p]
+		if !ok {
+			c.dynamic[j].AppendNull()
+			continue
+		}
+		c.dynamic[j].Append(e)
+	}
+	c.SharedOffsets.Append(uint64(c.SharedPaths.Rows()))
+}
+
+// addDynamicPath adds new dynamic path with rows of NULL values.
+func (c *ColJSON) addDynamicPath(path string, rows int) {
+	col := NewDynamic()
+	if c.maxDynamicTypes != 0 {
+		col.maxTypes = c.maxDynamicTypes
+	}
+	for i := 0; i < rows; i++ {
+		col.AppendNull()
+	}
+	idx, _ := searchPath(c.dynamicPaths, path)
+	c.dynamicPaths = append(c.dynamicPaths[:idx], append([]string{path}, c.dynamicPaths[idx:]...)...)
+	c.dynamic = append(c.dynamic[:idx], append([]*ColDynamic{col}, c.dynamic[idx:]...)...)
+}
+
+// Infer implements Inferable, parsing JSON type parameters and creating
+// columns for typed paths.
+func (c *ColJSON) Infer(t ColumnType) error {
+	if c.t == t && t != "" {
+		return nil
+	}
+	c.t = t
+	c.maxDynamicPaths = 0
+	c.maxDynamicTypes = 0
+	c.typedPaths = c.typedPaths[:0]
+	c.typed = c.typed[:0]
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	for _, p := range typ.Params {
+		switch {
+		case p.Kind == TypeParamSetting && (p.Name == "max_dynamic_paths" || p.Name == "max_dynamic_types"):
+			n, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return errors.Wrapf(err, "%s", p.Name)
+			}
+			if p.Name == "max_dynamic_paths" {
+				c.maxDynamicPaths = n
+			} else {
+				c.maxDynamicTypes = n
+			}
+		case p.Kind == TypeParamRaw && strings.HasPrefix(p.Value, "SKIP "):
+			// Skipped paths are not sent.
+			continue
+		case p.Kind == TypeParamType && p.Name != "":
+			col := new(ColAuto)
+			if err := col.Infer(p.Type.ColumnType()); err != nil {
+				return errors.Wrapf(err, "typed path %q", p.Name)
+			}
+			idx, _ := searchPath(c.typedPaths, p.Name)
+			c.typedPaths = append(c.typedPaths[:idx], append([]string{p.Name}, c.typedPaths[idx:]...)...)
+			c.typed = append(c.typed[:idx], append([]Column{col.Data}, c.typed[idx:]...)...)
+		default:
+			return errors.Errorf("invalid parameter %s", p)
+		}
+	}
+	return nil
+}
+
+// Prepare implements Preparable.
+func (c ColJSON) Prepare() error {
+	for i, v := range c.typed {
+		if s, ok := v.(Preparable); ok {
+			if err := s.Prepare(); err != nil {
+				return errors.Wrapf(err, "typed path %q", c.typedPaths[i])
+			}
+		}
+	}
+	for i, v := range c.dynamic {
+		if err := v.Prepare(); err != nil {
+			return errors.Wrapf(err, "dynamic path %q", c.dynamicPaths[i])
+		}
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColJSON) DecodeState(r *Reader) error {
+	version, err := r.UInt64()
+	if err != nil {
+		return errors.Wrap(err, "serialization version")
+	}
+	switch version {
+	case jsonSerializationV1:
+		v, err := r.UVarInt()
+		if err != nil {
+			return errors.Wrap(err, "max dynamic paths")
+		}
+		c.maxDynamicPaths = int(v)
+	case jsonSerializationV2:
+	case jsonSerializationString:
+		return errors.New("got JSON string serialization, use ColJSONStr " +
+			"or disable \"output_format_native_write_json_as_string\"")
+	default:
+		return errors.Errorf("unsupported JSON serialization version %d", version)
+	}
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "dynamic paths count")
+	}
+	if err := checkRows(int(n)); err != nil {
+		return errors.Wrap(err, "dynamic paths count")
+	}
+	paths := make([]string, 0, n)
+	for i := 0; i < int(n); i++ {
+		p, err := r.Str()
+		if err != nil {
+			return errors.Wrapf(err, "dynamic path [%d]", i)
+		}
+		paths = append(paths, p)
+	}
+	if !sort.StringsAreSorted(paths) {
+		sort.Strings(paths)
+	}
+	if !equalStrings(c.dynamicPaths, paths) {
+		c.dynamicPaths = paths
+		c.dynamic = c.dynamic[:0]
+		for range paths {
+			c.dynamic = append(c.dynamic, NewDynamic())
+		}
+	}
+	for i, v := range c.typed {
+		if s, ok := v.(StateDecoder); ok {
+			if err := s.DecodeState(r); err != nil {
+				return errors.Wrapf(err, "typed path %q state", c.typedPaths[i])
+			}
+		}
+	}
+	for i, v := range c.dynamic {
+		if err := v.DecodeState(r); err != nil {
+			return errors.Wrapf(err, "dynamic path %q state", c.dynamicPaths[i])
+		}
+	}
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeState implements StateEncoder.
+func (c ColJSON) EncodeState(b *Buffer) {
+	maxPaths := c.maxDynamicPaths
+	if maxPaths == 0 {
+		maxPaths = jsonDefaultMaxDynamicPaths
+	}
+	b.PutUInt64(jsonSerializationV1)
+	b.PutUVarInt(uint64(maxPaths))
+	b.PutUVarInt(uint64(len(c.dynamicPaths)))
+	for _, p := range c.dynamicPaths {
+		b.PutString(p)
+	}
+	for _, v := range c.typed {
+		if s, ok := v.(StateEncoder); ok {
+			s.EncodeState(b)
+		}
+	}
+	for _, v := range c.dynamic {
+		v.EncodeState(b)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColJSON) DecodeColumn(r *Reader, rows int) error {
+	for i, v := range c.typed {
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return errors.Wrapf(err, "typed path %q", c.typedPaths[i])
+		}
+	}
+	for i, v := range c.dynamic {
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return errors.Wrapf(err, "dynamic path %q", c.dynamicPaths[i])
+		}
+	}
+	if err := c.SharedOffsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "shared data offsets")
+	}
+	var size int
+	if rows > 0 {
+		size = int(c.SharedOffsets[rows-1])
+	}
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "shared data size")
+	}
+	if err := c.SharedPaths.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "shared data paths")
+	}
+	if err := c.SharedValues.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "shared data values")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+//
+// Paths are kept, so column memory can be reused for next block.
+func (c *ColJSON) Reset() {
+	for _, v := range c.typed {
+		v.Reset()
+	}
+	for _, v := range c.dynamic {
+		v.Reset()
+	}
+	c.SharedOffsets.Reset()
+	c.SharedPaths.Reset()
+	c.SharedValues.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColJSON) EncodeColumn(b *Buffer) {
+	for _, v := range c.typed {
+		v.EncodeColumn(b)
+	}
+	for _, v := range c.dynamic {
+		v.EncodeColumn(b)
+	}
+	c.SharedOffsets.EncodeColumn(b)
+	c.SharedPaths.EncodeColumn(b)
+	c.SharedValues.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColJSON) WriteColumn(w *Writer) {
+	for _, v := range c.typed {
+		v.WriteColumn(w)
+	}
+	for _, v := range c.dynamic {
+		v.WriteColumn(w)
+	}
+	c.SharedOffsets.WriteColumn(w)
+	c.SharedPaths.WriteColumn(w)
+	c.SharedValues.WriteColumn(w)
+}
+
+// columnAppendZero appends zero value to column that implements ColumnOf.
+func columnAppendZero(c Column) {
+	m := reflect.ValueOf(c).MethodByName("Append")
+	if !m.IsValid() || m.Type().NumIn() != 1 {
+		panic(fmt.Sprintf("column %s has no Append method", c.Type()))
+	}
+	m.Call([]reflect.Value{reflect.Zero(m.Type().In(0))})
+}
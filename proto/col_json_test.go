@@ -0,0 +1,129 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestColJSON(t *testing.T) {
+	t.Parallel()
+	const typ ColumnType = "JSON(max_dynamic_paths=16, a.b Int64, SKIP c)"
+	rows := []map[string]DynamicValue{
+		{
+			"a.b": {Value: int64(1)},
+			"x":   {Type: ColumnTypeString, Value: "foo"},
+		},
+		{
+			"a.b": {Value: int64(2)},
+			"y":   {Type: ColumnTypeFloat64, Value: 1.5},
+		},
+		{
+			"x": {Type: ColumnTypeInt64, Value: int64(3)},
+		},
+	}
+	var data ColJSON
+	require.NoError(t, data.Infer(typ))
+	for _, r := range rows {
+		data.Append(r)
+	}
+	require.NoError(t, data.Prepare())
+	require.Equal(t, typ, data.Type())
+	require.Equal(t, len(rows), data.Rows())
+	require.Equal(t, []string{"a.b"}, data.TypedPaths())
+	require.Equal(t, []string{"x", "y"}, data.DynamicPaths())
+
+	var buf Buffer
+	data.EncodeState(&buf)
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_json")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer(typ))
+		v := dec.Data.(*ColJSON)
+		require.NoError(t, v.DecodeState(r))
+		require.NoError(t, v.DecodeColumn(r, len(rows)))
+		require.Equal(t, len(rows), v.Rows())
+
+		ab, ok := v.TypedPath("a.b")
+		require.True(t, ok)
+		require.Equal(t, &ColInt64{1, 2, 0}, ab)
+
+		x, ok := JSONPathRow[string](v, "x", 0)
+		require.True(t, ok)
+		require.Equal(t, "foo", x)
+		_, ok = JSONPathRow[string](v, "x", 2)
+		require.False(t, ok)
+		n, ok := JSONPathRow[int64](v, "x", 2)
+		require.True(t, ok)
+		require.Equal(t, int64(3), n)
+		n, ok = JSONPathRow[int64](v, "a.b", 1)
+		require.True(t, ok)
+		require.Equal(t, int64(2), n)
+		_, ok = JSONPathRow[int64](v, "missing", 1)
+		require.False(t, ok)
+
+		require.Equal(t, map[string]any{
+			"a.b": int64(2),
+			"y":   1.5,
+		}, v.Row(1))
+
+		v.Reset()
+		require.Equal(t, 0, v.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		require.ErrorIs(t, new(ColJSON).DecodeState(r), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		requireNoShortRead(t, buf.Buf, jsonAware{t: typ, rows: len(rows)})
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+}
+
+type jsonAware struct {
+	t    ColumnType
+	rows int
+}
+
+func (a jsonAware) Decode(r *Reader) error {
+	var v ColJSON
+	if err := v.Infer(a.t); err != nil {
+		return err
+	}
+	if err := v.DecodeState(r); err != nil {
+		return err
+	}
+	return v.DecodeColumn(r, a.rows)
+}
+
+func TestColJSON_SharedData(t *testing.T) {
+	var b Buffer
+	b.PutUInt64(jsonSerializationV2)
+	b.PutUVarInt(0) // no dynamic paths
+	// Shared data of single row with single path.
+	b.PutUInt64(1)
+	b.PutString("a")
+	b.PutString("\x0a\x01") // binary encoded Int64 type and value
+	r := b.Reader()
+
+	var v ColJSON
+	require.NoError(t, v.Infer(ColumnTypeJSON))
+	require.NoError(t, v.DecodeState(r))
+	require.NoError(t, v.DecodeColumn(r, 1))
+	require.Equal(t, map[string]any{"a": []byte("\x0a\x01")}, v.Row(0))
+}
+
+func TestColJSON_StringSerialization(t *testing.T) {
+	var b Buffer
+	b.PutUInt64(JSONStringSerializationVersion)
+	require.Error(t, new(ColJSON).DecodeState(b.Reader()))
+}
@@ -31,8 +31,19 @@ const (
 //
 // https://github.com/ClickHouse/clickhouse-cpp/blob/b10d71eed0532405dfb4dd03aabce869ba68f581/clickhouse/columns/lowcardinality.cpp
 //
-// NB: shared dictionaries and on-the-fly dictionary update is not supported,
-// because it is not currently used in client protocol.
+// Global dictionary is read if NeedGlobalDictionary bit is set and it was not
+// read before or NeedUpdateDictionary bit is set. Keys that are less than
+// size of global dictionary refer to it, other keys refer to additional keys.
+//
+// Global dictionary is part of serialization state of column, so it is
+// shared only between granules of single serialization stream and is
+// discarded on DecodeState or Reset. Server starts new state for each
+// block of Native format, so in practice dictionary is shared only between
+// granules of one block.
+//
+// NB: columns are always encoded with additional keys only, because server
+// decodes each block of Native format with new state, so global dictionary
+// can't be shared between blocks on insertion.
 const (
 	cardinalityKeyMask = 0b0000_1111_1111 // last byte
 
@@ -89,10 +100,18 @@ type ColLowCardinality[T comparable] struct {
 
 	kv   map[T]int
 	keys []int
+
+	// Global dictionary that is shared between granules of serialization
+	// stream on decoding, until DecodeState or Reset.
+	dict    []T
+	hasDict bool
 }
 
 // DecodeState implements StateDecoder, ensuring state for index column.
+//
+// Global dictionary of previous serialization stream is discarded.
 func (c *ColLowCardinality[T]) DecodeState(r *Reader) error {
+	c.resetDictionary()
 	keySerialization, err := r.Int64()
 	if err != nil {
 		return errors.Wrap(err, "version")
@@ -119,77 +138,150 @@ func (c ColLowCardinality[T]) EncodeState(b *Buffer) {
 	}
 }
 
-func (c *ColLowCardinality[T]) DecodeColumn(r *Reader, rows int) error {
-	if rows == 0 {
-		// Skipping entirely of no rows.
-		return nil
-	}
-	meta, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "meta")
-	}
-	if (meta & cardinalityNeedGlobalDictionaryBit) == 1 {
-		return errors.New("global dictionary is not supported")
-	}
-	if (meta & cardinalityHasAdditionalKeysBit) == 0 {
-		return errors.New("additional keys bit is missing")
-	}
-
-	key := CardinalityKey(meta & cardinalityKeyMask)
-	if !key.IsACardinalityKey() {
-		return errors.Errorf("invalid low cardinality keys type %d", key)
-	}
-	c.key = key
-
-	indexRows, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "index size")
-	}
-	if err := checkRows(int(indexRows)); err != nil {
-		return errors.Wrap(err, "index size")
-	}
-	if err := c.index.DecodeColumn(r, int(indexRows)); err != nil {
-		return errors.Wrap(err, "index column")
-	}
-
-	keyRows, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "keys size")
-	}
-	if err := checkRows(int(keyRows)); err != nil {
-		return errors.Wrap(err, "index size")
-	}
+// decodeKeys decodes rows of keys of type c.key to c.keys.
+func (c *ColLowCardinality[T]) decodeKeys(r *Reader, rows int) error {
+	c.keys = c.keys[:0]
 	switch c.key {
 	case KeyUInt8:
+		c.keys8 = c.keys8[:0]
 		if err := c.keys8.DecodeColumn(r, rows); err != nil {
-			return errors.Wrap(err, "keys")
+			return err
 		}
 		c.keys = fillValues(c.keys, c.keys8)
 	case KeyUInt16:
+		c.keys16 = c.keys16[:0]
 		if err := c.keys16.DecodeColumn(r, rows); err != nil {
-			return errors.Wrap(err, "keys")
+			return err
 		}
 		c.keys = fillValues(c.keys, c.keys16)
 	case KeyUInt32:
+		c.keys32 = c.keys32[:0]
 		if err := c.keys32.DecodeColumn(r, rows); err != nil {
-			return errors.Wrap(err, "keys")
+			return err
 		}
 		c.keys = fillValues(c.keys, c.keys32)
 	case KeyUInt64:
+		c.keys64 = c.keys64[:0]
 		if err := c.keys64.DecodeColumn(r, rows); err != nil {
-			return errors.Wrap(err, "keys")
+			return err
 		}
 		c.keys = fillValues(c.keys, c.keys64)
 	default:
 		return errors.Errorf("invalid key format %s", c.key)
 	}
+	return nil
+}
+
+func (c *ColLowCardinality[T]) resetDictionary() {
+	c.dict = c.dict[:0]
+	c.hasDict = false
+}
 
+// decodeDictionary decodes global dictionary, replacing previous one.
+func (c *ColLowCardinality[T]) decodeDictionary(r *Reader) error {
+	n, err := r.Int64()
+	if err != nil {
+		return errors.Wrap(err, "size")
+	}
+	if err := checkRows(int(n)); err != nil {
+		return errors.Wrap(err, "size")
+	}
+	c.index.Reset()
+	if err := c.index.DecodeColumn(r, int(n)); err != nil {
+		return errors.Wrap(err, "column")
+	}
+	c.dict = c.dict[:0]
+	for i := 0; i < int(n); i++ {
+		c.dict = append(c.dict, c.index.Row(i))
+	}
+	if null, ok := c.null(); ok && n > 0 {
+		c.dict[0] = null
+	}
+	c.hasDict = true
+	return nil
+}
+
+// DecodeColumn implements ColResult.
+//
+// Column can consist of several granules, each of them with own keys type
+// and additional keys. Global dictionary is kept between granules and
+// consecutive DecodeColumn calls until granule with updated dictionary is
+// received, or until DecodeState or Reset that start new stream.
+func (c *ColLowCardinality[T]) DecodeColumn(r *Reader, rows int) error {
 	c.Values = c.Values[:0]
-	for _, idx := range c.keys {
-		if int64(idx) >= indexRows || idx < 0 {
-			return errors.Errorf("key index out of range [%d] with length %d", idx, indexRows)
+	for rows > 0 {
+		meta, err := r.Int64()
+		if err != nil {
+			return errors.Wrap(err, "meta")
+		}
+		key := CardinalityKey(meta & cardinalityKeyMask)
+		if !key.IsACardinalityKey() {
+			return errors.Errorf("invalid low cardinality keys type %d", key)
+		}
+		c.key = key
+
+		var (
+			global     = meta&cardinalityNeedGlobalDictionaryBit != 0
+			additional = meta&cardinalityHasAdditionalKeysBit != 0
+		)
+		if !global && !additional {
+			return errors.New("neither global dictionary nor additional keys are set")
+		}
+		if global && (!c.hasDict || meta&cardinalityNeedUpdateDictionary != 0) {
+			if err := c.decodeDictionary(r); err != nil {
+				return errors.Wrap(err, "global dictionary")
+			}
+		}
+		var dictRows int
+		if global {
+			dictRows = len(c.dict)
+		}
+
+		// Additional keys are placed after global dictionary keys.
+		c.index.Reset()
+		var indexRows int
+		if additional {
+			n, err := r.Int64()
+			if err != nil {
+				return errors.Wrap(err, "index size")
+			}
+			if err := checkRows(int(n)); err != nil {
+				return errors.Wrap(err, "index size")
+			}
+			if err := c.index.DecodeColumn(r, int(n)); err != nil {
+				return errors.Wrap(err, "index column")
+			}
+			indexRows = int(n)
+		}
+
+		keyRows, err := r.Int64()
+		if err != nil {
+			return errors.Wrap(err, "keys size")
+		}
+		if err := checkRows(int(keyRows)); err != nil {
+			return errors.Wrap(err, "keys size")
+		}
+		if keyRows == 0 || int(keyRows) > rows {
+			return errors.Errorf("got %d keys, expected at most %d", keyRows, rows)
+		}
+		if err := c.decodeKeys(r, int(keyRows)); err != nil {
+			return errors.Wrap(err, "keys")
 		}
-		c.Values = append(c.Values, c.index.Row(idx))
+		null, nullable := c.null()
+		for _, idx := range c.keys {
+			switch {
+			case idx >= 0 && idx < dictRows:
+				c.Values = append(c.Values, c.dict[idx])
+			case idx == 0 && !global && nullable:
+				// First additional key is NULL without global dictionary.
+				c.Values = append(c.Values, null)
+			case idx >= dictRows && idx-dictRows < indexRows:
+				c.Values = append(c.Values, c.index.Row(idx-dictRows))
+			default:
+				return errors.Errorf("key index out of range [%d] with length %d", idx, dictRows+indexRows)
+			}
+		}
+		rows -= int(keyRows)
 	}
 
 	return nil
@@ -199,6 +291,11 @@ func (c ColLowCardinality[T]) Type() ColumnType {
 	return ColumnTypeLowCardinality.Sub(c.index.Type())
 }
 
+// EncodeColumn implements ColInput.
+//
+// Column is encoded as single granule with additional keys only, without
+// global dictionary, because server does not share dictionary between
+// blocks on insertion.
 func (c *ColLowCardinality[T]) EncodeColumn(b *Buffer) {
 	// Using pointer receiver as Prepare() is expected to be called before
 	// encoding.
@@ -230,6 +327,7 @@ func (c *ColLowCardinality[T]) EncodeColumn(b *Buffer) {
 	}
 }
 
+// WriteColumn implements ColInput, see EncodeColumn.
 func (c *ColLowCardinality[T]) WriteColumn(w *Writer) {
 	// Using pointer receiver as Prepare() is expected to be called before
 	// encoding.
@@ -278,6 +376,7 @@ func (c *ColLowCardinality[T]) Reset() {
 	c.Values = c.Values[:0]
 
 	c.index.Reset()
+	c.resetDictionary()
 }
 
 type cardinalityKeyValue interface {
@@ -327,9 +426,14 @@ func (c *ColLowCardinality[T]) Prepare() error {
 		c.kv = map[T]int{}
 		c.index.Reset()
 	}
+	if null, ok := c.null(); ok && len(c.kv) == 0 {
+		// NULL is first key of dictionary.
+		c.index.Append(null)
+		c.kv[null] = 0
+	}
 
 	// Fill keys with value indexes.
-	var last int
+	last := len(c.kv)
 	for i, v := range c.Values {
 		idx, ok := c.kv[v]
 		if !ok {
@@ -374,6 +478,21 @@ func (c *ColLowCardinality[T]) Array() *ColArr[T] {
 	}
 }
 
+// lowCardinalityNull is implemented by dictionary column of
+// LowCardinality(Nullable(T)), which is encoded as T with NULL as first key.
+type lowCardinalityNull[T any] interface {
+	null() T
+}
+
+// null returns NULL value if column is LowCardinality(Nullable(T)).
+func (c *ColLowCardinality[T]) null() (T, bool) {
+	if n, ok := c.index.(lowCardinalityNull[T]); ok {
+		return n.null(), true
+	}
+	var zero T
+	return zero, false
+}
+
 // NewLowCardinality creates new LowCardinality column from another column for T.
 func NewLowCardinality[T comparable](c ColumnOf[T]) *ColLowCardinality[T] {
 	return &ColLowCardinality[T]{
@@ -1,6 +1,10 @@
 package proto
 
-import "github.com/go-faster/errors"
+import (
+	"math"
+
+	"github.com/go-faster/errors"
+)
 
 // ColLowCardinalityRaw is non-generic version of ColLowCardinality.
 type ColLowCardinalityRaw struct {
@@ -14,9 +18,17 @@ type ColLowCardinalityRaw struct {
 	Keys16 ColUInt16
 	Keys32 ColUInt32
 	Keys64 ColUInt64
+
+	// Global dictionary as encoded rows, that is shared between granules
+	// of serialization stream on decoding, until DecodeState or Reset.
+	dict      Buffer
+	dictRows  int
+	dictStart int // position of dictionary in Index
+	hasDict   bool
 }
 
 func (c *ColLowCardinalityRaw) DecodeState(r *Reader) error {
+	c.hasDict = false
 	keySerialization, err := r.Int64()
 	if err != nil {
 		return errors.Wrap(err, "version")
@@ -80,49 +92,210 @@ func (c ColLowCardinalityRaw) Rows() int {
 	return c.Keys().Rows()
 }
 
+// setKeys sets keys, selecting minimum key type for Index.
+func (c *ColLowCardinalityRaw) setKeys(keys []int) {
+	c.Keys8 = c.Keys8[:0]
+	c.Keys16 = c.Keys16[:0]
+	c.Keys32 = c.Keys32[:0]
+	c.Keys64 = c.Keys64[:0]
+	switch n := c.Index.Rows(); {
+	case n < math.MaxUint8:
+		c.Key = KeyUInt8
+		c.Keys8 = fillKeys(keys, c.Keys8)
+	case n < math.MaxUint16:
+		c.Key = KeyUInt16
+		c.Keys16 = fillKeys(keys, c.Keys16)
+	case uint32(n) < math.MaxUint32:
+		c.Key = KeyUInt32
+		c.Keys32 = fillKeys(keys, c.Keys32)
+	default:
+		c.Key = KeyUInt64
+		c.Keys64 = fillKeys(keys, c.Keys64)
+	}
+}
+
+// decodeCardinalityKeys decodes rows of keys of type key, appending them
+// to keys.
+func decodeCardinalityKeys(r *Reader, key CardinalityKey, rows int, keys []int) ([]int, error) {
+	switch key {
+	case KeyUInt8:
+		var v ColUInt8
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return nil, err
+		}
+		return fillValues(keys, v), nil
+	case KeyUInt16:
+		var v ColUInt16
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return nil, err
+		}
+		return fillValues(keys, v), nil
+	case KeyUInt32:
+		var v ColUInt32
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return nil, err
+		}
+		return fillValues(keys, v), nil
+	case KeyUInt64:
+		var v ColUInt64
+		if err := v.DecodeColumn(r, rows); err != nil {
+			return nil, err
+		}
+		return fillValues(keys, v), nil
+	default:
+		return nil, errors.Errorf("invalid key format %s", key)
+	}
+}
+
+// rawIndex collects parts of Index, i.e. global dictionary and additional
+// keys of granules.
+//
+// Columns can replace rows on decoding instead of appending them, so
+// parts are collected as encoded rows and decoded to Index at once if
+// there is more than one part.
+type rawIndex struct {
+	buf   Buffer
+	rows  int
+	parts int
+}
+
+// add appends encoded rows of part.
+func (i *rawIndex) add(data []byte, rows int) {
+	i.buf.PutRaw(data)
+	i.rows += rows
+	i.parts++
+}
+
+// DecodeColumn implements ColResult.
+//
+// Granules are decoded like in ColLowCardinality, but global dictionary
+// and additional keys of all granules are decoded to Index, and keys are
+// converted to refer to them, so Key can differ from keys type that was
+// sent. Index and keys are replaced on each call, global dictionary is
+// kept until DecodeState or Reset.
 func (c *ColLowCardinalityRaw) DecodeColumn(r *Reader, rows int) error {
 	if rows == 0 {
 		// Skipping entirely of no rows.
 		return nil
 	}
-	meta, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "meta")
+	if c.Index == nil {
+		return errors.New("index column is not set")
 	}
-	if (meta & cardinalityNeedGlobalDictionaryBit) == 1 {
-		return errors.New("global dictionary is not supported")
+	var (
+		index rawIndex
+		keys  []int
+		kept  = c.hasDict
+	)
+	if kept {
+		// Dictionary of previous call.
+		c.dictStart = 0
+		index.add(c.dict.Buf, c.dictRows)
 	}
-	if (meta & cardinalityHasAdditionalKeysBit) == 0 {
-		return errors.New("additional keys bit is missing")
+	decodePart := func(n int) ([]byte, error) {
+		c.Index.Reset()
+		if err := c.Index.DecodeColumn(r, n); err != nil {
+			return nil, err
+		}
+		start := len(index.buf.Buf)
+		c.Index.EncodeColumn(&index.buf)
+		data := index.buf.Buf[start:]
+		index.buf.Buf = index.buf.Buf[:start]
+		index.add(data, n)
+		return data, nil
 	}
+	for rows > 0 {
+		meta, err := r.Int64()
+		if err != nil {
+			return errors.Wrap(err, "meta")
+		}
+		key := CardinalityKey(meta & cardinalityKeyMask)
+		if !key.IsACardinalityKey() {
+			return errors.Errorf("invalid low cardinality keys type %d", key)
+		}
 
-	key := CardinalityKey(meta & cardinalityKeyMask)
-	if !key.IsACardinalityKey() {
-		return errors.Errorf("invalid low cardinality keys type %d", key)
-	}
-	c.Key = key
+		var (
+			global     = meta&cardinalityNeedGlobalDictionaryBit != 0
+			additional = meta&cardinalityHasAdditionalKeysBit != 0
+		)
+		if !global && !additional {
+			return errors.New("neither global dictionary nor additional keys are set")
+		}
+		if global && (!c.hasDict || meta&cardinalityNeedUpdateDictionary != 0) {
+			n, err := r.Int64()
+			if err != nil {
+				return errors.Wrap(err, "global dictionary size")
+			}
+			if err := checkRows(int(n)); err != nil {
+				return errors.Wrap(err, "global dictionary size")
+			}
+			c.dictStart = index.rows
+			data, err := decodePart(int(n))
+			if err != nil {
+				return errors.Wrap(err, "global dictionary")
+			}
+			c.dict.Reset()
+			c.dict.PutRaw(data)
+			c.dictRows = int(n)
+			c.hasDict = true
+		}
+		var dictRows int
+		if global {
+			dictRows = c.dictRows
+		}
 
-	indexRows, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "index size")
-	}
-	if err := checkRows(int(indexRows)); err != nil {
-		return errors.Wrap(err, "index size")
-	}
-	if err := c.Index.DecodeColumn(r, int(indexRows)); err != nil {
-		return errors.Wrap(err, "index column")
-	}
+		// Additional keys are placed after global dictionary keys.
+		var (
+			indexStart = index.rows
+			indexRows  int
+		)
+		if additional {
+			n, err := r.Int64()
+			if err != nil {
+				return errors.Wrap(err, "index size")
+			}
+			if err := checkRows(int(n)); err != nil {
+				return errors.Wrap(err, "index size")
+			}
+			if _, err := decodePart(int(n)); err != nil {
+				return errors.Wrap(err, "index column")
+			}
+			indexRows = int(n)
+		}
 
-	keyRows, err := r.Int64()
-	if err != nil {
-		return errors.Wrap(err, "keys size")
-	}
-	if err := checkRows(int(keyRows)); err != nil {
-		return errors.Wrap(err, "index size")
+		keyRows, err := r.Int64()
+		if err != nil {
+			return errors.Wrap(err, "keys size")
+		}
+		if err := checkRows(int(keyRows)); err != nil {
+			return errors.Wrap(err, "keys size")
+		}
+		if keyRows == 0 || int(keyRows) > rows {
+			return errors.Errorf("got %d keys, expected at most %d", keyRows, rows)
+		}
+		start := len(keys)
+		if keys, err = decodeCardinalityKeys(r, key, int(keyRows), keys); err != nil {
+			return errors.Wrap(err, "keys column")
+		}
+		for i, idx := range keys[start:] {
+			switch {
+			case idx >= 0 && idx < dictRows:
+				keys[start+i] = c.dictStart + idx
+			case idx >= dictRows && idx-dictRows < indexRows:
+				keys[start+i] = indexStart + idx - dictRows
+			default:
+				return errors.Errorf("key index out of range [%d] with length %d", idx, dictRows+indexRows)
+			}
+		}
+		rows -= int(keyRows)
 	}
-	if err := c.Keys().DecodeColumn(r, int(keyRows)); err != nil {
-		return errors.Wrap(err, "keys column")
+	if kept || index.parts > 1 {
+		// Last decoded part is not whole index.
+		c.Index.Reset()
+		if err := c.Index.DecodeColumn(index.buf.Reader(), index.rows); err != nil {
+			return errors.Wrap(err, "index")
+		}
 	}
+	c.setKeys(keys)
 
 	return nil
 }
@@ -133,8 +306,14 @@ func (c *ColLowCardinalityRaw) Reset() {
 	c.Keys16.Reset()
 	c.Keys32.Reset()
 	c.Keys64.Reset()
+	c.hasDict = false
 }
 
+// EncodeColumn implements ColInput.
+//
+// Column is encoded as single granule with Index as additional keys,
+// without global dictionary, because server does not share dictionary
+// between blocks on insertion.
 func (c ColLowCardinalityRaw) EncodeColumn(b *Buffer) {
 	if c.Rows() == 0 {
 		// Skipping encoding entirely.
@@ -156,6 +335,7 @@ func (c ColLowCardinalityRaw) EncodeColumn(b *Buffer) {
 	k.EncodeColumn(b)
 }
 
+// WriteColumn implements ColInput, see EncodeColumn.
 func (c ColLowCardinalityRaw) WriteColumn(w *Writer) {
 	if c.Rows() == 0 {
 		// Skipping encoding entirely.
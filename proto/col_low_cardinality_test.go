@@ -169,3 +169,92 @@ func TestColLowCardinality_DecodeColumn(t *testing.T) {
 		require.Error(t, dec.DecodeColumn(buf.Reader(), 1))
 	})
 }
+
+func TestColLowCardinality_GlobalDictionary(t *testing.T) {
+	var buf Buffer
+	// First granule: global dictionary and additional keys.
+	buf.PutInt64(cardinalityNeedGlobalDictionaryBit | cardinalityUpdateAll | int64(KeyUInt8))
+	buf.PutInt64(2)
+	dict := new(ColStr)
+	dict.AppendArr([]string{"neo", "trinity"})
+	dict.EncodeColumn(&buf)
+	buf.PutInt64(1)
+	additional := new(ColStr)
+	additional.Append("morpheus")
+	additional.EncodeColumn(&buf)
+	buf.PutInt64(3)
+	ColUInt8{2, 0, 1}.EncodeColumn(&buf)
+	// Second granule: only global dictionary.
+	buf.PutInt64(cardinalityNeedGlobalDictionaryBit | int64(KeyUInt16))
+	buf.PutInt64(2)
+	ColUInt16{1, 1}.EncodeColumn(&buf)
+
+	dec := new(ColStr).LowCardinality()
+	r := buf.Reader()
+	require.NoError(t, dec.DecodeColumn(r, 5))
+	require.Equal(t, []string{"morpheus", "neo", "trinity", "trinity", "trinity"}, dec.Values)
+
+	var next Buffer
+	next.PutInt64(cardinalityNeedGlobalDictionaryBit | int64(KeyUInt8))
+	next.PutInt64(2)
+	ColUInt8{0, 1}.EncodeColumn(&next)
+
+	t.Run("NextCall", func(t *testing.T) {
+		// Dictionary is kept between calls if it is not updated.
+		require.NoError(t, dec.DecodeColumn(next.Reader(), 2))
+		require.Equal(t, []string{"neo", "trinity"}, dec.Values)
+	})
+	t.Run("OutOfRange", func(t *testing.T) {
+		var b Buffer
+		b.PutInt64(cardinalityNeedGlobalDictionaryBit | int64(KeyUInt8))
+		b.PutInt64(1)
+		ColUInt8{2}.EncodeColumn(&b)
+
+		require.Error(t, dec.DecodeColumn(b.Reader(), 1))
+	})
+	t.Run("Reset", func(t *testing.T) {
+		// Dictionary of previous stream is not used.
+		dec.Reset()
+		require.Error(t, dec.DecodeColumn(next.Reader(), 2))
+
+		var state Buffer
+		state.PutInt64(int64(sharedDictionariesWithAdditionalKeys))
+		require.NoError(t, dec.DecodeColumn(buf.Reader(), 5))
+		require.NoError(t, dec.DecodeState(state.Reader()))
+		require.Error(t, dec.DecodeColumn(next.Reader(), 2))
+	})
+	t.Run("Raw", func(t *testing.T) {
+		raw := &ColLowCardinalityRaw{Index: new(ColStr)}
+		require.NoError(t, raw.DecodeColumn(buf.Reader(), 5))
+		require.Equal(t, KeyUInt8, raw.Key)
+		require.Equal(t, ColUInt8{2, 0, 1, 1, 1}, raw.Keys8)
+		require.Equal(t, []string{"neo", "trinity", "morpheus"}, rowsOf[string](raw.Index.(*ColStr)))
+
+		// Dictionary is kept for next call.
+		require.NoError(t, raw.DecodeColumn(next.Reader(), 2))
+		require.Equal(t, ColUInt8{0, 1}, raw.Keys8)
+		require.Equal(t, []string{"neo", "trinity"}, rowsOf[string](raw.Index.(*ColStr)))
+
+		raw.Reset()
+		require.Error(t, raw.DecodeColumn(next.Reader(), 2))
+	})
+	t.Run("RawUpdate", func(t *testing.T) {
+		var b Buffer
+		b.PutInt64(cardinalityNeedGlobalDictionaryBit | cardinalityNeedUpdateDictionary | int64(KeyUInt8))
+		b.PutInt64(2)
+		dict.EncodeColumn(&b)
+		b.PutInt64(2)
+		ColUInt8{1, 0}.EncodeColumn(&b)
+		// Second granule updates dictionary.
+		b.PutInt64(cardinalityNeedGlobalDictionaryBit | cardinalityNeedUpdateDictionary | int64(KeyUInt8))
+		b.PutInt64(1)
+		additional.EncodeColumn(&b)
+		b.PutInt64(1)
+		ColUInt8{0}.EncodeColumn(&b)
+
+		raw := &ColLowCardinalityRaw{Index: new(ColStr)}
+		require.NoError(t, raw.DecodeColumn(b.Reader(), 3))
+		require.Equal(t, ColUInt8{1, 0, 2}, raw.Keys8)
+		require.Equal(t, []string{"neo", "trinity", "morpheus"}, rowsOf[string](raw.Index.(*ColStr)))
+	})
+}
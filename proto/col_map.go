@@ -1,8 +1,6 @@
 package proto
 
 import (
-	"strings"
-
 	"github.com/go-faster/errors"
 )
 
@@ -191,19 +189,20 @@ func (c ColMap[K, V]) Prepare() error {
 
 // Infer ensures Inferable column propagation.
 func (c *ColMap[K, V]) Infer(t ColumnType) error {
-	keytype, valtype, hascomma := strings.Cut(string(t.Elem()), ",")
-	if !hascomma || strings.ContainsRune(valtype, ',') {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if len(typ.Elems) != 2 {
 		return errors.New("invalid map type")
 	}
 	if v, ok := c.Keys.(Inferable); ok {
-		ct := ColumnType(strings.TrimSpace(keytype))
-		if err := v.Infer(ct); err != nil {
+		if err := v.Infer(typ.Elems[0].ColumnType()); err != nil {
 			return errors.Wrap(err, "infer data")
 		}
 	}
 	if v, ok := c.Values.(Inferable); ok {
-		ct := ColumnType(strings.TrimSpace(valtype))
-		if err := v.Infer(ct); err != nil {
+		if err := v.Infer(typ.Elems[1].ColumnType()); err != nil {
 			return errors.Wrap(err, "infer data")
 		}
 	}
@@ -0,0 +1,435 @@
+package proto
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColNested.
+var (
+	_ ColInput     = (*ColNested)(nil)
+	_ ColResult    = (*ColNested)(nil)
+	_ Column       = (*ColNested)(nil)
+	_ StateEncoder = (*ColNested)(nil)
+	_ StateDecoder = (*ColNested)(nil)
+	_ Inferable    = (*ColNested)(nil)
+	_ Preparable   = (*ColNested)(nil)
+)
+
+// ColNested is Nested(name1 T1, name2 T2, ...) column.
+//
+// Nested is group of arrays of equal length per row, so it is stored as
+// single Offsets column and Data tuple of named columns, like
+// Array(Tuple(name1 T1, name2 T2, ...)).
+//
+// Depending on flatten_nested setting, ClickHouse represents Nested either
+// as single column (flatten_nested=0) or as separate name.name1 Array(T1),
+// name.name2 Array(T2) columns (flatten_nested=1, default). Use ColNested
+// directly for former and Flat, Results or Input for latter, so offsets
+// are shared and kept in sync in both cases.
+type ColNested struct {
+	Offsets ColUInt64
+	Data    ColTuple // named columns, see Named
+}
+
+// NewNested returns Nested column of named columns.
+//
+// Example: NewNested(Named[string](new(ColStr), "a"), Named[int64](new(ColInt64), "b"))
+func NewNested(columns ...Column) *ColNested {
+	return &ColNested{
+		Data: columns,
+	}
+}
+
+// columnName returns name of named column.
+func columnName(c Column) string {
+	if v, ok := c.(interface{ ColumnName() string }); ok {
+		return v.ColumnName()
+	}
+	return ""
+}
+
+// colNamedAny is named Column, like ColNamed for columns that are not
+// ColumnOf, e.g. inferred ones.
+type colNamedAny struct {
+	Column
+	name string
+}
+
+func (c colNamedAny) ColumnName() string { return c.name }
+
+func (c colNamedAny) Type() ColumnType {
+	return ColumnType(quoteTypeName(c.name) + " " + c.Column.Type().String())
+}
+
+func (c colNamedAny) DecodeState(r *Reader) error {
+	if v, ok := c.Column.(StateDecoder); ok {
+		return v.DecodeState(r)
+	}
+	return nil
+}
+
+func (c colNamedAny) EncodeState(b *Buffer) {
+	if v, ok := c.Column.(StateEncoder); ok {
+		v.EncodeState(b)
+	}
+}
+
+func (c colNamedAny) Prepare() error {
+	if v, ok := c.Column.(Preparable); ok {
+		return v.Prepare()
+	}
+	return nil
+}
+
+// unnamed returns column without name.
+func unnamed(c Column) Column {
+	switch v := c.(type) {
+	case colNamedAny:
+		return v.Column
+	case *colNamedAny:
+		return v.Column
+	}
+	if v, ok := c.(interface{ unwrapNamed() Column }); ok {
+		return v.unwrapNamed()
+	}
+	return c
+}
+
+func (c ColNamed[T]) unwrapNamed() Column { return c.ColumnOf }
+
+// Type returns Nested(name1 T1, name2 T2, ...).
+func (c ColNested) Type() ColumnType {
+	types := make([]ColumnType, 0, len(c.Data))
+	for _, v := range c.Data {
+		types = append(types, v.Type())
+	}
+	return ColumnTypeNested.Sub(types...)
+}
+
+// Rows returns rows count.
+func (c ColNested) Rows() int {
+	return c.Offsets.Rows()
+}
+
+// Names returns names of nested columns.
+func (c ColNested) Names() []string {
+	names := make([]string, 0, len(c.Data))
+	for _, v := range c.Data {
+		names = append(names, columnName(v))
+	}
+	return names
+}
+
+// Column returns nested column by name, without name wrapper.
+func (c ColNested) Column(name string) (Column, bool) {
+	for _, v := range c.Data {
+		if columnName(v) == name {
+			return unnamed(v), true
+		}
+	}
+	return nil, false
+}
+
+// rowRange returns [start, end) range of i-th row in Data.
+func (c ColNested) rowRange(i int) (start, end int) {
+	if i > 0 {
+		start = int(c.Offsets[i-1])
+	}
+	return start, int(c.Offsets[i])
+}
+
+// RowLen returns i-th row elements count.
+func (c ColNested) RowLen(i int) int {
+	start, end := c.rowRange(i)
+	return end - start
+}
+
+// Row returns i-th row as slice of tuples, ordered as Data.
+func (c ColNested) Row(i int) [][]any {
+	start, end := c.rowRange(i)
+	rows := make([][]any, 0, end-start)
+	for idx := start; idx < end; idx++ {
+		tuple := make([]any, 0, len(c.Data))
+		for _, v := range c.Data {
+			tuple = append(tuple, columnRow(unnamed(v), idx))
+		}
+		rows = append(rows, tuple)
+	}
+	return rows
+}
+
+// Append appends row, which should be slice of structs or slice of tuples,
+// i.e. []any with values ordered as Data.
+//
+// Struct fields are matched to columns by `ch` tag or by case-insensitive
+// name.
+//
+// Panics if v can't be appended.
+func (c *ColNested) Append(v any) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		panic(fmt.Sprintf("nested: %T is not slice", v))
+	}
+	for i := 0; i < rv.Len(); i++ {
+		c.appendElem(reflect.Indirect(rv.Index(i)))
+	}
+	c.Offsets.Append(lastOffset(c.Offsets) + uint64(rv.Len()))
+}
+
+func (c *ColNested) appendElem(e reflect.Value) {
+	if e.Kind() == reflect.Interface {
+		e = reflect.Indirect(e.Elem())
+	}
+	switch e.Kind() {
+	case reflect.Struct:
+		for _, col := range c.Data {
+			f, ok := nestedField(e, columnName(col))
+			if !ok {
+				panic(fmt.Sprintf("nested: no field for %q in %s", columnName(col), e.Type()))
+			}
+			columnAppend(unnamed(col), f.Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		if e.Len() != len(c.Data) {
+			panic(fmt.Sprintf("nested: got tuple of %d elements, expected %d", e.Len(), len(c.Data)))
+		}
+		for j, col := range c.Data {
+			columnAppend(unnamed(col), e.Index(j).Interface())
+		}
+	default:
+		panic(fmt.Sprintf("nested: unexpected element %s", e.Type()))
+	}
+}
+
+// nestedField finds struct field for column name.
+func nestedField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("ch"); ok {
+			if tag == name {
+				return v.Field(i), true
+			}
+			continue
+		}
+		if strings.EqualFold(f.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// lastOffset returns last offset, i.e. total elements count.
+func lastOffset(offsets ColUInt64) uint64 {
+	if len(offsets) == 0 {
+		return 0
+	}
+	return offsets[len(offsets)-1]
+}
+
+// Infer implements Inferable, creating named columns from type if they
+// are not set.
+func (c *ColNested) Infer(t ColumnType) error {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if len(typ.Names) != len(typ.Elems) {
+		return errors.Errorf("nested %q: elements should be named", t)
+	}
+	if len(c.Data) == 0 {
+		for i, e := range typ.Elems {
+			name := typ.Names[i]
+			if name == "" {
+				return errors.Errorf("nested [%d]: no name", i)
+			}
+			col := new(ColAuto)
+			if err := col.Infer(e.ColumnType()); err != nil {
+				return errors.Wrapf(err, "nested %q", name)
+			}
+			c.Data = append(c.Data, colNamedAny{Column: col.Data, name: name})
+		}
+		return nil
+	}
+	if len(typ.Elems) != len(c.Data) {
+		return errors.Errorf("got %d nested columns, expected %d", len(typ.Elems), len(c.Data))
+	}
+	for i, v := range c.Data {
+		if s, ok := unnamed(v).(Inferable); ok {
+			if err := s.Infer(typ.Elems[i].ColumnType()); err != nil {
+				return errors.Wrapf(err, "nested %q", columnName(v))
+			}
+		}
+	}
+	return nil
+}
+
+// Prepare implements Preparable, checking that all columns are named.
+func (c ColNested) Prepare() error {
+	for i, v := range c.Data {
+		if columnName(v) == "" {
+			return errors.Errorf("nested column [%d] has no name", i)
+		}
+	}
+	if err := c.Data.Prepare(); err != nil {
+		return errors.Wrap(err, "data")
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColNested) DecodeState(r *Reader) error {
+	return c.Data.DecodeState(r)
+}
+
+// EncodeState implements StateEncoder.
+func (c ColNested) EncodeState(b *Buffer) {
+	c.Data.EncodeState(b)
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColNested) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "nested size")
+	}
+	if err := c.Data.DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "data")
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColNested) Reset() {
+	c.Offsets.Reset()
+	c.Data.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColNested) EncodeColumn(b *Buffer) {
+	c.Offsets.EncodeColumn(b)
+	c.Data.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColNested) WriteColumn(w *Writer) {
+	c.Offsets.WriteColumn(w)
+	c.Data.WriteColumn(w)
+}
+
+// Flat returns flattened name.name_i Array(T_i) columns of c, which share
+// c.Offsets.
+func (c *ColNested) Flat() []Column {
+	columns := make([]Column, 0, len(c.Data))
+	for i := range c.Data {
+		columns = append(columns, &colNestedFlat{nested: c, idx: i})
+	}
+	return columns
+}
+
+// Results returns flattened result columns, as sent with flatten_nested=1.
+func (c *ColNested) Results(name string) Results {
+	var results Results
+	for i, col := range c.Flat() {
+		results = append(results, ResultColumn{
+			Name: name + "." + columnName(c.Data[i]),
+			Data: col,
+		})
+	}
+	return results
+}
+
+// Input returns flattened input columns, as expected with flatten_nested=1.
+func (c *ColNested) Input(name string) Input {
+	var input Input
+	for i, col := range c.Flat() {
+		input = append(input, InputColumn{
+			Name: name + "." + columnName(c.Data[i]),
+			Data: col,
+		})
+	}
+	return input
+}
+
+// colNestedFlat is idx-th column of ColNested as Array(T).
+type colNestedFlat struct {
+	nested *ColNested
+	idx    int
+}
+
+func (c colNestedFlat) data() Column { return unnamed(c.nested.Data[c.idx]) }
+
+func (c colNestedFlat) Type() ColumnType {
+	return ColumnTypeArray.Sub(c.data().Type())
+}
+
+func (c colNestedFlat) Rows() int { return c.nested.Rows() }
+
+func (c colNestedFlat) Infer(t ColumnType) error {
+	if v, ok := c.data().(Inferable); ok {
+		return v.Infer(t.Elem())
+	}
+	return nil
+}
+
+func (c colNestedFlat) Prepare() error {
+	if v, ok := c.data().(Preparable); ok {
+		return v.Prepare()
+	}
+	return nil
+}
+
+func (c colNestedFlat) DecodeState(r *Reader) error {
+	if v, ok := c.data().(StateDecoder); ok {
+		return v.DecodeState(r)
+	}
+	return nil
+}
+
+func (c colNestedFlat) EncodeState(b *Buffer) {
+	if v, ok := c.data().(StateEncoder); ok {
+		v.EncodeState(b)
+	}
+}
+
+// DecodeColumn decodes offsets to shared column, so offsets of last
+// decoded column are used; they are equal for all columns.
+func (c colNestedFlat) DecodeColumn(r *Reader, rows int) error {
+	c.nested.Offsets.Reset()
+	if err := c.nested.Offsets.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	size := int(lastOffset(c.nested.Offsets))
+	if err := checkRows(size); err != nil {
+		return errors.Wrap(err, "array size")
+	}
+	if err := c.data().DecodeColumn(r, size); err != nil {
+		return errors.Wrap(err, "data")
+	}
+	return nil
+}
+
+func (c colNestedFlat) Reset() {
+	c.nested.Offsets.Reset()
+	c.data().Reset()
+}
+
+func (c colNestedFlat) EncodeColumn(b *Buffer) {
+	c.nested.Offsets.EncodeColumn(b)
+	c.data().EncodeColumn(b)
+}
+
+func (c colNestedFlat) WriteColumn(w *Writer) {
+	c.nested.Offsets.WriteColumn(w)
+	c.data().WriteColumn(w)
+}
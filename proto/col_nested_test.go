@@ -0,0 +1,111 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+type nestedElem struct {
+	Name  string `ch:"a"`
+	Value int64  `ch:"b"`
+}
+
+func newTestNested() *ColNested {
+	return NewNested(
+		Named[string](new(ColStr), "a"),
+		Named[int64](new(ColInt64), "b"),
+	)
+}
+
+func TestColNested(t *testing.T) {
+	t.Parallel()
+	data := newTestNested()
+	data.Append([]nestedElem{{Name: "foo", Value: 1}, {Name: "bar", Value: 2}})
+	data.Append([]nestedElem{})
+	data.Append([][]any{{"baz", int64(3)}})
+	data.Append([]*nestedElem{{Name: "qux", Value: 4}})
+	const rows = 4
+
+	require.NoError(t, data.Prepare())
+	require.Equal(t, ColumnType("Nested(a String, b Int64)"), data.Type())
+	require.Equal(t, []string{"a", "b"}, data.Names())
+	require.Equal(t, rows, data.Rows())
+	require.Equal(t, [][]any{{"foo", int64(1)}, {"bar", int64(2)}}, data.Row(0))
+	require.Equal(t, 0, data.RowLen(1))
+	require.Equal(t, [][]any{{"baz", int64(3)}}, data.Row(2))
+
+	var buf Buffer
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_nested_str_int64")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer("Nested(a String, b Int64)"))
+		v := dec.Data.(*ColNested)
+		require.NoError(t, v.DecodeColumn(r, rows))
+		require.Equal(t, data.Offsets, v.Offsets)
+		for i := 0; i < rows; i++ {
+			require.Equal(t, data.Row(i), v.Row(i))
+		}
+		b, ok := v.Column("b")
+		require.True(t, ok)
+		require.Equal(t, &ColInt64{1, 2, 3, 4}, b)
+		v.Reset()
+		require.Equal(t, 0, v.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		require.ErrorIs(t, newTestNested().DecodeColumn(r, rows), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		requireNoShortRead(t, buf.Buf, colAware(newTestNested(), rows))
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+	t.Run("Flat", func(t *testing.T) {
+		var b Buffer
+		input := data.Input("n")
+		require.Equal(t, "(\"n.a\",\"n.b\")", input.Columns())
+		for _, c := range input {
+			require.Equal(t, rows, c.Data.Rows())
+			c.Data.EncodeColumn(&b)
+		}
+		// Same as separate arrays.
+		var expected Buffer
+		a := NewArray[string](new(ColStr))
+		a.AppendArr([][]string{{"foo", "bar"}, {}, {"baz"}, {"qux"}})
+		a.EncodeColumn(&expected)
+		require.Equal(t, ColumnType("Array(String)"), input[0].Data.Type())
+		require.Equal(t, expected.Buf, b.Buf[:len(expected.Buf)])
+
+		dec := newTestNested()
+		results := dec.Results("n")
+		r := b.Reader()
+		for _, c := range results {
+			c.Data.Reset()
+			require.NoError(t, c.Data.DecodeColumn(r, rows))
+		}
+		require.Equal(t, "n.b", results[1].Name)
+		for i := 0; i < rows; i++ {
+			require.Equal(t, data.Row(i), dec.Row(i))
+		}
+	})
+}
+
+func TestColNested_Prepare(t *testing.T) {
+	require.Error(t, NewNested(new(ColStr)).Prepare(), "should be named")
+}
+
+func TestColNested_Append(t *testing.T) {
+	v := newTestNested()
+	require.Panics(t, func() { v.Append(1) })
+	require.Panics(t, func() { v.Append([][]any{{"foo"}}) })
+	require.Panics(t, func() { v.Append([]struct{ A string }{{A: "foo"}}) })
+}
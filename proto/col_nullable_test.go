@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,6 +17,13 @@ func TestNullable(t *testing.T) {
 	require.Equal(t, NewNullable("bar").Or("foo"), "bar")
 }
 
+func TestColNullable_Infer(t *testing.T) {
+	col := NewColNullable[time.Time](new(ColDateTime64))
+	require.NoError(t, col.Infer("Nullable(DateTime64(3, 'UTC'))"))
+	require.Equal(t, ColumnType("Nullable(DateTime64(3, 'UTC'))"), col.Type())
+	require.NoError(t, new(ColStr).Nullable().Infer("Nullable(String)"))
+}
+
 func TestColNullable_EncodeColumn(t *testing.T) {
 	const rows = 10
 	data := new(ColStr).Nullable()
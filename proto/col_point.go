@@ -8,10 +8,11 @@ type Point struct {
 
 // Compile-time assertions for ColPoint.
 var (
-	_ ColInput        = ColPoint{}
-	_ ColResult       = (*ColPoint)(nil)
-	_ Column          = (*ColPoint)(nil)
-	_ ColumnOf[Point] = (*ColPoint)(nil)
+	_ ColInput         = ColPoint{}
+	_ ColResult        = (*ColPoint)(nil)
+	_ Column           = (*ColPoint)(nil)
+	_ ColumnOf[Point]  = (*ColPoint)(nil)
+	_ Arrayable[Point] = (*ColPoint)(nil)
 )
 
 type ColPoint struct {
@@ -36,6 +37,13 @@ func (c ColPoint) Row(i int) Point {
 	}
 }
 
+// Array is helper that creates Array(Point).
+func (c *ColPoint) Array() *ColArr[Point] {
+	return &ColArr[Point]{
+		Data: c,
+	}
+}
+
 func (c ColPoint) Type() ColumnType { return ColumnTypePoint }
 func (c ColPoint) Rows() int        { return c.X.Rows() }
 
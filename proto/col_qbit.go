@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math"
 	"strconv"
-	"strings"
 
 	"github.com/go-faster/errors"
 )
@@ -92,26 +91,23 @@ func qbitBitWidth(elementType ColumnType) (int, error) {
 
 // ParseQBitType parses a QBit type string like "QBit(Float32, 1024)".
 func ParseQBitType(t ColumnType) (elementType ColumnType, dimension int, err error) {
-	base := t.Base()
-	if base != "QBit" {
+	typ, err := ParseType(t)
+	if err != nil {
+		return "", 0, err
+	}
+	if typ.Name != ColumnTypeQBit {
 		return "", 0, fmt.Errorf("not a QBit type: %s", t)
 	}
-
-	elem := string(t.Elem())
-	parts := strings.Split(elem, ",")
-	if len(parts) != 2 {
+	if len(typ.Params) != 2 || typ.Params[0].Kind != TypeParamType || typ.Params[1].Kind != TypeParamNumber {
 		return "", 0, fmt.Errorf("invalid QBit format (expected 2 parameters): %s", t)
 	}
 
-	elementTypeStr := strings.TrimSpace(parts[0])
-	dimensionStr := strings.TrimSpace(parts[1])
-
-	elementType = ColumnType(elementTypeStr)
+	elementType = typ.Params[0].Type.ColumnType()
 	if elementType != ColumnTypeBFloat16 && elementType != ColumnTypeFloat32 && elementType != ColumnTypeFloat64 {
 		return "", 0, fmt.Errorf("invalid QBit element type: %s", elementType)
 	}
 
-	dimension, err = strconv.Atoi(dimensionStr)
+	dimension, err = strconv.Atoi(typ.Params[1].Value)
 	if err != nil {
 		return "", 0, fmt.Errorf("invalid QBit dimension: %s", err)
 	}
@@ -0,0 +1,127 @@
+package proto
+
+import (
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColSimpleAggregateFunction.
+var (
+	_ ColInput     = (*ColSimpleAggregateFunction)(nil)
+	_ ColResult    = (*ColSimpleAggregateFunction)(nil)
+	_ Column       = (*ColSimpleAggregateFunction)(nil)
+	_ StateEncoder = (*ColSimpleAggregateFunction)(nil)
+	_ StateDecoder = (*ColSimpleAggregateFunction)(nil)
+	_ Inferable    = (*ColSimpleAggregateFunction)(nil)
+	_ Preparable   = (*ColSimpleAggregateFunction)(nil)
+)
+
+// ColSimpleAggregateFunction is SimpleAggregateFunction(f, T) column.
+//
+// Values of SimpleAggregateFunction are values of T, so column has same
+// layout as T and delegates to Data, which holds values.
+type ColSimpleAggregateFunction struct {
+	Function string // e.g. max, sum or anyLast
+	Data     Column // of type T
+}
+
+// NewSimpleAggregateFunction returns SimpleAggregateFunction(f, T) column
+// for data of type T.
+func NewSimpleAggregateFunction(f string, data Column) *ColSimpleAggregateFunction {
+	return &ColSimpleAggregateFunction{
+		Function: f,
+		Data:     data,
+	}
+}
+
+// parseSimpleAggregateFunction returns function and type of values of
+// SimpleAggregateFunction(f, T).
+func parseSimpleAggregateFunction(t ColumnType) (string, ColumnType, error) {
+	typ, err := ParseType(t)
+	if err != nil {
+		return "", "", err
+	}
+	if typ.Name != ColumnTypeSimpleAggregateFunction {
+		return "", "", errors.Errorf("%s is not simple aggregate function", t)
+	}
+	if len(typ.Elems) != 2 || len(typ.Params) != 2 {
+		return "", "", errors.Errorf("got %d parameters, expected 2", len(typ.Params))
+	}
+	return typ.Elems[0].String(), typ.Elems[1].ColumnType(), nil
+}
+
+// Type returns SimpleAggregateFunction(f, T).
+func (c ColSimpleAggregateFunction) Type() ColumnType {
+	return ColumnTypeSimpleAggregateFunction.With(c.Function, c.Data.Type().String())
+}
+
+// Rows returns rows count.
+func (c ColSimpleAggregateFunction) Rows() int {
+	return c.Data.Rows()
+}
+
+// Infer implements Inferable, initializing Data from type if it is not
+// set.
+func (c *ColSimpleAggregateFunction) Infer(t ColumnType) error {
+	f, typ, err := parseSimpleAggregateFunction(t)
+	if err != nil {
+		return err
+	}
+	c.Function = f
+	if c.Data == nil {
+		v := new(ColAuto)
+		if err := v.Infer(typ); err != nil {
+			return errors.Wrap(err, "data")
+		}
+		c.Data = v.Data
+		return nil
+	}
+	if v, ok := c.Data.(Inferable); ok {
+		if err := v.Infer(typ); err != nil {
+			return errors.Wrap(err, "data")
+		}
+	}
+	return nil
+}
+
+// Prepare implements Preparable.
+func (c ColSimpleAggregateFunction) Prepare() error {
+	if v, ok := c.Data.(Preparable); ok {
+		return v.Prepare()
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColSimpleAggregateFunction) DecodeState(r *Reader) error {
+	if v, ok := c.Data.(StateDecoder); ok {
+		return v.DecodeState(r)
+	}
+	return nil
+}
+
+// EncodeState implements StateEncoder.
+func (c ColSimpleAggregateFunction) EncodeState(b *Buffer) {
+	if v, ok := c.Data.(StateEncoder); ok {
+		v.EncodeState(b)
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColSimpleAggregateFunction) DecodeColumn(r *Reader, rows int) error {
+	return c.Data.DecodeColumn(r, rows)
+}
+
+// Reset implements ColResult.
+func (c *ColSimpleAggregateFunction) Reset() {
+	c.Data.Reset()
+}
+
+// EncodeColumn implements ColInput.
+func (c ColSimpleAggregateFunction) EncodeColumn(b *Buffer) {
+	c.Data.EncodeColumn(b)
+}
+
+// WriteColumn implements ColInput.
+func (c ColSimpleAggregateFunction) WriteColumn(w *Writer) {
+	c.Data.WriteColumn(w)
+}
@@ -0,0 +1,77 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestColSimpleAggregateFunction(t *testing.T) {
+	t.Parallel()
+	const rows = 50
+	var values ColUInt64
+	for i := 0; i < rows; i++ {
+		values.Append(uint64(i))
+	}
+	data := NewSimpleAggregateFunction("max", &values)
+	require.Equal(t, ColumnType("SimpleAggregateFunction(max, UInt64)"), data.Type())
+	require.Equal(t, rows, data.Rows())
+
+	var buf Buffer
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_simple_aggregate_function_max_uint64")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer(data.Type()))
+		require.NoError(t, dec.Data.DecodeColumn(r, rows))
+		require.Equal(t, data.Type(), dec.Data.Type())
+		require.Equal(t, &values, dec.Data.(*ColSimpleAggregateFunction).Data)
+		dec.Data.Reset()
+		require.Equal(t, 0, dec.Data.Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		dec := NewSimpleAggregateFunction("max", new(ColUInt64))
+		require.ErrorIs(t, dec.DecodeColumn(r, rows), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		dec := NewSimpleAggregateFunction("max", new(ColUInt64))
+		requireNoShortRead(t, buf.Buf, colAware(dec, rows))
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+	t.Run("Infer", func(t *testing.T) {
+		dec := NewSimpleAggregateFunction("", new(ColDateTime))
+		require.NoError(t, dec.Infer("SimpleAggregateFunction(anyLast, DateTime('UTC'))"))
+		require.Equal(t, "anyLast", dec.Function)
+		require.Equal(t, ColumnType("SimpleAggregateFunction(anyLast, DateTime('UTC'))"), dec.Type())
+		require.Error(t, dec.Infer("SimpleAggregateFunction(max)"))
+		require.Error(t, dec.Infer("UInt64"))
+	})
+	t.Run("Results", func(t *testing.T) {
+		// Plain column can be used for SimpleAggregateFunction results.
+		var b Buffer
+		block := Block{Columns: 1, Rows: rows}
+		require.NoError(t, block.EncodeRawBlock(&b, Version, []InputColumn{
+			{Name: "v", Data: data},
+		}))
+		var (
+			dec     ColUInt64
+			results = Results{{Name: "v", Data: &dec}}
+		)
+		r := b.Reader()
+		_, err := r.Int()
+		require.NoError(t, err)
+		_, err = r.Int()
+		require.NoError(t, err)
+		require.NoError(t, results.DecodeResult(r, Version, block))
+		require.Equal(t, values, dec)
+	})
+}
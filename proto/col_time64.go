@@ -3,7 +3,6 @@ package proto
 import (
 	"encoding/binary"
 	"fmt"
-	"strconv"
 
 	"github.com/go-faster/errors"
 )
@@ -33,22 +32,15 @@ func (c ColTime64) Type() ColumnType {
 }
 
 func (c *ColTime64) Infer(t ColumnType) error {
-	elem := t.Elem()
-	if elem == "" {
-		c.Precision = PrecisionNano
-		return nil
-	}
-
-	precision, err := strconv.Atoi(string(elem))
+	typ, err := ParseType(t)
 	if err != nil {
-		return errors.Wrap(err, "parse precision")
+		return errors.Wrap(err, "parse type")
 	}
-
-	if precision < 0 || precision > 9 {
-		return errors.New("precision must be between 0 and 9")
+	if len(typ.Params) == 0 {
+		c.Precision = PrecisionNano
+		return nil
 	}
-
-	c.Precision = Precision(precision)
+	c.Precision = Precision(typ.Precision)
 	return nil
 }
 
@@ -92,7 +92,7 @@ func (c ColNamed[T]) ColumnName() string {
 }
 
 func (c ColNamed[T]) Type() ColumnType {
-	return ColumnType(c.Name + " " + c.ColumnOf.Type().String())
+	return ColumnType(quoteTypeName(c.Name) + " " + c.ColumnOf.Type().String())
 }
 
 func (c ColTuple) Prepare() error {
@@ -107,10 +107,17 @@ func (c ColTuple) Prepare() error {
 }
 
 func (c ColTuple) Infer(t ColumnType) error {
-	for _, v := range c {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if len(typ.Elems) != len(c) {
+		return errors.Errorf("got %d tuple elements, expected %d", len(typ.Elems), len(c))
+	}
+	for i, v := range c {
 		if s, ok := v.(Inferable); ok {
-			if err := s.Infer(t); err != nil {
-				return errors.Wrap(err, "infer")
+			if err := s.Infer(typ.Elems[i].ColumnType()); err != nil {
+				return errors.Wrapf(err, "infer [%d]", i)
 			}
 		}
 	}
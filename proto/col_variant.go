@@ -0,0 +1,274 @@
+package proto
+
+import (
+	"github.com/go-faster/errors"
+)
+
+// Compile-time assertions for ColVariant.
+var (
+	_ ColInput     = (*ColVariant)(nil)
+	_ ColResult    = (*ColVariant)(nil)
+	_ Column       = (*ColVariant)(nil)
+	_ StateEncoder = (*ColVariant)(nil)
+	_ StateDecoder = (*ColVariant)(nil)
+	_ Inferable    = (*ColVariant)(nil)
+	_ Preparable   = (*ColVariant)(nil)
+)
+
+// VariantNull is discriminator of NULL value in Variant column.
+const VariantNull uint8 = 255
+
+// Variant discriminators serialization modes.
+//
+// Only basic mode is used in Native format.
+const (
+	variantDiscriminatorsBasic   uint64 = 0
+	variantDiscriminatorsCompact uint64 = 1
+)
+
+// maxVariantTypes is maximum number of Variant alternatives, because
+// discriminator is UInt8 and VariantNull is reserved.
+const maxVariantTypes = int(VariantNull)
+
+// ColVariant is Variant(T1, T2, ...) column.
+//
+// Each row is either NULL or value of exactly one alternative, denoted by
+// discriminator. Alternatives store only rows that belong to them, so for
+// example ["foo", 1, NULL, "bar"] of Variant(String, UInt64) is encoded as:
+//
+//	Discriminators: [0, 1, 255, 0]
+//	Variants[0]:    ["foo", "bar"] (String)
+//	Variants[1]:    [1]            (UInt64)
+//
+// ClickHouse orders alternatives by type name, and discriminators refer to
+// that order, so Variants should be sorted accordingly.
+type ColVariant struct {
+	Discriminators ColUInt8
+	Variants       []Column
+
+	// offsets[i] is index of i-th row in its alternative column.
+	offsets []int
+}
+
+// NewVariant returns Variant column of provided alternatives.
+//
+// Alternatives must be sorted by type name, see ColVariant.
+func NewVariant(variants ...Column) *ColVariant {
+	return &ColVariant{
+		Variants: variants,
+	}
+}
+
+// Type returns Variant(T1, T2, ...).
+func (c ColVariant) Type() ColumnType {
+	types := make([]ColumnType, 0, len(c.Variants))
+	for _, v := range c.Variants {
+		types = append(types, v.Type())
+	}
+	return ColumnTypeVariant.Sub(types...)
+}
+
+// Rows returns rows count.
+func (c ColVariant) Rows() int {
+	return c.Discriminators.Rows()
+}
+
+// Discriminator returns discriminator of i-th row, which is index of
+// alternative in Variants or VariantNull.
+func (c ColVariant) Discriminator(i int) uint8 {
+	return c.Discriminators[i]
+}
+
+// IsNull reports whether i-th row is NULL.
+func (c ColVariant) IsNull(i int) bool {
+	return c.Discriminators[i] == VariantNull
+}
+
+// Offset returns index of i-th row in its alternative column.
+//
+// Result is undefined for NULL rows.
+func (c ColVariant) Offset(i int) int {
+	return c.offsets[i]
+}
+
+// RowType returns type of i-th row or ColumnTypeNothing for NULL.
+func (c ColVariant) RowType(i int) ColumnType {
+	d := c.Discriminators[i]
+	if d == VariantNull {
+		return ColumnTypeNothing
+	}
+	return c.Variants[d].Type()
+}
+
+// AppendNull appends NULL row.
+func (c *ColVariant) AppendNull() {
+	c.Discriminators.Append(VariantNull)
+	c.offsets = append(c.offsets, 0)
+}
+
+// appendDiscriminator records that last row of d-th alternative is new row.
+func (c *ColVariant) appendDiscriminator(d uint8) {
+	c.Discriminators.Append(d)
+	c.offsets = append(c.offsets, c.Variants[d].Rows()-1)
+}
+
+// VariantAppend appends v as value of d-th alternative of c.
+//
+// Panics if d-th alternative is not ColumnOf[T].
+func VariantAppend[T any](c *ColVariant, d int, v T) {
+	c.Variants[d].(ColumnOf[T]).Append(v)
+	c.appendDiscriminator(uint8(d))
+}
+
+// VariantRow returns i-th row of c if it is value of ColumnOf[T]
+// alternative. Reports false for NULL rows and other alternatives.
+func VariantRow[T any](c *ColVariant, i int) (T, bool) {
+	var zero T
+	d := c.Discriminators[i]
+	if d == VariantNull {
+		return zero, false
+	}
+	col, ok := c.Variants[d].(ColumnOf[T])
+	if !ok {
+		return zero, false
+	}
+	return col.Row(c.offsets[i]), true
+}
+
+// Infer ensures Inferable column propagation, initializing alternatives
+// from type if they are not set.
+func (c *ColVariant) Infer(t ColumnType) error {
+	typ, err := ParseType(t)
+	if err != nil {
+		return errors.Wrap(err, "parse type")
+	}
+	if len(typ.Elems) > maxVariantTypes {
+		return errors.Errorf("too many variant types: %d", len(typ.Elems))
+	}
+	if len(c.Variants) == 0 {
+		for i, e := range typ.Elems {
+			v := new(ColAuto)
+			if err := v.Infer(e.ColumnType()); err != nil {
+				return errors.Wrapf(err, "variant [%d]", i)
+			}
+			c.Variants = append(c.Variants, v.Data)
+		}
+		return nil
+	}
+	if len(typ.Elems) != len(c.Variants) {
+		return errors.Errorf("got %d variant types, expected %d", len(typ.Elems), len(c.Variants))
+	}
+	for i, v := range c.Variants {
+		if s, ok := v.(Inferable); ok {
+			if err := s.Infer(typ.Elems[i].ColumnType()); err != nil {
+				return errors.Wrapf(err, "variant [%d]", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Prepare ensures Preparable column propagation and checks alternatives
+// order.
+func (c ColVariant) Prepare() error {
+	if len(c.Variants) > maxVariantTypes {
+		return errors.Errorf("too many variant types: %d", len(c.Variants))
+	}
+	for i, v := range c.Variants {
+		if i > 0 && c.Variants[i-1].Type() >= v.Type() {
+			return errors.Errorf("variant [%d] %s should be sorted after %s",
+				i, v.Type(), c.Variants[i-1].Type(),
+			)
+		}
+		if s, ok := v.(Preparable); ok {
+			if err := s.Prepare(); err != nil {
+				return errors.Wrapf(err, "variant [%d]", i)
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColVariant) DecodeState(r *Reader) error {
+	mode, err := r.UInt64()
+	if err != nil {
+		return errors.Wrap(err, "discriminators mode")
+	}
+	switch mode {
+	case variantDiscriminatorsBasic:
+	case variantDiscriminatorsCompact:
+		return errors.New("compact discriminators serialization is not supported")
+	default:
+		return errors.Errorf("unknown discriminators mode %d", mode)
+	}
+	for i, v := range c.Variants {
+		if s, ok := v.(StateDecoder); ok {
+			if err := s.DecodeState(r); err != nil {
+				return errors.Wrapf(err, "variant [%d] state", i)
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeState implements StateEncoder.
+func (c ColVariant) EncodeState(b *Buffer) {
+	b.PutUInt64(variantDiscriminatorsBasic)
+	for _, v := range c.Variants {
+		if s, ok := v.(StateEncoder); ok {
+			s.EncodeState(b)
+		}
+	}
+}
+
+// DecodeColumn implements ColResult.
+func (c *ColVariant) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Discriminators.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "discriminators")
+	}
+	counts := make([]int, len(c.Variants))
+	c.offsets = c.offsets[:0]
+	for i, d := range c.Discriminators {
+		if d == VariantNull {
+			c.offsets = append(c.offsets, 0)
+			continue
+		}
+		if int(d) >= len(c.Variants) {
+			return errors.Errorf("[%d]: invalid discriminator %d", i, d)
+		}
+		c.offsets = append(c.offsets, counts[d])
+		counts[d]++
+	}
+	for i, v := range c.Variants {
+		if err := v.DecodeColumn(r, counts[i]); err != nil {
+			return errors.Wrapf(err, "variant [%d]", i)
+		}
+	}
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColVariant) Reset() {
+	c.Discriminators.Reset()
+	c.offsets = c.offsets[:0]
+	for _, v := range c.Variants {
+		v.Reset()
+	}
+}
+
+// EncodeColumn implements ColInput.
+func (c ColVariant) EncodeColumn(b *Buffer) {
+	c.Discriminators.EncodeColumn(b)
+	for _, v := range c.Variants {
+		v.EncodeColumn(b)
+	}
+}
+
+// WriteColumn implements ColInput.
+func (c ColVariant) WriteColumn(w *Writer) {
+	c.Discriminators.WriteColumn(w)
+	for _, v := range c.Variants {
+		v.WriteColumn(w)
+	}
+}
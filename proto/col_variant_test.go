@@ -0,0 +1,97 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
+)
+
+func TestColVariant(t *testing.T) {
+	t.Parallel()
+	const rows = 50
+	data := NewVariant(new(ColStr), new(ColUInt64))
+	for i := 0; i < rows; i++ {
+		switch i % 3 {
+		case 0:
+			VariantAppend[string](data, 0, "foo")
+		case 1:
+			VariantAppend[uint64](data, 1, uint64(i))
+		default:
+			data.AppendNull()
+		}
+	}
+	require.NoError(t, data.Prepare())
+	require.Equal(t, ColumnType("Variant(String, UInt64)"), data.Type())
+	require.Equal(t, rows, data.Rows())
+
+	var buf Buffer
+	data.EncodeState(&buf)
+	data.EncodeColumn(&buf)
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+		gold.Bytes(t, buf.Buf, "col_variant_str_uint64")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(buf.Buf))
+		dec := new(ColAuto)
+		require.NoError(t, dec.Infer("Variant(String, UInt64)"))
+		v := dec.Data.(*ColVariant)
+		require.NoError(t, v.DecodeState(r))
+		require.NoError(t, v.DecodeColumn(r, rows))
+		require.Equal(t, rows, v.Rows())
+		for i := 0; i < rows; i++ {
+			switch i % 3 {
+			case 0:
+				s, ok := VariantRow[string](v, i)
+				require.True(t, ok)
+				require.Equal(t, "foo", s)
+				require.Equal(t, ColumnTypeString, v.RowType(i))
+				_, ok = VariantRow[uint64](v, i)
+				require.False(t, ok)
+			case 1:
+				n, ok := VariantRow[uint64](v, i)
+				require.True(t, ok)
+				require.Equal(t, uint64(i), n)
+				require.Equal(t, ColumnTypeUInt64, v.RowType(i))
+			default:
+				require.True(t, v.IsNull(i))
+				require.Equal(t, ColumnTypeNothing, v.RowType(i))
+				_, ok := VariantRow[string](v, i)
+				require.False(t, ok)
+			}
+		}
+		v.Reset()
+		require.Equal(t, 0, v.Rows())
+		require.Equal(t, 0, v.Variants[0].Rows())
+	})
+	t.Run("EOF", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(nil))
+		dec := NewVariant(new(ColStr), new(ColUInt64))
+		require.ErrorIs(t, dec.DecodeColumn(r, rows), io.EOF)
+	})
+	t.Run("NoShortRead", func(t *testing.T) {
+		dec := NewVariant(new(ColStr), new(ColUInt64))
+		requireNoShortRead(t, buf.Buf[8:], colAware(dec, rows))
+	})
+	t.Run("WriteColumn", checkWriteColumn(data))
+	t.Run("InvalidDiscriminator", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{2}))
+		dec := NewVariant(new(ColStr), new(ColUInt64))
+		require.Error(t, dec.DecodeColumn(r, 1))
+	})
+	t.Run("CompactMode", func(t *testing.T) {
+		var b Buffer
+		b.PutUInt64(variantDiscriminatorsCompact)
+		dec := NewVariant(new(ColStr), new(ColUInt64))
+		require.Error(t, dec.DecodeState(b.Reader()))
+	})
+}
+
+func TestColVariant_Prepare(t *testing.T) {
+	require.NoError(t, NewVariant(new(ColStr), new(ColUInt64)).Prepare())
+	require.Error(t, NewVariant(new(ColUInt64), new(ColStr)).Prepare(), "should be sorted")
+}
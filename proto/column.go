@@ -2,7 +2,6 @@ package proto
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/go-faster/errors"
@@ -69,9 +68,15 @@ func (c ColumnType) String() string {
 	return string(c)
 }
 
+// Base returns type name without parameters, e.g. Array for
+// Array(String).
 func (c ColumnType) Base() ColumnType {
-	if c == "" {
-		return ""
+	if isPlainType(c) {
+		// Fast path for types without parameters.
+		return c
+	}
+	if t, err := ParseType(c); err == nil {
+		return t.Name
 	}
 	var (
 		v     = string(c)
@@ -84,79 +89,35 @@ func (c ColumnType) Base() ColumnType {
 	return c[:start]
 }
 
-// reduces Decimal(P, ...) to Decimal32/Decimal64/Decimal128/Decimal256
-// returns c if any errors occur during conversion
-func (c ColumnType) decimalDowncast() ColumnType {
-	if c.Base() != ColumnTypeDecimal {
-		return c
-	}
-	elem := c.Elem()
-	precStr, _, _ := strings.Cut(string(elem), ",")
-	precStr = strings.TrimSpace(precStr)
-	prec, err := strconv.Atoi(precStr)
-	if err != nil {
-		return c
-	}
-	switch {
-	case prec < 10:
-		return ColumnTypeDecimal32
-	case prec < 19:
-		return ColumnTypeDecimal64
-	case prec < 39:
-		return ColumnTypeDecimal128
-	case prec < 77:
-		return ColumnTypeDecimal256
-	default:
+// simpleAggregateElem returns T of SimpleAggregateFunction(f, T) or c for
+// other types.
+func (c ColumnType) simpleAggregateElem() ColumnType {
+	t, err := ParseType(c)
+	if err != nil || t.Name != ColumnTypeSimpleAggregateFunction {
 		return c
 	}
+	return t.simpleAggregateElem().ColumnType()
 }
 
 // Conflicts reports whether two types conflict.
+//
+// Types are compared structurally, so Map(String,String) does not conflict
+// with Map(String, String) and names of tuple elements are checked only if
+// both tuples are named. SimpleAggregateFunction(f, T) does not conflict
+// with T, and types that can't be parsed conflict unless they are equal.
 func (c ColumnType) Conflicts(b ColumnType) bool {
 	if c == b {
 		return false
 	}
-	cBase := c.Base()
-	bBase := b.Base()
-	if (cBase == ColumnTypeEnum8 && b == ColumnTypeInt8) ||
-		(cBase == ColumnTypeEnum16 && b == ColumnTypeInt16) ||
-		(bBase == ColumnTypeEnum8 && c == ColumnTypeInt8) ||
-		(bBase == ColumnTypeEnum16 && c == ColumnTypeInt16) {
-		return false
-	}
-	if cBase == ColumnTypeDecimal || bBase == ColumnTypeDecimal {
-		return c.decimalDowncast() != b.decimalDowncast()
-	}
-
-	if cBase != bBase {
+	ct, err := ParseType(c)
+	if err != nil {
 		return true
 	}
-	switch cBase {
-	case ColumnTypeEnum8, ColumnTypeEnum16:
-		return false
-	}
-
-	if c.normalizeCommas() == b.normalizeCommas() {
-		return false
-	}
-	switch cBase {
-	case ColumnTypeArray, ColumnTypeNullable, ColumnTypeLowCardinality:
-		return c.Elem().Conflicts(b.Elem())
-	case ColumnTypeDateTime, ColumnTypeDateTime64:
-		// TODO(ernado): improve check
-		return false
-	}
-	return true
-}
-
-func (c ColumnType) normalizeCommas() ColumnType {
-	// Should we check for escaped commas in enums here?
-	const sep = ","
-	var elems []string
-	for _, e := range strings.Split(string(c), sep) {
-		elems = append(elems, strings.TrimSpace(e))
+	bt, err := ParseType(b)
+	if err != nil {
+		return true
 	}
-	return ColumnType(strings.Join(elems, sep))
+	return ct.conflicts(bt)
 }
 
 // With returns ColumnType(p1, p2, ...) from ColumnType.
@@ -179,20 +140,21 @@ func (c ColumnType) Sub(subtypes ...ColumnType) ColumnType {
 	return c.With(params...)
 }
 
+// Elem returns parameters of type, e.g. Int16 for Array(Int16).
 func (c ColumnType) Elem() ColumnType {
-	if c == "" {
+	if isPlainType(c) {
+		// Fast path for types without parameters.
 		return ""
 	}
-	var (
-		v     = string(c)
-		start = strings.IndexByte(v, '(')
-		end   = strings.LastIndexByte(v, ')')
-	)
-	if start <= 0 || end <= 0 || end < start {
-		// No element.
+	t, err := ParseType(c)
+	if err != nil || len(t.Params) == 0 {
 		return ""
 	}
-	return c[start+1 : end]
+	var params []string
+	for _, p := range t.Params {
+		params = append(params, p.String())
+	}
+	return ColumnType(strings.Join(params, ", "))
 }
 
 // IsArray reports whether ColumnType is composite.
@@ -210,51 +172,61 @@ func (c ColumnType) Array() ColumnType {
 //
 // For example: Array(Int8) or even Array(Array(String)).
 const (
-	ColumnTypeNone           ColumnType = ""
-	ColumnTypeInt8           ColumnType = "Int8"
-	ColumnTypeInt16          ColumnType = "Int16"
-	ColumnTypeInt32          ColumnType = "Int32"
-	ColumnTypeInt64          ColumnType = "Int64"
-	ColumnTypeInt128         ColumnType = "Int128"
-	ColumnTypeInt256         ColumnType = "Int256"
-	ColumnTypeUInt8          ColumnType = "UInt8"
-	ColumnTypeUInt16         ColumnType = "UInt16"
-	ColumnTypeUInt32         ColumnType = "UInt32"
-	ColumnTypeUInt64         ColumnType = "UInt64"
-	ColumnTypeUInt128        ColumnType = "UInt128"
-	ColumnTypeUInt256        ColumnType = "UInt256"
-	ColumnTypeFloat32        ColumnType = "Float32"
-	ColumnTypeFloat64        ColumnType = "Float64"
-	ColumnTypeBFloat16       ColumnType = "BFloat16"
-	ColumnTypeString         ColumnType = "String"
-	ColumnTypeFixedString    ColumnType = "FixedString"
-	ColumnTypeArray          ColumnType = "Array"
-	ColumnTypeIPv4           ColumnType = "IPv4"
-	ColumnTypeIPv6           ColumnType = "IPv6"
-	ColumnTypeDateTime       ColumnType = "DateTime"
-	ColumnTypeDateTime64     ColumnType = "DateTime64"
-	ColumnTypeTime32         ColumnType = "Time32"
-	ColumnTypeTime64         ColumnType = "Time64"
-	ColumnTypeDate           ColumnType = "Date"
-	ColumnTypeDate32         ColumnType = "Date32"
-	ColumnTypeUUID           ColumnType = "UUID"
-	ColumnTypeEnum8          ColumnType = "Enum8"
-	ColumnTypeEnum16         ColumnType = "Enum16"
-	ColumnTypeLowCardinality ColumnType = "LowCardinality"
-	ColumnTypeMap            ColumnType = "Map"
-	ColumnTypeBool           ColumnType = "Bool"
-	ColumnTypeTuple          ColumnType = "Tuple"
-	ColumnTypeNullable       ColumnType = "Nullable"
-	ColumnTypeDecimal        ColumnType = "Decimal"
-	ColumnTypeDecimal32      ColumnType = "Decimal32"
-	ColumnTypeDecimal64      ColumnType = "Decimal64"
-	ColumnTypeDecimal128     ColumnType = "Decimal128"
-	ColumnTypeDecimal256     ColumnType = "Decimal256"
-	ColumnTypePoint          ColumnType = "Point"
-	ColumnTypeInterval       ColumnType = "Interval"
-	ColumnTypeNothing        ColumnType = "Nothing"
-	ColumnTypeJSON           ColumnType = "JSON"
-	ColumnTypeQBit           ColumnType = "QBit"
+	ColumnTypeNone                    ColumnType = ""
+	ColumnTypeInt8                    ColumnType = "Int8"
+	ColumnTypeInt16                   ColumnType = "Int16"
+	ColumnTypeInt32                   ColumnType = "Int32"
+	ColumnTypeInt64                   ColumnType = "Int64"
+	ColumnTypeInt128                  ColumnType = "Int128"
+	ColumnTypeInt256                  ColumnType = "Int256"
+	ColumnTypeUInt8                   ColumnType = "UInt8"
+	ColumnTypeUInt16                  ColumnType = "UInt16"
+	ColumnTypeUInt32                  ColumnType = "UInt32"
+	ColumnTypeUInt64                  ColumnType = "UInt64"
+	ColumnTypeUInt128                 ColumnType = "UInt128"
+	ColumnTypeUInt256                 ColumnType = "UInt256"
+	ColumnTypeFloat32                 ColumnType = "Float32"
+	ColumnTypeFloat64                 ColumnType = "Float64"
+	ColumnTypeBFloat16                ColumnType = "BFloat16"
+	ColumnTypeString                  ColumnType = "String"
+	ColumnTypeFixedString             ColumnType = "FixedString"
+	ColumnTypeArray                   ColumnType = "Array"
+	ColumnTypeIPv4                    ColumnType = "IPv4"
+	ColumnTypeIPv6                    ColumnType = "IPv6"
+	ColumnTypeDateTime                ColumnType = "DateTime"
+	ColumnTypeDateTime64              ColumnType = "DateTime64"
+	ColumnTypeTime32                  ColumnType = "Time32"
+	ColumnTypeTime64                  ColumnType = "Time64"
+	ColumnTypeDate                    ColumnType = "Date"
+	ColumnTypeDate32                  ColumnType = "Date32"
+	ColumnTypeUUID                    ColumnType = "UUID"
+	ColumnTypeEnum8                   ColumnType = "Enum8"
+	ColumnTypeEnum16                  ColumnType = "Enum16"
+	ColumnTypeLowCardinality          ColumnType = "LowCardinality"
+	ColumnTypeMap                     ColumnType = "Map"
+	ColumnTypeBool                    ColumnType = "Bool"
+	ColumnTypeTuple                   ColumnType = "Tuple"
+	ColumnTypeNullable                ColumnType = "Nullable"
+	ColumnTypeDecimal                 ColumnType = "Decimal"
+	ColumnTypeDecimal32               ColumnType = "Decimal32"
+	ColumnTypeDecimal64               ColumnType = "Decimal64"
+	ColumnTypeDecimal128              ColumnType = "Decimal128"
+	ColumnTypeDecimal256              ColumnType = "Decimal256"
+	ColumnTypePoint                   ColumnType = "Point"
+	ColumnTypeRing                    ColumnType = "Ring"
+	ColumnTypePolygon                 ColumnType = "Polygon"
+	ColumnTypeMultiPolygon            ColumnType = "MultiPolygon"
+	ColumnTypeLineString              ColumnType = "LineString"
+	ColumnTypeMultiLineString         ColumnType = "MultiLineString"
+	ColumnTypeInterval                ColumnType = "Interval"
+	ColumnTypeNothing                 ColumnType = "Nothing"
+	ColumnTypeJSON                    ColumnType = "JSON"
+	ColumnTypeQBit                    ColumnType = "QBit"
+	ColumnTypeVariant                 ColumnType = "Variant"
+	ColumnTypeDynamic                 ColumnType = "Dynamic"
+	ColumnTypeNested                  ColumnType = "Nested"
+	ColumnTypeAggregateFunction       ColumnType = "AggregateFunction"
+	ColumnTypeSimpleAggregateFunction ColumnType = "SimpleAggregateFunction"
 )
 
 // colWrap wraps Column with type t.
@@ -314,14 +286,8 @@ func (s *ColInfoInput) DecodeResult(r *Reader, version int, b Block) error {
 		if err != nil {
 			return errors.Wrapf(err, "column [%d] type", i)
 		}
-		if FeatureCustomSerialization.In(version) {
-			customSerialization, err := r.Bool()
-			if err != nil {
-				return errors.Wrapf(err, "column [%d] custom serialization", i)
-			}
-			if customSerialization {
-				return errors.Wrapf(err, "column [%d] has custom serialization (not supported)", i)
-			}
+		if _, err := decodeColumnHeader(r, version, ColumnType(columnTypeRaw)); err != nil {
+			return errors.Wrapf(err, "column [%d]", i)
 		}
 		*s = append(*s, ColInfo{
 			Name: columnName,
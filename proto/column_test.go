@@ -64,6 +64,15 @@ func TestColumnType_Elem(t *testing.T) {
 				{A: "Enum8", B: "Enum8('increment' = 1, 'gauge' = 2)"},
 				{A: "Decimal256", B: "Decimal(76, 38)"},
 				{A: "Nullable(Decimal256)", B: "Nullable(Decimal(76, 38))"},
+				{A: "SimpleAggregateFunction(max, UInt64)", B: "UInt64"},
+				{A: "SimpleAggregateFunction(anyLast, DateTime('UTC'))", B: "DateTime"},
+				{A: "SimpleAggregateFunction(sum, Map(String, UInt64))", B: "Map(String,UInt64)"},
+				{A: "Tuple(a String, b Int64)", B: "Tuple(String, Int64)"},
+				{A: "Enum8('a,b' = 1)", B: "Enum8('c' = 1)"},
+				{A: "DateTime64(3, 'Europe/Moscow')", B: "DateTime64(3)"},
+				{A: "BIGINT", B: "Int64"},
+				{A: "Array(VARCHAR(255))", B: "Array(String)"},
+				{A: "Decimal32(2)", B: "Decimal(9, 2)"},
 			} {
 				assert.False(t, tt.A.Conflicts(tt.B),
 					"%s ~ %s", tt.A, tt.B,
@@ -83,6 +92,12 @@ func TestColumnType_Elem(t *testing.T) {
 				{A: ColumnTypeArray.Sub(ColumnTypeInt32), B: ColumnTypeArray.Sub(ColumnTypeInt64)},
 				{A: "Map(String,String)", B: "Map(String,Int32)"},
 				{A: "Enum16('increment' = 1, 'gauge' = 2)", B: "Int8"},
+				{A: "SimpleAggregateFunction(max, UInt64)", B: "UInt32"},
+				{A: "Tuple(a String, b Int64)", B: "Tuple(a String, c Int64)"},
+				{A: "Decimal(10, 2)", B: "Decimal(10, 3)"},
+				{A: "Decimal32(2)", B: "Decimal64(2)"},
+				{A: "AggregateFunction(quantiles(0.5), UInt64)", B: "AggregateFunction(quantiles(0.9), UInt64)"},
+				{A: "Array(", B: "Array(String)"},
 			} {
 				assert.True(t, tt.A.Conflicts(tt.B),
 					"%s !~ %s", tt.A, tt.B,
@@ -94,3 +109,18 @@ func TestColumnType_Elem(t *testing.T) {
 		})
 	})
 }
+
+func BenchmarkColumnType_Base(b *testing.B) {
+	for _, v := range []ColumnType{
+		ColumnTypeUInt64,
+		ColumnTypeUInt64.Array(),
+	} {
+		b.Run(v.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = v.Base()
+				_ = v.Elem()
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package proto
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/compress"
+)
+
+// NativeVersion is protocol version of blocks in Native format, as written
+// by clickhouse-local or "FORMAT Native" query.
+//
+// Note that proto.Version can't be used, as it enables custom serialization
+// that is not present in Native format.
+const NativeVersion = 54451
+
+// NativeWriter writes blocks of Native format to io.Writer.
+type NativeWriter struct {
+	dst    io.Writer
+	w      *Writer
+	frames *compress.StreamWriter
+}
+
+// NewNativeWriter returns new NativeWriter that writes to w.
+func NewNativeWriter(w io.Writer) *NativeWriter {
+	n := &NativeWriter{dst: w}
+	n.setWriter(w)
+	return n
+}
+
+func (w *NativeWriter) setWriter(dst io.Writer) {
+	w.w = NewWriter(dst, new(Buffer))
+	// Flushing large blocks between columns to bound memory usage.
+	w.w.SetFlushSize(compress.DefaultFrameSize)
+}
+
+// WithCompression makes writer compress blocks with method m and level l
+// to frames of at most compress.DefaultFrameSize bytes of uncompressed
+// data, like clickhouse-compressor does, so blocks of any size can be
+// written with bounded memory.
+//
+// Such stream can be read by NativeReader with compression enabled.
+func (w *NativeWriter) WithCompression(m compress.Method, l compress.Level) *NativeWriter {
+	w.frames = compress.NewStreamWriter(w.dst, compress.NewWriter(l, m), compress.DefaultFrameSize)
+	w.setWriter(w.frames)
+	return w
+}
+
+// WriteBlock writes columns of input as single block.
+//
+// All columns should have same number of rows. Large block can be written
+// partially if it can't be encoded, e.g. if column can't be prepared.
+func (w *NativeWriter) WriteBlock(input Input) error {
+	b := Block{Columns: len(input)}
+	if len(input) > 0 {
+		b.Rows = input[0].Data.Rows()
+	}
+	if w.frames != nil {
+		w.frames.Reset(w.dst)
+	}
+	if err := b.WriteRawBlock(w.w, NativeVersion, input); err != nil {
+		// Discarding rest of block.
+		if w.frames != nil {
+			w.frames.Reset(io.Discard)
+		}
+		w.w.reset()
+		return errors.Wrap(err, "encode")
+	}
+	if _, err := w.w.Flush(); err != nil {
+		return errors.Wrap(err, "write")
+	}
+	if w.frames != nil {
+		if err := w.frames.Flush(); err != nil {
+			return errors.Wrap(err, "flush frame")
+		}
+	}
+	return nil
+}
+
+// NativeReader reads blocks of Native format from io.Reader.
+type NativeReader struct {
+	src    io.Reader
+	data   *bufio.Reader // data, decompressed or same as src
+	r      *Reader
+	inited bool
+}
+
+// NewNativeReader returns new NativeReader that reads from r.
+func NewNativeReader(r io.Reader) *NativeReader {
+	return &NativeReader{src: r}
+}
+
+// WithCompression makes reader decompress stream of compressed frames,
+// as written by NativeWriter with compression or clickhouse-compressor.
+//
+// Should be called before first ReadBlock.
+func (r *NativeReader) WithCompression() *NativeReader {
+	r.src = compress.NewReader(r.src)
+	return r
+}
+
+// ReadBlock reads next block into target, returning io.EOF if there are no
+// more blocks.
+//
+// Use Results.Auto to infer columns from the first block.
+func (r *NativeReader) ReadBlock(target Result) (Block, error) {
+	if !r.inited {
+		r.data = bufio.NewReaderSize(r.src, defaultReaderSize)
+		// Reader uses data directly, as it is already buffered.
+		r.r = NewReader(r.data)
+		r.inited = true
+	}
+	if _, err := r.data.Peek(1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Block{}, io.EOF
+		}
+		return Block{}, errors.Wrap(err, "peek")
+	}
+	var b Block
+	if err := b.DecodeRawBlock(r.r, NativeVersion, target); err != nil {
+		return Block{}, errors.Wrap(err, "decode")
+	}
+	return b, nil
+}
@@ -0,0 +1,144 @@
+package proto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/compress"
+)
+
+func TestNativeReader_Dump(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("_testdata", "test_dump_native.raw"))
+	require.NoError(t, err)
+
+	var (
+		results Results
+		ids     []int8
+		values  []string
+	)
+	r := NewNativeReader(bytes.NewReader(data))
+	for {
+		b, err := r.ReadBlock(results.Auto())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, 2, b.Columns)
+		for i := 0; i < b.Rows; i++ {
+			ids = append(ids, results[0].Data.(*ColInt8).Row(i))
+			values = append(values, results[1].Data.(*ColStr).Row(i))
+		}
+	}
+	require.Equal(t, []int8{1, 2, 3}, ids)
+	require.Equal(t, []string{"First", "Second", "Third"}, values)
+}
+
+func TestNative(t *testing.T) {
+	for _, tt := range []struct {
+		Name     string
+		Compress bool
+		Method   compress.Method
+	}{
+		{Name: "Raw"},
+		{Name: "None", Compress: true, Method: compress.None},
+		{Name: "LZ4", Compress: true, Method: compress.LZ4},
+		{Name: "ZSTD", Compress: true, Method: compress.ZSTD},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			var (
+				buf  bytes.Buffer
+				ids  ColUInt64
+				tags = new(ColStr).LowCardinality().Array()
+			)
+			w := NewNativeWriter(&buf)
+			if tt.Compress {
+				w.WithCompression(tt.Method, compress.LevelZero)
+			}
+			input := Input{
+				{Name: "id", Data: &ids},
+				{Name: "tags", Data: tags},
+			}
+			for block := 0; block < 3; block++ {
+				input.Reset()
+				for i := 0; i < 100; i++ {
+					ids.Append(uint64(block*100 + i))
+					tags.Append([]string{"foo", "bar"}[:i%3])
+				}
+				require.NoError(t, w.WriteBlock(input))
+			}
+
+			r := NewNativeReader(&buf)
+			if tt.Compress {
+				r.WithCompression()
+			}
+			var (
+				results Results
+				rows    int
+			)
+			for {
+				b, err := r.ReadBlock(results.Auto())
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				require.Equal(t, 100, b.Rows)
+				require.Equal(t, "id", results[0].Name)
+				require.Equal(t, ColumnType("Array(LowCardinality(String))"), results[1].Data.Type())
+				for i := 0; i < b.Rows; i++ {
+					require.Equal(t, uint64(rows), results[0].Data.(*ColUInt64).Row(i))
+					rows++
+				}
+			}
+			require.Equal(t, 300, rows)
+		})
+	}
+}
+
+func TestNativeReader_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewNativeWriter(&buf).WriteBlock(Input{
+		{Name: "id", Data: ColUInt64{1, 2, 3}},
+	}))
+	data := buf.Bytes()[:buf.Len()-1]
+
+	var results Results
+	_, err := NewNativeReader(bytes.NewReader(data)).ReadBlock(results.Auto())
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+}
+
+func TestNativeWriter_Frames(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		ids ColUInt64
+	)
+	for i := 0; i < 400_000; i++ {
+		ids.Append(uint64(i))
+	}
+	w := NewNativeWriter(&buf).WithCompression(compress.LZ4, compress.LevelZero)
+	require.NoError(t, w.WriteBlock(Input{{Name: "id", Data: &ids}}))
+
+	// Block is split into frames of bounded size.
+	var frames int
+	for data := buf.Bytes(); len(data) > 0; frames++ {
+		const headerSize = 16 + 1 + 4 + 4 // checksum, method and sizes
+		require.GreaterOrEqual(t, len(data), headerSize)
+		compressed := binary.LittleEndian.Uint32(data[17:])
+		size := binary.LittleEndian.Uint32(data[21:])
+		require.LessOrEqual(t, int(size), compress.DefaultFrameSize)
+		data = data[16+int(compressed):]
+	}
+	require.Greater(t, frames, 1)
+
+	var results Results
+	b, err := NewNativeReader(&buf).WithCompression().ReadBlock(results.Auto())
+	require.NoError(t, err)
+	require.Equal(t, ids.Rows(), b.Rows)
+	require.Equal(t, ids, *results[0].Data.(*ColUInt64))
+}
@@ -14,6 +14,8 @@ func TestProfile_EncodeAware(t *testing.T) {
 		AppliedLimit:              true,
 		RowsBeforeLimit:           2341,
 		CalculatedRowsBeforeLimit: false,
+		AppliedAggregation:        true,
+		RowsBeforeAggregation:     5000,
 	}
 	var b Buffer
 	p.EncodeAware(&b, Version)
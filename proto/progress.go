@@ -6,9 +6,10 @@ import (
 
 // Progress of query execution.
 type Progress struct {
-	Rows      uint64
-	Bytes     uint64
-	TotalRows uint64
+	Rows       uint64
+	Bytes      uint64
+	TotalRows  uint64
+	TotalBytes uint64
 
 	WroteRows  uint64
 	WroteBytes uint64
@@ -19,6 +20,9 @@ func (p Progress) EncodeAware(b *Buffer, version int) {
 	b.PutUVarInt(p.Rows)
 	b.PutUVarInt(p.Bytes)
 	b.PutUVarInt(p.TotalRows)
+	if FeatureTotalBytesInProgress.In(version) {
+		b.PutUVarInt(p.TotalBytes)
+	}
 	if FeatureClientWriteInfo.In(version) {
 		b.PutUVarInt(p.WroteRows)
 		b.PutUVarInt(p.WroteBytes)
@@ -50,6 +54,13 @@ func (p *Progress) DecodeAware(r *Reader, version int) error {
 		}
 		p.TotalRows = v
 	}
+	if FeatureTotalBytesInProgress.In(version) {
+		v, err := r.UVarInt()
+		if err != nil {
+			return errors.Wrap(err, "total bytes")
+		}
+		p.TotalBytes = v
+	}
 	if FeatureClientWriteInfo.In(version) {
 		{
 			v, err := r.UVarInt()
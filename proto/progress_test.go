@@ -14,6 +14,7 @@ func TestProgress_EncodeAware(t *testing.T) {
 		Rows:       100,
 		Bytes:      608120,
 		TotalRows:  1000,
+		TotalBytes: 6081200,
 		WroteRows:  441,
 		WroteBytes: 91023,
 	}
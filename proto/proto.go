@@ -3,6 +3,6 @@ package proto
 
 // Defaults for ClientHello.
 const (
-	Version = 54460
+	Version = 54470
 	Name    = "clickhouse/ch-go"
 )
@@ -43,20 +43,14 @@ func (s *Results) decodeAuto(r *Reader, version int, b Block) error {
 		if err != nil {
 			return errors.Wrapf(err, "column [%d] type", i)
 		}
-		var customSerialization bool
-		if FeatureCustomSerialization.In(version) {
-			if customSerialization, err = r.Bool(); err != nil {
-				return errors.Wrapf(err, "column [%d] custom serialization", i)
-			}
-			if customSerialization {
-				// Not implemented.
-				return errors.Wrapf(err, "column [%d] has custom serialization (not supported)", i)
-			}
-		}
 		var (
 			colType = ColumnType(columnTypeRaw)
 			col     = &ColAuto{}
 		)
+		info, err := decodeColumnHeader(r, version, colType)
+		if err != nil {
+			return errors.Wrapf(err, "column [%d]", i)
+		}
 		if err := col.Infer(colType); err != nil {
 			return errors.Wrap(err, "column type inference")
 		}
@@ -67,7 +61,7 @@ func (s *Results) decodeAuto(r *Reader, version int, b Block) error {
 					return errors.Wrapf(err, "%s state", columnName)
 				}
 			}
-			if err := col.Data.DecodeColumn(r, b.Rows); err != nil {
+			if err := decodeColumn(r, col.Data, info, b.Rows); err != nil {
 				return errors.Wrap(err, columnName)
 			}
 		}
@@ -98,15 +92,9 @@ func (s Results) DecodeResult(r *Reader, version int, b Block) error {
 		if err != nil {
 			return errors.Wrapf(err, "column [%d] type", i)
 		}
-		if FeatureCustomSerialization.In(version) {
-			customSerialization, err := r.Bool()
-			if err != nil {
-				return errors.Wrapf(err, "column [%d] custom serialization", i)
-			}
-			if customSerialization {
-				// Not implemented.
-				return errors.Wrapf(err, "column [%d] has custom serialization (not supported)", i)
-			}
+		info, err := decodeColumnHeader(r, version, ColumnType(columnType))
+		if err != nil {
+			return errors.Wrapf(err, "column [%d]", i)
 		}
 		if noTarget {
 			// Just reading types and names.
@@ -125,7 +113,14 @@ func (s Results) DecodeResult(r *Reader, version int, b Block) error {
 		}
 		gotType := ColumnType(columnType)
 		if infer, ok := t.Data.(Inferable); ok {
-			if err := infer.Infer(gotType); err != nil {
+			inferType := gotType
+			switch t.Data.(type) {
+			case *ColAuto, *ColSimpleAggregateFunction:
+			default:
+				// Values of SimpleAggregateFunction(f, T) are values of T.
+				inferType = gotType.simpleAggregateElem()
+			}
+			if err := infer.Infer(inferType); err != nil {
 				return errors.Wrap(err, "infer")
 			}
 		}
@@ -144,7 +139,7 @@ func (s Results) DecodeResult(r *Reader, version int, b Block) error {
 				return errors.Wrapf(err, "%s state", columnName)
 			}
 		}
-		if err := t.Data.DecodeColumn(r, b.Rows); err != nil {
+		if err := decodeColumn(r, t.Data, info, b.Rows); err != nil {
 			return errors.Wrap(err, columnName)
 		}
 	}
@@ -0,0 +1,397 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/go-faster/errors"
+)
+
+// RowBinaryFormat is one of RowBinary formats.
+type RowBinaryFormat byte
+
+// RowBinary formats.
+const (
+	// RowBinary contains only values of rows.
+	RowBinary RowBinaryFormat = iota
+	// RowBinaryWithNames contains header of column names before rows.
+	RowBinaryWithNames
+	// RowBinaryWithNamesAndTypes contains header of column names and types
+	// before rows.
+	RowBinaryWithNamesAndTypes
+)
+
+func (f RowBinaryFormat) String() string {
+	switch f {
+	case RowBinary:
+		return "RowBinary"
+	case RowBinaryWithNames:
+		return "RowBinaryWithNames"
+	case RowBinaryWithNamesAndTypes:
+		return "RowBinaryWithNamesAndTypes"
+	default:
+		return "Unknown"
+	}
+}
+
+// rowBinaryColumn is column of RowBinaryEncoder or RowBinaryDecoder.
+type rowBinaryColumn struct {
+	codec rowCodec
+	buf   Buffer // Native encoding of column
+}
+
+// newRowBinaryColumns returns columns for types.
+func newRowBinaryColumns(types []ColumnType) ([]rowBinaryColumn, error) {
+	columns := make([]rowBinaryColumn, len(types))
+	for i, t := range types {
+		typ, err := ParseType(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		c, err := newRowCodec(typ)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		columns[i].codec = c
+	}
+	return columns, nil
+}
+
+// rowBinaryData returns inferred column of c, if any.
+func rowBinaryData(c any) any {
+	switch v := c.(type) {
+	case *ColAuto:
+		return v.Data
+	case ColAuto:
+		return v.Data
+	}
+	return c
+}
+
+// RowBinaryEncoder encodes rows of input columns in RowBinary format.
+//
+// Columns are encoded depending on their ColumnType, so encoder supports
+// all columns, except QBit and LowCardinality(Nullable(T)).
+type RowBinaryEncoder struct {
+	format  RowBinaryFormat
+	input   Input
+	columns []rowBinaryColumn
+	rows    int
+}
+
+// NewRowBinaryEncoder returns new RowBinaryEncoder of input columns.
+func NewRowBinaryEncoder(f RowBinaryFormat, input Input) (*RowBinaryEncoder, error) {
+	types := make([]ColumnType, len(input))
+	for i, c := range input {
+		types[i] = c.Data.Type()
+	}
+	columns, err := newRowBinaryColumns(types)
+	if err != nil {
+		return nil, errors.Wrap(err, "columns")
+	}
+	return &RowBinaryEncoder{
+		format:  f,
+		input:   input,
+		columns: columns,
+	}, nil
+}
+
+// EncodeHeader encodes header of format, if any.
+func (e *RowBinaryEncoder) EncodeHeader(b *Buffer) {
+	if e.format == RowBinary {
+		return
+	}
+	b.PutInt(len(e.input))
+	for _, c := range e.input {
+		b.PutString(c.Name)
+	}
+	if e.format == RowBinaryWithNamesAndTypes {
+		for _, c := range e.input {
+			b.PutString(string(c.Data.Type()))
+		}
+	}
+}
+
+// Prepare prepares current rows of input columns for encoding, returning
+// error if columns can't be encoded.
+//
+// Should be called after columns are filled and before EncodeRow.
+func (e *RowBinaryEncoder) Prepare() error {
+	e.rows = 0
+	if len(e.input) > 0 {
+		e.rows = e.input[0].Data.Rows()
+	}
+	for i, c := range e.input {
+		if r := c.Data.Rows(); r != e.rows {
+			return errors.Errorf("%q has %d rows, expected %d", c.Name, r, e.rows)
+		}
+		col := &e.columns[i]
+		col.buf.Reset()
+		data := rowBinaryData(c.Data)
+		if v, ok := data.(Preparable); ok {
+			if err := v.Prepare(); err != nil {
+				return errors.Wrapf(err, "prepare %q", c.Name)
+			}
+		}
+		if v, ok := data.(StateEncoder); ok {
+			v.EncodeState(&col.buf)
+		}
+		c.Data.EncodeColumn(&col.buf)
+
+		tail, err := col.codec.scanState(col.buf.Buf)
+		if err != nil {
+			return errors.Wrapf(err, "%q state", c.Name)
+		}
+		if tail, err = col.codec.scan(tail, e.rows); err != nil {
+			return errors.Wrapf(err, "%q", c.Name)
+		}
+		if len(tail) != 0 {
+			return errors.Errorf("%q: %d unexpected bytes", c.Name, len(tail))
+		}
+	}
+	return nil
+}
+
+// Rows returns count of prepared rows.
+func (e *RowBinaryEncoder) Rows() int {
+	return e.rows
+}
+
+// EncodeRow encodes i-th prepared row.
+func (e *RowBinaryEncoder) EncodeRow(b *Buffer, i int) {
+	for _, c := range e.columns {
+		c.codec.encode(b, i)
+	}
+}
+
+// Encode prepares and encodes all rows of input columns, without header.
+func (e *RowBinaryEncoder) Encode(b *Buffer) error {
+	if err := e.Prepare(); err != nil {
+		return err
+	}
+	for i := 0; i < e.rows; i++ {
+		e.EncodeRow(b, i)
+	}
+	return nil
+}
+
+// rowCountReader counts bytes read from r.
+type rowCountReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *rowCountReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+// RowBinaryDecoder decodes rows of RowBinary format to target columns.
+//
+// Rows are collected until Flush, which decodes them to target columns.
+//
+// Columns are decoded depending on their ColumnType, so types of target
+// columns should be known before decoding rows, either explicitly or from
+// header of RowBinaryWithNamesAndTypes.
+type RowBinaryDecoder struct {
+	format  RowBinaryFormat
+	target  Results
+	columns []rowBinaryColumn
+	rows    int
+
+	in  rowCountReader
+	r   Reader // of in
+	src bytes.Reader
+	out Reader // of src
+}
+
+// NewRowBinaryDecoder returns new RowBinaryDecoder to target columns.
+func NewRowBinaryDecoder(f RowBinaryFormat, target Results) *RowBinaryDecoder {
+	d := &RowBinaryDecoder{
+		format: f,
+		target: target,
+	}
+	d.r = Reader{data: &d.in, b: new(Buffer)}
+	d.out = Reader{data: &d.src, b: new(Buffer)}
+	return d
+}
+
+// DecodeHeader decodes header of format, if any.
+//
+// Blank names of target columns are set from header, and Inferable columns
+// are inferred from types of RowBinaryWithNamesAndTypes.
+func (d *RowBinaryDecoder) DecodeHeader(r *Reader) error {
+	if d.format == RowBinary {
+		return d.init()
+	}
+	n, err := r.Int()
+	if err != nil {
+		return errors.Wrap(err, "columns")
+	}
+	if n != len(d.target) {
+		return errors.Errorf("%d (columns) != %d (target)", n, len(d.target))
+	}
+	for i := range d.target {
+		name, err := r.Str()
+		if err != nil {
+			return errors.Wrapf(err, "column [%d] name", i)
+		}
+		c := &d.target[i]
+		if c.Name == "" {
+			c.Name = name
+		}
+		if c.Name != name {
+			return errors.Errorf("[%d]: unexpected column %q (%q expected)", i, name, c.Name)
+		}
+	}
+	if d.format == RowBinaryWithNamesAndTypes {
+		for i, c := range d.target {
+			v, err := r.Str()
+			if err != nil {
+				return errors.Wrapf(err, "column [%d] type", i)
+			}
+			t := ColumnType(v)
+			if infer, ok := c.Data.(Inferable); ok {
+				if err := infer.Infer(t); err != nil {
+					return errors.Wrapf(err, "%s: infer", c.Name)
+				}
+			}
+			if has := c.Data.Type(); t.Conflicts(has) {
+				return errors.Errorf("[%d]: %s: unexpected type %q (got) instead of %q (has)",
+					i, c.Name, t, has,
+				)
+			}
+		}
+	}
+	return d.init()
+}
+
+func (d *RowBinaryDecoder) init() error {
+	types := make([]ColumnType, len(d.target))
+	for i, c := range d.target {
+		types[i] = c.Data.Type()
+	}
+	columns, err := newRowBinaryColumns(types)
+	if err != nil {
+		return errors.Wrap(err, "columns")
+	}
+	d.columns = columns
+	d.rows = 0
+	return nil
+}
+
+// DecodeRow decodes next row, returning io.EOF if there are no more rows.
+//
+// Decoder can't be used after other errors.
+func (d *RowBinaryDecoder) DecodeRow(r *Reader) error {
+	if d.columns == nil {
+		if d.format != RowBinary {
+			return errors.New("header is not decoded")
+		}
+		if err := d.init(); err != nil {
+			return err
+		}
+	}
+	d.in.r = r
+	d.in.n = 0
+	for i, c := range d.columns {
+		if err := c.codec.decode(&d.r); err != nil {
+			if errors.Is(err, io.EOF) {
+				if d.in.n == 0 {
+					return io.EOF
+				}
+				err = io.ErrUnexpectedEOF
+			}
+			return errors.Wrapf(err, "row %d: %q", d.rows, d.target[i].Name)
+		}
+	}
+	d.rows++
+	return nil
+}
+
+// Rows returns count of decoded rows that are not flushed.
+func (d *RowBinaryDecoder) Rows() int {
+	return d.rows
+}
+
+// Flush resets target columns and decodes rows to them.
+func (d *RowBinaryDecoder) Flush() error {
+	if d.columns == nil {
+		// Nothing is decoded.
+		return nil
+	}
+	for i, c := range d.target {
+		col := &d.columns[i]
+		col.buf.Reset()
+		col.codec.putState(&col.buf)
+		col.codec.flush(&col.buf)
+
+		d.src.Reset(col.buf.Buf)
+		c.Data.Reset()
+		if v, ok := rowBinaryData(c.Data).(StateDecoder); ok {
+			if err := v.DecodeState(&d.out); err != nil {
+				return errors.Wrapf(err, "%q state", c.Name)
+			}
+		}
+		if err := c.Data.DecodeColumn(&d.out, d.rows); err != nil {
+			return errors.Wrapf(err, "%q", c.Name)
+		}
+		if n := d.src.Len(); n != 0 {
+			return errors.Errorf("%q: %d bytes left", c.Name, n)
+		}
+	}
+	d.rows = 0
+	return nil
+}
+
+// rowBinaryValue encodes single values of type in RowBinary format.
+type rowBinaryValue struct {
+	typ   Type
+	col   Column
+	codec rowCodec
+	buf   Buffer
+}
+
+func newRowBinaryValue(t ColumnType) (*rowBinaryValue, error) {
+	typ, err := ParseType(t)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newRowCodec(typ)
+	if err != nil {
+		return nil, err
+	}
+	col := new(ColAuto)
+	if err := col.Infer(t); err != nil {
+		return nil, err
+	}
+	return &rowBinaryValue{typ: typ, col: col.Data, codec: codec}, nil
+}
+
+// encode appends RowBinary encoding of v.
+//
+// Panics if v can't be appended to column of type.
+func (v *rowBinaryValue) encode(b *Buffer, value any) error {
+	v.col.Reset()
+	columnAppend(v.col, value)
+	if p, ok := v.col.(Preparable); ok {
+		if err := p.Prepare(); err != nil {
+			return err
+		}
+	}
+	v.buf.Reset()
+	if s, ok := v.col.(StateEncoder); ok {
+		s.EncodeState(&v.buf)
+	}
+	v.col.EncodeColumn(&v.buf)
+	tail, err := v.codec.scanState(v.buf.Buf)
+	if err != nil {
+		return errors.Wrap(err, "state")
+	}
+	if _, err := v.codec.scan(tail, 1); err != nil {
+		return err
+	}
+	v.codec.encode(b, 0)
+	return nil
+}
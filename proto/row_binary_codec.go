@@ -0,0 +1,1523 @@
+package proto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// rowCodec transcodes values of column type between Native and RowBinary
+// formats.
+//
+// Native encoding of column is scanned once per block, so values can be
+// encoded to RowBinary row by row without allocations. Decoded RowBinary
+// values are collected in Native encoding and flushed to column once per
+// block.
+type rowCodec interface {
+	// scanState skips state prefix of Native encoding.
+	scanState(data []byte) ([]byte, error)
+	// scan scans Native encoding of rows values, returning remaining data.
+	scan(data []byte, rows int) ([]byte, error)
+	// encode appends RowBinary encoding of i-th scanned value.
+	encode(b *Buffer, i int)
+
+	// putState appends state prefix of Native encoding.
+	putState(b *Buffer)
+	// decode reads RowBinary encoding of value.
+	decode(r *Reader) error
+	// appendDefault appends default value.
+	appendDefault()
+	// flush appends Native encoding of decoded values and resets them.
+	flush(b *Buffer)
+}
+
+// newRowCodec returns rowCodec for type t.
+func newRowCodec(t Type) (rowCodec, error) {
+	if size := rowFixedSize(t); size > 0 {
+		return &rowFixed{size: size}, nil
+	}
+	// Elements of types that are encoded like other types.
+	point := func() rowCodec {
+		return &rowTuple{elems: []rowCodec{&rowFixed{size: 8}, &rowFixed{size: 8}}}
+	}
+	array := func(elem rowCodec, depth int) rowCodec {
+		for i := 0; i < depth; i++ {
+			elem = &rowArray{elem: elem}
+		}
+		return elem
+	}
+	elems := func() ([]rowCodec, error) {
+		var codecs []rowCodec
+		for i, e := range t.Elems {
+			c, err := newRowCodec(e)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s [%d]", t.Name, i)
+			}
+			codecs = append(codecs, c)
+		}
+		return codecs, nil
+	}
+	elem := func(n int) ([]rowCodec, error) {
+		if len(t.Elems) != n {
+			return nil, errors.Errorf("%s: got %d types, expected %d", t.Name, len(t.Elems), n)
+		}
+		return elems()
+	}
+	switch t.Name {
+	case ColumnTypeString:
+		return &rowString{}, nil
+	case ColumnTypeNothing:
+		return &rowNothing{}, nil
+	case ColumnTypeNullable:
+		e, err := elem(1)
+		if err != nil {
+			return nil, err
+		}
+		return &rowNullable{elem: e[0]}, nil
+	case ColumnTypeArray:
+		e, err := elem(1)
+		if err != nil {
+			return nil, err
+		}
+		return &rowArray{elem: e[0]}, nil
+	case ColumnTypeMap:
+		// Map(K, V) is encoded as Array(Tuple(K, V)).
+		e, err := elem(2)
+		if err != nil {
+			return nil, err
+		}
+		return &rowArray{elem: &rowTuple{elems: e}}, nil
+	case ColumnTypeTuple:
+		e, err := elems()
+		if err != nil {
+			return nil, err
+		}
+		return &rowTuple{elems: e}, nil
+	case ColumnTypeNested:
+		// Nested(a A, b B) is encoded as Array(Tuple(a A, b B)).
+		e, err := elems()
+		if err != nil {
+			return nil, err
+		}
+		return &rowArray{elem: &rowTuple{elems: e}}, nil
+	case ColumnTypeLowCardinality:
+		if len(t.Elems) == 1 && t.Elems[0].Name == ColumnTypeNullable {
+			return nil, errors.Errorf("%s: not supported", t)
+		}
+		e, err := elem(1)
+		if err != nil {
+			return nil, err
+		}
+		return &rowLowCardinality{dict: e[0]}, nil
+	case ColumnTypeVariant:
+		e, err := elems()
+		if err != nil {
+			return nil, err
+		}
+		return &rowVariant{elems: e}, nil
+	case ColumnTypeDynamic:
+		var maxTypes int
+		for _, p := range t.Params {
+			if p.Kind != TypeParamSetting || p.Name != "max_types" {
+				return nil, errors.Errorf("%s: invalid parameter %s", t.Name, p)
+			}
+			n, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: max_types", t.Name)
+			}
+			maxTypes = n
+		}
+		return newRowDynamic(maxTypes), nil
+	case ColumnTypeJSON:
+		return newRowJSON(t)
+	case ColumnTypeSimpleAggregateFunction:
+		if len(t.Elems) != 2 {
+			return nil, errors.Errorf("%s: got %d types, expected 2", t.Name, len(t.Elems))
+		}
+		return newRowCodec(t.simpleAggregateElem())
+	case ColumnTypeAggregateFunction:
+		f, err := ParseAggregateFunction(t.ColumnType())
+		if err != nil {
+			return nil, errors.Wrap(err, "aggregate function")
+		}
+		return newRowAggregate(f)
+	case ColumnTypePoint:
+		return point(), nil
+	case ColumnTypeRing, ColumnTypeLineString:
+		return array(point(), 1), nil
+	case ColumnTypePolygon, ColumnTypeMultiLineString:
+		return array(point(), 2), nil
+	case ColumnTypeMultiPolygon:
+		return array(point(), 3), nil
+	default:
+		return nil, errors.Errorf("%s: not supported", t)
+	}
+}
+
+// rowFixedSize returns size of value of fixed size type t, or zero.
+func rowFixedSize(t Type) int {
+	switch t.Name {
+	case ColumnTypeInt8, ColumnTypeUInt8, ColumnTypeBool, ColumnTypeEnum8:
+		return 1
+	case ColumnTypeInt16, ColumnTypeUInt16, ColumnTypeDate, ColumnTypeEnum16, ColumnTypeBFloat16:
+		return 2
+	case ColumnTypeInt32, ColumnTypeUInt32, ColumnTypeFloat32, ColumnTypeDate32, ColumnTypeDateTime,
+		ColumnTypeIPv4, ColumnTypeTime32, "Time":
+		return 4
+	case ColumnTypeInt64, ColumnTypeUInt64, ColumnTypeFloat64, ColumnTypeDateTime64, ColumnTypeTime64:
+		return 8
+	case ColumnTypeInt128, ColumnTypeUInt128, ColumnTypeUUID, ColumnTypeIPv6:
+		return 16
+	case ColumnTypeInt256, ColumnTypeUInt256:
+		return 32
+	case ColumnTypeFixedString:
+		return t.Size
+	}
+	switch t.decimalWidth() {
+	case ColumnTypeDecimal32:
+		return 4
+	case ColumnTypeDecimal64:
+		return 8
+	case ColumnTypeDecimal128:
+		return 16
+	case ColumnTypeDecimal256:
+		return 32
+	}
+	if strings.HasPrefix(t.Name.String(), ColumnTypeInterval.String()) {
+		return 8
+	}
+	return 0
+}
+
+var errRowNative = errors.New("unexpected end of native data")
+
+// scanRaw returns first n bytes of data and remaining data.
+func scanRaw(data []byte, n int) (v, tail []byte, err error) {
+	if n < 0 || len(data) < n {
+		return nil, nil, errRowNative
+	}
+	return data[:n], data[n:], nil
+}
+
+// scanInt64 returns little-endian int64 at start of data and remaining data.
+func scanInt64(data []byte) (int64, []byte, error) {
+	v, tail, err := scanRaw(data, 8)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int64(binary.LittleEndian.Uint64(v)), tail, nil
+}
+
+// scanRows returns int64 at start of data, checking that it is valid rows
+// count.
+func scanRows(data []byte) (int, []byte, error) {
+	v, tail, err := scanInt64(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkRows(int(v)); err != nil {
+		return 0, nil, err
+	}
+	return int(v), tail, nil
+}
+
+// scanString returns string at start of data and remaining data.
+func scanString(data []byte) (string, []byte, error) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)-size) {
+		return "", nil, errRowNative
+	}
+	end := size + int(n)
+	return string(data[size:end]), data[end:], nil
+}
+
+// rowStateless implements state methods of rowCodec for types without state.
+type rowStateless struct{}
+
+func (rowStateless) scanState(data []byte) ([]byte, error) { return data, nil }
+func (rowStateless) putState(*Buffer)                      {}
+
+// rowFixed is codec of fixed size values, which are encoded in the same way
+// in both formats.
+type rowFixed struct {
+	rowStateless
+	size int
+	data []byte
+	buf  []byte
+}
+
+func (c *rowFixed) scan(data []byte, rows int) ([]byte, error) {
+	v, tail, err := scanRaw(data, c.size*rows)
+	if err != nil {
+		return nil, err
+	}
+	c.data = v
+	return tail, nil
+}
+
+func (c *rowFixed) encode(b *Buffer, i int) {
+	b.PutRaw(c.data[i*c.size : (i+1)*c.size])
+}
+
+func (c *rowFixed) decode(r *Reader) error {
+	n := len(c.buf)
+	c.buf = append(c.buf, make([]byte, c.size)...)
+	if err := r.ReadFull(c.buf[n:]); err != nil {
+		c.buf = c.buf[:n]
+		return err
+	}
+	return nil
+}
+
+func (c *rowFixed) appendDefault() {
+	c.buf = append(c.buf, make([]byte, c.size)...)
+}
+
+func (c *rowFixed) flush(b *Buffer) {
+	b.PutRaw(c.buf)
+	c.buf = c.buf[:0]
+}
+
+// rowString is codec of String values, which are encoded in the same way in
+// both formats.
+type rowString struct {
+	rowStateless
+	data []byte
+	pos  []int // start of i-th value in data, and end of data
+	buf  []byte
+}
+
+func (c *rowString) scan(data []byte, rows int) ([]byte, error) {
+	c.pos = append(c.pos[:0], 0)
+	var offset int
+	for i := 0; i < rows; i++ {
+		n, size := binary.Uvarint(data[offset:])
+		if size <= 0 || n > uint64(len(data)-offset-size) {
+			return nil, errRowNative
+		}
+		offset += size + int(n)
+		c.pos = append(c.pos, offset)
+	}
+	c.data = data[:offset]
+	return data[offset:], nil
+}
+
+func (c *rowString) encode(b *Buffer, i int) {
+	b.PutRaw(c.data[c.pos[i]:c.pos[i+1]])
+}
+
+// value returns i-th scanned value without size.
+func (c *rowString) value(i int) []byte {
+	v := c.data[c.pos[i]:c.pos[i+1]]
+	_, size := binary.Uvarint(v)
+	return v[size:]
+}
+
+func (c *rowString) decode(r *Reader) error {
+	n, err := r.StrLen()
+	if err != nil {
+		return err
+	}
+	if n > maxRowStringSize {
+		return errors.Errorf("string size %d is too big", n)
+	}
+	c.buf = binary.AppendUvarint(c.buf, uint64(n))
+	start := len(c.buf)
+	c.buf = append(c.buf, make([]byte, n)...)
+	return r.ReadFull(c.buf[start:])
+}
+
+// maxRowStringSize limits size of decoded string to prevent OOM.
+const maxRowStringSize = 1024 * 1024 * 1024 // 1GB
+
+// appendValue appends v as decoded value.
+func (c *rowString) appendValue(v []byte) {
+	c.buf = binary.AppendUvarint(c.buf, uint64(len(v)))
+	c.buf = append(c.buf, v...)
+}
+
+func (c *rowString) appendDefault() {
+	c.buf = append(c.buf, 0)
+}
+
+func (c *rowString) flush(b *Buffer) {
+	b.PutRaw(c.buf)
+	c.buf = c.buf[:0]
+}
+
+// rowNothing is codec of Nothing values, which are placeholder bytes in
+// Native format and are not encoded in RowBinary.
+type rowNothing struct {
+	rowStateless
+	rows int
+}
+
+func (c *rowNothing) scan(data []byte, rows int) ([]byte, error) {
+	_, tail, err := scanRaw(data, rows)
+	return tail, err
+}
+
+func (c *rowNothing) encode(*Buffer, int) {}
+
+func (c *rowNothing) decode(*Reader) error {
+	c.rows++
+	return nil
+}
+
+func (c *rowNothing) appendDefault() { c.rows++ }
+
+func (c *rowNothing) flush(b *Buffer) {
+	b.PutRaw(make([]byte, c.rows))
+	c.rows = 0
+}
+
+// rowNullable is codec of Nullable(T) values.
+//
+// Native format contains null map and values, including default values of
+// nulls. RowBinary format contains null flag and value if it is not null.
+type rowNullable struct {
+	elem  rowCodec
+	nulls []byte
+	buf   []byte
+}
+
+func (c *rowNullable) scanState(data []byte) ([]byte, error) {
+	return c.elem.scanState(data)
+}
+
+func (c *rowNullable) scan(data []byte, rows int) ([]byte, error) {
+	v, tail, err := scanRaw(data, rows)
+	if err != nil {
+		return nil, err
+	}
+	c.nulls = v
+	return c.elem.scan(tail, rows)
+}
+
+func (c *rowNullable) encode(b *Buffer, i int) {
+	if c.nulls[i] == boolTrue {
+		b.PutByte(boolTrue)
+		return
+	}
+	b.PutByte(boolFalse)
+	c.elem.encode(b, i)
+}
+
+func (c *rowNullable) putState(b *Buffer) {
+	c.elem.putState(b)
+}
+
+func (c *rowNullable) decode(r *Reader) error {
+	null, err := r.Byte()
+	if err != nil {
+		return err
+	}
+	switch null {
+	case boolTrue:
+		c.buf = append(c.buf, boolTrue)
+		c.elem.appendDefault()
+		return nil
+	case boolFalse:
+		c.buf = append(c.buf, boolFalse)
+		return c.elem.decode(r)
+	default:
+		return errors.Errorf("unexpected null flag %d", null)
+	}
+}
+
+func (c *rowNullable) appendDefault() {
+	c.buf = append(c.buf, boolTrue)
+	c.elem.appendDefault()
+}
+
+func (c *rowNullable) flush(b *Buffer) {
+	b.PutRaw(c.buf)
+	c.buf = c.buf[:0]
+	c.elem.flush(b)
+}
+
+// rowArray is codec of Array(T) values.
+//
+// Native format contains offsets and all elements. RowBinary format
+// contains size and elements of array.
+type rowArray struct {
+	elem    rowCodec
+	offsets []byte
+	buf     []byte
+	last    uint64
+}
+
+func (c *rowArray) offset(i int) int {
+	if i < 0 {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint64(c.offsets[i*8:]))
+}
+
+func (c *rowArray) scanState(data []byte) ([]byte, error) {
+	return c.elem.scanState(data)
+}
+
+func (c *rowArray) scan(data []byte, rows int) ([]byte, error) {
+	v, tail, err := scanRaw(data, rows*8)
+	if err != nil {
+		return nil, err
+	}
+	c.offsets = v
+	var prev int
+	for i := 0; i < rows; i++ {
+		offset := c.offset(i)
+		if offset < prev || offset > maxRowsInBLock {
+			return nil, errors.Errorf("invalid offset %d", offset)
+		}
+		prev = offset
+	}
+	return c.elem.scan(tail, prev)
+}
+
+func (c *rowArray) encode(b *Buffer, i int) {
+	start, end := c.offset(i-1), c.offset(i)
+	b.PutUVarInt(uint64(end - start))
+	for j := start; j < end; j++ {
+		c.elem.encode(b, j)
+	}
+}
+
+func (c *rowArray) putState(b *Buffer) {
+	c.elem.putState(b)
+}
+
+func (c *rowArray) decode(r *Reader) error {
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "size")
+	}
+	if n > maxRowsInBLock-c.last {
+		return errors.Errorf("array size %d is too big", n)
+	}
+	c.last += n
+	c.buf = binary.LittleEndian.AppendUint64(c.buf, c.last)
+	for i := uint64(0); i < n; i++ {
+		if err := c.elem.decode(r); err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+	}
+	return nil
+}
+
+func (c *rowArray) appendDefault() {
+	c.buf = binary.LittleEndian.AppendUint64(c.buf, c.last)
+}
+
+func (c *rowArray) flush(b *Buffer) {
+	b.PutRaw(c.buf)
+	c.buf = c.buf[:0]
+	c.last = 0
+	c.elem.flush(b)
+}
+
+// rowTuple is codec of Tuple(T1, T2, ...) values.
+type rowTuple struct {
+	elems []rowCodec
+}
+
+func (c *rowTuple) scanState(data []byte) ([]byte, error) {
+	var err error
+	for _, e := range c.elems {
+		if data, err = e.scanState(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (c *rowTuple) scan(data []byte, rows int) ([]byte, error) {
+	var err error
+	for _, e := range c.elems {
+		if data, err = e.scan(data, rows); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (c *rowTuple) encode(b *Buffer, i int) {
+	for _, e := range c.elems {
+		e.encode(b, i)
+	}
+}
+
+func (c *rowTuple) putState(b *Buffer) {
+	for _, e := range c.elems {
+		e.putState(b)
+	}
+}
+
+func (c *rowTuple) decode(r *Reader) error {
+	for i, e := range c.elems {
+		if err := e.decode(r); err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+	}
+	return nil
+}
+
+func (c *rowTuple) appendDefault() {
+	for _, e := range c.elems {
+		e.appendDefault()
+	}
+}
+
+func (c *rowTuple) flush(b *Buffer) {
+	for _, e := range c.elems {
+		e.flush(b)
+	}
+}
+
+// rowLowCardinality is codec of LowCardinality(T) values, which are encoded
+// as T in RowBinary format.
+//
+// Decoded values are flushed as dictionary without deduplication.
+type rowLowCardinality struct {
+	dict     rowCodec
+	dictRows int
+	keys     []byte
+	keySize  int
+	rows     int
+}
+
+func (c *rowLowCardinality) key(i int) int {
+	k := c.keys[i*c.keySize:]
+	switch c.keySize {
+	case 1:
+		return int(k[0])
+	case 2:
+		return int(binary.LittleEndian.Uint16(k))
+	case 4:
+		return int(binary.LittleEndian.Uint32(k))
+	default:
+		return int(binary.LittleEndian.Uint64(k))
+	}
+}
+
+func (c *rowLowCardinality) scanState(data []byte) ([]byte, error) {
+	v, tail, err := scanInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	if v != int64(sharedDictionariesWithAdditionalKeys) {
+		return nil, errors.Errorf("got version %d, expected %d", v, sharedDictionariesWithAdditionalKeys)
+	}
+	return tail, nil
+}
+
+func (c *rowLowCardinality) scan(data []byte, rows int) ([]byte, error) {
+	if rows == 0 {
+		return data, nil
+	}
+	meta, data, err := scanInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	key := CardinalityKey(meta & cardinalityKeyMask)
+	if !key.IsACardinalityKey() {
+		return nil, errors.Errorf("invalid low cardinality keys type %d", key)
+	}
+	if meta&cardinalityNeedGlobalDictionaryBit != 0 || meta&cardinalityHasAdditionalKeysBit == 0 {
+		return nil, errors.New("only additional keys are supported")
+	}
+	if c.dictRows, data, err = scanRows(data); err != nil {
+		return nil, errors.Wrap(err, "index size")
+	}
+	if data, err = c.dict.scan(data, c.dictRows); err != nil {
+		return nil, errors.Wrap(err, "index")
+	}
+	keyRows, data, err := scanRows(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "keys size")
+	}
+	if keyRows != rows {
+		return nil, errors.Errorf("got %d keys, expected %d", keyRows, rows)
+	}
+	c.keySize = 1 << key
+	if c.keys, data, err = scanRaw(data, rows*c.keySize); err != nil {
+		return nil, errors.Wrap(err, "keys")
+	}
+	for i := 0; i < rows; i++ {
+		if k := c.key(i); k >= c.dictRows {
+			return nil, errors.Errorf("key index out of range [%d] with length %d", k, c.dictRows)
+		}
+	}
+	return data, nil
+}
+
+func (c *rowLowCardinality) encode(b *Buffer, i int) {
+	c.dict.encode(b, c.key(i))
+}
+
+func (c *rowLowCardinality) putState(b *Buffer) {
+	b.PutInt64(int64(sharedDictionariesWithAdditionalKeys))
+}
+
+func (c *rowLowCardinality) decode(r *Reader) error {
+	if err := c.dict.decode(r); err != nil {
+		return err
+	}
+	c.rows++
+	return nil
+}
+
+func (c *rowLowCardinality) appendDefault() {
+	c.dict.appendDefault()
+	c.rows++
+}
+
+func (c *rowLowCardinality) flush(b *Buffer) {
+	if c.rows == 0 {
+		return
+	}
+	key := KeyUInt64
+	switch {
+	case c.rows-1 <= math.MaxUint8:
+		key = KeyUInt8
+	case c.rows-1 <= math.MaxUint16:
+		key = KeyUInt16
+	case c.rows-1 <= math.MaxUint32:
+		key = KeyUInt32
+	}
+	b.PutInt64(cardinalityUpdateAll | int64(key))
+	b.PutInt64(int64(c.rows))
+	c.dict.flush(b)
+	b.PutInt64(int64(c.rows))
+	for i := 0; i < c.rows; i++ {
+		switch key {
+		case KeyUInt8:
+			b.PutUInt8(uint8(i))
+		case KeyUInt16:
+			b.PutUInt16(uint16(i))
+		case KeyUInt32:
+			b.PutUInt32(uint32(i))
+		default:
+			b.PutUInt64(uint64(i))
+		}
+	}
+	c.rows = 0
+}
+
+// rowVariant is codec of Variant(T1, T2, ...) values.
+//
+// Native format contains discriminators and values of each variant.
+// RowBinary format contains discriminator and value if it is not null.
+type rowVariant struct {
+	elems   []rowCodec
+	discr   []byte
+	offsets []int // of i-th value in its variant
+	counts  []int
+	buf     []byte
+}
+
+func (c *rowVariant) scanState(data []byte) ([]byte, error) {
+	mode, data, err := scanInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(mode) != variantDiscriminatorsBasic {
+		return nil, errors.Errorf("unsupported discriminators mode %d", mode)
+	}
+	for _, e := range c.elems {
+		if data, err = e.scanState(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (c *rowVariant) scan(data []byte, rows int) ([]byte, error) {
+	v, data, err := scanRaw(data, rows)
+	if err != nil {
+		return nil, err
+	}
+	c.discr = v
+	c.offsets = c.offsets[:0]
+	c.counts = append(c.counts[:0], make([]int, len(c.elems))...)
+	for i, d := range c.discr {
+		if d == VariantNull {
+			c.offsets = append(c.offsets, 0)
+			continue
+		}
+		if int(d) >= len(c.elems) {
+			return nil, errors.Errorf("[%d]: invalid discriminator %d", i, d)
+		}
+		c.offsets = append(c.offsets, c.counts[d])
+		c.counts[d]++
+	}
+	for i, e := range c.elems {
+		if data, err = e.scan(data, c.counts[i]); err != nil {
+			return nil, errors.Wrapf(err, "variant [%d]", i)
+		}
+	}
+	return data, nil
+}
+
+func (c *rowVariant) encode(b *Buffer, i int) {
+	d := c.discr[i]
+	b.PutByte(d)
+	if d != VariantNull {
+		c.elems[d].encode(b, c.offsets[i])
+	}
+}
+
+func (c *rowVariant) putState(b *Buffer) {
+	b.PutUInt64(variantDiscriminatorsBasic)
+	for _, e := range c.elems {
+		e.putState(b)
+	}
+}
+
+func (c *rowVariant) decode(r *Reader) error {
+	d, err := r.Byte()
+	if err != nil {
+		return err
+	}
+	if d != VariantNull && int(d) >= len(c.elems) {
+		return errors.Errorf("invalid discriminator %d", d)
+	}
+	c.buf = append(c.buf, d)
+	if d == VariantNull {
+		return nil
+	}
+	return c.elems[d].decode(r)
+}
+
+func (c *rowVariant) appendDefault() {
+	c.buf = append(c.buf, VariantNull)
+}
+
+func (c *rowVariant) flush(b *Buffer) {
+	b.PutRaw(c.buf)
+	c.buf = c.buf[:0]
+	for _, e := range c.elems {
+		e.flush(b)
+	}
+}
+
+// rowAggregate is codec of AggregateFunction states, which are encoded in
+// the same way in both formats.
+//
+// States are not prefixed by size, so they are decoded to find their
+// boundaries.
+type rowAggregate struct {
+	rowStateless
+	state AggregateState
+	zero  Buffer // encoded default state
+
+	data []byte
+	pos  []int // start of i-th state in data, and end of data
+	src  bytes.Reader
+	r    Reader // of src
+	buf  Buffer
+}
+
+func newRowAggregate(f AggregateFunction) (*rowAggregate, error) {
+	state, err := f.NewState()
+	if err != nil {
+		return nil, err
+	}
+	c := &rowAggregate{state: state}
+	state.EncodeAggregateState(&c.zero)
+	c.r = Reader{data: &c.src, b: new(Buffer)}
+	return c, nil
+}
+
+func (c *rowAggregate) scan(data []byte, rows int) ([]byte, error) {
+	c.src.Reset(data)
+	c.pos = append(c.pos[:0], 0)
+	for i := 0; i < rows; i++ {
+		if err := c.state.DecodeAggregateState(&c.r); err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		c.pos = append(c.pos, len(data)-c.src.Len())
+	}
+	offset := len(data) - c.src.Len()
+	c.data = data[:offset]
+	return data[offset:], nil
+}
+
+func (c *rowAggregate) encode(b *Buffer, i int) {
+	b.PutRaw(c.data[c.pos[i]:c.pos[i+1]])
+}
+
+func (c *rowAggregate) decode(r *Reader) error {
+	if err := c.state.DecodeAggregateState(r); err != nil {
+		return err
+	}
+	c.state.EncodeAggregateState(&c.buf)
+	return nil
+}
+
+func (c *rowAggregate) appendDefault() {
+	c.buf.PutRaw(c.zero.Buf)
+}
+
+func (c *rowAggregate) flush(b *Buffer) {
+	b.PutRaw(c.buf.Buf)
+	c.buf.Reset()
+}
+
+// rowTeeReader appends bytes that are read from r to buf.
+type rowTeeReader struct {
+	r   io.Reader
+	buf *Buffer
+}
+
+func (t *rowTeeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.buf.PutRaw(p[:n])
+	return n, err
+}
+
+// Decoded rows of rowDynamic that are not values of decoded types.
+const (
+	rowDynamicNull   = -1
+	rowDynamicShared = -2
+)
+
+// rowDynamicCodec is codec of values of Dynamic type.
+type rowDynamicCodec struct {
+	codec  rowCodec
+	binary []byte // binary encoded type
+}
+
+// rowDynamic is codec of Dynamic values.
+//
+// Native format contains list of types and Variant of their values, where
+// values of types that exceed max_types are stored in shared variant as
+// binary encoded type and value. RowBinary format contains binary encoded
+// type and value, or Nothing type for NULL.
+type rowDynamic struct {
+	maxTypes int
+	codecs   map[ColumnType]rowDynamicCodec
+
+	// Scanned Native encoding.
+	variant rowVariant
+	types   []ColumnType // of variant alternatives
+	binary  [][]byte     // binary encoded types of alternatives
+
+	// Decoded RowBinary values.
+	decoded       []ColumnType // in order of first value
+	index         map[ColumnType]int
+	discr         []int // index of decoded type of each row
+	sorted        []int // decoded types and rowDynamicShared sorted by name
+	variants      []byte
+	sharedVariant byte
+	shared        rowString
+	raw           Buffer // of value that is decoded to shared variant
+	skipped       Buffer
+	src           rowTeeReader
+	r             Reader // of src
+}
+
+func newRowDynamic(maxTypes int) *rowDynamic {
+	c := &rowDynamic{
+		maxTypes: maxTypes,
+		codecs:   map[ColumnType]rowDynamicCodec{},
+		index:    map[ColumnType]int{},
+	}
+	c.src.buf = &c.raw
+	c.r = Reader{data: &c.src, b: new(Buffer)}
+	return c
+}
+
+// codec returns codec of values of type t.
+func (c *rowDynamic) codec(t ColumnType) (rowDynamicCodec, error) {
+	if v, ok := c.codecs[t]; ok {
+		return v, nil
+	}
+	var v rowDynamicCodec
+	if t == DynamicSharedVariant {
+		v.codec = &rowString{}
+	} else {
+		typ, err := ParseType(t)
+		if err != nil {
+			return v, err
+		}
+		if v.codec, err = newRowCodec(typ); err != nil {
+			return v, err
+		}
+		b := new(Buffer)
+		if err := putBinaryType(b, typ); err != nil {
+			return v, err
+		}
+		v.binary = b.Buf
+	}
+	c.codecs[t] = v
+	return v, nil
+}
+
+func (c *rowDynamic) scanState(data []byte) ([]byte, error) {
+	version, data, err := scanInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	switch uint64(version) {
+	case dynamicSerializationV1:
+		if _, size := binary.Uvarint(data); size > 0 {
+			data = data[size:]
+		} else {
+			return nil, errRowNative
+		}
+	case dynamicSerializationV2:
+	default:
+		return nil, errors.Errorf("unsupported dynamic structure version %d", version)
+	}
+	n, size := binary.Uvarint(data)
+	if size <= 0 {
+		return nil, errRowNative
+	}
+	if n >= uint64(maxVariantTypes) {
+		return nil, errors.Errorf("too many dynamic types: %d", n)
+	}
+	data = data[size:]
+	c.types = c.types[:0]
+	for i := 0; i < int(n); i++ {
+		var s string
+		if s, data, err = scanString(data); err != nil {
+			return nil, errors.Wrapf(err, "type [%d]", i)
+		}
+		c.types = append(c.types, ColumnType(s))
+	}
+	// Shared variant is not listed, but takes its place among sorted types.
+	idx := sort.Search(len(c.types), func(i int) bool {
+		return c.types[i] >= DynamicSharedVariant
+	})
+	c.types = slices.Insert(c.types, idx, DynamicSharedVariant)
+	c.variant.elems = c.variant.elems[:0]
+	c.binary = c.binary[:0]
+	for _, t := range c.types {
+		v, err := c.codec(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", t)
+		}
+		c.variant.elems = append(c.variant.elems, v.codec)
+		c.binary = append(c.binary, v.binary)
+	}
+	return c.variant.scanState(data)
+}
+
+func (c *rowDynamic) scan(data []byte, rows int) ([]byte, error) {
+	return c.variant.scan(data, rows)
+}
+
+// null reports whether i-th scanned value is NULL.
+func (c *rowDynamic) null(i int) bool {
+	return c.variant.discr[i] == VariantNull
+}
+
+func (c *rowDynamic) encode(b *Buffer, i int) {
+	d := c.variant.discr[i]
+	switch {
+	case d == VariantNull:
+		b.PutByte(binaryTypeNothing)
+	case c.types[d] == DynamicSharedVariant:
+		// Already encoded as type and value.
+		b.PutRaw(c.variant.elems[d].(*rowString).value(c.variant.offsets[i]))
+	default:
+		b.PutRaw(c.binary[d])
+		c.variant.elems[d].encode(b, c.variant.offsets[i])
+	}
+}
+
+func (c *rowDynamic) putState(b *Buffer) {
+	// Variant alternatives are sorted by type name, including shared variant.
+	c.sorted = append(c.sorted[:0], rowDynamicShared)
+	for i := range c.decoded {
+		c.sorted = append(c.sorted, i)
+	}
+	name := func(d int) ColumnType {
+		if d == rowDynamicShared {
+			return DynamicSharedVariant
+		}
+		return c.decoded[d]
+	}
+	sort.Slice(c.sorted, func(i, j int) bool {
+		return name(c.sorted[i]) < name(c.sorted[j])
+	})
+
+	maxTypes := c.maxTypes
+	if maxTypes == 0 {
+		maxTypes = dynamicDefaultMaxTypes
+	}
+	b.PutUInt64(dynamicSerializationV1)
+	b.PutUVarInt(uint64(maxTypes))
+	b.PutUVarInt(uint64(len(c.decoded)))
+	c.variants = append(c.variants[:0], make([]byte, len(c.decoded))...)
+	for i, d := range c.sorted {
+		if d == rowDynamicShared {
+			c.sharedVariant = byte(i)
+			continue
+		}
+		c.variants[d] = byte(i)
+		b.PutString(c.decoded[d].String())
+	}
+	b.PutUInt64(variantDiscriminatorsBasic)
+	for _, d := range c.sorted {
+		if d != rowDynamicShared {
+			c.codecs[c.decoded[d]].codec.putState(b)
+		}
+	}
+}
+
+func (c *rowDynamic) decode(r *Reader) error {
+	c.src.r = r
+	c.raw.Reset()
+	t, err := readBinaryType(&c.r)
+	if err != nil {
+		return errors.Wrap(err, "type")
+	}
+	if t == ColumnTypeNothing {
+		c.discr = append(c.discr, rowDynamicNull)
+		return nil
+	}
+	idx, ok := c.index[t]
+	if !ok && len(c.decoded) < dynamicLimit(c.maxTypes) {
+		if _, err := c.codec(t); err != nil {
+			return errors.Wrapf(err, "%s", t)
+		}
+		idx, ok = len(c.decoded), true
+		c.decoded = append(c.decoded, t)
+		c.index[t] = idx
+	}
+	if ok {
+		c.discr = append(c.discr, idx)
+		return c.codecs[t].codec.decode(r)
+	}
+	// Values of types that exceed max_types are stored as is.
+	if err := c.skip(t); err != nil {
+		return err
+	}
+	c.shared.appendValue(c.raw.Buf)
+	c.discr = append(c.discr, rowDynamicShared)
+	return nil
+}
+
+// decodeRaw decodes value, returning its binary encoded type and value,
+// which are valid until next call, or nil for NULL.
+func (c *rowDynamic) decodeRaw(r *Reader) ([]byte, error) {
+	c.src.r = r
+	c.raw.Reset()
+	t, err := readBinaryType(&c.r)
+	if err != nil {
+		return nil, errors.Wrap(err, "type")
+	}
+	if t == ColumnTypeNothing {
+		return nil, nil
+	}
+	if err := c.skip(t); err != nil {
+		return nil, err
+	}
+	return c.raw.Buf, nil
+}
+
+// skip decodes value of type t, which is only appended to raw.
+func (c *rowDynamic) skip(t ColumnType) error {
+	v, err := c.codec(t)
+	if err != nil {
+		return errors.Wrapf(err, "%s", t)
+	}
+	if err := v.codec.decode(&c.r); err != nil {
+		return err
+	}
+	v.codec.flush(&c.skipped)
+	c.skipped.Reset()
+	return nil
+}
+
+func (c *rowDynamic) appendDefault() {
+	c.discr = append(c.discr, rowDynamicNull)
+}
+
+func (c *rowDynamic) flush(b *Buffer) {
+	for _, d := range c.discr {
+		switch d {
+		case rowDynamicNull:
+			b.PutByte(VariantNull)
+		case rowDynamicShared:
+			b.PutByte(c.sharedVariant)
+		default:
+			b.PutByte(c.variants[d])
+		}
+	}
+	for _, d := range c.sorted {
+		if d == rowDynamicShared {
+			c.shared.flush(b)
+			continue
+		}
+		c.codecs[c.decoded[d]].codec.flush(b)
+	}
+	c.discr = c.discr[:0]
+	c.decoded = c.decoded[:0]
+	clear(c.index)
+}
+
+// rowJSONShared is path of shared data of decoded row.
+type rowJSONShared struct {
+	path       string
+	start, end int // of value in rowJSON.values
+}
+
+// rowJSON is codec of JSON values in object serialization.
+//
+// Native format contains typed paths, dynamic paths and shared data, i.e.
+// paths that exceed max_dynamic_paths with binary encoded values. RowBinary
+// format contains count of paths and each path with value, which is
+// encoded as path type for typed paths and as Dynamic for others.
+type rowJSON struct {
+	typedPaths []string
+	typed      []rowCodec
+	maxPaths   int
+	maxTypes   int
+
+	// Scanned Native encoding.
+	dynamicPaths []string
+	dynamic      []*rowDynamic
+	scanned      map[string]*rowDynamic
+	offsets      []byte
+	sharedPaths  rowString
+	sharedValues rowString
+
+	// Decoded RowBinary values.
+	rows      int
+	decoded   []string // dynamic paths in order of first value
+	decoders  []*rowDynamic
+	index     map[string]int
+	sorted    []int // decoded paths sorted by name
+	pool      map[string]*rowDynamic
+	set       []bool // typed and decoded paths of row
+	shared    []rowJSONShared
+	values    []byte
+	raw       *rowDynamic // of shared data values
+	buf       []byte      // shared data offsets
+	last      uint64
+	pathsBuf  rowString
+	valuesBuf rowString
+}
+
+func newRowJSON(t Type) (*rowJSON, error) {
+	c := &rowJSON{
+		scanned: map[string]*rowDynamic{},
+		index:   map[string]int{},
+		pool:    map[string]*rowDynamic{},
+	}
+	for _, p := range t.Params {
+		switch {
+		case p.Kind == TypeParamSetting && (p.Name == "max_dynamic_paths" || p.Name == "max_dynamic_types"):
+			n, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: %s", t.Name, p.Name)
+			}
+			if p.Name == "max_dynamic_paths" {
+				c.maxPaths = n
+			} else {
+				c.maxTypes = n
+			}
+		case p.Kind == TypeParamRaw && strings.HasPrefix(p.Value, "SKIP "):
+			// Skipped paths are not sent.
+			continue
+		case p.Kind == TypeParamType && p.Name != "":
+			codec, err := newRowCodec(*p.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: typed path %q", t.Name, p.Name)
+			}
+			idx, _ := searchPath(c.typedPaths, p.Name)
+			c.typedPaths = slices.Insert(c.typedPaths, idx, p.Name)
+			c.typed = slices.Insert(c.typed, idx, codec)
+		default:
+			return nil, errors.Errorf("%s: invalid parameter %s", t.Name, p)
+		}
+	}
+	c.raw = newRowDynamic(c.maxTypes)
+	return c, nil
+}
+
+func (c *rowJSON) scanState(data []byte) ([]byte, error) {
+	version, data, err := scanInt64(data)
+	if err != nil {
+		return nil, err
+	}
+	switch uint64(version) {
+	case jsonSerializationV1:
+		if _, size := binary.Uvarint(data); size > 0 {
+			data = data[size:]
+		} else {
+			return nil, errRowNative
+		}
+	case jsonSerializationV2:
+	default:
+		return nil, errors.Errorf("unsupported JSON serialization version %d", version)
+	}
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > maxRowsInBLock {
+		return nil, errRowNative
+	}
+	data = data[size:]
+	c.dynamicPaths = c.dynamicPaths[:0]
+	c.dynamic = c.dynamic[:0]
+	for i := 0; i < int(n); i++ {
+		var p string
+		if p, data, err = scanString(data); err != nil {
+			return nil, errors.Wrapf(err, "dynamic path [%d]", i)
+		}
+		d, ok := c.scanned[p]
+		if !ok {
+			d = newRowDynamic(c.maxTypes)
+			c.scanned[p] = d
+		}
+		c.dynamicPaths = append(c.dynamicPaths, p)
+		c.dynamic = append(c.dynamic, d)
+	}
+	for i, e := range c.typed {
+		if data, err = e.scanState(data); err != nil {
+			return nil, errors.Wrapf(err, "typed path %q", c.typedPaths[i])
+		}
+	}
+	for i, e := range c.dynamic {
+		if data, err = e.scanState(data); err != nil {
+			return nil, errors.Wrapf(err, "dynamic path %q", c.dynamicPaths[i])
+		}
+	}
+	return data, nil
+}
+
+// offset returns end of shared data of i-th scanned row.
+func (c *rowJSON) offset(i int) int {
+	if i < 0 {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint64(c.offsets[i*8:]))
+}
+
+func (c *rowJSON) scan(data []byte, rows int) ([]byte, error) {
+	var err error
+	for i, e := range c.typed {
+		if data, err = e.scan(data, rows); err != nil {
+			return nil, errors.Wrapf(err, "typed path %q", c.typedPaths[i])
+		}
+	}
+	for i, e := range c.dynamic {
+		if data, err = e.scan(data, rows); err != nil {
+			return nil, errors.Wrapf(err, "dynamic path %q", c.dynamicPaths[i])
+		}
+	}
+	if c.offsets, data, err = scanRaw(data, rows*8); err != nil {
+		return nil, errors.Wrap(err, "shared data offsets")
+	}
+	var prev int
+	for i := 0; i < rows; i++ {
+		offset := c.offset(i)
+		if offset < prev || offset > maxRowsInBLock {
+			return nil, errors.Errorf("invalid shared data offset %d", offset)
+		}
+		prev = offset
+	}
+	if data, err = c.sharedPaths.scan(data, prev); err != nil {
+		return nil, errors.Wrap(err, "shared data paths")
+	}
+	if data, err = c.sharedValues.scan(data, prev); err != nil {
+		return nil, errors.Wrap(err, "shared data values")
+	}
+	return data, nil
+}
+
+func (c *rowJSON) encode(b *Buffer, i int) {
+	start, end := c.offset(i-1), c.offset(i)
+	n := len(c.typed) + end - start
+	for _, d := range c.dynamic {
+		if !d.null(i) {
+			n++
+		}
+	}
+	b.PutUVarInt(uint64(n))
+	for j, e := range c.typed {
+		b.PutString(c.typedPaths[j])
+		e.encode(b, i)
+	}
+	for j, d := range c.dynamic {
+		if !d.null(i) {
+			b.PutString(c.dynamicPaths[j])
+			d.encode(b, i)
+		}
+	}
+	for j := start; j < end; j++ {
+		c.sharedPaths.encode(b, j)
+		b.PutRaw(c.sharedValues.value(j))
+	}
+}
+
+func (c *rowJSON) putState(b *Buffer) {
+	c.sorted = c.sorted[:0]
+	for i := range c.decoded {
+		c.sorted = append(c.sorted, i)
+	}
+	sort.Slice(c.sorted, func(i, j int) bool {
+		return c.decoded[c.sorted[i]] < c.decoded[c.sorted[j]]
+	})
+
+	maxPaths := c.maxPaths
+	if maxPaths == 0 {
+		maxPaths = jsonDefaultMaxDynamicPaths
+	}
+	b.PutUInt64(jsonSerializationV1)
+	b.PutUVarInt(uint64(maxPaths))
+	b.PutUVarInt(uint64(len(c.decoded)))
+	for _, d := range c.sorted {
+		b.PutString(c.decoded[d])
+	}
+	for _, e := range c.typed {
+		e.putState(b)
+	}
+	for _, d := range c.sorted {
+		c.decoders[d].putState(b)
+	}
+}
+
+// dynamicPath returns index of decoded dynamic path, adding it if there
+// are less than max_dynamic_paths paths.
+func (c *rowJSON) dynamicPath(path string) (int, bool) {
+	if idx, ok := c.index[path]; ok {
+		return idx, true
+	}
+	maxPaths := c.maxPaths
+	if maxPaths == 0 {
+		maxPaths = jsonDefaultMaxDynamicPaths
+	}
+	if len(c.decoded) >= maxPaths {
+		return 0, false
+	}
+	d, ok := c.pool[path]
+	if !ok {
+		d = newRowDynamic(c.maxTypes)
+		c.pool[path] = d
+	}
+	// Path is NULL in previous rows.
+	for i := 0; i < c.rows; i++ {
+		d.appendDefault()
+	}
+	idx := len(c.decoded)
+	c.decoded = append(c.decoded, path)
+	c.decoders = append(c.decoders, d)
+	c.index[path] = idx
+	c.set = append(c.set, false)
+	return idx, true
+}
+
+func (c *rowJSON) decode(r *Reader) error {
+	n, err := r.UVarInt()
+	if err != nil {
+		return errors.Wrap(err, "paths count")
+	}
+	if n > maxRowsInBLock {
+		return errors.Errorf("paths count %d is too big", n)
+	}
+	c.set = append(c.set[:0], make([]bool, len(c.typed)+len(c.decoded))...)
+	c.shared = c.shared[:0]
+	c.values = c.values[:0]
+	for i := 0; i < int(n); i++ {
+		path, err := r.Str()
+		if err != nil {
+			return errors.Wrapf(err, "path [%d]", i)
+		}
+		if idx, ok := searchPath(c.typedPaths, path); ok {
+			if c.set[idx] {
+				return errors.Errorf("duplicate path %q", path)
+			}
+			c.set[idx] = true
+			if err := c.typed[idx].decode(r); err != nil {
+				return errors.Wrapf(err, "typed path %q", path)
+			}
+			continue
+		}
+		if idx, ok := c.dynamicPath(path); ok {
+			if c.set[len(c.typed)+idx] {
+				return errors.Errorf("duplicate path %q", path)
+			}
+			c.set[len(c.typed)+idx] = true
+			if err := c.decoders[idx].decode(r); err != nil {
+				return errors.Wrapf(err, "dynamic path %q", path)
+			}
+			continue
+		}
+		v, err := c.raw.decodeRaw(r)
+		if err != nil {
+			return errors.Wrapf(err, "shared data path %q", path)
+		}
+		if v == nil {
+			// NULL values are not stored.
+			continue
+		}
+		start := len(c.values)
+		c.values = append(c.values, v...)
+		c.shared = append(c.shared, rowJSONShared{path: path, start: start, end: len(c.values)})
+	}
+	for i, e := range c.typed {
+		if !c.set[i] {
+			e.appendDefault()
+		}
+	}
+	for i, d := range c.decoders {
+		if !c.set[len(c.typed)+i] {
+			d.appendDefault()
+		}
+	}
+	// Shared data of row is sorted by path.
+	sort.Slice(c.shared, func(i, j int) bool {
+		return c.shared[i].path < c.shared[j].path
+	})
+	for i, s := range c.shared {
+		if i > 0 && c.shared[i-1].path == s.path {
+			return errors.Errorf("duplicate path %q", s.path)
+		}
+		c.pathsBuf.appendValue([]byte(s.path))
+		c.valuesBuf.appendValue(c.values[s.start:s.end])
+	}
+	c.last += uint64(len(c.shared))
+	c.buf = binary.LittleEndian.AppendUint64(c.buf, c.last)
+	c.rows++
+	return nil
+}
+
+func (c *rowJSON) appendDefault() {
+	for _, e := range c.typed {
+		e.appendDefault()
+	}
+	for _, d := range c.decoders {
+		d.appendDefault()
+	}
+	c.buf = binary.LittleEndian.AppendUint64(c.buf, c.last)
+	c.rows++
+}
+
+func (c *rowJSON) flush(b *Buffer) {
+	for _, e := range c.typed {
+		e.flush(b)
+	}
+	for _, d := range c.sorted {
+		c.decoders[d].flush(b)
+	}
+	b.PutRaw(c.buf)
+	c.pathsBuf.flush(b)
+	c.valuesBuf.flush(b)
+
+	c.rows = 0
+	c.decoded = c.decoded[:0]
+	c.decoders = c.decoders[:0]
+	clear(c.index)
+	c.buf = c.buf[:0]
+	c.last = 0
+}
@@ -0,0 +1,340 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// rowBinaryInput returns input columns with two rows of various types.
+func rowBinaryInput(t *testing.T) Input {
+	t.Helper()
+	var (
+		id       ColUInt64
+		str      ColStr
+		fixed    = &ColFixedStr{Size: 3}
+		dec      ColDecimal64
+		date     = &ColDateTime{Location: time.UTC}
+		u        ColUUID
+		enum     = new(ColEnum)
+		nullable = new(ColStr).Nullable()
+		arr      = new(ColStr).Array()
+		lc       = new(ColStr).LowCardinality()
+		lcArr    = new(ColStr).LowCardinality().Array()
+		m        = NewMap[string, uint8](new(ColStr), new(ColUInt8))
+		tuple    = ColTuple{new(ColInt8), new(ColStr)}
+		variant  = NewVariant(new(ColStr), new(ColUInt64))
+		points   = new(ColRing)
+		nothing  = new(ColNothing).Nullable()
+		sum      = new(ColAggregateFunction)
+		dynamic  = NewDynamic()
+		json     = new(ColJSON)
+	)
+	require.NoError(t, enum.Infer("Enum8('a' = 1, 'b' = 2)"))
+	require.NoError(t, sum.Infer("AggregateFunction(sum, UInt64)"))
+	require.NoError(t, json.Infer("JSON(a UInt8)"))
+
+	id.Append(1)
+	str.Append("foo")
+	fixed.Append([]byte("abc"))
+	dec.Append(1234)
+	date.Append(time.Unix(1546290000, 0))
+	u.Append(uuid.MustParse("00112233-4455-6677-8899-aabbccddeeff"))
+	enum.Append("b")
+	nullable.Append(Null[string]())
+	arr.Append([]string{"a", "b"})
+	lc.Append("x")
+	lcArr.Append([]string{"x", "y", "x"})
+	m.Append(map[string]uint8{"k": 3})
+	tuple[0].(*ColInt8).Append(-1)
+	tuple[1].(*ColStr).Append("t")
+	VariantAppend(variant, 0, "v")
+	points.Append(Ring{{X: 1, Y: 2}})
+	nothing.Append(Null[Nothing]())
+	sum.AppendState(&SumState[uint64]{Sum: 10})
+	dynamic.Append(DynamicValue{Type: "String", Value: "d"})
+	json.Append(map[string]DynamicValue{
+		"a":   {Value: uint8(1)},
+		"b.c": {Type: "Int64", Value: int64(2)},
+	})
+
+	id.Append(2)
+	str.Append("")
+	fixed.Append([]byte("de\x00"))
+	dec.Append(-1)
+	date.Append(time.Unix(0, 0))
+	u.Append(uuid.Nil)
+	enum.Append("a")
+	nullable.Append(NewNullable("bar"))
+	arr.Append(nil)
+	lc.Append("x")
+	lcArr.Append(nil)
+	m.Append(map[string]uint8{})
+	tuple[0].(*ColInt8).Append(2)
+	tuple[1].(*ColStr).Append("")
+	variant.AppendNull()
+	points.Append(nil)
+	nothing.Append(Null[Nothing]())
+	sum.AppendState(&SumState[uint64]{})
+	dynamic.AppendNull()
+	json.Append(map[string]DynamicValue{})
+
+	return Input{
+		{Name: "id", Data: &id},
+		{Name: "str", Data: &str},
+		{Name: "fixed", Data: fixed},
+		{Name: "dec", Data: Alias(&dec, "Decimal(18, 2)")},
+		{Name: "date", Data: date},
+		{Name: "uuid", Data: &u},
+		{Name: "enum", Data: enum},
+		{Name: "nullable", Data: nullable},
+		{Name: "arr", Data: arr},
+		{Name: "lc", Data: lc},
+		{Name: "lc_arr", Data: lcArr},
+		{Name: "map", Data: m},
+		{Name: "tuple", Data: tuple},
+		{Name: "variant", Data: variant},
+		{Name: "ring", Data: points},
+		{Name: "nothing", Data: nothing},
+		{Name: "sum", Data: sum},
+		{Name: "dynamic", Data: dynamic},
+		{Name: "json", Data: json},
+	}
+}
+
+func TestRowBinary(t *testing.T) {
+	for _, f := range []RowBinaryFormat{
+		RowBinary,
+		RowBinaryWithNames,
+		RowBinaryWithNamesAndTypes,
+	} {
+		t.Run(f.String(), func(t *testing.T) {
+			input := rowBinaryInput(t)
+			e, err := NewRowBinaryEncoder(f, input)
+			require.NoError(t, err)
+
+			var buf Buffer
+			e.EncodeHeader(&buf)
+			require.NoError(t, e.Encode(&buf))
+			require.Equal(t, 2, e.Rows())
+
+			var results Results
+			for _, c := range input {
+				col := new(ColAuto)
+				if f != RowBinaryWithNamesAndTypes {
+					require.NoError(t, col.Infer(c.Data.Type()))
+				}
+				results = append(results, ResultColumn{Data: col})
+			}
+			r := NewReader(bytes.NewReader(buf.Buf))
+			d := NewRowBinaryDecoder(f, results)
+			require.NoError(t, d.DecodeHeader(r))
+			for {
+				err := d.DecodeRow(r)
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+			}
+			require.Equal(t, 2, d.Rows())
+			require.NoError(t, d.Flush())
+			require.Equal(t, 0, d.Rows())
+			if f == RowBinary {
+				// Names are not encoded.
+				for i := range results {
+					results[i].Name = input[i].Name
+				}
+			}
+
+			require.Equal(t, "foo", results[1].Data.(*ColAuto).Data.(*ColStr).Row(0))
+
+			// Decoded columns should be encoded to the same rows.
+			var decoded Input
+			for i, c := range results {
+				require.Equal(t, input[i].Data.Type(), c.Data.Type())
+				decoded = append(decoded, InputColumn{Name: c.Name, Data: c.Data.(ColInput)})
+			}
+			e, err = NewRowBinaryEncoder(f, decoded)
+			require.NoError(t, err)
+			var got Buffer
+			e.EncodeHeader(&got)
+			require.NoError(t, e.Encode(&got))
+			require.Equal(t, buf.Buf, got.Buf)
+		})
+	}
+}
+
+func TestRowBinary_Encode(t *testing.T) {
+	var (
+		id    = ColUInt8{1}
+		s     ColStr
+		null  = new(ColUInt16).Nullable()
+		arr   = new(ColInt8).Array()
+		m     = NewMap[string, uint8](new(ColStr), new(ColUInt8))
+		lc    = new(ColStr).LowCardinality()
+		input = Input{
+			{Name: "id", Data: &id},
+			{Name: "s", Data: &s},
+			{Name: "null", Data: null},
+			{Name: "arr", Data: arr},
+			{Name: "m", Data: m},
+			{Name: "lc", Data: lc},
+		}
+	)
+	s.Append("ab")
+	null.Append(Null[uint16]())
+	arr.Append([]int8{1, -1})
+	m.Append(map[string]uint8{"k": 3})
+	lc.Append("x")
+
+	e, err := NewRowBinaryEncoder(RowBinaryWithNamesAndTypes, Input{
+		{Name: "a", Data: &id},
+	})
+	require.NoError(t, err)
+	var buf Buffer
+	e.EncodeHeader(&buf)
+	require.Equal(t, []byte{1, 1, 'a', 5, 'U', 'I', 'n', 't', '8'}, buf.Buf)
+
+	e, err = NewRowBinaryEncoder(RowBinary, input)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, e.Encode(&buf))
+	require.Equal(t, []byte{
+		1,           // id
+		2, 'a', 'b', // s
+		1,          // null
+		2, 1, 0xff, // arr
+		1, 1, 'k', 3, // m
+		1, 'x', // lc
+	}, buf.Buf)
+
+	// Encoding of prepared rows should not allocate.
+	require.Zero(t, testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		e.EncodeRow(&buf, 0)
+	}))
+}
+
+func TestRowBinaryDecoder_Error(t *testing.T) {
+	input := Input{{Name: "s", Data: &ColStr{}}}
+	input[0].Data.(*ColStr).Append("foo")
+	e, err := NewRowBinaryEncoder(RowBinary, input)
+	require.NoError(t, err)
+	var buf Buffer
+	require.NoError(t, e.Encode(&buf))
+
+	results := Results{{Name: "s", Data: new(ColStr)}}
+	d := NewRowBinaryDecoder(RowBinary, results)
+	r := NewReader(bytes.NewReader(buf.Buf[:len(buf.Buf)-1]))
+	require.ErrorIs(t, d.DecodeRow(r), io.ErrUnexpectedEOF)
+
+	d = NewRowBinaryDecoder(RowBinaryWithNames, results)
+	require.Error(t, d.DecodeRow(NewReader(bytes.NewReader(buf.Buf))), "header")
+
+	lc := new(ColAuto)
+	require.NoError(t, lc.Infer("LowCardinality(Nullable(String))"))
+	_, err = NewRowBinaryEncoder(RowBinary, Input{{Name: "lc", Data: lc}})
+	require.Error(t, err)
+}
+
+// rowBinaryRoundTrip encodes input in RowBinary format, decodes it to
+// columns of types and checks that they are encoded to the same rows.
+func rowBinaryRoundTrip(t *testing.T, input Input, types ...ColumnType) ([]byte, Results) {
+	t.Helper()
+	e, err := NewRowBinaryEncoder(RowBinary, input)
+	require.NoError(t, err)
+	var buf Buffer
+	require.NoError(t, e.Encode(&buf))
+
+	var results Results
+	for i, typ := range types {
+		col := new(ColAuto)
+		require.NoError(t, col.Infer(typ))
+		results = append(results, ResultColumn{Name: input[i].Name, Data: col})
+	}
+	d := NewRowBinaryDecoder(RowBinary, results)
+	r := NewReader(bytes.NewReader(buf.Buf))
+	for {
+		err := d.DecodeRow(r)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, d.Flush())
+
+	var decoded Input
+	for _, c := range results {
+		decoded = append(decoded, InputColumn{Name: c.Name, Data: c.Data.(ColInput)})
+	}
+	e, err = NewRowBinaryEncoder(RowBinary, decoded)
+	require.NoError(t, err)
+	var got Buffer
+	require.NoError(t, e.Encode(&got))
+	require.Equal(t, buf.Buf, got.Buf)
+	return buf.Buf, results
+}
+
+func TestRowBinary_Dynamic(t *testing.T) {
+	c := NewDynamic()
+	c.Append(DynamicValue{Type: "String", Value: "a"})
+	c.Append(DynamicValue{Type: "UInt8", Value: uint8(1)})
+	c.AppendNull()
+
+	data, results := rowBinaryRoundTrip(t, Input{{Name: "d", Data: c}}, "Dynamic(max_types=1)")
+	require.Equal(t, []byte{
+		binaryTypeString, 1, 'a',
+		binaryTypeUInt8, 1,
+		binaryTypeNothing,
+	}, data)
+
+	// Types that exceed max_types are decoded to shared variant.
+	got := results[0].Data.(*ColAuto).Data.(*ColDynamic)
+	require.Equal(t, []ColumnType{DynamicSharedVariant, "String"}, got.Types())
+	require.Equal(t, ColumnType("String"), got.RowType(0))
+	require.Equal(t, DynamicSharedVariant, got.RowType(1))
+	require.Equal(t, string([]byte{binaryTypeUInt8, 1}), got.Row(1).Value)
+	require.True(t, got.Row(2).IsNull())
+}
+
+func TestRowBinary_JSON(t *testing.T) {
+	c := new(ColJSON)
+	require.NoError(t, c.Infer("JSON(id UInt8)"))
+	c.Append(map[string]DynamicValue{
+		"id": {Value: uint8(1)},
+		"a":  {Type: "String", Value: "x"},
+		"b":  {Type: "Int8", Value: int8(-1)},
+	})
+	c.Append(map[string]DynamicValue{
+		"id": {Value: uint8(2)},
+	})
+
+	data, results := rowBinaryRoundTrip(t, Input{{Name: "j", Data: c}}, "JSON(max_dynamic_paths=1, id UInt8)")
+	require.Equal(t, []byte{
+		3,           // paths
+		2, 'i', 'd', // typed path
+		1,
+		1, 'a', // dynamic path
+		binaryTypeString, 1, 'x',
+		1, 'b',
+		binaryTypeInt8, 0xff,
+
+		1, // paths, NULL dynamic paths are omitted
+		2, 'i', 'd',
+		2,
+	}, data)
+
+	// Paths that exceed max_dynamic_paths are decoded to shared data.
+	got := results[0].Data.(*ColAuto).Data.(*ColJSON)
+	require.Equal(t, []string{"a"}, got.DynamicPaths())
+	require.Equal(t, map[string]any{
+		"id": uint8(1),
+		"a":  "x",
+		"b":  []byte{binaryTypeInt8, 0xff},
+	}, got.Row(0))
+	require.Equal(t, map[string]any{"id": uint8(2)}, got.Row(1))
+}
@@ -16,8 +16,26 @@ type ServerHello struct {
 	Timezone    string
 	DisplayName string
 	Patch       int
+
+	// ChunkedSend and ChunkedRecv are chunking capabilities of server for
+	// sending and receiving packets.
+	ChunkedSend Chunking
+	ChunkedRecv Chunking
+
+	PasswordComplexityRules []PasswordComplexityRule
+	// Nonce for inter-server secret.
+	Nonce uint64
+}
+
+// PasswordComplexityRule is rule for passwords of users, set on server.
+type PasswordComplexityRule struct {
+	Pattern string
+	Message string
 }
 
+// maxPasswordComplexityRules limits count of password complexity rules.
+const maxPasswordComplexityRules = 1024
+
 // Features implemented by server.
 func (s ServerHello) Features() []Feature {
 	var features []Feature
@@ -76,6 +94,10 @@ func (s *ServerHello) DecodeAware(r *Reader, v int) error {
 	}
 
 	s.Major, s.Minor, s.Revision = major, minor, revision
+	if revision < v {
+		// Server sends only fields of features it implements.
+		v = revision
+	}
 
 	if FeatureTimezone.In(v) {
 		v, err := r.Str()
@@ -98,6 +120,44 @@ func (s *ServerHello) DecodeAware(r *Reader, v int) error {
 		}
 		s.Patch = path
 	}
+	if FeatureChunkedPackets.In(v) {
+		send, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "chunked send")
+		}
+		recv, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "chunked recv")
+		}
+		s.ChunkedSend, s.ChunkedRecv = Chunking(send), Chunking(recv)
+	}
+	if FeaturePasswordComplexityRules.In(v) {
+		n, err := r.Int()
+		if err != nil {
+			return errors.Wrap(err, "password complexity rules")
+		}
+		if n > maxPasswordComplexityRules {
+			return errors.Errorf("too many password complexity rules (%d)", n)
+		}
+		s.PasswordComplexityRules = s.PasswordComplexityRules[:0]
+		for i := 0; i < n; i++ {
+			var rule PasswordComplexityRule
+			if rule.Pattern, err = r.Str(); err != nil {
+				return errors.Wrapf(err, "password complexity rule [%d]: pattern", i)
+			}
+			if rule.Message, err = r.Str(); err != nil {
+				return errors.Wrapf(err, "password complexity rule [%d]: message", i)
+			}
+			s.PasswordComplexityRules = append(s.PasswordComplexityRules, rule)
+		}
+	}
+	if FeatureInterServerSecretV2.In(v) {
+		nonce, err := r.UInt64()
+		if err != nil {
+			return errors.Wrap(err, "nonce")
+		}
+		s.Nonce = nonce
+	}
 
 	return nil
 }
@@ -108,6 +168,9 @@ func (s *ServerHello) EncodeAware(b *Buffer, v int) {
 	b.PutInt(s.Major)
 	b.PutInt(s.Minor)
 	b.PutInt(s.Revision)
+	if s.Revision < v {
+		v = s.Revision
+	}
 	if FeatureTimezone.In(v) {
 		b.PutString(s.Timezone)
 	}
@@ -117,4 +180,18 @@ func (s *ServerHello) EncodeAware(b *Buffer, v int) {
 	if FeatureVersionPatch.In(v) {
 		b.PutInt(s.Patch)
 	}
+	if FeatureChunkedPackets.In(v) {
+		b.PutString(string(s.ChunkedSend))
+		b.PutString(string(s.ChunkedRecv))
+	}
+	if FeaturePasswordComplexityRules.In(v) {
+		b.PutInt(len(s.PasswordComplexityRules))
+		for _, rule := range s.PasswordComplexityRules {
+			b.PutString(rule.Pattern)
+			b.PutString(rule.Message)
+		}
+	}
+	if FeatureInterServerSecretV2.In(v) {
+		b.PutUInt64(s.Nonce)
+	}
 }
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/gold"
 )
 
 func TestServerHello_DecodeAware(t *testing.T) {
@@ -58,6 +60,43 @@ func TestServerHello_EncodeAware(t *testing.T) {
 	})
 }
 
+func TestServerHello_Chunked(t *testing.T) {
+	v := ServerHello{
+		Name:        "ClickHouse",
+		Major:       24,
+		Minor:       10,
+		Patch:       1,
+		Revision:    Version,
+		Timezone:    "UTC",
+		DisplayName: "alpha",
+		ChunkedSend: ChunkingChunkedOptional,
+		ChunkedRecv: ChunkingNotChunked,
+		PasswordComplexityRules: []PasswordComplexityRule{
+			{Pattern: ".{12}", Message: "be at least 12 characters long"},
+		},
+		Nonce: 0xdeadbeef,
+	}
+	var b Buffer
+	v.EncodeAware(&b, Version)
+	gold.Bytes(t, b.Buf, "server_hello_chunked")
+
+	buf := skipCode(t, b.Buf, int(ServerCodeHello))
+	var dec ServerHello
+	requireDecode(t, buf, aware(&dec))
+	require.Equal(t, v, dec)
+	requireNoShortRead(t, buf, aware(&dec))
+
+	t.Run("OlderClient", func(t *testing.T) {
+		var old Buffer
+		v.EncodeAware(&old, FeatureChunkedPackets.Version()-1)
+		var dec ServerHello
+		r := NewReader(bytes.NewReader(skipCode(t, old.Buf, int(ServerCodeHello))))
+		require.NoError(t, dec.DecodeAware(r, FeatureChunkedPackets.Version()-1))
+		require.Empty(t, dec.ChunkedSend)
+		require.Equal(t, v.Nonce, dec.Nonce)
+	})
+}
+
 func BenchmarkServerHello_Decode(b *testing.B) {
 	var raw Buffer
 	raw.PutString("ClickHouse server")
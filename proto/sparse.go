@@ -0,0 +1,326 @@
+package proto
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/go-faster/errors"
+)
+
+// serializationKind is kind of column serialization, sent when column
+// has custom serialization.
+type serializationKind byte
+
+// Supported serialization kinds.
+const (
+	serializationDefault serializationKind = 0
+	serializationSparse  serializationKind = 1
+)
+
+// serializationInfo describes custom serialization of column.
+//
+// Tuple elements have their own serialization kinds.
+type serializationInfo struct {
+	Kind  serializationKind
+	Elems []serializationInfo
+}
+
+// custom reports whether any part of column is not in default
+// serialization.
+func (s serializationInfo) custom() bool {
+	if s.Kind != serializationDefault {
+		return true
+	}
+	for _, e := range s.Elems {
+		if e.custom() {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSerializationInfo decodes serialization kinds of column of type t.
+func decodeSerializationInfo(r *Reader, t ColumnType) (serializationInfo, error) {
+	v, err := r.UInt8()
+	if err != nil {
+		return serializationInfo{}, errors.Wrap(err, "kind")
+	}
+	info := serializationInfo{Kind: serializationKind(v)}
+	switch info.Kind {
+	case serializationDefault, serializationSparse:
+	default:
+		return info, errors.Errorf("unsupported serialization kind %d", v)
+	}
+	if t.Base() != ColumnTypeTuple {
+		return info, nil
+	}
+	typ, err := ParseType(t)
+	if err != nil {
+		return info, err
+	}
+	for i, e := range typ.Elems {
+		v, err := decodeSerializationInfo(r, e.ColumnType())
+		if err != nil {
+			return info, errors.Wrapf(err, "tuple [%d]", i)
+		}
+		info.Elems = append(info.Elems, v)
+	}
+	return info, nil
+}
+
+// decodeColumnHeader decodes custom serialization flag and serialization
+// info of column if it is supported by protocol version.
+func decodeColumnHeader(r *Reader, version int, t ColumnType) (serializationInfo, error) {
+	if !FeatureCustomSerialization.In(version) {
+		return serializationInfo{}, nil
+	}
+	custom, err := r.Bool()
+	if err != nil {
+		return serializationInfo{}, errors.Wrap(err, "custom serialization")
+	}
+	if !custom {
+		return serializationInfo{}, nil
+	}
+	info, err := decodeSerializationInfo(r, t)
+	if err != nil {
+		return info, errors.Wrap(err, "serialization info")
+	}
+	return info, nil
+}
+
+// decodeColumn decodes rows of column in serialization described by info.
+//
+// Sparse columns are expanded, unless column is ColSparse.
+func decodeColumn(r *Reader, col ColResult, info serializationInfo, rows int) error {
+	if !info.custom() {
+		return col.DecodeColumn(r, rows)
+	}
+	switch v := col.(type) {
+	case *ColAuto:
+		return decodeColumn(r, v.Data, info, rows)
+	case ColAuto:
+		return decodeColumn(r, v.Data, info, rows)
+	}
+	if info.Kind == serializationSparse {
+		return decodeSparse(r, col, rows)
+	}
+	tuple, ok := col.(ColTuple)
+	if !ok {
+		return errors.Errorf("%s: custom serialization of elements is supported only for tuples", col.Type())
+	}
+	if len(tuple) != len(info.Elems) {
+		return errors.Errorf("got %d tuple elements serialization, expected %d", len(info.Elems), len(tuple))
+	}
+	for i, e := range tuple {
+		if err := decodeColumn(r, e, info.Elems[i], rows); err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+	}
+	return nil
+}
+
+// sparseEndOfGranule is flag of last group in sparse offsets.
+const sparseEndOfGranule = 1 << 62
+
+// decodeSparseOffsets decodes indexes of non-default rows.
+//
+// Offsets are encoded as sequence of group sizes, i.e. count of default
+// rows before next non-default row, and count of trailing default rows
+// with end of granule flag.
+func decodeSparseOffsets(r *Reader, rows int) ([]int, error) {
+	var (
+		offsets []int
+		start   int
+	)
+	for {
+		v, err := r.UVarInt()
+		if err != nil {
+			return nil, errors.Wrap(err, "group size")
+		}
+		end := v&sparseEndOfGranule != 0
+		group := int(v &^ sparseEndOfGranule)
+		if group < 0 || group > rows-start {
+			return nil, errors.Errorf("group size %d out of range", group)
+		}
+		start += group
+		if end {
+			break
+		}
+		if start >= rows {
+			return nil, errors.Errorf("offset %d out of range", start)
+		}
+		offsets = append(offsets, start)
+		start++
+	}
+	if start != rows {
+		return nil, errors.Errorf("got %d rows, expected %d", start, rows)
+	}
+	return offsets, nil
+}
+
+// decodeSparse decodes sparse column, expanding it to rows with default
+// values.
+func decodeSparse(r *Reader, col ColResult, rows int) error {
+	offsets, err := decodeSparseOffsets(r, rows)
+	if err != nil {
+		return errors.Wrap(err, "offsets")
+	}
+	if s, ok := col.(*ColSparse); ok {
+		return s.decodeSparse(r, offsets, rows)
+	}
+	before := col.Rows()
+	if err := col.DecodeColumn(r, len(offsets)); err != nil {
+		return errors.Wrap(err, "values")
+	}
+	if err := expandSparse(col, before, offsets, rows); err != nil {
+		return errors.Wrap(err, "expand")
+	}
+	return nil
+}
+
+// expandSparse expands values of non-default rows that are appended to
+// col after first n rows to rows with defaults in place of other rows.
+func expandSparse(col ColResult, n int, offsets []int, rows int) error {
+	v := reflect.ValueOf(col)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		// Fast path for columns that are slices of values, e.g. ColInt64.
+		s := v.Elem()
+		s.Set(reflect.AppendSlice(s, reflect.MakeSlice(s.Type(), rows-len(offsets), rows-len(offsets))))
+		zero := reflect.Zero(s.Type().Elem())
+		k := len(offsets) - 1
+		for i := rows - 1; i >= 0; i-- {
+			if k >= 0 && offsets[k] == i {
+				s.Index(n + i).Set(s.Index(n + k))
+				k--
+				continue
+			}
+			s.Index(n + i).Set(zero)
+		}
+		return nil
+	}
+	if n != 0 {
+		return errors.Errorf("%s: expected empty column", col.Type())
+	}
+	c, ok := col.(Column)
+	if !ok {
+		return errors.Errorf("%s: can't append to column", col.Type())
+	}
+	if !reflect.ValueOf(c).MethodByName("Row").IsValid() || !reflect.ValueOf(c).MethodByName("Append").IsValid() {
+		return errors.Errorf("%s: column should implement ColumnOf", col.Type())
+	}
+	values := make([]any, 0, len(offsets))
+	for i := range offsets {
+		values = append(values, columnRow(c, i))
+	}
+	c.Reset()
+	var k int
+	for i := 0; i < rows; i++ {
+		if k < len(offsets) && offsets[k] == i {
+			columnAppend(c, values[k])
+			k++
+			continue
+		}
+		appendDefault(c)
+	}
+	return nil
+}
+
+// defaultAppender is column with default value of type other than zero
+// value of Go type, like Enum.
+type defaultAppender interface {
+	appendDefault()
+}
+
+// appendDefault appends default value of column type to c.
+func appendDefault(c Column) {
+	if d, ok := c.(defaultAppender); ok {
+		d.appendDefault()
+		return
+	}
+	columnAppendZero(c)
+}
+
+// Compile-time assertions for ColSparse.
+var (
+	_ ColResult    = (*ColSparse)(nil)
+	_ StateDecoder = (*ColSparse)(nil)
+	_ Inferable    = (*ColSparse)(nil)
+)
+
+// ColSparse is result column that keeps sparse serialization instead of
+// expanding it, i.e. only values of non-default rows and their indexes.
+//
+// Dense columns are decoded as sparse columns without default rows.
+type ColSparse struct {
+	Values  ColResult // values of non-default rows
+	Offsets []int     // indexes of rows in Values, sorted
+
+	rows int
+}
+
+// NewSparse returns new sparse result column of values.
+func NewSparse(values ColResult) *ColSparse {
+	return &ColSparse{Values: values}
+}
+
+// Type returns type of values.
+func (c ColSparse) Type() ColumnType { return c.Values.Type() }
+
+// Rows returns rows count, including default rows.
+func (c ColSparse) Rows() int { return c.rows }
+
+// Index returns index of i-th row in Values or false if row has default
+// value.
+func (c ColSparse) Index(i int) (int, bool) {
+	idx := sort.SearchInts(c.Offsets, i)
+	if idx < len(c.Offsets) && c.Offsets[idx] == i {
+		return idx, true
+	}
+	return 0, false
+}
+
+// Infer implements Inferable.
+func (c *ColSparse) Infer(t ColumnType) error {
+	if v, ok := c.Values.(Inferable); ok {
+		return v.Infer(t)
+	}
+	return nil
+}
+
+// DecodeState implements StateDecoder.
+func (c *ColSparse) DecodeState(r *Reader) error {
+	if v, ok := c.Values.(StateDecoder); ok {
+		return v.DecodeState(r)
+	}
+	return nil
+}
+
+// DecodeColumn decodes dense column.
+func (c *ColSparse) DecodeColumn(r *Reader, rows int) error {
+	if err := c.Values.DecodeColumn(r, rows); err != nil {
+		return errors.Wrap(err, "values")
+	}
+	for i := 0; i < rows; i++ {
+		c.Offsets = append(c.Offsets, c.rows+i)
+	}
+	c.rows += rows
+	return nil
+}
+
+func (c *ColSparse) decodeSparse(r *Reader, offsets []int, rows int) error {
+	if err := c.Values.DecodeColumn(r, len(offsets)); err != nil {
+		return errors.Wrap(err, "values")
+	}
+	for _, o := range offsets {
+		c.Offsets = append(c.Offsets, c.rows+o)
+	}
+	c.rows += rows
+	return nil
+}
+
+// Reset implements ColResult.
+func (c *ColSparse) Reset() {
+	c.Values.Reset()
+	c.Offsets = c.Offsets[:0]
+	c.rows = 0
+}
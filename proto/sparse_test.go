@@ -0,0 +1,218 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// putSparseOffsets encodes indexes of non-default rows.
+func putSparseOffsets(b *Buffer, offsets []int, rows int) {
+	var start int
+	for _, o := range offsets {
+		b.PutUVarInt(uint64(o - start))
+		start = o + 1
+	}
+	b.PutUVarInt(uint64(rows-start) | sparseEndOfGranule)
+}
+
+func TestDecodeSparseOffsets(t *testing.T) {
+	for _, tt := range []struct {
+		Name    string
+		Offsets []int
+		Rows    int
+	}{
+		{Name: "Empty", Rows: 0},
+		{Name: "AllDefault", Rows: 10},
+		{Name: "AllValues", Offsets: []int{0, 1, 2}, Rows: 3},
+		{Name: "Trailing", Offsets: []int{1, 5}, Rows: 10},
+		{Name: "Last", Offsets: []int{3, 9}, Rows: 10},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			var b Buffer
+			putSparseOffsets(&b, tt.Offsets, tt.Rows)
+			offsets, err := decodeSparseOffsets(b.Reader(), tt.Rows)
+			require.NoError(t, err)
+			require.Equal(t, tt.Offsets, offsets)
+		})
+	}
+	t.Run("OutOfRange", func(t *testing.T) {
+		var b Buffer
+		putSparseOffsets(&b, []int{1, 5}, 10)
+		_, err := decodeSparseOffsets(b.Reader(), 4)
+		require.Error(t, err)
+	})
+	t.Run("RowsMismatch", func(t *testing.T) {
+		var b Buffer
+		putSparseOffsets(&b, []int{1}, 3)
+		_, err := decodeSparseOffsets(b.Reader(), 5)
+		require.Error(t, err)
+	})
+}
+
+// sparseBlock returns block with sparse "ints" Int64 and "strs" String
+// columns and dense "dense" UInt8 column.
+func sparseBlock(t testing.TB) (Block, *Buffer) {
+	t.Helper()
+	const rows = 6
+	b := new(Buffer)
+	block := Block{Columns: 4, Rows: rows}
+
+	b.PutString("ints")
+	b.PutString("Int64")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(b, []int{1, 4}, rows)
+	ColInt64{10, 40}.EncodeColumn(b)
+
+	b.PutString("strs")
+	b.PutString("String")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(b, []int{5}, rows)
+	var s ColStr
+	s.Append("foo")
+	s.EncodeColumn(b)
+
+	b.PutString("tuple")
+	b.PutString("Tuple(a UInt8, b String)")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationDefault))
+	b.PutUInt8(uint8(serializationSparse))
+	b.PutUInt8(uint8(serializationDefault))
+	putSparseOffsets(b, []int{0}, rows)
+	ColUInt8{7}.EncodeColumn(b)
+	var ts ColStr
+	ts.AppendArr([]string{"a", "b", "c", "d", "e", "f"})
+	ts.EncodeColumn(b)
+
+	b.PutString("dense")
+	b.PutString("UInt8")
+	b.PutBool(false)
+	ColUInt8{1, 2, 3, 4, 5, 6}.EncodeColumn(b)
+
+	return block, b
+}
+
+func TestResults_Sparse(t *testing.T) {
+	block, b := sparseBlock(t)
+	t.Run("Expand", func(t *testing.T) {
+		var (
+			ints  ColInt64
+			strs  ColStr
+			ta    ColUInt8
+			tb    ColStr
+			dense ColUInt8
+		)
+		results := Results{
+			{Name: "ints", Data: &ints},
+			{Name: "strs", Data: &strs},
+			{Name: "tuple", Data: ColTuple{Named[uint8](&ta, "a"), Named[string](&tb, "b")}},
+			{Name: "dense", Data: &dense},
+		}
+		require.NoError(t, results.DecodeResult(b.Reader(), Version, block))
+		require.Equal(t, ColInt64{0, 10, 0, 0, 40, 0}, ints)
+		require.Equal(t, []string{"", "", "", "", "", "foo"}, rowsOf[string](&strs))
+		require.Equal(t, ColUInt8{7, 0, 0, 0, 0, 0}, ta)
+		require.Equal(t, 6, tb.Rows())
+		require.Equal(t, ColUInt8{1, 2, 3, 4, 5, 6}, dense)
+	})
+	t.Run("Sparse", func(t *testing.T) {
+		ints := NewSparse(new(ColInt64))
+		dense := NewSparse(new(ColUInt8))
+		var tb ColStr
+		results := Results{
+			{Name: "ints", Data: ints},
+			{Name: "strs", Data: new(ColStr)},
+			{Name: "tuple", Data: ColTuple{Named[uint8](new(ColUInt8), "a"), Named[string](&tb, "b")}},
+			{Name: "dense", Data: dense},
+		}
+		require.NoError(t, results.DecodeResult(b.Reader(), Version, block))
+		require.Equal(t, 6, ints.Rows())
+		require.Equal(t, []int{1, 4}, ints.Offsets)
+		require.Equal(t, &ColInt64{10, 40}, ints.Values)
+		idx, ok := ints.Index(4)
+		require.True(t, ok)
+		require.Equal(t, 1, idx)
+		_, ok = ints.Index(2)
+		require.False(t, ok)
+		require.Equal(t, []int{0, 1, 2, 3, 4, 5}, dense.Offsets)
+		ints.Reset()
+		require.Equal(t, 0, ints.Rows())
+	})
+	t.Run("ColInfo", func(t *testing.T) {
+		var info ColInfoInput
+		var header Buffer
+		header.PutString("ints")
+		header.PutString("Int64")
+		header.PutBool(true)
+		header.PutUInt8(uint8(serializationSparse))
+		require.NoError(t, info.DecodeResult(header.Reader(), Version, Block{Columns: 1}))
+		require.Equal(t, ColInfoInput{{Name: "ints", Type: ColumnTypeInt64}}, info)
+	})
+}
+
+func TestResults_SparseAuto(t *testing.T) {
+	var b Buffer
+	b.PutString("ints")
+	b.PutString("Int64")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(&b, []int{2}, 3)
+	ColInt64{5}.EncodeColumn(&b)
+	b.PutString("strs")
+	b.PutString("Nullable(String)")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(&b, []int{0}, 3)
+	v := new(ColStr).Nullable()
+	v.Append(NewNullable("foo"))
+	v.EncodeColumn(&b)
+
+	var results Results
+	require.NoError(t, results.Auto().DecodeResult(b.Reader(), Version, Block{Columns: 2, Rows: 3}))
+	require.Equal(t, &ColInt64{0, 0, 5}, results[0].Data)
+	require.Equal(t, []Nullable[string]{
+		NewNullable("foo"), Null[string](), Null[string](),
+	}, rowsOf[Nullable[string]](results[1].Data.(ColumnOf[Nullable[string]])))
+}
+
+func TestResults_SparseEnum(t *testing.T) {
+	var b Buffer
+	b.PutString("e8")
+	b.PutString("Enum8('a' = 1, 'b' = 0)")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(&b, []int{1}, 3)
+	ColEnum8{1}.EncodeColumn(&b)
+	b.PutString("e16")
+	b.PutString("Enum16('x' = 300, 'y' = -5, 'z' = 3)")
+	b.PutBool(true)
+	b.PutUInt8(uint8(serializationSparse))
+	putSparseOffsets(&b, []int{0}, 3)
+	ColEnum16{300}.EncodeColumn(&b)
+
+	var e8, e16 ColEnum
+	results := Results{
+		{Name: "e8", Data: &e8},
+		{Name: "e16", Data: &e16},
+	}
+	require.NoError(t, results.DecodeResult(b.Reader(), Version, Block{Columns: 2, Rows: 3}))
+	require.Equal(t, []string{"b", "a", "b"}, e8.Values)
+	require.Equal(t, []string{"x", "y", "y"}, e16.Values)
+}
+
+func TestDecodeSerializationInfo(t *testing.T) {
+	var b Buffer
+	b.PutUInt8(2)
+	_, err := decodeSerializationInfo(b.Reader(), ColumnTypeInt8)
+	require.Error(t, err, "unknown kind")
+}
+
+func rowsOf[T any](c ColumnOf[T]) []T {
+	var v []T
+	for i := 0; i < c.Rows(); i++ {
+		v = append(v, c.Row(i))
+	}
+	return v
+}
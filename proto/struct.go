@@ -0,0 +1,551 @@
+package proto
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// StructMapper maps fields of Go struct to columns, so rows can be
+// appended from structs and scanned to them.
+//
+// Column name is set by `ch:"name"` tag or is name of field, and fields
+// with `ch:"-"` tag or unexported fields are skipped. Fields of embedded
+// structs without tag are mapped as fields of parent struct.
+//
+// Column types are derived from field types:
+//
+//   - bool, integers, floats and string as Bool, Int*, UInt*, Float* and
+//     String, where int and uint are Int64 and UInt64
+//   - []byte as String
+//   - time.Time as DateTime
+//   - slices as Array, maps as Map and pointers as Nullable
+//   - structs as named Tuple
+//
+// ClickHouse type can be set explicitly after name, e.g.
+// `ch:"ts,DateTime64(9, 'UTC')"` or `ch:"tags,Array(LowCardinality(String))"`,
+// if column of that type has values of field type.
+//
+// Mapping is cached per struct type, and appending pointers to structs
+// without maps or slices does not allocate, except growing of columns.
+type StructMapper struct {
+	info *structInfo
+	cols []Column
+}
+
+// NewStructMapper returns StructMapper for type of v, which is struct or
+// pointer to struct, e.g. (*Row)(nil).
+func NewStructMapper(v any) (*StructMapper, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("%T is not struct", v)
+	}
+	info, err := structInfoOf(t)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", t)
+	}
+	m := &StructMapper{info: info}
+	for _, f := range info.fields {
+		m.cols = append(m.cols, f.codec.column())
+	}
+	return m, nil
+}
+
+// Input returns columns as Input.
+func (m *StructMapper) Input() Input {
+	input := make(Input, len(m.cols))
+	for i, f := range m.info.fields {
+		input[i] = InputColumn{Name: f.name, Data: m.cols[i]}
+	}
+	return input
+}
+
+// Results returns columns as Results.
+//
+// Columns should be selected in order of struct fields.
+func (m *StructMapper) Results() Results {
+	results := make(Results, len(m.cols))
+	for i, f := range m.info.fields {
+		results[i] = ResultColumn{Name: f.name, Data: m.cols[i]}
+	}
+	return results
+}
+
+// Rows returns count of rows.
+func (m *StructMapper) Rows() int {
+	if len(m.cols) == 0 {
+		return 0
+	}
+	return m.cols[0].Rows()
+}
+
+// Reset all columns.
+func (m *StructMapper) Reset() {
+	for _, c := range m.cols {
+		c.Reset()
+	}
+}
+
+// AppendStruct appends v, which is struct or pointer to struct, as row.
+func (m *StructMapper) AppendStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Type() != m.info.t {
+		return errors.Errorf("got %T, expected %s", v, m.info.t)
+	}
+	for i, f := range m.info.fields {
+		f.codec.append(m.cols[i], rv.FieldByIndex(f.index))
+	}
+	return nil
+}
+
+// Scan sets fields of struct pointed by v to values of i-th row.
+func (m *StructMapper) Scan(i int, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != m.info.t {
+		return errors.Errorf("got %T, expected *%s", v, m.info.t)
+	}
+	rv = rv.Elem()
+	for j, f := range m.info.fields {
+		f.codec.scan(m.cols[j], i, rv.FieldByIndex(f.index))
+	}
+	return nil
+}
+
+// structInfo is cached mapping of struct type.
+type structInfo struct {
+	t      reflect.Type
+	fields []structField
+}
+
+type structField struct {
+	name  string
+	index []int
+	codec structCodec
+}
+
+// structCodec appends values of Go type to column and scans them back.
+type structCodec struct {
+	column func() Column
+	append func(c Column, v reflect.Value)
+	scan   func(c Column, i int, v reflect.Value) // v is settable
+}
+
+var structInfos sync.Map // reflect.Type -> *structInfo
+
+func structInfoOf(t reflect.Type) (*structInfo, error) {
+	if v, ok := structInfos.Load(t); ok {
+		return v.(*structInfo), nil
+	}
+	fields, err := structFields(t, nil, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields")
+	}
+	v, _ := structInfos.LoadOrStore(t, &structInfo{t: t, fields: fields})
+	return v.(*structInfo), nil
+}
+
+// structFields returns mapped fields of struct t.
+//
+// Types of structs that are being mapped are in seen to reject recursive
+// types.
+func structFields(t reflect.Type, index []int, seen map[reflect.Type]bool) ([]structField, error) {
+	if seen[t] {
+		return nil, errors.Errorf("recursive type %s", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("ch")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct {
+			embedded, err := structFields(f.Type, fieldIndex, seen)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, rawType, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		var typ *Type
+		if rawType = strings.TrimSpace(rawType); rawType != "" {
+			v, err := ParseType(ColumnType(rawType))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s", f.Name)
+			}
+			typ = &v
+		}
+		codec, err := structCodecOf(f.Type, typ, seen)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", f.Name)
+		}
+		fields = append(fields, structField{
+			name:  name,
+			index: fieldIndex,
+			codec: codec,
+		})
+	}
+	return fields, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structCodecOf returns codec of Go type rt for ClickHouse type t, which is
+// derived from rt if nil.
+func structCodecOf(rt reflect.Type, t *Type, seen map[reflect.Type]bool) (structCodec, error) {
+	if rt == timeType {
+		return leafCodec(t, func() ColumnOf[time.Time] { return new(ColDateTime) },
+			func(v reflect.Value) time.Time {
+				if v.CanAddr() {
+					return *v.Addr().Interface().(*time.Time)
+				}
+				return v.Interface().(time.Time)
+			},
+			func(v reflect.Value, x time.Time) { *v.Addr().Interface().(*time.Time) = x },
+		)
+	}
+	switch rt.Kind() {
+	case reflect.Bool:
+		return leafCodec(t, func() ColumnOf[bool] { return new(ColBool) },
+			func(v reflect.Value) bool { return v.Bool() },
+			func(v reflect.Value, x bool) { v.SetBool(x) },
+		)
+	case reflect.Int8:
+		return intCodec(t, func() ColumnOf[int8] { return new(ColInt8) })
+	case reflect.Int16:
+		return intCodec(t, func() ColumnOf[int16] { return new(ColInt16) })
+	case reflect.Int32:
+		return intCodec(t, func() ColumnOf[int32] { return new(ColInt32) })
+	case reflect.Int64, reflect.Int:
+		return intCodec(t, func() ColumnOf[int64] { return new(ColInt64) })
+	case reflect.Uint8:
+		return uintCodec(t, func() ColumnOf[uint8] { return new(ColUInt8) })
+	case reflect.Uint16:
+		return uintCodec(t, func() ColumnOf[uint16] { return new(ColUInt16) })
+	case reflect.Uint32:
+		return uintCodec(t, func() ColumnOf[uint32] { return new(ColUInt32) })
+	case reflect.Uint64, reflect.Uint:
+		return uintCodec(t, func() ColumnOf[uint64] { return new(ColUInt64) })
+	case reflect.Float32:
+		return floatCodec(t, func() ColumnOf[float32] { return new(ColFloat32) })
+	case reflect.Float64:
+		return floatCodec(t, func() ColumnOf[float64] { return new(ColFloat64) })
+	case reflect.String:
+		return leafCodec(t, func() ColumnOf[string] { return new(ColStr) },
+			func(v reflect.Value) string { return v.String() },
+			func(v reflect.Value, x string) { v.SetString(x) },
+		)
+	case reflect.Slice:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return bytesCodec(t)
+		}
+		return arrayCodec(rt, t, seen)
+	case reflect.Map:
+		return mapCodec(rt, t, seen)
+	case reflect.Ptr:
+		return nullableCodec(rt, t, seen)
+	case reflect.Struct:
+		return tupleCodec(rt, t, seen)
+	default:
+		return structCodec{}, errors.Errorf("unsupported type %s", rt)
+	}
+}
+
+// leafCodec returns codec of ColumnOf[T], which is created by newColumn
+// or inferred from t if it is set.
+func leafCodec[T any](
+	t *Type,
+	newColumn func() ColumnOf[T],
+	get func(v reflect.Value) T,
+	set func(v reflect.Value, x T),
+) (structCodec, error) {
+	column := func() Column { return newColumn() }
+	if t != nil {
+		ct := t.ColumnType()
+		inferred := new(ColAuto)
+		if err := inferred.Infer(ct); err != nil {
+			return structCodec{}, err
+		}
+		if _, ok := inferred.Data.(ColumnOf[T]); !ok {
+			return structCodec{}, errors.Errorf("%s can't be used for %T", ct, *new(T))
+		}
+		column = func() Column {
+			v := new(ColAuto)
+			_ = v.Infer(ct) // checked above
+			return v.Data
+		}
+	}
+	return structCodec{
+		column: column,
+		append: func(c Column, v reflect.Value) {
+			c.(ColumnOf[T]).Append(get(v))
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			set(v, c.(ColumnOf[T]).Row(i))
+		},
+	}, nil
+}
+
+func intCodec[T int8 | int16 | int32 | int64](t *Type, newColumn func() ColumnOf[T]) (structCodec, error) {
+	return leafCodec(t, newColumn,
+		func(v reflect.Value) T { return T(v.Int()) },
+		func(v reflect.Value, x T) { v.SetInt(int64(x)) },
+	)
+}
+
+func uintCodec[T uint8 | uint16 | uint32 | uint64](t *Type, newColumn func() ColumnOf[T]) (structCodec, error) {
+	return leafCodec(t, newColumn,
+		func(v reflect.Value) T { return T(v.Uint()) },
+		func(v reflect.Value, x T) { v.SetUint(uint64(x)) },
+	)
+}
+
+func floatCodec[T float32 | float64](t *Type, newColumn func() ColumnOf[T]) (structCodec, error) {
+	return leafCodec(t, newColumn,
+		func(v reflect.Value) T { return T(v.Float()) },
+		func(v reflect.Value, x T) { v.SetFloat(float64(x)) },
+	)
+}
+
+// bytesCodec returns codec of []byte, which is String by default.
+func bytesCodec(t *Type) (structCodec, error) {
+	if t != nil && t.Name != ColumnTypeString {
+		// E.g. FixedString(N).
+		return leafCodec(t, nil,
+			func(v reflect.Value) []byte { return v.Bytes() },
+			func(v reflect.Value, x []byte) { v.SetBytes(append([]byte(nil), x...)) },
+		)
+	}
+	return structCodec{
+		column: func() Column { return new(ColStr) },
+		append: func(c Column, v reflect.Value) {
+			c.(*ColStr).AppendBytes(v.Bytes())
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			v.SetBytes(append([]byte(nil), c.(*ColStr).RowBytes(i)...))
+		},
+	}, nil
+}
+
+// elemType returns type of i-th element of t if it is name type, or nil
+// if t is nil.
+func elemType(t *Type, name ColumnType, i int) (*Type, error) {
+	if t == nil {
+		return nil, nil
+	}
+	if t.Name != name || i >= len(t.Elems) {
+		return nil, errors.Errorf("unexpected type %s", t)
+	}
+	return &t.Elems[i], nil
+}
+
+func arrayCodec(rt reflect.Type, t *Type, seen map[reflect.Type]bool) (structCodec, error) {
+	et, err := elemType(t, ColumnTypeArray, 0)
+	if err != nil {
+		return structCodec{}, err
+	}
+	elem, err := structCodecOf(rt.Elem(), et, seen)
+	if err != nil {
+		return structCodec{}, errors.Wrap(err, "array")
+	}
+	return structCodec{
+		column: func() Column {
+			return &ColArr[any]{Data: colAny{Column: elem.column()}}
+		},
+		append: func(c Column, v reflect.Value) {
+			arr := c.(*ColArr[any])
+			data := arr.Data.(colAny).Column
+			for j := 0; j < v.Len(); j++ {
+				elem.append(data, v.Index(j))
+			}
+			arr.Offsets = append(arr.Offsets, uint64(data.Rows()))
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			arr := c.(*ColArr[any])
+			data := arr.Data.(colAny).Column
+			var start int
+			if i > 0 {
+				start = int(arr.Offsets[i-1])
+			}
+			n := int(arr.Offsets[i]) - start
+			s := reflect.MakeSlice(rt, n, n)
+			for j := 0; j < n; j++ {
+				elem.scan(data, start+j, s.Index(j))
+			}
+			v.Set(s)
+		},
+	}, nil
+}
+
+func mapCodec(rt reflect.Type, t *Type, seen map[reflect.Type]bool) (structCodec, error) {
+	kt, err := elemType(t, ColumnTypeMap, 0)
+	if err != nil {
+		return structCodec{}, err
+	}
+	vt, err := elemType(t, ColumnTypeMap, 1)
+	if err != nil {
+		return structCodec{}, err
+	}
+	key, err := structCodecOf(rt.Key(), kt, seen)
+	if err != nil {
+		return structCodec{}, errors.Wrap(err, "map key")
+	}
+	value, err := structCodecOf(rt.Elem(), vt, seen)
+	if err != nil {
+		return structCodec{}, errors.Wrap(err, "map value")
+	}
+	return structCodec{
+		column: func() Column {
+			return NewMap[any, any](
+				colAny{Column: key.column(), key: true},
+				colAny{Column: value.column()},
+			)
+		},
+		append: func(c Column, v reflect.Value) {
+			m := c.(*ColMap[any, any])
+			keys, values := m.Keys.(colAny).Column, m.Values.(colAny).Column
+			for it := v.MapRange(); it.Next(); {
+				key.append(keys, it.Key())
+				value.append(values, it.Value())
+			}
+			m.Offsets = append(m.Offsets, uint64(keys.Rows()))
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			m := c.(*ColMap[any, any])
+			keys, values := m.Keys.(colAny).Column, m.Values.(colAny).Column
+			var start int
+			if i > 0 {
+				start = int(m.Offsets[i-1])
+			}
+			end := int(m.Offsets[i])
+			out := reflect.MakeMapWithSize(rt, end-start)
+			for j := start; j < end; j++ {
+				k := reflect.New(rt.Key()).Elem()
+				key.scan(keys, j, k)
+				e := reflect.New(rt.Elem()).Elem()
+				value.scan(values, j, e)
+				out.SetMapIndex(k, e)
+			}
+			v.Set(out)
+		},
+	}, nil
+}
+
+func nullableCodec(rt reflect.Type, t *Type, seen map[reflect.Type]bool) (structCodec, error) {
+	et, err := elemType(t, ColumnTypeNullable, 0)
+	if err != nil {
+		return structCodec{}, err
+	}
+	elem, err := structCodecOf(rt.Elem(), et, seen)
+	if err != nil {
+		return structCodec{}, errors.Wrap(err, "nullable")
+	}
+	var (
+		zero = reflect.Zero(rt.Elem())
+		null = reflect.Zero(rt)
+	)
+	return structCodec{
+		column: func() Column {
+			return &ColNullable[any]{Values: colAny{Column: elem.column()}}
+		},
+		append: func(c Column, v reflect.Value) {
+			col := c.(*ColNullable[any])
+			values := col.Values.(colAny).Column
+			if v.IsNil() {
+				col.Nulls.Append(boolTrue)
+				elem.append(values, zero)
+				return
+			}
+			col.Nulls.Append(boolFalse)
+			elem.append(values, v.Elem())
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			col := c.(*ColNullable[any])
+			if col.Nulls[i] == boolTrue {
+				v.Set(null)
+				return
+			}
+			p := reflect.New(rt.Elem())
+			elem.scan(col.Values.(colAny).Column, i, p.Elem())
+			v.Set(p)
+		},
+	}, nil
+}
+
+func tupleCodec(rt reflect.Type, t *Type, seen map[reflect.Type]bool) (structCodec, error) {
+	if t != nil && t.Name != ColumnTypeTuple {
+		return structCodec{}, errors.Errorf("unexpected type %s", t)
+	}
+	fields, err := structFields(rt, nil, seen)
+	if err != nil {
+		return structCodec{}, errors.Wrap(err, "tuple")
+	}
+	if len(fields) == 0 {
+		return structCodec{}, errors.Errorf("tuple %s: no fields", rt)
+	}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	if t != nil {
+		// Elements are mapped by order, so only their types are checked.
+		if len(t.Elems) != len(fields) {
+			return structCodec{}, errors.Errorf("tuple %s: got %d elements, expected %d", rt, len(t.Elems), len(fields))
+		}
+		for i := range fields {
+			codec, err := structCodecOf(rt.FieldByIndex(fields[i].index).Type, &t.Elems[i], seen)
+			if err != nil {
+				return structCodec{}, errors.Wrapf(err, "tuple [%d]", i)
+			}
+			fields[i].codec = codec
+		}
+		names = t.Names
+	}
+	return structCodec{
+		column: func() Column {
+			tuple := make(ColTuple, len(fields))
+			for i, f := range fields {
+				tuple[i] = f.codec.column()
+				if names != nil && names[i] != "" {
+					tuple[i] = colNamedAny{Column: tuple[i], name: names[i]}
+				}
+			}
+			return tuple
+		},
+		append: func(c Column, v reflect.Value) {
+			tuple := c.(ColTuple)
+			for i, f := range fields {
+				f.codec.append(unnamed(tuple[i]), v.FieldByIndex(f.index))
+			}
+		},
+		scan: func(c Column, i int, v reflect.Value) {
+			tuple := c.(ColTuple)
+			for j, f := range fields {
+				f.codec.scan(unnamed(tuple[j]), i, v.FieldByIndex(f.index))
+			}
+		},
+	}, nil
+}
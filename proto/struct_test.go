@@ -0,0 +1,203 @@
+package proto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type structTestPoint struct {
+	X int32 `ch:"x"`
+	Y int32 `ch:"y"`
+}
+
+type structTestMeta struct {
+	Host string `ch:"host"`
+}
+
+type structTestRow struct {
+	structTestMeta
+
+	ID       uint64            `ch:"id"`
+	Name     string            `ch:"name"`
+	Level    string            `ch:"level,LowCardinality(String)"`
+	Status   string            `ch:"status,Enum8('ok' = 1, 'fail' = 2)"`
+	Time     time.Time         `ch:"ts,DateTime('UTC')"`
+	Precise  time.Time         `ch:"precise,DateTime64(9, 'UTC')"`
+	Raw      []byte            `ch:"raw"`
+	Hash     []byte            `ch:"hash,FixedString(2)"`
+	Tags     []string          `ch:"tags"`
+	Attrs    map[string]uint16 `ch:"attrs"`
+	Comment  *string           `ch:"comment"`
+	Point    structTestPoint   `ch:"point"`
+	Points   []structTestPoint `ch:"points"`
+	Valid    bool
+	Score    float64 `ch:"score"`
+	Skipped  int     `ch:"-"`
+	internal int
+}
+
+func TestStructMapper(t *testing.T) {
+	m, err := NewStructMapper((*structTestRow)(nil))
+	require.NoError(t, err)
+
+	var types []string
+	for _, c := range m.Input() {
+		types = append(types, c.Name+" "+c.Data.Type().String())
+	}
+	require.Equal(t, []string{
+		"host String",
+		"id UInt64",
+		"name String",
+		"level LowCardinality(String)",
+		"status Enum8('ok' = 1, 'fail' = 2)",
+		"ts DateTime('UTC')",
+		"precise DateTime64(9, 'UTC')",
+		"raw String",
+		"hash FixedString(2)",
+		"tags Array(String)",
+		"attrs Map(String, UInt16)",
+		"comment Nullable(String)",
+		"point Tuple(x Int32, y Int32)",
+		"points Array(Tuple(x Int32, y Int32))",
+		"Valid Bool",
+		"score Float64",
+	}, types)
+
+	comment := "hello"
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var rows []structTestRow
+	for i := 0; i < 10; i++ {
+		row := structTestRow{
+			structTestMeta: structTestMeta{Host: "host"},
+
+			ID:      uint64(i),
+			Name:    "name",
+			Level:   "info",
+			Status:  "ok",
+			Time:    start.Add(time.Duration(i) * time.Second),
+			Precise: start.Add(time.Duration(i) * time.Nanosecond),
+			Raw:     []byte{byte(i)},
+			Hash:    []byte{'a', byte('0' + i)},
+			Tags:    []string{"a", "b"},
+			Attrs:   map[string]uint16{"a": uint16(i)},
+			Point:   structTestPoint{X: int32(i), Y: -int32(i)},
+			Valid:   i%2 == 0,
+			Score:   float64(i) / 2,
+		}
+		if i%3 == 0 {
+			row.Comment = &comment
+			row.Status = "fail"
+			row.Points = []structTestPoint{{X: 1, Y: 2}, {X: 3, Y: 4}}
+		}
+		rows = append(rows, row)
+		if i%2 == 0 {
+			require.NoError(t, m.AppendStruct(row))
+		} else {
+			require.NoError(t, m.AppendStruct(&row))
+		}
+	}
+	require.Equal(t, len(rows), m.Rows())
+
+	// Encoding and decoding to columns of other mapper.
+	dec, err := NewStructMapper(structTestRow{})
+	require.NoError(t, err)
+	results := dec.Results()
+	for i, c := range m.Input() {
+		if v, ok := c.Data.(Preparable); ok {
+			require.NoError(t, v.Prepare())
+		}
+		var b Buffer
+		if v, ok := c.Data.(StateEncoder); ok {
+			v.EncodeState(&b)
+		}
+		c.Data.EncodeColumn(&b)
+
+		r := b.Reader()
+		col := results[i].Data
+		if v, ok := col.(Inferable); ok {
+			require.NoError(t, v.Infer(c.Data.Type()))
+		}
+		if v, ok := col.(StateDecoder); ok {
+			require.NoError(t, v.DecodeState(r))
+		}
+		require.NoError(t, col.DecodeColumn(r, m.Rows()), c.Name)
+	}
+	require.Equal(t, len(rows), dec.Rows())
+	for i, expected := range rows {
+		var got structTestRow
+		require.NoError(t, dec.Scan(i, &got))
+		expected.Skipped = 0
+		if expected.Points == nil {
+			expected.Points = []structTestPoint{}
+		}
+		require.Equal(t, expected, got)
+	}
+
+	m.Reset()
+	require.Zero(t, m.Rows())
+}
+
+type structTestPlain struct {
+	ID    int64     `ch:"id"`
+	Name  string    `ch:"name"`
+	Time  time.Time `ch:"ts"`
+	Value float32   `ch:"v"`
+	Ok    bool      `ch:"ok"`
+}
+
+func TestStructMapper_AppendStructAllocs(t *testing.T) {
+	m, err := NewStructMapper((*structTestPlain)(nil))
+	require.NoError(t, err)
+	row := &structTestPlain{ID: 1, Name: "name", Time: time.Unix(100, 0), Value: 1.5, Ok: true}
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, m.AppendStruct(row))
+	}
+	m.Reset()
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = m.AppendStruct(row)
+	})
+	require.Zero(t, allocs)
+}
+
+func TestStructMapper_Errors(t *testing.T) {
+	for _, v := range []any{
+		nil,
+		1,
+		struct{}{},
+		struct{ C chan int }{},
+		struct {
+			V string `ch:"v,UInt8"`
+		}{},
+		struct {
+			V int64 `ch:"v,Array(Int64)"`
+		}{},
+		struct {
+			V []int64 `ch:"v,Map(String, Int64)"`
+		}{},
+		struct {
+			V time.Time `ch:"v,DateTime64(x)"`
+		}{},
+		struct {
+			V structTestPoint `ch:"v,Tuple(Int32)"`
+		}{},
+		structTestRecursive{},
+	} {
+		_, err := NewStructMapper(v)
+		require.Error(t, err, "%T", v)
+	}
+
+	m, err := NewStructMapper(structTestPlain{})
+	require.NoError(t, err)
+	require.Error(t, m.AppendStruct(structTestPoint{}))
+	require.Error(t, m.AppendStruct(nil))
+	require.Error(t, m.AppendStruct((*structTestPlain)(nil)))
+	require.NoError(t, m.AppendStruct(structTestPlain{}))
+	require.Error(t, m.Scan(0, structTestPlain{}))
+	require.Error(t, m.Scan(0, &structTestPoint{}))
+}
+
+type structTestRecursive struct {
+	Next *structTestRecursive
+}
@@ -1,12 +1,26 @@
 package proto
 
-import "github.com/go-faster/errors"
+import (
+	"strconv"
+	"strings"
 
+	"github.com/go-faster/errors"
+)
+
+// TableColumns is description of table columns sent by server before
+// INSERT data.
 type TableColumns struct {
-	First  string
+	// First is name of external table, blank for INSERT.
+	First string
+	// Second is description of columns in text format, see Columns.
 	Second string
 }
 
+// Columns parses description of table columns.
+func (c TableColumns) Columns() ([]ColumnDescription, error) {
+	return ParseColumnsDescription(c.Second)
+}
+
 func (c *TableColumns) DecodeAware(r *Reader, _ int) error {
 	{
 		v, err := r.Str()
@@ -30,3 +44,228 @@ func (c TableColumns) EncodeAware(b *Buffer, _ int) {
 	b.PutString(c.First)
 	b.PutString(c.Second)
 }
+
+// ColumnDefaultKind is kind of column default expression.
+type ColumnDefaultKind string
+
+// Possible kinds of column default expression.
+const (
+	ColumnDefault      ColumnDefaultKind = "DEFAULT"
+	ColumnMaterialized ColumnDefaultKind = "MATERIALIZED"
+	ColumnAlias        ColumnDefaultKind = "ALIAS"
+	ColumnEphemeral    ColumnDefaultKind = "EPHEMERAL"
+)
+
+// Insertable reports whether column of such kind can be inserted.
+func (k ColumnDefaultKind) Insertable() bool {
+	return k != ColumnMaterialized && k != ColumnAlias
+}
+
+func (k ColumnDefaultKind) valid() bool {
+	switch k {
+	case ColumnDefault, ColumnMaterialized, ColumnAlias, ColumnEphemeral:
+		return true
+	default:
+		return false
+	}
+}
+
+// ColumnDescription describes table column.
+type ColumnDescription struct {
+	Name string
+	Type ColumnType
+
+	// DefaultKind is blank if column has no default expression.
+	DefaultKind ColumnDefaultKind
+	DefaultExpr string
+
+	Comment string
+	Codec   string // like "CODEC(ZSTD(1))"
+	TTL     string
+}
+
+const columnsDescriptionVersion = "columns format version: 1"
+
+// ParseColumnsDescription parses columns description in text format,
+// like the server sends in TableColumns packet.
+func ParseColumnsDescription(s string) ([]ColumnDescription, error) {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, errors.New("no header")
+	}
+	if lines[0] != columnsDescriptionVersion {
+		return nil, errors.Errorf("unexpected format %q", lines[0])
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(lines[1], " columns:"))
+	if err != nil {
+		return nil, errors.Wrap(err, "count")
+	}
+	if n != len(lines)-2 {
+		return nil, errors.Errorf("unexpected count %d of %d columns", len(lines)-2, n)
+	}
+	columns := make([]ColumnDescription, 0, n)
+	for i, line := range lines[2:] {
+		v, err := parseColumnDescription(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		columns = append(columns, v)
+	}
+	return columns, nil
+}
+
+// parseColumnDescription parses line like
+//
+//	`name` Type\tDEFAULT\texpr\tCOMMENT 'comment'
+func parseColumnDescription(line string) (ColumnDescription, error) {
+	var c ColumnDescription
+	if !strings.HasPrefix(line, "`") {
+		return c, errors.Errorf("bad name in %q", line)
+	}
+	end := 1
+	for ; end < len(line); end++ {
+		if line[end] == '\\' {
+			end++
+			continue
+		}
+		if line[end] == '`' {
+			break
+		}
+	}
+	if end >= len(line) {
+		return c, errors.Errorf("unterminated name in %q", line)
+	}
+	c.Name = unescapeColumnsDescription(line[1:end])
+	line = line[end+1:]
+	if !strings.HasPrefix(line, " ") {
+		return c, errors.Errorf("no type of %q", c.Name)
+	}
+	fields := strings.Split(line[1:], "\t")
+	c.Type = ColumnType(unescapeColumnsDescription(fields[0]))
+	for i := 1; i < len(fields); i++ {
+		f := fields[i]
+		switch kind := ColumnDefaultKind(f); {
+		case kind.valid():
+			if i+1 >= len(fields) {
+				return c, errors.Errorf("no %s expression of %q", kind, c.Name)
+			}
+			i++
+			c.DefaultKind = kind
+			c.DefaultExpr = unescapeColumnsDescription(fields[i])
+		case strings.HasPrefix(f, "COMMENT "):
+			c.Comment = unquoteColumnsDescription(strings.TrimPrefix(f, "COMMENT "))
+		case strings.HasPrefix(f, "CODEC("):
+			c.Codec = unescapeColumnsDescription(f)
+		case strings.HasPrefix(f, "TTL "):
+			c.TTL = unescapeColumnsDescription(strings.TrimPrefix(f, "TTL "))
+		default:
+			// Skipping unknown fields, like SETTINGS or STATISTICS.
+		}
+	}
+	return c, nil
+}
+
+// FormatColumnsDescription formats columns description in text format,
+// inverse of ParseColumnsDescription.
+func FormatColumnsDescription(columns []ColumnDescription) string {
+	var b strings.Builder
+	b.WriteString(columnsDescriptionVersion)
+	b.WriteByte('\n')
+	b.WriteString(strconv.Itoa(len(columns)))
+	b.WriteString(" columns:\n")
+	for _, c := range columns {
+		b.WriteByte('`')
+		escapeColumnsDescription(&b, c.Name, '`')
+		b.WriteString("` ")
+		escapeColumnsDescription(&b, string(c.Type), '\'')
+		if c.DefaultKind != "" {
+			b.WriteByte('\t')
+			b.WriteString(string(c.DefaultKind))
+			b.WriteByte('\t')
+			escapeColumnsDescription(&b, c.DefaultExpr, '\'')
+		}
+		if c.Comment != "" {
+			b.WriteString("\tCOMMENT '")
+			escapeColumnsDescription(&b, c.Comment, '\'')
+			b.WriteByte('\'')
+		}
+		if c.Codec != "" {
+			b.WriteByte('\t')
+			escapeColumnsDescription(&b, c.Codec, '\'')
+		}
+		if c.TTL != "" {
+			b.WriteString("\tTTL ")
+			escapeColumnsDescription(&b, c.TTL, '\'')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// escapeColumnsDescription writes escaped s, like writeEscapedString of
+// ClickHouse.
+func escapeColumnsDescription(b *strings.Builder, s string, quote byte) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case 0:
+			b.WriteString(`\0`)
+		case '\\':
+			b.WriteString(`\\`)
+		case quote:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// unescapeColumnsDescription is inverse of escapeColumnsDescription.
+func unescapeColumnsDescription(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// unquoteColumnsDescription returns value of quoted string literal.
+func unquoteColumnsDescription(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = s[1 : len(s)-1]
+	}
+	return unescapeColumnsDescription(s)
+}
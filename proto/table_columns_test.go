@@ -26,3 +26,69 @@ func TestTableColumns_EncodeAware(t *testing.T) {
 		requireNoShortRead(t, buf, aware(&dec))
 	})
 }
+
+func TestParseColumnsDescription(t *testing.T) {
+	const s = "columns format version: 1\n" +
+		"5 columns:\n" +
+		"`id` UInt64\n" +
+		"`name` String\tDEFAULT\t\\'anonymous\\'\tCOMMENT 'user\\'s name'\n" +
+		"`name_len` UInt64\tMATERIALIZED\tlength(name)\tCODEC(ZSTD(1))\n" +
+		"`a\\`b` Enum8(\\'a\\' = 1)\tALIAS\tid + 1\n" +
+		"`t` DateTime\tEPHEMERAL\tnow()\tTTL t + toIntervalDay(1)\n"
+	columns, err := ParseColumnsDescription(s)
+	require.NoError(t, err)
+	require.Equal(t, []ColumnDescription{
+		{Name: "id", Type: ColumnTypeUInt64},
+		{
+			Name:        "name",
+			Type:        ColumnTypeString,
+			DefaultKind: ColumnDefault,
+			DefaultExpr: "'anonymous'",
+			Comment:     "user's name",
+		},
+		{
+			Name:        "name_len",
+			Type:        ColumnTypeUInt64,
+			DefaultKind: ColumnMaterialized,
+			DefaultExpr: "length(name)",
+			Codec:       "CODEC(ZSTD(1))",
+		},
+		{
+			Name:        "a`b",
+			Type:        "Enum8('a' = 1)",
+			DefaultKind: ColumnAlias,
+			DefaultExpr: "id + 1",
+		},
+		{
+			Name:        "t",
+			Type:        ColumnTypeDateTime,
+			DefaultKind: ColumnEphemeral,
+			DefaultExpr: "now()",
+			TTL:         "t + toIntervalDay(1)",
+		},
+	}, columns)
+	require.Equal(t, s, FormatColumnsDescription(columns))
+
+	v := TableColumns{Second: s}
+	got, err := v.Columns()
+	require.NoError(t, err)
+	require.Equal(t, columns, got)
+
+	require.True(t, ColumnDefault.Insertable())
+	require.True(t, ColumnEphemeral.Insertable())
+	require.False(t, ColumnMaterialized.Insertable())
+	require.False(t, ColumnAlias.Insertable())
+
+	for _, bad := range []string{
+		"",
+		"columns format version: 2\n0 columns:\n",
+		"columns format version: 1\n2 columns:\n`id` UInt8\n",
+		"columns format version: 1\n1 columns:\nid UInt8\n",
+		"columns format version: 1\n1 columns:\n`id UInt8\n",
+		"columns format version: 1\n1 columns:\n`id`\n",
+		"columns format version: 1\n1 columns:\n`id` UInt8\tDEFAULT\n",
+	} {
+		_, err := ParseColumnsDescription(bad)
+		require.Error(t, err, bad)
+	}
+}
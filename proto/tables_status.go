@@ -0,0 +1,149 @@
+package proto
+
+import "github.com/go-faster/errors"
+
+// TableName is qualified name of table.
+type TableName struct {
+	Database string
+	Table    string
+}
+
+func (t TableName) String() string {
+	return t.Database + "." + t.Table
+}
+
+func (t TableName) encode(b *Buffer) {
+	b.PutString(t.Database)
+	b.PutString(t.Table)
+}
+
+func (t *TableName) decode(r *Reader) error {
+	{
+		v, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "database")
+		}
+		t.Database = v
+	}
+	{
+		v, err := r.Str()
+		if err != nil {
+			return errors.Wrap(err, "table")
+		}
+		t.Table = v
+	}
+	return nil
+}
+
+// maxTablesStatus limits count of tables in TablesStatus request or response.
+const maxTablesStatus = 1 << 16
+
+// TablesStatusRequest is request of ClientTablesStatusRequest, used to check
+// tables of replica, e.g. by Distributed tables.
+type TablesStatusRequest struct {
+	Tables []TableName
+}
+
+// EncodeAware encodes request with client code.
+func (t TablesStatusRequest) EncodeAware(b *Buffer, _ int) {
+	ClientTablesStatusRequest.Encode(b)
+	b.PutInt(len(t.Tables))
+	for _, v := range t.Tables {
+		v.encode(b)
+	}
+}
+
+// DecodeAware decodes request without client code.
+func (t *TablesStatusRequest) DecodeAware(r *Reader, _ int) error {
+	n, err := r.Int()
+	if err != nil {
+		return errors.Wrap(err, "tables")
+	}
+	if n > maxTablesStatus {
+		return errors.Errorf("too many tables (%d)", n)
+	}
+	t.Tables = t.Tables[:0]
+	for i := 0; i < n; i++ {
+		var v TableName
+		if err := v.decode(r); err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+		t.Tables = append(t.Tables, v)
+	}
+	return nil
+}
+
+// TableStatus is status of table on replica.
+type TableStatus struct {
+	Table TableName
+
+	Replicated bool
+	// AbsoluteDelay is replication delay in seconds, only for
+	// replicated tables.
+	AbsoluteDelay uint32
+	// ReadOnly reports whether replicated table is read-only, e.g. if
+	// replica lost connection to Keeper.
+	ReadOnly bool
+}
+
+// TablesStatusResponse is response of ServerCodeTablesStatus.
+//
+// Tables that are not found on server are omitted.
+type TablesStatusResponse struct {
+	Tables []TableStatus
+}
+
+// EncodeAware encodes response with server code.
+func (t TablesStatusResponse) EncodeAware(b *Buffer, version int) {
+	ServerCodeTablesStatus.Encode(b)
+	b.PutInt(len(t.Tables))
+	for _, v := range t.Tables {
+		v.Table.encode(b)
+		b.PutBool(v.Replicated)
+		if v.Replicated {
+			b.PutUVarInt(uint64(v.AbsoluteDelay))
+			if FeatureTableReadOnlyCheck.In(version) {
+				b.PutBool(v.ReadOnly)
+			}
+		}
+	}
+}
+
+// DecodeAware decodes response without server code.
+func (t *TablesStatusResponse) DecodeAware(r *Reader, version int) error {
+	n, err := r.Int()
+	if err != nil {
+		return errors.Wrap(err, "tables")
+	}
+	if n > maxTablesStatus {
+		return errors.Errorf("too many tables (%d)", n)
+	}
+	t.Tables = t.Tables[:0]
+	for i := 0; i < n; i++ {
+		var v TableStatus
+		if err := v.Table.decode(r); err != nil {
+			return errors.Wrapf(err, "[%d]", i)
+		}
+		replicated, err := r.Bool()
+		if err != nil {
+			return errors.Wrapf(err, "[%d]: replicated", i)
+		}
+		v.Replicated = replicated
+		if replicated {
+			delay, err := r.UVarInt()
+			if err != nil {
+				return errors.Wrapf(err, "[%d]: absolute delay", i)
+			}
+			v.AbsoluteDelay = uint32(delay)
+			if FeatureTableReadOnlyCheck.In(version) {
+				readOnly, err := r.Bool()
+				if err != nil {
+					return errors.Wrapf(err, "[%d]: read only", i)
+				}
+				v.ReadOnly = readOnly
+			}
+		}
+		t.Tables = append(t.Tables, v)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTablesStatusRequest_EncodeAware(t *testing.T) {
+	req := TablesStatusRequest{
+		Tables: []TableName{
+			{Database: "default", Table: "events"},
+			{Database: "logs", Table: "requests"},
+		},
+	}
+	var b Buffer
+	req.EncodeAware(&b, Version)
+	Gold(t, req)
+
+	t.Run("Decode", func(t *testing.T) {
+		buf := skipCode(t, b.Buf, int(ClientTablesStatusRequest))
+		var dec TablesStatusRequest
+		requireDecode(t, buf, aware(&dec))
+		require.Equal(t, req, dec)
+		requireNoShortRead(t, buf, aware(&dec))
+	})
+}
+
+func TestTablesStatusResponse_EncodeAware(t *testing.T) {
+	resp := TablesStatusResponse{
+		Tables: []TableStatus{
+			{Table: TableName{Database: "default", Table: "events"}, Replicated: true, AbsoluteDelay: 300, ReadOnly: true},
+			{Table: TableName{Database: "default", Table: "local"}},
+		},
+	}
+	var b Buffer
+	resp.EncodeAware(&b, Version)
+	Gold(t, resp)
+
+	t.Run("Decode", func(t *testing.T) {
+		buf := skipCode(t, b.Buf, int(ServerCodeTablesStatus))
+		var dec TablesStatusResponse
+		requireDecode(t, buf, aware(&dec))
+		require.Equal(t, resp, dec)
+		requireNoShortRead(t, buf, aware(&dec))
+	})
+}
@@ -0,0 +1,846 @@
+package proto
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// TypeParamKind is kind of TypeParam.
+type TypeParamKind byte
+
+// Possible type parameter kinds.
+const (
+	TypeParamType    TypeParamKind = iota // type, optionally named, e.g. "a String"
+	TypeParamNumber                       // numeric literal, e.g. 3
+	TypeParamString                       // string literal, e.g. 'UTC'
+	TypeParamEnum                         // enum value, e.g. 'a' = 1
+	TypeParamSetting                      // setting, e.g. max_types=10
+	TypeParamRaw                          // unparsed parameter, e.g. SKIP a.b
+)
+
+// TypeParam is parameter of parametric type.
+type TypeParam struct {
+	Kind  TypeParamKind
+	Name  string // element, setting or enum value name
+	Type  *Type  // element type of TypeParamType
+	Value string // literal value (unquoted) or raw parameter
+}
+
+func (p TypeParam) String() string {
+	switch p.Kind {
+	case TypeParamType:
+		if p.Name == "" {
+			return p.Type.String()
+		}
+		return quoteTypeName(p.Name) + " " + p.Type.String()
+	case TypeParamString:
+		return quoteTypeString(p.Value)
+	case TypeParamEnum:
+		return quoteTypeString(p.Name) + " = " + p.Value
+	case TypeParamSetting:
+		return p.Name + "=" + p.Value
+	default:
+		return p.Value
+	}
+}
+
+// EnumValue is named value of Enum8 or Enum16.
+type EnumValue struct {
+	Name  string
+	Value int
+}
+
+// Type is parsed ColumnType, see ParseType.
+type Type struct {
+	Name   ColumnType  // e.g. Array or DateTime64, with SQL aliases resolved
+	Params []TypeParam // nil if type has no parameters
+
+	// Following fields are derived from Params by ParseType.
+
+	Elems     []Type      // parameters that are types, e.g. of Array, Map or Tuple
+	Names     []string    // names of Elems if they are named, e.g. of Nested
+	Enum      []EnumValue // of Enum8 or Enum16
+	Precision int         // of Decimal, DateTime64 or Time64
+	Scale     int         // of Decimal
+	Timezone  string      // of DateTime or DateTime64, if set
+	Size      int         // of FixedString
+}
+
+// ParseType parses column type, e.g. Array(Nullable(String)),
+// Tuple(a String, `b c` Enum8('x' = 1)) or DateTime64(3, 'UTC').
+//
+// SQL aliases like BIGINT UNSIGNED or VARCHAR(255) are resolved to
+// ClickHouse types.
+func ParseType(t ColumnType) (Type, error) {
+	p := typeParser{s: string(t)}
+	v, err := p.parseType()
+	if err != nil {
+		return Type{}, errors.Wrapf(err, "parse %q", t)
+	}
+	if p.skipSpace(); !p.eof() {
+		return Type{}, errors.Errorf("parse %q: unexpected %q at %d", t, p.s[p.pos:], p.pos)
+	}
+	if err := v.init(); err != nil {
+		return Type{}, errors.Wrapf(err, "parse %q", t)
+	}
+	return v, nil
+}
+
+// String returns canonical type name.
+func (t Type) String() string {
+	if t.Params == nil {
+		return string(t.Name)
+	}
+	var b strings.Builder
+	b.WriteString(string(t.Name))
+	b.WriteByte('(')
+	for i, p := range t.Params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.String())
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// ColumnType returns canonical ColumnType of t.
+func (t Type) ColumnType() ColumnType {
+	return ColumnType(t.String())
+}
+
+// typeAlias is ClickHouse type of SQL alias.
+type typeAlias struct {
+	Name   ColumnType
+	Params bool // whether parameters are kept, e.g. of DECIMAL(P, S)
+}
+
+// typeAliases are case-insensitive SQL aliases of types.
+var typeAliases = map[string]typeAlias{
+	"BOOL":               {Name: ColumnTypeBool},
+	"BOOLEAN":            {Name: ColumnTypeBool},
+	"TINYINT":            {Name: ColumnTypeInt8},
+	"TINYINT SIGNED":     {Name: ColumnTypeInt8},
+	"INT1":               {Name: ColumnTypeInt8},
+	"INT1 SIGNED":        {Name: ColumnTypeInt8},
+	"BYTE":               {Name: ColumnTypeInt8},
+	"TINYINT UNSIGNED":   {Name: ColumnTypeUInt8},
+	"INT1 UNSIGNED":      {Name: ColumnTypeUInt8},
+	"SMALLINT":           {Name: ColumnTypeInt16},
+	"SMALLINT SIGNED":    {Name: ColumnTypeInt16},
+	"SMALLINT UNSIGNED":  {Name: ColumnTypeUInt16},
+	"YEAR":               {Name: ColumnTypeUInt16},
+	"INT":                {Name: ColumnTypeInt32},
+	"INT SIGNED":         {Name: ColumnTypeInt32},
+	"INTEGER":            {Name: ColumnTypeInt32},
+	"INTEGER SIGNED":     {Name: ColumnTypeInt32},
+	"MEDIUMINT":          {Name: ColumnTypeInt32},
+	"MEDIUMINT SIGNED":   {Name: ColumnTypeInt32},
+	"INT UNSIGNED":       {Name: ColumnTypeUInt32},
+	"INTEGER UNSIGNED":   {Name: ColumnTypeUInt32},
+	"MEDIUMINT UNSIGNED": {Name: ColumnTypeUInt32},
+	"BIGINT":             {Name: ColumnTypeInt64},
+	"BIGINT SIGNED":      {Name: ColumnTypeInt64},
+	"BIGINT UNSIGNED":    {Name: ColumnTypeUInt64},
+	"FLOAT":              {Name: ColumnTypeFloat32},
+	"REAL":               {Name: ColumnTypeFloat32},
+	"SINGLE":             {Name: ColumnTypeFloat32},
+	"DOUBLE":             {Name: ColumnTypeFloat64},
+	"DOUBLE PRECISION":   {Name: ColumnTypeFloat64},
+	"DECIMAL":            {Name: ColumnTypeDecimal, Params: true},
+	"DEC":                {Name: ColumnTypeDecimal, Params: true},
+	"NUMERIC":            {Name: ColumnTypeDecimal, Params: true},
+	"FIXED":              {Name: ColumnTypeDecimal, Params: true},
+	"TEXT":               {Name: ColumnTypeString},
+	"TINYTEXT":           {Name: ColumnTypeString},
+	"MEDIUMTEXT":         {Name: ColumnTypeString},
+	"LONGTEXT":           {Name: ColumnTypeString},
+	"CHAR":               {Name: ColumnTypeString},
+	"CHARACTER":          {Name: ColumnTypeString},
+	"CHAR VARYING":       {Name: ColumnTypeString},
+	"CHARACTER VARYING":  {Name: ColumnTypeString},
+	"VARCHAR":            {Name: ColumnTypeString},
+	"VARCHAR2":           {Name: ColumnTypeString},
+	"NCHAR":              {Name: ColumnTypeString},
+	"NVARCHAR":           {Name: ColumnTypeString},
+	"BLOB":               {Name: ColumnTypeString},
+	"TINYBLOB":           {Name: ColumnTypeString},
+	"MEDIUMBLOB":         {Name: ColumnTypeString},
+	"LONGBLOB":           {Name: ColumnTypeString},
+	"CLOB":               {Name: ColumnTypeString},
+	"BYTEA":              {Name: ColumnTypeString},
+	"BINARY":             {Name: ColumnTypeString},
+	"VARBINARY":          {Name: ColumnTypeString},
+	"TIMESTAMP":          {Name: ColumnTypeDateTime, Params: true},
+	"INET4":              {Name: ColumnTypeIPv4},
+	"INET6":              {Name: ColumnTypeIPv6},
+}
+
+// lookupTypeAlias returns alias by case-insensitive name without
+// allocating.
+func lookupTypeAlias(name string) (typeAlias, bool) {
+	var buf [32]byte
+	if len(name) > len(buf) {
+		return typeAlias{}, false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	a, ok := typeAliases[string(buf[:len(name)])]
+	return a, ok
+}
+
+// isPlainType reports whether c is type name without parameters that is
+// not an alias, so it is equal to its parsed form and can be used without
+// parsing.
+func isPlainType(c ColumnType) bool {
+	if strings.ContainsAny(string(c), "( ") {
+		return false
+	}
+	_, alias := lookupTypeAlias(string(c))
+	return !alias
+}
+
+// typeModifiers are words that continue type name, e.g. in INT UNSIGNED.
+var typeModifiers = map[string]bool{
+	"SIGNED":    true,
+	"UNSIGNED":  true,
+	"PRECISION": true,
+	"VARYING":   true,
+}
+
+func isTypeModifier(s string) bool {
+	return typeModifiers[strings.ToUpper(s)]
+}
+
+// init resolves aliases and derives fields from parameters.
+func (t *Type) init() error {
+	for _, p := range t.Params {
+		if p.Kind != TypeParamType {
+			continue
+		}
+		if err := p.Type.init(); err != nil {
+			return err
+		}
+	}
+	if a, ok := lookupTypeAlias(string(t.Name)); ok {
+		t.Name = a.Name
+		if !a.Params {
+			t.Params = nil
+		}
+	}
+
+	if err := t.initParams(); err != nil {
+		return err
+	}
+
+	var named bool
+	for _, p := range t.Params {
+		if p.Kind != TypeParamType {
+			continue
+		}
+		t.Elems = append(t.Elems, *p.Type)
+		t.Names = append(t.Names, p.Name)
+		named = named || p.Name != ""
+	}
+	if !named {
+		t.Names = nil
+	}
+	return nil
+}
+
+// initParams derives fields from parameters of known types.
+func (t *Type) initParams() error {
+	switch t.Name {
+	case ColumnTypeEnum8, ColumnTypeEnum16:
+		return t.initEnum()
+	case ColumnTypeDecimal, ColumnTypeDecimal32, ColumnTypeDecimal64, ColumnTypeDecimal128, ColumnTypeDecimal256:
+		return t.initDecimal()
+	case ColumnTypeDateTime:
+		if len(t.Params) > 1 {
+			return errors.Errorf("%s: got %d parameters, expected at most 1", t.Name, len(t.Params))
+		}
+		if len(t.Params) == 1 {
+			return t.initTimezone(0)
+		}
+	case ColumnTypeDateTime64, ColumnTypeTime64:
+		// Precision is optional for Time64 and checked by column for
+		// DateTime64.
+		maxParams := 2
+		if t.Name == ColumnTypeTime64 {
+			maxParams = 1
+		}
+		if len(t.Params) > maxParams {
+			return errors.Errorf("%s: got %d parameters, expected at most %d", t.Name, len(t.Params), maxParams)
+		}
+		if len(t.Params) == 0 {
+			return nil
+		}
+		n, err := t.intParam(0)
+		if err != nil {
+			return errors.Wrapf(err, "%s precision", t.Name)
+		}
+		if !Precision(n).Valid() {
+			return errors.Errorf("%s: precision %d is invalid", t.Name, n)
+		}
+		t.Precision = n
+		if len(t.Params) == 2 {
+			return t.initTimezone(1)
+		}
+	case ColumnTypeFixedString:
+		if len(t.Params) != 1 {
+			return errors.Errorf("%s: got %d parameters, expected 1", t.Name, len(t.Params))
+		}
+		n, err := t.intParam(0)
+		if err != nil {
+			return errors.Wrapf(err, "%s size", t.Name)
+		}
+		if n <= 0 {
+			return errors.Errorf("%s: invalid size %d", t.Name, n)
+		}
+		t.Size = n
+	}
+	return nil
+}
+
+// intParam returns i-th parameter as integer.
+func (t Type) intParam(i int) (int, error) {
+	p := t.Params[i]
+	if p.Kind != TypeParamNumber {
+		return 0, errors.Errorf("%s is not a number", p)
+	}
+	return strconv.Atoi(p.Value)
+}
+
+// initTimezone sets timezone from i-th parameter, which is quoted in
+// canonical form even if it is not in t.
+func (t *Type) initTimezone(i int) error {
+	p := &t.Params[i]
+	switch {
+	case p.Kind == TypeParamString, p.Kind == TypeParamRaw:
+	case p.Kind == TypeParamType && p.Name == "" && p.Type.Params == nil:
+		// Unquoted, e.g. DateTime(UTC).
+		*p = TypeParam{Value: string(p.Type.Name)}
+	default:
+		return errors.Errorf("%s: invalid timezone %s", t.Name, p)
+	}
+	p.Kind = TypeParamString
+	t.Timezone = p.Value
+	return nil
+}
+
+func (t *Type) initEnum() error {
+	var (
+		limit = 1 << 7
+		next  = 1
+	)
+	if t.Name == ColumnTypeEnum16 {
+		limit = 1 << 15
+	}
+	for i := range t.Params {
+		p := &t.Params[i]
+		v := EnumValue{Name: p.Name, Value: next}
+		switch p.Kind {
+		case TypeParamEnum:
+			n, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return errors.Wrapf(err, "%s: value of %s", t.Name, p)
+			}
+			v.Value = n
+		case TypeParamString:
+			// Values are implicitly assigned in order.
+			v.Name = p.Value
+			*p = TypeParam{Kind: TypeParamEnum, Name: p.Value, Value: strconv.Itoa(next)}
+		default:
+			return errors.Errorf("%s: invalid value %s", t.Name, p)
+		}
+		if v.Value < -limit || v.Value >= limit {
+			return errors.Errorf("%s: value %d of %q is out of range", t.Name, v.Value, v.Name)
+		}
+		next = v.Value + 1
+		t.Enum = append(t.Enum, v)
+	}
+	return nil
+}
+
+func (t *Type) initDecimal() error {
+	if t.Name != ColumnTypeDecimal {
+		// Decimal32(S), Decimal64(S), etc.
+		switch t.Name {
+		case ColumnTypeDecimal32:
+			t.Precision = 9
+		case ColumnTypeDecimal64:
+			t.Precision = 18
+		case ColumnTypeDecimal128:
+			t.Precision = 38
+		case ColumnTypeDecimal256:
+			t.Precision = 76
+		}
+		switch len(t.Params) {
+		case 0:
+			return nil
+		case 1:
+			s, err := t.intParam(0)
+			if err != nil {
+				return errors.Wrapf(err, "%s scale", t.Name)
+			}
+			if s < 0 || s > t.Precision {
+				return errors.Errorf("%s: scale %d out of range", t.Name, s)
+			}
+			t.Scale = s
+			return nil
+		default:
+			return errors.Errorf("%s: got %d parameters, expected 1", t.Name, len(t.Params))
+		}
+	}
+
+	// Decimal(P, S), where both are optional.
+	t.Precision = 10
+	if len(t.Params) > 2 {
+		return errors.Errorf("%s: got %d parameters, expected at most 2", t.Name, len(t.Params))
+	}
+	if len(t.Params) > 0 {
+		p, err := t.intParam(0)
+		if err != nil {
+			return errors.Wrapf(err, "%s precision", t.Name)
+		}
+		t.Precision = p
+	}
+	if len(t.Params) > 1 {
+		s, err := t.intParam(1)
+		if err != nil {
+			return errors.Wrapf(err, "%s scale", t.Name)
+		}
+		t.Scale = s
+	}
+	if t.Precision < 1 || t.Precision > 76 {
+		return errors.Errorf("%s: precision %d out of range", t.Name, t.Precision)
+	}
+	if t.Scale < 0 || t.Scale > t.Precision {
+		return errors.Errorf("%s: scale %d out of range", t.Name, t.Scale)
+	}
+	t.Params = []TypeParam{
+		{Kind: TypeParamNumber, Value: strconv.Itoa(t.Precision)},
+		{Kind: TypeParamNumber, Value: strconv.Itoa(t.Scale)},
+	}
+	return nil
+}
+
+// decimalWidth returns Decimal32, Decimal64, Decimal128 or Decimal256 that
+// can hold values of decimal type, or blank type for other types.
+func (t Type) decimalWidth() ColumnType {
+	switch t.Name {
+	case ColumnTypeDecimal, ColumnTypeDecimal32, ColumnTypeDecimal64, ColumnTypeDecimal128, ColumnTypeDecimal256:
+	default:
+		return ""
+	}
+	switch {
+	case t.Precision < 10:
+		return ColumnTypeDecimal32
+	case t.Precision < 19:
+		return ColumnTypeDecimal64
+	case t.Precision < 39:
+		return ColumnTypeDecimal128
+	default:
+		return ColumnTypeDecimal256
+	}
+}
+
+// simpleAggregateElem returns T of SimpleAggregateFunction(f, T) or t for
+// other types.
+func (t Type) simpleAggregateElem() Type {
+	if t.Name != ColumnTypeSimpleAggregateFunction || len(t.Elems) != 2 {
+		return t
+	}
+	return t.Elems[1]
+}
+
+// conflicts reports whether t and b conflict, see ColumnType.Conflicts.
+func (t Type) conflicts(b Type) bool {
+	t, b = t.simpleAggregateElem(), b.simpleAggregateElem()
+	if (t.Name == ColumnTypeEnum8 && b.Name == ColumnTypeInt8) ||
+		(t.Name == ColumnTypeEnum16 && b.Name == ColumnTypeInt16) ||
+		(b.Name == ColumnTypeEnum8 && t.Name == ColumnTypeInt8) ||
+		(b.Name == ColumnTypeEnum16 && t.Name == ColumnTypeInt16) {
+		return false
+	}
+	if w := t.decimalWidth(); w != "" || b.decimalWidth() != "" {
+		if w != b.decimalWidth() {
+			return true
+		}
+		// Scale is checked only if it is set in both types.
+		return t.Params != nil && b.Params != nil && t.Scale != b.Scale
+	}
+	if t.Name != b.Name {
+		return true
+	}
+	switch t.Name {
+	case ColumnTypeEnum8, ColumnTypeEnum16, ColumnTypeDateTime, ColumnTypeDateTime64:
+		// Same binary representation.
+		return false
+	}
+	if len(t.Params) != len(b.Params) {
+		return true
+	}
+	for i, p := range t.Params {
+		q := b.Params[i]
+		if p.Kind != q.Kind {
+			return true
+		}
+		if p.Kind != TypeParamType {
+			if p.String() != q.String() {
+				return true
+			}
+			continue
+		}
+		// Names are checked only if both elements are named.
+		if p.Name != "" && q.Name != "" && p.Name != q.Name {
+			return true
+		}
+		if p.Type.conflicts(*q.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeParser is recursive descent parser of ColumnType.
+type typeParser struct {
+	s   string
+	pos int
+}
+
+func (p *typeParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *typeParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *typeParser) skipSpace() {
+	for !p.eof() {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func isTypeIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isTypeIdent(c byte) bool {
+	return isTypeIdentStart(c) || (c >= '0' && c <= '9') || c == '.'
+}
+
+// ident reads identifier, e.g. type name or JSON path, returning blank
+// string if there is none.
+func (p *typeParser) ident() string {
+	start := p.pos
+	if !isTypeIdentStart(p.peek()) {
+		return ""
+	}
+	for !p.eof() && isTypeIdent(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// peekIdent returns next identifier without reading it.
+func (p *typeParser) peekIdent() string {
+	pos := p.pos
+	s := p.ident()
+	p.pos = pos
+	return s
+}
+
+// number reads numeric literal, returning blank string if there is none.
+func (p *typeParser) number() string {
+	start := p.pos
+	if c := p.peek(); c == '-' || c == '+' {
+		p.pos++
+	}
+	if c := p.peek(); c < '0' || c > '9' {
+		p.pos = start
+		return ""
+	}
+	for !p.eof() {
+		c := p.s[p.pos]
+		switch {
+		case c >= '0' && c <= '9', c == '.', c == 'x', c == '_',
+			c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		case (c == '-' || c == '+') && (p.s[p.pos-1] == 'e' || p.s[p.pos-1] == 'E'):
+		default:
+			return p.s[start:p.pos]
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// quoted reads literal quoted by q, which is ' for strings and ` for names.
+func (p *typeParser) quoted(q byte) (string, error) {
+	start := p.pos
+	p.pos++ // opening quote
+	var b strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && !p.eof():
+			c = p.s[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '0':
+				c = 0
+			}
+		case c == q && p.peek() == q:
+			// Doubled quote.
+			p.pos++
+		case c == q:
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+	}
+	return "", errors.Errorf("unterminated literal at %d", start)
+}
+
+func (p *typeParser) parseType() (Type, error) {
+	p.skipSpace()
+	name := p.ident()
+	if name == "" {
+		return Type{}, errors.Errorf("expected type at %d", p.pos)
+	}
+	name = p.modifiers(name)
+	t := Type{Name: ColumnType(name)}
+	p.skipSpace()
+	if p.peek() != '(' {
+		return t, nil
+	}
+	p.pos++
+	t.Params = []TypeParam{}
+	p.skipSpace()
+	if p.peek() == ')' {
+		// E.g. empty Tuple().
+		p.pos++
+		return t, nil
+	}
+	for {
+		v, err := p.parseParam()
+		if err != nil {
+			return Type{}, err
+		}
+		t.Params = append(t.Params, v)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			// E.g. INT(11) UNSIGNED.
+			t.Name = ColumnType(p.modifiers(name))
+			return t, nil
+		default:
+			return Type{}, errors.Errorf("expected ',' or ')' at %d", p.pos)
+		}
+	}
+}
+
+// modifiers appends modifiers to type name, e.g. UNSIGNED.
+func (p *typeParser) modifiers(name string) string {
+	for {
+		pos := p.pos
+		p.skipSpace()
+		m := p.ident()
+		if m == "" || !isTypeModifier(m) {
+			p.pos = pos
+			return name
+		}
+		name += " " + m
+	}
+}
+
+// parseParam parses type parameter, falling back to raw parameter if it
+// can't be parsed, e.g. SKIP REGEXP 'a.*' of JSON.
+func (p *typeParser) parseParam() (TypeParam, error) {
+	p.skipSpace()
+	start := p.pos
+	if v, err := p.parseKnownParam(); err == nil {
+		p.skipSpace()
+		if c := p.peek(); c == ',' || c == ')' {
+			return v, nil
+		}
+	}
+	p.pos = start
+	return p.parseRawParam()
+}
+
+func (p *typeParser) parseKnownParam() (TypeParam, error) {
+	switch c := p.peek(); {
+	case c == '\'':
+		s, err := p.quoted('\'')
+		if err != nil {
+			return TypeParam{}, err
+		}
+		p.skipSpace()
+		if p.peek() != '=' {
+			return TypeParam{Kind: TypeParamString, Value: s}, nil
+		}
+		p.pos++
+		p.skipSpace()
+		n := p.number()
+		if n == "" {
+			return TypeParam{}, errors.Errorf("expected enum value at %d", p.pos)
+		}
+		return TypeParam{Kind: TypeParamEnum, Name: s, Value: n}, nil
+	case c == '-' || c == '+' || (c >= '0' && c <= '9'):
+		n := p.number()
+		if n == "" {
+			return TypeParam{}, errors.Errorf("expected number at %d", p.pos)
+		}
+		return TypeParam{Kind: TypeParamNumber, Value: n}, nil
+	case c == '`':
+		name, err := p.quoted('`')
+		if err != nil {
+			return TypeParam{}, err
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return TypeParam{}, err
+		}
+		return TypeParam{Kind: TypeParamType, Name: name, Type: &t}, nil
+	case isTypeIdentStart(c):
+		start := p.pos
+		name := p.ident()
+		p.skipSpace()
+		if p.peek() == '=' {
+			p.pos++
+			p.skipSpace()
+			value := p.number()
+			if value == "" {
+				value = p.ident()
+			}
+			if value == "" {
+				return TypeParam{}, errors.Errorf("expected value of %s at %d", name, p.pos)
+			}
+			return TypeParam{Kind: TypeParamSetting, Name: name, Value: value}, nil
+		}
+		if next := p.peekIdent(); next != "" && !isTypeModifier(next) && name != "SKIP" {
+			// Named element, e.g. "a String".
+			t, err := p.parseType()
+			if err != nil {
+				return TypeParam{}, err
+			}
+			return TypeParam{Kind: TypeParamType, Name: name, Type: &t}, nil
+		}
+		p.pos = start
+		t, err := p.parseType()
+		if err != nil {
+			return TypeParam{}, err
+		}
+		return TypeParam{Kind: TypeParamType, Type: &t}, nil
+	}
+	return TypeParam{}, errors.Errorf("unexpected %q at %d", p.peek(), p.pos)
+}
+
+// parseRawParam reads parameter until top-level comma or closing
+// parenthesis.
+func (p *typeParser) parseRawParam() (TypeParam, error) {
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		switch p.s[p.pos] {
+		case '\'', '`':
+			if _, err := p.quoted(p.s[p.pos]); err != nil {
+				return TypeParam{}, err
+			}
+			continue
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return p.rawParam(start)
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return p.rawParam(start)
+			}
+		}
+		p.pos++
+	}
+	return TypeParam{}, errors.Errorf("unterminated parameter at %d", start)
+}
+
+func (p *typeParser) rawParam(start int) (TypeParam, error) {
+	v := strings.TrimSpace(p.s[start:p.pos])
+	if v == "" {
+		return TypeParam{}, errors.Errorf("empty parameter at %d", start)
+	}
+	return TypeParam{Kind: TypeParamRaw, Value: v}, nil
+}
+
+// quoteTypeString returns s as quoted string literal.
+func quoteTypeString(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case 0:
+			b.WriteString(`\0`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// quoteTypeName returns name of element, quoting it with backticks if
+// needed.
+func quoteTypeName(s string) string {
+	plain := s != "" && s != "SKIP" && isTypeIdentStart(s[0]) && !isTypeModifier(s)
+	for i := 0; i < len(s) && plain; i++ {
+		plain = isTypeIdent(s[i])
+	}
+	if plain {
+		return s
+	}
+	return "`" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "`", "\\`") + "`"
+}
@@ -0,0 +1,651 @@
+package proto
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+// Binary encoding of data types, used for values of Dynamic and JSON in
+// shared variant, shared data and RowBinary format.
+//
+// See https://clickhouse.com/docs/en/sql-reference/data-types/data-types-binary-encoding
+const (
+	binaryTypeNothing                 byte = 0x00
+	binaryTypeUInt8                   byte = 0x01
+	binaryTypeUInt16                  byte = 0x02
+	binaryTypeUInt32                  byte = 0x03
+	binaryTypeUInt64                  byte = 0x04
+	binaryTypeUInt128                 byte = 0x05
+	binaryTypeUInt256                 byte = 0x06
+	binaryTypeInt8                    byte = 0x07
+	binaryTypeInt16                   byte = 0x08
+	binaryTypeInt32                   byte = 0x09
+	binaryTypeInt64                   byte = 0x0A
+	binaryTypeInt128                  byte = 0x0B
+	binaryTypeInt256                  byte = 0x0C
+	binaryTypeFloat32                 byte = 0x0D
+	binaryTypeFloat64                 byte = 0x0E
+	binaryTypeDate                    byte = 0x0F
+	binaryTypeDate32                  byte = 0x10
+	binaryTypeDateTime                byte = 0x11
+	binaryTypeDateTimeWithTimezone    byte = 0x12
+	binaryTypeDateTime64              byte = 0x13
+	binaryTypeDateTime64WithTimezone  byte = 0x14
+	binaryTypeString                  byte = 0x15
+	binaryTypeFixedString             byte = 0x16
+	binaryTypeEnum8                   byte = 0x17
+	binaryTypeEnum16                  byte = 0x18
+	binaryTypeDecimal32               byte = 0x19
+	binaryTypeDecimal64               byte = 0x1A
+	binaryTypeDecimal128              byte = 0x1B
+	binaryTypeDecimal256              byte = 0x1C
+	binaryTypeUUID                    byte = 0x1D
+	binaryTypeArray                   byte = 0x1E
+	binaryTypeTuple                   byte = 0x1F
+	binaryTypeNamedTuple              byte = 0x20
+	binaryTypeInterval                byte = 0x22
+	binaryTypeNullable                byte = 0x23
+	binaryTypeAggregateFunction       byte = 0x25
+	binaryTypeLowCardinality          byte = 0x26
+	binaryTypeMap                     byte = 0x27
+	binaryTypeIPv4                    byte = 0x28
+	binaryTypeIPv6                    byte = 0x29
+	binaryTypeVariant                 byte = 0x2A
+	binaryTypeDynamic                 byte = 0x2B
+	binaryTypeCustom                  byte = 0x2C
+	binaryTypeBool                    byte = 0x2D
+	binaryTypeSimpleAggregateFunction byte = 0x2E
+	binaryTypeNested                  byte = 0x2F
+	binaryTypeJSON                    byte = 0x30
+	binaryTypeBFloat16                byte = 0x31
+	binaryTypeTime                    byte = 0x32
+	binaryTypeTime64                  byte = 0x34
+)
+
+// binaryTypeSimple are types without parameters and their binary encoding.
+var binaryTypeSimple = map[ColumnType]byte{
+	ColumnTypeNothing:  binaryTypeNothing,
+	ColumnTypeUInt8:    binaryTypeUInt8,
+	ColumnTypeUInt16:   binaryTypeUInt16,
+	ColumnTypeUInt32:   binaryTypeUInt32,
+	ColumnTypeUInt64:   binaryTypeUInt64,
+	ColumnTypeUInt128:  binaryTypeUInt128,
+	ColumnTypeUInt256:  binaryTypeUInt256,
+	ColumnTypeInt8:     binaryTypeInt8,
+	ColumnTypeInt16:    binaryTypeInt16,
+	ColumnTypeInt32:    binaryTypeInt32,
+	ColumnTypeInt64:    binaryTypeInt64,
+	ColumnTypeInt128:   binaryTypeInt128,
+	ColumnTypeInt256:   binaryTypeInt256,
+	ColumnTypeFloat32:  binaryTypeFloat32,
+	ColumnTypeFloat64:  binaryTypeFloat64,
+	ColumnTypeBFloat16: binaryTypeBFloat16,
+	ColumnTypeDate:     binaryTypeDate,
+	ColumnTypeDate32:   binaryTypeDate32,
+	ColumnTypeString:   binaryTypeString,
+	ColumnTypeUUID:     binaryTypeUUID,
+	ColumnTypeIPv4:     binaryTypeIPv4,
+	ColumnTypeIPv6:     binaryTypeIPv6,
+	ColumnTypeBool:     binaryTypeBool,
+	ColumnTypeTime32:   binaryTypeTime,
+	"Time":             binaryTypeTime,
+}
+
+// binaryTypeCustomNames are types that are encoded by name.
+var binaryTypeCustomNames = map[ColumnType]bool{
+	ColumnTypePoint:           true,
+	ColumnTypeRing:            true,
+	ColumnTypePolygon:         true,
+	ColumnTypeMultiPolygon:    true,
+	ColumnTypeLineString:      true,
+	ColumnTypeMultiLineString: true,
+}
+
+// binaryTypeIntervals are interval kinds in order of their binary encoding.
+var binaryTypeIntervals = []string{
+	"Nanosecond", "Microsecond", "Millisecond", "Second", "Minute",
+	"Hour", "Day", "Week", "Month", "Quarter", "Year",
+}
+
+// maxBinaryTypeDepth limits nesting of decoded binary types.
+const maxBinaryTypeDepth = 64
+
+// putBinaryType appends binary encoding of type t.
+func putBinaryType(b *Buffer, t Type) error {
+	if v, ok := binaryTypeSimple[t.Name]; ok && t.Params == nil {
+		b.PutByte(v)
+		return nil
+	}
+	if binaryTypeCustomNames[t.Name] {
+		b.PutByte(binaryTypeCustom)
+		b.PutString(string(t.Name))
+		return nil
+	}
+	elems := func(code byte) error {
+		b.PutByte(code)
+		for i, e := range t.Elems {
+			if err := putBinaryType(b, e); err != nil {
+				return errors.Wrapf(err, "%s [%d]", t.Name, i)
+			}
+		}
+		return nil
+	}
+	elemsCount := func(code byte) error {
+		b.PutByte(code)
+		b.PutUVarInt(uint64(len(t.Elems)))
+		for i, e := range t.Elems {
+			if t.Names != nil {
+				b.PutString(t.Names[i])
+			}
+			if err := putBinaryType(b, e); err != nil {
+				return errors.Wrapf(err, "%s [%d]", t.Name, i)
+			}
+		}
+		return nil
+	}
+	switch t.Name {
+	case ColumnTypeDateTime:
+		if t.Timezone == "" {
+			b.PutByte(binaryTypeDateTime)
+			return nil
+		}
+		b.PutByte(binaryTypeDateTimeWithTimezone)
+		b.PutString(t.Timezone)
+		return nil
+	case ColumnTypeDateTime64:
+		if t.Timezone == "" {
+			b.PutByte(binaryTypeDateTime64)
+			b.PutUInt8(uint8(t.Precision))
+			return nil
+		}
+		b.PutByte(binaryTypeDateTime64WithTimezone)
+		b.PutUInt8(uint8(t.Precision))
+		b.PutString(t.Timezone)
+		return nil
+	case ColumnTypeTime64:
+		b.PutByte(binaryTypeTime64)
+		b.PutUInt8(uint8(t.Precision))
+		return nil
+	case ColumnTypeFixedString:
+		b.PutByte(binaryTypeFixedString)
+		b.PutUVarInt(uint64(t.Size))
+		return nil
+	case ColumnTypeEnum8, ColumnTypeEnum16:
+		if t.Name == ColumnTypeEnum8 {
+			b.PutByte(binaryTypeEnum8)
+		} else {
+			b.PutByte(binaryTypeEnum16)
+		}
+		b.PutUVarInt(uint64(len(t.Enum)))
+		for _, v := range t.Enum {
+			b.PutString(v.Name)
+			if t.Name == ColumnTypeEnum8 {
+				b.PutInt8(int8(v.Value))
+			} else {
+				b.PutInt16(int16(v.Value))
+			}
+		}
+		return nil
+	case ColumnTypeArray:
+		return elems(binaryTypeArray)
+	case ColumnTypeNullable:
+		return elems(binaryTypeNullable)
+	case ColumnTypeLowCardinality:
+		return elems(binaryTypeLowCardinality)
+	case ColumnTypeMap:
+		return elems(binaryTypeMap)
+	case ColumnTypeTuple:
+		if t.Names != nil {
+			return elemsCount(binaryTypeNamedTuple)
+		}
+		return elemsCount(binaryTypeTuple)
+	case ColumnTypeNested:
+		return elemsCount(binaryTypeNested)
+	case ColumnTypeVariant:
+		return elemsCount(binaryTypeVariant)
+	case ColumnTypeDynamic:
+		maxTypes := dynamicDefaultMaxTypes
+		for _, p := range t.Params {
+			if p.Kind == TypeParamSetting && p.Name == "max_types" {
+				n, err := strconv.Atoi(p.Value)
+				if err != nil {
+					return errors.Wrap(err, "max_types")
+				}
+				maxTypes = n
+			}
+		}
+		b.PutByte(binaryTypeDynamic)
+		b.PutUInt8(uint8(maxTypes))
+		return nil
+	case ColumnTypeJSON:
+		return putBinaryTypeJSON(b, t)
+	case ColumnTypeAggregateFunction, ColumnTypeSimpleAggregateFunction:
+		return putBinaryTypeAggregate(b, t)
+	}
+	switch w := t.decimalWidth(); w {
+	case ColumnTypeDecimal32, ColumnTypeDecimal64, ColumnTypeDecimal128, ColumnTypeDecimal256:
+		b.PutByte(map[ColumnType]byte{
+			ColumnTypeDecimal32:  binaryTypeDecimal32,
+			ColumnTypeDecimal64:  binaryTypeDecimal64,
+			ColumnTypeDecimal128: binaryTypeDecimal128,
+			ColumnTypeDecimal256: binaryTypeDecimal256,
+		}[w])
+		b.PutUInt8(uint8(t.Precision))
+		b.PutUInt8(uint8(t.Scale))
+		return nil
+	}
+	if kind, ok := strings.CutPrefix(string(t.Name), string(ColumnTypeInterval)); ok {
+		for i, k := range binaryTypeIntervals {
+			if k == kind {
+				b.PutByte(binaryTypeInterval)
+				b.PutUInt8(uint8(i))
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("%s: binary encoding not supported", t)
+}
+
+func putBinaryTypeJSON(b *Buffer, t Type) error {
+	var (
+		maxPaths = jsonDefaultMaxDynamicPaths
+		maxTypes = dynamicDefaultMaxTypes
+		typed    []TypeParam
+		skip     []string
+		regexps  []string
+	)
+	for _, p := range t.Params {
+		switch {
+		case p.Kind == TypeParamSetting && (p.Name == "max_dynamic_paths" || p.Name == "max_dynamic_types"):
+			n, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return errors.Wrapf(err, "%s", p.Name)
+			}
+			if p.Name == "max_dynamic_paths" {
+				maxPaths = n
+			} else {
+				maxTypes = n
+			}
+		case p.Kind == TypeParamType && p.Name != "":
+			typed = append(typed, p)
+		case p.Kind == TypeParamRaw && strings.HasPrefix(p.Value, "SKIP REGEXP "):
+			s := strings.TrimSpace(strings.TrimPrefix(p.Value, "SKIP REGEXP "))
+			v := typeParser{s: s}
+			r, err := v.quoted('\'')
+			if err != nil {
+				return errors.Wrapf(err, "%s", p)
+			}
+			regexps = append(regexps, r)
+		case p.Kind == TypeParamRaw && strings.HasPrefix(p.Value, "SKIP "):
+			skip = append(skip, strings.Trim(strings.TrimSpace(strings.TrimPrefix(p.Value, "SKIP ")), "`"))
+		default:
+			return errors.Errorf("invalid parameter %s", p)
+		}
+	}
+	b.PutByte(binaryTypeJSON)
+	b.PutUInt8(0) // serialization version
+	b.PutUVarInt(uint64(maxPaths))
+	b.PutUInt8(uint8(maxTypes))
+	b.PutUVarInt(uint64(len(typed)))
+	for _, p := range typed {
+		b.PutString(p.Name)
+		if err := putBinaryType(b, *p.Type); err != nil {
+			return errors.Wrapf(err, "typed path %q", p.Name)
+		}
+	}
+	b.PutUVarInt(uint64(len(skip)))
+	for _, s := range skip {
+		b.PutString(s)
+	}
+	b.PutUVarInt(uint64(len(regexps)))
+	for _, s := range regexps {
+		b.PutString(s)
+	}
+	return nil
+}
+
+func putBinaryTypeAggregate(b *Buffer, t Type) error {
+	var (
+		name string
+		args []Type
+	)
+	if t.Name == ColumnTypeAggregateFunction {
+		f, err := ParseAggregateFunction(t.ColumnType())
+		if err != nil {
+			return errors.Wrap(err, "aggregate function")
+		}
+		if len(f.Params) > 0 {
+			return errors.Errorf("%s: binary encoding of parameters not supported", t)
+		}
+		b.PutByte(binaryTypeAggregateFunction)
+		b.PutUVarInt(uint64(f.Version))
+		name = f.Name
+		for _, a := range f.Args {
+			typ, err := ParseType(a)
+			if err != nil {
+				return err
+			}
+			args = append(args, typ)
+		}
+	} else {
+		if len(t.Params) < 2 || t.Params[0].Kind != TypeParamType || t.Params[0].Type.Params != nil {
+			return errors.Errorf("%s: binary encoding not supported", t)
+		}
+		b.PutByte(binaryTypeSimpleAggregateFunction)
+		name = string(t.Params[0].Type.Name)
+		args = t.Elems[1:]
+	}
+	b.PutString(name)
+	b.PutUVarInt(0) // parameters
+	b.PutUVarInt(uint64(len(args)))
+	for i, a := range args {
+		if err := putBinaryType(b, a); err != nil {
+			return errors.Wrapf(err, "argument [%d]", i)
+		}
+	}
+	return nil
+}
+
+// readBinaryType reads binary encoding of type.
+func readBinaryType(r *Reader) (ColumnType, error) {
+	return readBinaryTypeDepth(r, 0)
+}
+
+func readBinaryTypeDepth(r *Reader, depth int) (ColumnType, error) {
+	if depth > maxBinaryTypeDepth {
+		return "", errors.New("type is too deep")
+	}
+	code, err := r.Byte()
+	if err != nil {
+		return "", err
+	}
+	for t, v := range binaryTypeSimple {
+		if v == code && t != ColumnTypeTime32 {
+			return t, nil
+		}
+	}
+	elem := func() (ColumnType, error) {
+		return readBinaryTypeDepth(r, depth+1)
+	}
+	count := func() (int, error) {
+		n, err := r.UVarInt()
+		if err != nil {
+			return 0, err
+		}
+		if n > uint64(maxVariantTypes)*16 {
+			return 0, errors.Errorf("too many elements: %d", n)
+		}
+		return int(n), nil
+	}
+	elems := func(named bool) ([]string, error) {
+		n, err := count()
+		if err != nil {
+			return nil, errors.Wrap(err, "count")
+		}
+		params := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			var name string
+			if named {
+				if name, err = r.Str(); err != nil {
+					return nil, errors.Wrapf(err, "[%d] name", i)
+				}
+				name = quoteTypeName(name) + " "
+			}
+			e, err := elem()
+			if err != nil {
+				return nil, errors.Wrapf(err, "[%d]", i)
+			}
+			params = append(params, name+e.String())
+		}
+		return params, nil
+	}
+	with := func(t ColumnType, named bool) (ColumnType, error) {
+		params, err := elems(named)
+		if err != nil {
+			return "", errors.Wrapf(err, "%s", t)
+		}
+		return t.With(params...), nil
+	}
+	switch code {
+	case binaryTypeDateTimeWithTimezone:
+		tz, err := r.Str()
+		if err != nil {
+			return "", errors.Wrap(err, "timezone")
+		}
+		return ColumnTypeDateTime.With(quoteTypeString(tz)), nil
+	case binaryTypeDateTime64, binaryTypeDateTime64WithTimezone, binaryTypeTime64:
+		p, err := r.UInt8()
+		if err != nil {
+			return "", errors.Wrap(err, "precision")
+		}
+		if code == binaryTypeTime64 {
+			return ColumnTypeTime64.With(strconv.Itoa(int(p))), nil
+		}
+		if code == binaryTypeDateTime64 {
+			return ColumnTypeDateTime64.With(strconv.Itoa(int(p))), nil
+		}
+		tz, err := r.Str()
+		if err != nil {
+			return "", errors.Wrap(err, "timezone")
+		}
+		return ColumnTypeDateTime64.With(strconv.Itoa(int(p)), quoteTypeString(tz)), nil
+	case binaryTypeFixedString:
+		n, err := r.UVarInt()
+		if err != nil {
+			return "", errors.Wrap(err, "size")
+		}
+		return ColumnTypeFixedString.With(strconv.FormatUint(n, 10)), nil
+	case binaryTypeEnum8, binaryTypeEnum16:
+		t := ColumnTypeEnum8
+		if code == binaryTypeEnum16 {
+			t = ColumnTypeEnum16
+		}
+		n, err := count()
+		if err != nil {
+			return "", errors.Wrap(err, "enum size")
+		}
+		params := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			name, err := r.Str()
+			if err != nil {
+				return "", errors.Wrapf(err, "enum [%d]", i)
+			}
+			var v int
+			if code == binaryTypeEnum8 {
+				x, err := r.Int8()
+				if err != nil {
+					return "", errors.Wrapf(err, "enum [%d]", i)
+				}
+				v = int(x)
+			} else {
+				x, err := r.Int16()
+				if err != nil {
+					return "", errors.Wrapf(err, "enum [%d]", i)
+				}
+				v = int(x)
+			}
+			params = append(params, quoteTypeString(name)+" = "+strconv.Itoa(v))
+		}
+		return t.With(params...), nil
+	case binaryTypeDecimal32, binaryTypeDecimal64, binaryTypeDecimal128, binaryTypeDecimal256:
+		p, err := r.UInt8()
+		if err != nil {
+			return "", errors.Wrap(err, "precision")
+		}
+		s, err := r.UInt8()
+		if err != nil {
+			return "", errors.Wrap(err, "scale")
+		}
+		return ColumnTypeDecimal.With(strconv.Itoa(int(p)), strconv.Itoa(int(s))), nil
+	case binaryTypeArray, binaryTypeNullable, binaryTypeLowCardinality:
+		t := map[byte]ColumnType{
+			binaryTypeArray:          ColumnTypeArray,
+			binaryTypeNullable:       ColumnTypeNullable,
+			binaryTypeLowCardinality: ColumnTypeLowCardinality,
+		}[code]
+		e, err := elem()
+		if err != nil {
+			return "", errors.Wrapf(err, "%s", t)
+		}
+		return t.Sub(e), nil
+	case binaryTypeMap:
+		k, err := elem()
+		if err != nil {
+			return "", errors.Wrap(err, "map key")
+		}
+		v, err := elem()
+		if err != nil {
+			return "", errors.Wrap(err, "map value")
+		}
+		return ColumnTypeMap.With(k.String(), v.String()), nil
+	case binaryTypeTuple:
+		return with(ColumnTypeTuple, false)
+	case binaryTypeNamedTuple:
+		return with(ColumnTypeTuple, true)
+	case binaryTypeNested:
+		return with(ColumnTypeNested, true)
+	case binaryTypeVariant:
+		return with(ColumnTypeVariant, false)
+	case binaryTypeInterval:
+		k, err := r.UInt8()
+		if err != nil {
+			return "", errors.Wrap(err, "interval kind")
+		}
+		if int(k) >= len(binaryTypeIntervals) {
+			return "", errors.Errorf("invalid interval kind %d", k)
+		}
+		return ColumnType(string(ColumnTypeInterval) + binaryTypeIntervals[k]), nil
+	case binaryTypeDynamic:
+		n, err := r.UInt8()
+		if err != nil {
+			return "", errors.Wrap(err, "max_types")
+		}
+		if int(n) == dynamicDefaultMaxTypes {
+			return ColumnTypeDynamic, nil
+		}
+		return ColumnTypeDynamic.With("max_types=" + strconv.Itoa(int(n))), nil
+	case binaryTypeCustom:
+		name, err := r.Str()
+		if err != nil {
+			return "", errors.Wrap(err, "custom type")
+		}
+		return ColumnType(name), nil
+	case binaryTypeJSON:
+		return readBinaryTypeJSON(r, depth)
+	case binaryTypeAggregateFunction, binaryTypeSimpleAggregateFunction:
+		return readBinaryTypeAggregate(r, code, depth)
+	default:
+		return "", errors.Errorf("unknown binary type 0x%02x", code)
+	}
+}
+
+func readBinaryTypeJSON(r *Reader, depth int) (ColumnType, error) {
+	if _, err := r.UInt8(); err != nil {
+		return "", errors.Wrap(err, "serialization version")
+	}
+	maxPaths, err := r.UVarInt()
+	if err != nil {
+		return "", errors.Wrap(err, "max_dynamic_paths")
+	}
+	maxTypes, err := r.UInt8()
+	if err != nil {
+		return "", errors.Wrap(err, "max_dynamic_types")
+	}
+	var params []string
+	if maxPaths != jsonDefaultMaxDynamicPaths {
+		params = append(params, "max_dynamic_paths="+strconv.FormatUint(maxPaths, 10))
+	}
+	if int(maxTypes) != dynamicDefaultMaxTypes {
+		params = append(params, "max_dynamic_types="+strconv.Itoa(int(maxTypes)))
+	}
+	strs := func(name string, f func(s string) (string, error)) error {
+		n, err := r.UVarInt()
+		if err != nil {
+			return errors.Wrapf(err, "%s count", name)
+		}
+		if err := checkRows(int(n)); err != nil {
+			return errors.Wrapf(err, "%s count", name)
+		}
+		for i := 0; i < int(n); i++ {
+			s, err := r.Str()
+			if err != nil {
+				return errors.Wrapf(err, "%s [%d]", name, i)
+			}
+			p, err := f(s)
+			if err != nil {
+				return errors.Wrapf(err, "%s %q", name, s)
+			}
+			params = append(params, p)
+		}
+		return nil
+	}
+	if err := strs("typed path", func(s string) (string, error) {
+		t, err := readBinaryTypeDepth(r, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return quoteTypeName(s) + " " + t.String(), nil
+	}); err != nil {
+		return "", err
+	}
+	if err := strs("skip path", func(s string) (string, error) {
+		return "SKIP " + quoteTypeName(s), nil
+	}); err != nil {
+		return "", err
+	}
+	if err := strs("skip regexp", func(s string) (string, error) {
+		return "SKIP REGEXP " + quoteTypeString(s), nil
+	}); err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return ColumnTypeJSON, nil
+	}
+	return ColumnTypeJSON.With(params...), nil
+}
+
+func readBinaryTypeAggregate(r *Reader, code byte, depth int) (ColumnType, error) {
+	var version uint64
+	if code == binaryTypeAggregateFunction {
+		v, err := r.UVarInt()
+		if err != nil {
+			return "", errors.Wrap(err, "version")
+		}
+		version = v
+	}
+	name, err := r.Str()
+	if err != nil {
+		return "", errors.Wrap(err, "function name")
+	}
+	n, err := r.UVarInt()
+	if err != nil {
+		return "", errors.Wrap(err, "parameters")
+	}
+	if n != 0 {
+		return "", errors.Errorf("%s: binary encoding of parameters not supported", name)
+	}
+	if n, err = r.UVarInt(); err != nil {
+		return "", errors.Wrap(err, "arguments")
+	}
+	if n > uint64(maxVariantTypes) {
+		return "", errors.Errorf("too many arguments: %d", n)
+	}
+	var params []string
+	if version != 0 {
+		params = append(params, strconv.FormatUint(version, 10))
+	}
+	params = append(params, name)
+	for i := 0; i < int(n); i++ {
+		t, err := readBinaryTypeDepth(r, depth+1)
+		if err != nil {
+			return "", errors.Wrapf(err, "argument [%d]", i)
+		}
+		params = append(params, t.String())
+	}
+	if code == binaryTypeAggregateFunction {
+		return ColumnTypeAggregateFunction.With(params...), nil
+	}
+	return ColumnTypeSimpleAggregateFunction.With(params...), nil
+}
@@ -0,0 +1,72 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryType(t *testing.T) {
+	for _, tt := range []struct {
+		Type ColumnType
+		Data []byte
+	}{
+		{Type: "Nothing", Data: []byte{0x00}},
+		{Type: "UInt64", Data: []byte{0x04}},
+		{Type: "Array(Nullable(String))", Data: []byte{0x1E, 0x23, 0x15}},
+		{Type: "DateTime('UTC')", Data: []byte{0x12, 3, 'U', 'T', 'C'}},
+		{Type: "DateTime64(3)", Data: []byte{0x13, 3}},
+		{Type: "FixedString(4)", Data: []byte{0x16, 4}},
+		{Type: "Enum8('a' = 1, 'b' = -1)", Data: []byte{0x17, 2, 1, 'a', 1, 1, 'b', 0xFF}},
+		{Type: "Decimal(18, 4)", Data: []byte{0x1A, 18, 4}},
+		{Type: "Map(String, UInt8)", Data: []byte{0x27, 0x15, 0x01}},
+		{Type: "Tuple(a Int8, b Bool)", Data: []byte{0x20, 2, 1, 'a', 0x07, 1, 'b', 0x2D}},
+		{Type: "Dynamic(max_types=8)", Data: []byte{0x2B, 8}},
+		{Type: "Point", Data: []byte{0x2C, 5, 'P', 'o', 'i', 'n', 't'}},
+		{Type: "IntervalSecond", Data: []byte{0x22, 3}},
+		{Type: "Tuple(UInt8, String)"},
+		{Type: "Nested(a UInt8, `b c` String)"},
+		{Type: "LowCardinality(Nullable(String))"},
+		{Type: "Variant(String, UInt64)"},
+		{Type: "Enum16('x' = 1000)"},
+		{Type: "DateTime64(6, 'Europe/Moscow')"},
+		{Type: "Decimal(76, 10)"},
+		{Type: "Dynamic"},
+		{Type: "JSON"},
+		{Type: "JSON(max_dynamic_paths=10, a.b UInt32, SKIP c, SKIP REGEXP 'd.*')"},
+		{Type: "AggregateFunction(sum, UInt64)"},
+		{Type: "SimpleAggregateFunction(any, String)"},
+		{Type: "MultiPolygon"},
+	} {
+		t.Run(tt.Type.String(), func(t *testing.T) {
+			typ, err := ParseType(tt.Type)
+			require.NoError(t, err)
+			var b Buffer
+			require.NoError(t, putBinaryType(&b, typ))
+			if tt.Data != nil {
+				require.Equal(t, tt.Data, b.Buf)
+			}
+			got, err := readBinaryType(b.Reader())
+			require.NoError(t, err)
+			require.Equal(t, typ.ColumnType(), got)
+		})
+	}
+	t.Run("Unsupported", func(t *testing.T) {
+		typ, err := ParseType("AggregateFunction(quantiles(0.5), Float64)")
+		require.NoError(t, err)
+		require.Error(t, putBinaryType(new(Buffer), typ))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		var b Buffer
+		b.PutByte(0xFF)
+		_, err := readBinaryType(b.Reader())
+		require.Error(t, err)
+
+		b.Reset()
+		for i := 0; i <= maxBinaryTypeDepth+1; i++ {
+			b.PutByte(binaryTypeArray)
+		}
+		_, err = readBinaryType(b.Reader())
+		require.Error(t, err)
+	})
+}
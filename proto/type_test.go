@@ -0,0 +1,140 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseType(t *testing.T) {
+	for _, tt := range []struct {
+		Input  ColumnType
+		String string
+		Check  func(t *testing.T, v Type)
+	}{
+		{Input: "String", String: "String"},
+		{Input: "Array( Nullable(String) )", String: "Array(Nullable(String))", Check: func(t *testing.T, v Type) {
+			require.Equal(t, ColumnTypeArray, v.Name)
+			require.Len(t, v.Elems, 1)
+			require.Equal(t, ColumnTypeNullable, v.Elems[0].Name)
+			require.Equal(t, ColumnTypeString, v.Elems[0].Elems[0].Name)
+		}},
+		{Input: "Map(String,UInt64)", String: "Map(String, UInt64)"},
+		{Input: "Tuple()", String: "Tuple()"},
+		{Input: "Tuple(a String, `b c` Array(Int8), `SKIP` UInt8)", String: "Tuple(a String, `b c` Array(Int8), `SKIP` UInt8)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, []string{"a", "b c", "SKIP"}, v.Names)
+			require.Equal(t, ColumnTypeString, v.Elems[0].Name)
+			require.Equal(t, ColumnType("Array(Int8)"), v.Elems[1].ColumnType())
+		}},
+		{Input: "Tuple(String, Int64)", String: "Tuple(String, Int64)", Check: func(t *testing.T, v Type) {
+			require.Nil(t, v.Names)
+			require.Len(t, v.Elems, 2)
+		}},
+		{Input: "Enum8('a,(b' = 1, 'c\\'' = -2)", String: "Enum8('a,(b' = 1, 'c\\'' = -2)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, []EnumValue{{Name: "a,(b", Value: 1}, {Name: "c'", Value: -2}}, v.Enum)
+		}},
+		{Input: "Enum16('a', 'b')", String: "Enum16('a' = 1, 'b' = 2)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, []EnumValue{{Name: "a", Value: 1}, {Name: "b", Value: 2}}, v.Enum)
+		}},
+		{Input: "DateTime64(3, 'Europe/Moscow')", String: "DateTime64(3, 'Europe/Moscow')", Check: func(t *testing.T, v Type) {
+			require.Equal(t, 3, v.Precision)
+			require.Equal(t, "Europe/Moscow", v.Timezone)
+			require.Nil(t, v.Elems)
+		}},
+		{Input: "DateTime(Europe/Moscow)", String: "DateTime('Europe/Moscow')", Check: func(t *testing.T, v Type) {
+			require.Equal(t, "Europe/Moscow", v.Timezone)
+		}},
+		{Input: "DateTime(UTC)", String: "DateTime('UTC')", Check: func(t *testing.T, v Type) {
+			require.Equal(t, "UTC", v.Timezone)
+			require.Nil(t, v.Elems)
+		}},
+		{Input: "Decimal", String: "Decimal(10, 0)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, 10, v.Precision)
+			require.Equal(t, ColumnTypeDecimal64, v.decimalWidth())
+		}},
+		{Input: "Decimal(20,2)", String: "Decimal(20, 2)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, 20, v.Precision)
+			require.Equal(t, 2, v.Scale)
+			require.Equal(t, ColumnTypeDecimal128, v.decimalWidth())
+		}},
+		{Input: "Decimal32(3)", String: "Decimal32(3)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, 9, v.Precision)
+			require.Equal(t, 3, v.Scale)
+		}},
+		{Input: "FixedString(16)", String: "FixedString(16)", Check: func(t *testing.T, v Type) {
+			require.Equal(t, 16, v.Size)
+		}},
+		{Input: "BIGINT", String: "Int64"},
+		{Input: "bigint unsigned", String: "UInt64"},
+		{Input: "INT(11) UNSIGNED", String: "UInt32"},
+		{Input: "VARCHAR(255)", String: "String"},
+		{Input: "DOUBLE PRECISION", String: "Float64"},
+		{Input: "Nullable(BOOLEAN)", String: "Nullable(Bool)"},
+		{Input: "NUMERIC(10, 2)", String: "Decimal(10, 2)"},
+		{Input: "Tuple(x INT UNSIGNED, y TEXT)", String: "Tuple(x UInt32, y String)"},
+		{Input: "JSON(max_dynamic_paths = 10, a.b UInt32, SKIP a.c, SKIP REGEXP 'x(,)')", String: "JSON(max_dynamic_paths=10, a.b UInt32, SKIP a.c, SKIP REGEXP 'x(,)')", Check: func(t *testing.T, v Type) {
+			require.Equal(t, TypeParamSetting, v.Params[0].Kind)
+			require.Equal(t, "max_dynamic_paths", v.Params[0].Name)
+			require.Equal(t, "10", v.Params[0].Value)
+			require.Equal(t, []string{"a.b"}, v.Names)
+			require.Equal(t, TypeParamRaw, v.Params[2].Kind)
+			require.Equal(t, TypeParamRaw, v.Params[3].Kind)
+		}},
+		{Input: "AggregateFunction(1, quantiles(0.5, 0.9), UInt64)", String: "AggregateFunction(1, quantiles(0.5, 0.9), UInt64)"},
+		{Input: "SimpleAggregateFunction(anyLast, DateTime('UTC'))", String: "SimpleAggregateFunction(anyLast, DateTime('UTC'))", Check: func(t *testing.T, v Type) {
+			require.Equal(t, ColumnType("DateTime('UTC')"), v.simpleAggregateElem().ColumnType())
+		}},
+	} {
+		t.Run(tt.Input.String(), func(t *testing.T) {
+			v, err := ParseType(tt.Input)
+			require.NoError(t, err)
+			require.Equal(t, tt.String, v.String())
+			if tt.Check != nil {
+				tt.Check(t, v)
+			}
+
+			// Canonical form is stable.
+			canonical, err := ParseType(v.ColumnType())
+			require.NoError(t, err)
+			require.Equal(t, tt.String, canonical.String())
+		})
+	}
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []ColumnType{
+			"",
+			"Array(",
+			"Array(String",
+			"Array(String))",
+			"Tuple(String,)",
+			"(String)",
+			"Enum8('a' = 1000)",
+			"Enum8('a' = x)",
+			"Enum8('a",
+			"DateTime64(10)",
+			"DateTime(1, 2)",
+			"Decimal(100, 2)",
+			"Decimal(10, 20)",
+			"FixedString(a)",
+			"FixedString(0)",
+			"Array(DateTime64(12))",
+		} {
+			_, err := ParseType(s)
+			require.Error(t, err, "%q", s)
+		}
+	})
+}
+
+func TestColumnType_Base(t *testing.T) {
+	for _, tt := range []struct {
+		Type       ColumnType
+		Base, Elem ColumnType
+	}{
+		{Type: "Enum8('a(' = 1)", Base: ColumnTypeEnum8, Elem: "'a(' = 1"},
+		{Type: "Tuple(a String, b Int8)", Base: ColumnTypeTuple, Elem: "a String, b Int8"},
+		{Type: "BIGINT", Base: ColumnTypeInt64},
+		{Type: "DateTime64(3, 'Europe/Moscow')", Base: ColumnTypeDateTime64, Elem: "3, 'Europe/Moscow'"},
+	} {
+		require.Equal(t, tt.Base, tt.Type.Base(), "%s", tt.Type)
+		require.Equal(t, tt.Elem, tt.Type.Elem(), "%s", tt.Type)
+	}
+}
@@ -2,7 +2,9 @@ package proto
 
 import (
 	"io"
+	"math"
 	"net"
+	"slices"
 )
 
 // Writer is a column writer.
@@ -14,8 +16,13 @@ type Writer struct {
 	buf       *Buffer
 	bufOffset int
 	needCut   bool
+	flushSize int
 
 	vec net.Buffers
+
+	chunked    bool
+	chunkStart int  // index of first segment of vec not framed as chunk
+	inPacket   bool // whether chunks of current packet were framed
 }
 
 // NewWriter creates new [Writer].
@@ -45,6 +52,23 @@ func (w *Writer) ChainBuffer(cb func(*Buffer)) {
 	cb(w.buf)
 }
 
+// SetFlushSize sets size of buffered data, after which [Block.WriteBlock]
+// flushes writer between columns, bounding memory used for large blocks.
+//
+// Zero disables flushing, which is default.
+func (w *Writer) SetFlushSize(n int) {
+	w.flushSize = n
+}
+
+// flushIfNeeded flushes writer if buffered data exceeds flush size.
+func (w *Writer) flushIfNeeded() error {
+	if w.flushSize == 0 || len(w.buf.Buf) < w.flushSize {
+		return nil
+	}
+	_, err := w.Flush()
+	return err
+}
+
 func (w *Writer) cutBuffer() {
 	newOffset := len(w.buf.Buf)
 	data := w.buf.Buf[w.bufOffset:newOffset:newOffset]
@@ -57,6 +81,7 @@ func (w *Writer) cutBuffer() {
 
 func (w *Writer) reset() {
 	w.bufOffset = 0
+	w.chunkStart = 0
 	w.needCut = false
 	w.buf.Reset()
 	// Do not hold references, to avoid memory leaks.
@@ -64,8 +89,77 @@ func (w *Writer) reset() {
 	w.vec = w.vec[:0]
 }
 
+// EnableChunked enables chunked framing of packets, see [Writer.FinishChunk].
+func (w *Writer) EnableChunked() {
+	w.chunked = true
+}
+
+// maxChunkSize limits size of single chunk.
+const maxChunkSize = math.MaxInt32
+
+// frameChunk frames data written since last framing as chunks.
+func (w *Writer) frameChunk() {
+	w.cutBuffer()
+	for start := w.chunkStart; start < len(w.vec); {
+		end, size := start, 0
+		for end < len(w.vec) && size+len(w.vec[end]) <= maxChunkSize {
+			size += len(w.vec[end])
+			end++
+		}
+		if end == start {
+			// Splitting segment that does not fit into single chunk.
+			v := w.vec[start]
+			w.vec = slices.Insert(w.vec, start+1, v[maxChunkSize:])
+			w.vec[start] = v[:maxChunkSize]
+			continue
+		}
+		if size == 0 {
+			// Empty chunk terminates packet, so skipping it.
+			start = end
+			continue
+		}
+		w.buf.PutUInt32(uint32(size))
+		header := w.buf.Buf[w.bufOffset:len(w.buf.Buf):len(w.buf.Buf)]
+		w.bufOffset = len(w.buf.Buf)
+		w.vec = slices.Insert(w.vec, start, header)
+		w.inPacket = true
+		start = end + 1
+	}
+	w.chunkStart = len(w.vec)
+}
+
+// FinishChunk ends current packet if chunked framing is enabled, so data
+// written since previous call is sent as chunks terminated by empty chunk.
+func (w *Writer) FinishChunk() {
+	if !w.chunked {
+		return
+	}
+	w.frameChunk()
+	if !w.inPacket {
+		return
+	}
+	w.buf.PutChunkEnd()
+	w.cutBuffer()
+	w.chunkStart = len(w.vec)
+	w.inPacket = false
+}
+
+// Write implements io.Writer, flushing buffered data and p.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.ChainWrite(p)
+	if _, err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 // Flush flushes all data to writer.
+//
+// If chunked framing is enabled, data is sent as chunks of current packet.
 func (w *Writer) Flush() (n int64, err error) {
+	if w.chunked {
+		w.frameChunk()
+	}
 	w.cutBuffer()
 	n, err = w.vec.WriteTo(w.conn)
 	w.reset()
@@ -38,8 +38,15 @@ func (c *Client) cancelQuery() error {
 	proto.ClientCodeCancel.Encode(&b)
 
 	var retErr error
-	if err := c.flushBuf(ctx, &b); err != nil {
-		retErr = errors.Join(retErr, errors.Wrap(err, "flush"))
+	if c.writeMux.TryLock() {
+		if err := c.flushBuf(ctx, &b); err != nil {
+			retErr = errors.Join(retErr, errors.Wrap(err, "flush"))
+		}
+		c.writeMux.Unlock()
+	} else {
+		// Packet is being written, so Cancel can't be sent without
+		// corrupting it. Closing connection cancels query anyway.
+		c.lg.Debug("Skipping Cancel packet, connection is busy")
 	}
 
 	// Always close connection to prevent further queries.
@@ -50,6 +57,23 @@ func (c *Client) cancelQuery() error {
 	return retErr
 }
 
+// sendCancel asks server to cancel current query, keeping connection open.
+//
+// Server finishes query with end of stream or exception, so remaining
+// packets should be drained before next query.
+func (c *Client) sendCancel(ctx context.Context) error {
+	c.lg.Debug("Cancel query")
+
+	// Not using c.buf to prevent data race.
+	var b proto.Buffer
+	proto.ClientCodeCancel.Encode(&b)
+
+	// Waiting until current packet is written.
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	return c.flushBuf(ctx, &b)
+}
+
 func (c *Client) querySettings(q Query) []proto.Setting {
 	var result []proto.Setting
 	for _, s := range c.settings {
@@ -136,6 +160,12 @@ type Query struct {
 	QuotaKey string
 
 	// Input columns for INSERT operations.
+	//
+	// Columns with server-side defaults can be omitted from Input if they
+	// are omitted from column list of query too, e.g. by using Input.Into,
+	// so server can apply DEFAULT expressions. Otherwise server fills them
+	// with zero values, so client rejects such input, as well as input of
+	// MATERIALIZED and ALIAS columns.
 	Input proto.Input
 	// OnInput is called to allow ingesting more data to Input.
 	//
@@ -151,8 +181,31 @@ type Query struct {
 	//
 	// Optional, but query will fail of more than one block is received
 	// and no OnResult is provided.
+	//
+	// Block.Info contains overflows flag and bucket number of block.
 	OnResult func(ctx context.Context, block proto.Block) error
 
+	// Totals columns for queries WITH TOTALS, optional.
+	//
+	// If not provided, totals are decoded to Result and passed to OnResult,
+	// like regular data.
+	Totals proto.Result
+	// OnTotals is called when Totals is filled with totals block.
+	//
+	// Requires Totals.
+	OnTotals func(ctx context.Context, block proto.Block) error
+
+	// Extremes columns for queries with extremes setting enabled, optional.
+	//
+	// If not provided, extremes are skipped.
+	Extremes proto.Result
+	// OnExtremes is called when Extremes is filled with extremes block.
+	OnExtremes func(ctx context.Context, block proto.Block) error
+
+	// OnTableColumns is optional handler for description of table columns,
+	// sent by server before INSERT data.
+	OnTableColumns func(ctx context.Context, columns []proto.ColumnDescription) error
+
 	// OnProgress is optional progress handler. The progress value contain
 	// difference, so progress should be accumulated if needed.
 	OnProgress func(ctx context.Context, p proto.Progress) error
@@ -266,11 +319,18 @@ func (c *Client) decodeBlock(ctx context.Context, opt decodeOptions) error {
 	return nil
 }
 
-// encodeBlock encodes data block into buf, performing compression if needed.
+// encodeBlock encodes data block, performing compression if needed.
+//
+// Compressed blocks are written to connection directly, see writeCompressed.
 //
 // If input length is zero, blank block will be encoded, which is special case
 // for "end of data".
 func (c *Client) encodeBlock(ctx context.Context, tableName string, input []proto.InputColumn) error {
+	// Compressed block is written by parts, so holding lock until whole
+	// packet is written.
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+
 	c.writer.ChainBuffer(func(buf *proto.Buffer) {
 		proto.ClientCodeData.Encode(buf)
 		clientData := proto.ClientData{
@@ -298,35 +358,51 @@ func (c *Client) encodeBlock(ctx context.Context, tableName string, input []prot
 			return err
 		}
 	} else {
-		// TODO(tdakkota): find out if we can actually stream compressed blocks.
-
-		var rerr error
-		c.writer.ChainBuffer(func(buf *proto.Buffer) {
-			// Saving offset of compressible data.
-			start := len(buf.Buf)
-			if err := b.EncodeBlock(buf, c.protocolVersion, input); err != nil {
-				rerr = errors.Wrap(err, "encode")
-				return
-			}
-
-			// Performing compression.
-			//
-			// Note: only blocks are compressed.
-			// See "Compressible" method of server or client code for reference.
-			if c.compression == proto.CompressionEnabled {
-				data := buf.Buf[start:]
-				if err := c.compressor.Compress(data); err != nil {
-					rerr = errors.Wrap(err, "compress")
-					return
-				}
-				buf.Buf = append(buf.Buf[:start], c.compressor.Data...)
-			}
-		})
-		if rerr != nil {
-			return rerr
+		if err := c.writeCompressed(ctx, b, input); err != nil {
+			return errors.Wrap(err, "write compressed")
 		}
 	}
+	c.writer.FinishChunk()
+
+	return nil
+}
 
+// writeCompressed writes block to connection as stream of compressed
+// frames of bounded size, so block is never buffered or compressed as a whole.
+//
+// Frames are written through c.writer, so they are framed as chunks of
+// data packet if chunked framing is enabled.
+//
+// Note: only blocks are compressed.
+// See "Compressible" method of server or client code for reference.
+func (c *Client) writeCompressed(ctx context.Context, b proto.Block, input []proto.InputColumn) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetWriteDeadline(deadline); err != nil {
+			return errors.Wrap(err, "set write deadline")
+		}
+		// Reset deadline.
+		defer func() { _ = c.conn.SetWriteDeadline(time.Time{}) }()
+	}
+	// Data packet header is not compressed and is flushed with first frame.
+	c.frames.Reset(c.writer)
+	if err := b.WriteBlock(c.compressed, c.protocolVersion, input); err != nil {
+		// Discarding rest of block.
+		c.frames.Reset(io.Discard)
+		_, _ = c.compressed.Flush()
+		return errors.Wrap(err, "write block")
+	}
+	if _, err := c.compressed.Flush(); err != nil {
+		return errors.Wrap(err, "flush block")
+	}
+	if err := c.frames.Flush(); err != nil {
+		return errors.Wrap(err, "flush frame")
+	}
+	// Writing end of packet too, so no other packet is written in the
+	// middle of it after c.writeMux is released.
+	c.writer.FinishChunk()
+	if _, err := c.writer.Flush(); err != nil {
+		return errors.Wrap(err, "flush packet")
+	}
 	return nil
 }
 
@@ -336,6 +412,46 @@ func (c *Client) encodeBlankBlock(ctx context.Context) error {
 	return c.encodeBlock(ctx, "", nil)
 }
 
+// checkInput checks that input can be inserted to table with provided
+// header and columns description.
+//
+// Columns description is sent by server only if
+// "input_format_defaults_for_omitted_fields" is enabled (default), so
+// client can fill defaults of omitted fields, like clickhouse-client does
+// for text formats. Otherwise input is not checked.
+func checkInput(input proto.Input, header proto.ColInfoInput, columns []proto.ColumnDescription) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	described := make(map[string]proto.ColumnDescription, len(columns))
+	for _, c := range columns {
+		described[c.Name] = c
+	}
+	inputs := make(map[string]struct{}, len(input))
+	for _, c := range input {
+		inputs[c.Name] = struct{}{}
+		if d, ok := described[c.Name]; ok && !d.DefaultKind.Insertable() {
+			return errors.Errorf("can't insert %s column %q", d.DefaultKind, c.Name)
+		}
+	}
+	for _, c := range header {
+		if _, ok := inputs[c.Name]; ok {
+			continue
+		}
+		if d := described[c.Name]; d.DefaultExpr != "" {
+			// Server fills columns of query that are missing from Native
+			// data with zero values, regardless of
+			// "input_format_defaults_for_omitted_fields". Default
+			// expressions are applied only to columns that are missing
+			// from column list of query.
+			return errors.Errorf("column %q with %s %s is missing from input, omit it from query too (e.g. use Input.Into)",
+				c.Name, d.DefaultKind, d.DefaultExpr,
+			)
+		}
+	}
+	return nil
+}
+
 func (c *Client) sendInput(ctx context.Context, info proto.ColInfoInput, q Query) error {
 	if len(q.Input) == 0 {
 		return nil
@@ -430,7 +546,7 @@ End:
 	return nil
 }
 
-func (c *Client) resultHandler(q Query) func(ctx context.Context, b proto.Block) error {
+func resultHandler(q Query) func(ctx context.Context, b proto.Block) error {
 	if q.OnResult != nil {
 		return q.OnResult
 	}
@@ -448,6 +564,14 @@ func (c *Client) resultHandler(q Query) func(ctx context.Context, b proto.Block)
 	}
 }
 
+// blockHandler returns f or no-op handler if f is nil.
+func blockHandler(f func(ctx context.Context, b proto.Block) error) func(ctx context.Context, b proto.Block) error {
+	if f != nil {
+		return f
+	}
+	return func(ctx context.Context, b proto.Block) error { return nil }
+}
+
 type (
 	ProfileEvent     = proto.ProfileEvent
 	ProfileEventType = proto.ProfileEventType
@@ -501,12 +625,44 @@ func (c *Client) handlePacket(ctx context.Context, p proto.ServerCode, q Query)
 			}
 		}
 		return nil
+	case proto.ServerCodeExtremes:
+		result := q.Extremes
+		if result == nil {
+			// Skipping, but not to Result, which may still be read after
+			// query or contain rows that are not handled yet.
+			var discard proto.Results
+			result = discard.Auto()
+		}
+		if err := c.decodeBlock(ctx, decodeOptions{
+			Handler:      blockHandler(q.OnExtremes),
+			Result:       result,
+			Compressible: p.Compressible(),
+		}); err != nil {
+			return errors.Wrap(err, "decode extremes")
+		}
+		return nil
+	case proto.ServerCodeTimezoneUpdate:
+		tz, err := c.reader.Str()
+		if err != nil {
+			return errors.Wrap(err, "timezone update")
+		}
+		c.lg.Debug("Timezone update", zap.String("timezone", tz))
+		return nil
 	case proto.ServerCodeTableColumns:
-		// Ignoring for now.
 		var info proto.TableColumns
 		if err := c.decode(&info); err != nil {
 			return errors.Wrap(err, "table columns")
 		}
+		if q.OnTableColumns == nil {
+			return nil
+		}
+		columns, err := info.Columns()
+		if err != nil {
+			return errors.Wrap(err, "parse table columns")
+		}
+		if err := q.OnTableColumns(ctx, columns); err != nil {
+			return errors.Wrap(err, "table columns")
+		}
 		return nil
 	case proto.ServerProfileEvents:
 		var data proto.ProfileEvents
@@ -599,6 +755,9 @@ func (c *Client) Do(ctx context.Context, q Query) (err error) {
 			c.protocolVersion, c.server,
 		)
 	}
+	if q.OnTotals != nil && q.Totals == nil {
+		return errors.New("OnTotals requires Totals")
+	}
 	if q.QueryID == "" {
 		q.QueryID = uuid.New().String()
 	}
@@ -674,8 +833,20 @@ func (c *Client) Do(ctx context.Context, q Query) (err error) {
 	var (
 		gotException atomic.Bool
 		colInfo      chan proto.ColInfoInput
+		tableColumns []proto.ColumnDescription
 	)
 	if q.Result == nil && len(q.Input) > 0 {
+		// Table columns are received before column info, so reading them
+		// after column info is received is safe.
+		onTableColumns := q.OnTableColumns
+		q.OnTableColumns = func(ctx context.Context, columns []proto.ColumnDescription) error {
+			tableColumns = columns
+			if onTableColumns == nil {
+				return nil
+			}
+			return onTableColumns(ctx, columns)
+		}
+
 		// Handling input column type inference, e.g. enums.
 		result := proto.ColInfoInput{}
 		q.Result = &result
@@ -713,6 +884,9 @@ func (c *Client) Do(ctx context.Context, q Query) (err error) {
 			case v := <-colInfo:
 				info = v
 			}
+			if err := checkInput(q.Input, info, tableColumns); err != nil {
+				return errors.Wrap(err, "check input")
+			}
 		}
 		if err := c.sendInput(ctx, info, q); err != nil {
 			return errors.Wrap(err, "send input")
@@ -728,7 +902,7 @@ func (c *Client) Do(ctx context.Context, q Query) (err error) {
 		if colInfo != nil {
 			defer close(colInfo)
 		}
-		onResult := c.resultHandler(q)
+		onResult := resultHandler(q)
 		for {
 			if ctx.Err() != nil {
 				return ctx.Err()
@@ -743,6 +917,16 @@ func (c *Client) Do(ctx context.Context, q Query) (err error) {
 			}
 			switch code {
 			case proto.ServerCodeData, proto.ServerCodeTotals:
+				if code == proto.ServerCodeTotals && q.Totals != nil {
+					if err := c.decodeBlock(ctx, decodeOptions{
+						Handler:      blockHandler(q.OnTotals),
+						Result:       q.Totals,
+						Compressible: code.Compressible(),
+					}); err != nil {
+						return errors.Wrap(err, "decode totals")
+					}
+					continue
+				}
 				if err := c.decodeBlock(ctx, decodeOptions{
 					Handler:      onResult,
 					Result:       q.Result,
@@ -0,0 +1,194 @@
+package ch
+
+import (
+	"context"
+
+	"github.com/go-faster/errors"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Rows is a cursor over result blocks of query started by Client.Query.
+//
+// Columns of Query.Result are filled with data of current block and are
+// reused for the next one, so data should be copied if it is needed after
+// Next call.
+//
+// Rows is not goroutine-safe and client can't be used for other queries
+// until Rows are closed.
+type Rows struct {
+	client *Client
+
+	blocks  chan proto.Block
+	release chan struct{}
+	closing chan struct{}
+	done    chan struct{}
+
+	block    proto.Block
+	hasBlock bool
+	closed   bool
+
+	// Accessed by query goroutine until done is closed.
+	canceled bool
+	err      error
+}
+
+// Query starts query and returns cursor over result blocks.
+//
+// Query.Result is required, Query.OnResult and Input are not supported.
+// Rows must be closed. Closing Rows before the end of result cancels query
+// and drains remaining packets, so client stays usable.
+func (c *Client) Query(ctx context.Context, q Query) (*Rows, error) {
+	if c.IsClosed() {
+		return nil, ErrClosed
+	}
+	if q.Result == nil {
+		return nil, errors.New("no Result provided")
+	}
+	if q.OnResult != nil {
+		return nil, errors.New("query with OnResult is not supported")
+	}
+	if len(q.Input) > 0 || q.OnInput != nil {
+		return nil, errors.New("query with Input is not supported")
+	}
+	r := &Rows{
+		client:  c,
+		blocks:  make(chan proto.Block),
+		release: make(chan struct{}),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	q.OnResult = r.handleResult
+	onProgress := q.OnProgress
+	q.OnProgress = func(ctx context.Context, p proto.Progress) error {
+		// Server sends progress periodically, so cancellation is not
+		// delayed by slow queries that don't produce blocks.
+		if err := r.checkClosing(ctx); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			return onProgress(ctx, p)
+		}
+		return nil
+	}
+	go func() {
+		defer close(r.done)
+		err := c.Do(ctx, q)
+		if r.canceled && IsErr(err, proto.ErrQueryWasCancelled, proto.ErrQueryWasCancelledByClient) {
+			// Query was canceled by Close.
+			err = nil
+		}
+		r.err = err
+	}()
+	return r, nil
+}
+
+// handleResult passes block to consumer and waits until it is processed.
+func (r *Rows) handleResult(ctx context.Context, b proto.Block) error {
+	if err := r.checkClosing(ctx); err != nil || r.canceled {
+		// Draining blocks of canceled query.
+		return err
+	}
+	if b.Rows == 0 {
+		// Server can send block with zero rows on start,
+		// providing a way to check column metadata.
+		return nil
+	}
+	select {
+	case r.blocks <- b:
+	case <-r.closing:
+		return r.cancel(ctx)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// Result columns are reused for next block, so waiting until consumer
+	// is done with current one.
+	select {
+	case <-r.release:
+		return nil
+	case <-r.closing:
+		return r.cancel(ctx)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkClosing cancels query if Close was called.
+func (r *Rows) checkClosing(ctx context.Context) error {
+	select {
+	case <-r.closing:
+		return r.cancel(ctx)
+	default:
+		return nil
+	}
+}
+
+// cancel sends Cancel packet once.
+//
+// Connection is not closed, remaining packets are drained by Do.
+func (r *Rows) cancel(ctx context.Context) error {
+	if r.canceled {
+		return nil
+	}
+	r.canceled = true
+	if err := r.client.sendCancel(ctx); err != nil {
+		return errors.Wrap(err, "cancel")
+	}
+	return nil
+}
+
+// Next prepares next result block, filling columns of Query.Result.
+//
+// Returns false if there are no more blocks or query failed, so Err
+// should be checked.
+func (r *Rows) Next() bool {
+	if r.closed {
+		return false
+	}
+	if r.hasBlock {
+		r.hasBlock = false
+		r.block = proto.Block{}
+		select {
+		case r.release <- struct{}{}:
+		case <-r.done:
+		}
+	}
+	select {
+	case b := <-r.blocks:
+		r.block = b
+		r.hasBlock = true
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// Block returns current block.
+func (r *Rows) Block() proto.Block {
+	return r.block
+}
+
+// Err returns query error, if any.
+func (r *Rows) Err() error {
+	select {
+	case <-r.done:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// Close cancels query if it is not done and waits until connection is
+// drained. Returns query error, if any.
+//
+// Close is idempotent.
+func (r *Rows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.hasBlock = false
+		r.block = proto.Block{}
+		close(r.closing)
+	}
+	<-r.done
+	return r.err
+}
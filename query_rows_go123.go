@@ -0,0 +1,50 @@
+//go:build go1.23
+
+package ch
+
+import (
+	"iter"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Blocks returns an [iter.Seq2] iterator over result blocks.
+//
+// Rows are closed when iteration is done or stopped. Query error, if any,
+// is yielded as the last element.
+func (r *Rows) Blocks() iter.Seq2[proto.Block, error] {
+	return func(yield func(proto.Block, error) bool) {
+		defer func() { _ = r.Close() }()
+		for r.Next() {
+			if !yield(r.Block(), nil) {
+				return
+			}
+		}
+		if err := r.Close(); err != nil {
+			yield(proto.Block{}, err)
+		}
+	}
+}
+
+// Rows returns an [iter.Seq2] iterator over row indexes of result blocks.
+//
+// Index is relative to current block, so values should be read from
+// columns of Query.Result, e.g. col.Row(i).
+//
+// Rows are closed when iteration is done or stopped. Query error, if any,
+// is yielded as the last element.
+func (r *Rows) Rows() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		defer func() { _ = r.Close() }()
+		for r.Next() {
+			for i := 0; i < r.Block().Rows; i++ {
+				if !yield(i, nil) {
+					return
+				}
+			}
+		}
+		if err := r.Close(); err != nil {
+			yield(0, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package ch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestClient_QueryRows(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	settings := []Setting{SettingInt("max_block_size", 10)}
+
+	t.Run("Next", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		var data proto.ColUInt64
+		rows, err := conn.Query(ctx, Query{
+			Body:     "SELECT number FROM system.numbers LIMIT 100",
+			Settings: settings,
+			Result: proto.Results{
+				{Name: "number", Data: &data},
+			},
+		})
+		require.NoError(t, err)
+		var (
+			total  int
+			blocks int
+		)
+		for rows.Next() {
+			require.Equal(t, data.Rows(), rows.Block().Rows)
+			for i := 0; i < data.Rows(); i++ {
+				require.Equal(t, uint64(total), data.Row(i))
+				total++
+			}
+			blocks++
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+		require.Equal(t, 100, total)
+		require.Greater(t, blocks, 1)
+	})
+	t.Run("CloseEarly", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		var data proto.ColUInt64
+		rows, err := conn.Query(ctx, Query{
+			Body:     "SELECT number FROM system.numbers",
+			Settings: settings,
+			Result: proto.Results{
+				{Name: "number", Data: &data},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Close())
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Err())
+
+		// Connection should be usable after early close.
+		require.False(t, conn.IsClosed())
+		require.NoError(t, conn.Ping(ctx))
+		var one proto.ColUInt8
+		require.NoError(t, conn.Do(ctx, Query{
+			Body: "SELECT 1 AS v",
+			Result: proto.Results{
+				{Name: "v", Data: &one},
+			},
+		}))
+		require.Equal(t, uint8(1), one.Row(0))
+	})
+	t.Run("Blocks", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		var data proto.ColUInt64
+		rows, err := conn.Query(ctx, Query{
+			Body:     "SELECT number FROM system.numbers LIMIT 50",
+			Settings: settings,
+			Result: proto.Results{
+				{Name: "number", Data: &data},
+			},
+		})
+		require.NoError(t, err)
+		var total int
+		for b, err := range rows.Blocks() {
+			require.NoError(t, err)
+			total += b.Rows
+		}
+		require.Equal(t, 50, total)
+	})
+	t.Run("Rows", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		var data proto.ColUInt64
+		rows, err := conn.Query(ctx, Query{
+			Body:     "SELECT number FROM system.numbers",
+			Settings: settings,
+			Result: proto.Results{
+				{Name: "number", Data: &data},
+			},
+		})
+		require.NoError(t, err)
+		var got []uint64
+		for i, err := range rows.Rows() {
+			require.NoError(t, err)
+			got = append(got, data.Row(i))
+			if len(got) == 25 {
+				break
+			}
+		}
+		require.Len(t, got, 25)
+		require.Equal(t, uint64(24), got[24])
+		require.NoError(t, conn.Ping(ctx))
+	})
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		var data proto.ColUInt8
+		rows, err := conn.Query(ctx, Query{
+			Body: "SELECT throwIf(number = 50) AS v FROM system.numbers",
+			Result: proto.Results{
+				{Name: "v", Data: &data},
+			},
+		})
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.True(t, IsException(rows.Err()))
+		require.Error(t, rows.Close())
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		conn := Conn(t)
+		_, err := conn.Query(ctx, Query{Body: "SELECT 1"})
+		require.Error(t, err)
+		var data proto.ColUInt8
+		_, err = conn.Query(ctx, Query{
+			Body:     "SELECT 1 AS v",
+			Result:   proto.Results{{Name: "v", Data: &data}},
+			OnResult: func(ctx context.Context, block proto.Block) error { return nil },
+		})
+		require.Error(t, err)
+	})
+}
@@ -3,10 +3,13 @@ package ch
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"net/netip"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,8 +17,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/ClickHouse/ch-go/cht"
+	"github.com/ClickHouse/ch-go/compress"
 	"github.com/ClickHouse/ch-go/proto"
 )
 
@@ -58,6 +63,224 @@ func TestWithTotals(t *testing.T) {
 	require.Equal(t, uint64(100), data[100])
 }
 
+func TestTotalsExtremes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	conn := Conn(t)
+	var (
+		n, c         proto.ColUInt64
+		totalN       proto.ColUInt64
+		totalC       proto.ColUInt64
+		extremeN     proto.ColUInt64
+		extremeC     proto.ColUInt64
+		data, totals []uint64
+		extremes     []uint64
+		overflows    bool
+	)
+	query := Query{
+		Body: `
+			SELECT
+				number % 10 AS n,
+				COUNT() AS c
+			FROM (
+				SELECT number FROM system.numbers LIMIT 100
+			) GROUP BY n WITH TOTALS
+		`,
+		Settings: []Setting{SettingInt("extremes", 1)},
+		Result: proto.Results{
+			{Name: "n", Data: &n},
+			{Name: "c", Data: &c},
+		},
+		OnResult: func(ctx context.Context, b proto.Block) error {
+			data = append(data, c...)
+			return nil
+		},
+		Totals: proto.Results{
+			{Name: "n", Data: &totalN},
+			{Name: "c", Data: &totalC},
+		},
+		OnTotals: func(ctx context.Context, b proto.Block) error {
+			totals = append(totals, totalC...)
+			overflows = b.Info.Overflows
+			return nil
+		},
+		Extremes: proto.Results{
+			{Name: "n", Data: &extremeN},
+			{Name: "c", Data: &extremeC},
+		},
+		OnExtremes: func(ctx context.Context, b proto.Block) error {
+			extremes = append(extremes, extremeN...)
+			return nil
+		},
+	}
+	require.NoError(t, conn.Do(ctx, query))
+	require.Len(t, data, 10)
+	require.Equal(t, []uint64{100}, totals)
+	require.False(t, overflows)
+	require.Equal(t, []uint64{0, 9}, extremes)
+
+	// Extremes are skipped if not requested.
+	query.Extremes = nil
+	query.OnExtremes = nil
+	data = data[:0]
+	require.NoError(t, conn.Do(ctx, query))
+	require.Len(t, data, 10)
+
+	// Totals are not decoded to Result, which contains data.
+	query.Totals = nil
+	require.ErrorContains(t, conn.Do(ctx, query), "OnTotals requires Totals")
+}
+
+func TestClient_TableColumns(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	server := cht.New(t)
+	dial := func() *Client {
+		conn, err := Dial(ctx, Options{Address: server.TCP})
+		require.NoError(t, err)
+		return conn
+	}
+	conn := dial()
+	defer func() { _ = conn.Close() }()
+	require.NoError(t, conn.Do(ctx, Query{
+		Body: `CREATE TABLE test_table_columns (
+			id UInt64,
+			name String DEFAULT 'anonymous',
+			name_len UInt64 MATERIALIZED length(name),
+			id_next UInt64 ALIAS id + 1
+		) ENGINE = Memory`,
+	}))
+
+	var (
+		id      = proto.ColUInt64{1}
+		columns []proto.ColumnDescription
+		input   = proto.Input{{Name: "id", Data: &id}}
+	)
+	require.NoError(t, conn.Do(ctx, Query{
+		Body:  input.Into("test_table_columns"),
+		Input: input,
+		OnTableColumns: func(ctx context.Context, c []proto.ColumnDescription) error {
+			columns = c
+			return nil
+		},
+	}))
+	require.Equal(t, []proto.ColumnDescription{
+		{Name: "id", Type: proto.ColumnTypeUInt64},
+		{Name: "name", Type: proto.ColumnTypeString, DefaultKind: proto.ColumnDefault, DefaultExpr: "'anonymous'"},
+		{Name: "name_len", Type: proto.ColumnTypeUInt64, DefaultKind: proto.ColumnMaterialized, DefaultExpr: "length(name)"},
+		{Name: "id_next", Type: proto.ColumnTypeUInt64, DefaultKind: proto.ColumnAlias, DefaultExpr: "id + 1"},
+	}, columns)
+
+	// selectName returns name of row with id.
+	selectName := func(id int) string {
+		var name proto.ColStr
+		require.NoError(t, conn.Do(ctx, Query{
+			Body:   fmt.Sprintf("SELECT name FROM test_table_columns WHERE id = %d", id),
+			Result: proto.Results{{Name: "name", Data: &name}},
+		}))
+		require.Equal(t, 1, name.Rows())
+		return name.Row(0)
+	}
+	// DEFAULT expression is applied to column omitted from query.
+	require.Equal(t, "anonymous", selectName(1))
+
+	// Column omitted only from data is filled with zero value, so such
+	// input is rejected. Table columns are not sent if defaults for omitted
+	// fields are disabled, so input can't be checked.
+	next := proto.ColUInt64{2}
+	require.NoError(t, conn.Do(ctx, Query{
+		Body:     "INSERT INTO test_table_columns VALUES",
+		Input:    proto.Input{{Name: "id", Data: &next}},
+		Settings: []Setting{SettingInt("input_format_defaults_for_omitted_fields", 0)},
+	}))
+	require.Equal(t, "", selectName(2))
+
+	// Rejected input cancels query and closes connection.
+	t.Run("OmittedFromData", func(t *testing.T) {
+		err := dial().Do(ctx, Query{
+			Body:  "INSERT INTO test_table_columns VALUES",
+			Input: input,
+		})
+		require.ErrorContains(t, err, `column "name" with DEFAULT 'anonymous' is missing from input`)
+	})
+	t.Run("Materialized", func(t *testing.T) {
+		nameLen := proto.ColUInt64{1}
+		err := dial().Do(ctx, Query{
+			Body: "INSERT INTO test_table_columns VALUES",
+			Input: proto.Input{
+				{Name: "id", Data: &id},
+				{Name: "name", Data: &proto.ColStr{}},
+				{Name: "name_len", Data: &nameLen},
+			},
+		})
+		require.ErrorContains(t, err, `can't insert MATERIALIZED column "name_len"`)
+	})
+}
+
+func TestCheckInput(t *testing.T) {
+	columns := []proto.ColumnDescription{
+		{Name: "id", Type: proto.ColumnTypeUInt64},
+		{Name: "name", Type: proto.ColumnTypeString, DefaultKind: proto.ColumnDefault, DefaultExpr: "'anonymous'"},
+		{Name: "name_len", Type: proto.ColumnTypeUInt64, DefaultKind: proto.ColumnMaterialized, DefaultExpr: "length(name)"},
+		{Name: "id_next", Type: proto.ColumnTypeUInt64, DefaultKind: proto.ColumnAlias, DefaultExpr: "id + 1"},
+		{Name: "flags", Type: proto.ColumnTypeUInt8},
+	}
+	var (
+		id   proto.ColUInt64
+		name proto.ColStr
+	)
+	header := proto.ColInfoInput{
+		{Name: "id", Type: proto.ColumnTypeUInt64},
+		{Name: "name", Type: proto.ColumnTypeString},
+		{Name: "flags", Type: proto.ColumnTypeUInt8},
+	}
+	for _, tt := range []struct {
+		Name   string
+		Input  proto.Input
+		Header proto.ColInfoInput
+		Error  string
+	}{
+		{
+			Name:   "All",
+			Input:  proto.Input{{Name: "id", Data: &id}, {Name: "name", Data: &name}},
+			Header: header,
+		},
+		{
+			Name:   "OmittedFromQuery",
+			Input:  proto.Input{{Name: "id", Data: &id}},
+			Header: header[:1],
+		},
+		{
+			Name:   "OmittedFromData",
+			Input:  proto.Input{{Name: "id", Data: &id}},
+			Header: header,
+			Error:  `column "name" with DEFAULT 'anonymous' is missing from input`,
+		},
+		{
+			Name:   "Materialized",
+			Input:  proto.Input{{Name: "id", Data: &id}, {Name: "name_len", Data: &id}},
+			Header: header,
+			Error:  `can't insert MATERIALIZED column "name_len"`,
+		},
+		{
+			Name:   "Alias",
+			Input:  proto.Input{{Name: "id_next", Data: &id}},
+			Header: header[:1],
+			Error:  `can't insert ALIAS column "id_next"`,
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := checkInput(tt.Input, tt.Header, columns)
+			if tt.Error == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.Error)
+			}
+		})
+	}
+	require.NoError(t, checkInput(proto.Input{{Name: "id_next", Data: &id}}, header, nil))
+}
+
 func TestDateTimeOverflow(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -1151,6 +1374,152 @@ func TestClientCompression(t *testing.T) {
 	t.Run("Disabled", testCompression(CompressionDisabled))
 }
 
+// bufferConn is net.Conn that writes to buffer.
+type bufferConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *bufferConn) Write(p []byte) (int, error) { return c.buf.Write(p) }
+
+func TestClient_encodeBlockCompressed(t *testing.T) {
+	ctx := context.Background()
+	conn := new(bufferConn)
+	compressor := compress.NewWriter(compress.LevelZero, compress.LZ4)
+	writer := proto.NewWriter(conn, new(proto.Buffer))
+	frames := compress.NewStreamWriter(writer, compressor, compress.DefaultFrameSize)
+	c := &Client{
+		conn:            conn,
+		writer:          writer,
+		protocolVersion: proto.Version,
+		lg:              zap.NewNop(),
+		compression:     proto.CompressionEnabled,
+		compressor:      compressor,
+		compressed:      proto.NewWriter(frames, new(proto.Buffer)),
+		frames:          frames,
+	}
+	c.compressed.SetFlushSize(compress.DefaultFrameSize)
+
+	var (
+		ids  proto.ColUInt64
+		strs proto.ColStr
+	)
+	for i := 0; i < 300_000; i++ {
+		ids.Append(uint64(i))
+		strs.Append(fmt.Sprintf("row %d", i))
+	}
+	require.NoError(t, c.encodeBlock(ctx, "", proto.Input{
+		{Name: "id", Data: ids},
+		{Name: "s", Data: &strs},
+	}))
+
+	var header proto.Buffer
+	proto.ClientCodeData.Encode(&header)
+	proto.ClientData{}.EncodeAware(&header, proto.Version)
+	require.True(t, bytes.HasPrefix(conn.buf.Bytes(), header.Buf))
+
+	// Checking that block is split into bounded frames.
+	var frameCount int
+	for b := conn.buf.Bytes()[len(header.Buf):]; len(b) > 0; frameCount++ {
+		const (
+			checksumSize = 16
+			rawSize      = checksumSize + 1
+			dataSize     = rawSize + 4
+		)
+		require.LessOrEqual(t, int(binary.LittleEndian.Uint32(b[dataSize:])), compress.DefaultFrameSize)
+		b = b[checksumSize+int(binary.LittleEndian.Uint32(b[rawSize:])):]
+	}
+	require.Greater(t, frameCount, 1)
+
+	r := proto.NewReader(bytes.NewReader(conn.buf.Bytes()[len(header.Buf):]))
+	r.EnableCompression()
+	var (
+		block   proto.Block
+		gotIDs  proto.ColUInt64
+		gotStrs proto.ColStr
+	)
+	require.NoError(t, block.DecodeBlock(r, proto.Version, proto.Results{
+		{Name: "id", Data: &gotIDs},
+		{Name: "s", Data: &gotStrs},
+	}))
+	require.Equal(t, ids, gotIDs)
+	require.Equal(t, strs.Rows(), gotStrs.Rows())
+	require.Equal(t, strs.Row(strs.Rows()-1), gotStrs.Row(gotStrs.Rows()-1))
+}
+
+// notifyConn is bufferConn that notifies about first write.
+type notifyConn struct {
+	bufferConn
+	once    sync.Once
+	written chan struct{}
+}
+
+func (c *notifyConn) Write(p []byte) (int, error) {
+	n, err := c.bufferConn.Write(p)
+	c.once.Do(func() { close(c.written) })
+	return n, err
+}
+
+func TestClient_sendCancelChunked(t *testing.T) {
+	ctx := context.Background()
+	conn := &notifyConn{written: make(chan struct{})}
+	compressor := compress.NewWriter(compress.LevelZero, compress.LZ4)
+	writer := proto.NewWriter(conn, new(proto.Buffer))
+	writer.EnableChunked()
+	frames := compress.NewStreamWriter(writer, compressor, compress.DefaultFrameSize)
+	c := &Client{
+		conn:            conn,
+		writer:          writer,
+		protocolVersion: proto.Version,
+		lg:              zap.NewNop(),
+		compression:     proto.CompressionEnabled,
+		compressor:      compressor,
+		compressed:      proto.NewWriter(frames, new(proto.Buffer)),
+		frames:          frames,
+		sendChunked:     true,
+	}
+	c.compressed.SetFlushSize(compress.DefaultFrameSize)
+
+	var ids proto.ColUInt64
+	for i := 0; i < 1_000_000; i++ {
+		ids.Append(uint64(i))
+	}
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return c.encodeBlock(gCtx, "", proto.Input{{Name: "id", Data: ids}})
+	})
+	g.Go(func() error {
+		// Block is being written.
+		<-conn.written
+		return c.sendCancel(gCtx)
+	})
+	require.NoError(t, g.Wait())
+
+	// Cancel should be sent as separate packet, not in the middle of block.
+	var (
+		packets [][]byte
+		packet  []byte
+	)
+	for b := conn.buf.Bytes(); len(b) > 0; {
+		require.GreaterOrEqual(t, len(b), 4)
+		size := int(binary.LittleEndian.Uint32(b))
+		b = b[4:]
+		if size == 0 {
+			packets = append(packets, packet)
+			packet = nil
+			continue
+		}
+		require.GreaterOrEqual(t, len(b), size)
+		packet = append(packet, b[:size]...)
+		b = b[size:]
+	}
+	require.Nil(t, packet, "unterminated packet")
+	require.Len(t, packets, 2)
+	var cancel proto.Buffer
+	proto.ClientCodeCancel.Encode(&cancel)
+	require.Contains(t, packets, cancel.Buf)
+}
+
 func TestClient_ServerLog(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -17,18 +17,37 @@ import (
 
 // Server is basic ClickHouse server.
 type Server struct {
-	lg    *zap.Logger
-	tz    *time.Location
-	conn  atomic.Uint64
-	ver   int
-	onErr func(err error)
+	lg     *zap.Logger
+	tz     *time.Location
+	conn   atomic.Uint64
+	ver    int
+	onErr  func(err error)
+	status TablesStatusHandler
+
+	chunkedSend proto.Chunking
+	chunkedRecv proto.Chunking
 }
 
+// TablesStatusHandler returns status of tables for TablesStatus request.
+type TablesStatusHandler func(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error)
+
 // ServerOptions wraps possible Server configuration.
 type ServerOptions struct {
 	Logger   *zap.Logger
 	Timezone *time.Location
 	OnError  func(err error)
+
+	// TablesStatus handles TablesStatus requests, optional.
+	//
+	// By default, no tables are reported.
+	TablesStatus TablesStatusHandler
+
+	// ChunkedSend and ChunkedRecv are chunked framing capabilities of
+	// packets sent and received by server.
+	//
+	// Default is proto.ChunkingNotChunked.
+	ChunkedSend proto.Chunking
+	ChunkedRecv proto.Chunking
 }
 
 // NewServer returns new ClickHouse Server.
@@ -42,11 +61,26 @@ func NewServer(opt ServerOptions) *Server {
 	if opt.OnError == nil {
 		opt.OnError = func(err error) {}
 	}
+	if opt.TablesStatus == nil {
+		opt.TablesStatus = func(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+			return nil, nil
+		}
+	}
+	if opt.ChunkedSend == "" {
+		opt.ChunkedSend = proto.ChunkingNotChunked
+	}
+	if opt.ChunkedRecv == "" {
+		opt.ChunkedRecv = proto.ChunkingNotChunked
+	}
 	return &Server{
-		lg:    opt.Logger,
-		tz:    opt.Timezone,
-		ver:   proto.Version,
-		onErr: opt.OnError,
+		lg:     opt.Logger,
+		tz:     opt.Timezone,
+		ver:    proto.Version,
+		onErr:  opt.OnError,
+		status: opt.TablesStatus,
+
+		chunkedSend: opt.ChunkedSend,
+		chunkedRecv: opt.ChunkedRecv,
 	}
 }
 
@@ -60,6 +94,10 @@ type ServerConn struct {
 	client proto.ClientHello
 	info   proto.ServerHello
 	ver    int
+	status TablesStatusHandler
+
+	// sendChunked is true if packets are sent in chunked framing.
+	sendChunked bool
 
 	// compressor performs block compression,
 	// see encodeBlock.
@@ -99,11 +137,33 @@ func (c *ServerConn) handshake() error {
 	if err := c.client.Decode(c.reader); err != nil {
 		return errors.Wrap(err, "decode hello")
 	}
-	c.ver = c.client.ProtocolVersion
+	c.ver = min(c.client.ProtocolVersion, c.info.Revision)
 	c.info.EncodeAware(c.buf, c.ver)
 	if err := c.flush(); err != nil {
 		return errors.Wrap(err, "flush")
 	}
+	if proto.FeatureAddendum.In(c.ver) {
+		var a proto.Addendum
+		if err := a.DecodeAware(c.reader, c.ver); err != nil {
+			return errors.Wrap(err, "addendum")
+		}
+		if proto.FeatureChunkedPackets.In(c.ver) {
+			// Client sends resolved chunking, checking that it is
+			// compatible with server capabilities.
+			recv, err := c.info.ChunkedRecv.Negotiate(a.ChunkedSend)
+			if err != nil {
+				return errors.Wrap(err, "negotiate chunked recv")
+			}
+			send, err := c.info.ChunkedSend.Negotiate(a.ChunkedRecv)
+			if err != nil {
+				return errors.Wrap(err, "negotiate chunked send")
+			}
+			if recv {
+				c.reader.EnableChunked()
+			}
+			c.sendChunked = send
+		}
+	}
 
 	_ = c.compressor // hack
 	_ = c.settings   // hack
@@ -111,12 +171,24 @@ func (c *ServerConn) handshake() error {
 	return nil
 }
 
+// flush writes c.buf to connection as single packet.
+//
+// With chunked framing whole c.buf is framed as one packet, so c.buf must
+// contain exactly one packet, e.g. Data and EndOfStream packets should be
+// flushed separately.
 func (c *ServerConn) flush() error {
-	n, err := c.conn.Write(c.buf.Buf)
+	data := c.buf.Buf
+	if c.sendChunked {
+		var framed proto.Buffer
+		framed.PutChunk(data)
+		framed.PutChunkEnd()
+		data = framed.Buf
+	}
+	n, err := c.conn.Write(data)
 	if err != nil {
 		return errors.Wrap(err, "write")
 	}
-	if n != len(c.buf.Buf) {
+	if n != len(data) {
 		return errors.Wrap(io.ErrShortWrite, "wrote less than expected")
 	}
 	if ce := c.lg.Check(zap.DebugLevel, "Flush"); ce != nil {
@@ -132,6 +204,8 @@ func (c *ServerConn) handlePacket(p proto.ClientCode) error {
 		return c.handlePing()
 	case proto.ClientCodeQuery:
 		return c.handleQuery()
+	case proto.ClientTablesStatusRequest:
+		return c.handleTablesStatus()
 	default:
 		return errors.Errorf("%q not implemented", p)
 	}
@@ -142,6 +216,29 @@ func (c *ServerConn) handlePing() error {
 	return c.flush()
 }
 
+func (c *ServerConn) handleTablesStatus() error {
+	var req proto.TablesStatusRequest
+	if err := req.DecodeAware(c.reader, c.ver); err != nil {
+		return errors.Wrap(err, "decode")
+	}
+	tables, err := c.status(context.Background(), req.Tables)
+	if err != nil {
+		e := proto.Exception{
+			Code:    proto.ErrUnknownException,
+			Name:    "DB::Exception",
+			Message: err.Error(),
+		}
+		if exc, ok := AsException(err); ok {
+			e.Code, e.Name, e.Message = exc.Code, exc.Name, exc.Message
+		}
+		proto.ServerCodeException.Encode(c.buf)
+		e.EncodeAware(c.buf, c.ver)
+		return c.flush()
+	}
+	proto.TablesStatusResponse{Tables: tables}.EncodeAware(c.buf, c.ver)
+	return c.flush()
+}
+
 func (c *ServerConn) handleClientData(ctx context.Context, q proto.Query) error {
 	var data proto.ClientData
 	if err := data.DecodeAware(c.reader, c.ver); err != nil {
@@ -245,12 +342,15 @@ func (s *Server) handle(conn net.Conn) error {
 		lg:     lg,
 		conn:   conn,
 		ver:    s.ver,
+		status: s.status,
 		buf:    new(proto.Buffer),
 		reader: proto.NewReader(conn),
 		client: proto.ClientHello{},
 		info: proto.ServerHello{
-			Name:     "CH",
-			Revision: s.ver,
+			Name:        "CH",
+			Revision:    s.ver,
+			ChunkedSend: s.chunkedSend,
+			ChunkedRecv: s.chunkedRecv,
 		},
 		tz:         time.UTC,
 		compressor: compress.NewWriter(compress.LevelZero, compress.None),
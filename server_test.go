@@ -1,8 +1,10 @@
 package ch
 
 import (
+	"bytes"
 	"context"
 	"net"
+	"sync"
 	"testing"
 
 	"github.com/go-faster/errors"
@@ -11,7 +13,9 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/ClickHouse/ch-go/cht"
+	"github.com/ClickHouse/ch-go/internal/gold"
 	"github.com/ClickHouse/ch-go/internal/ztest"
+	"github.com/ClickHouse/ch-go/proto"
 )
 
 func TestServer_Serve(t *testing.T) {
@@ -65,3 +69,129 @@ func TestServer_Serve(t *testing.T) {
 	})
 	require.NoError(t, g.Wait())
 }
+
+// recordConn records data written to and read from connection.
+type recordConn struct {
+	net.Conn
+
+	mux   sync.Mutex
+	read  bytes.Buffer
+	wrote bytes.Buffer
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.mux.Lock()
+	c.read.Write(p[:n])
+	c.mux.Unlock()
+	return n, err
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.mux.Lock()
+	c.wrote.Write(p[:n])
+	c.mux.Unlock()
+	return n, err
+}
+
+func (c *recordConn) Reset() {
+	c.mux.Lock()
+	c.read.Reset()
+	c.wrote.Reset()
+	c.mux.Unlock()
+}
+
+type recordDialer struct {
+	conn *recordConn
+}
+
+func (d *recordDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var nd net.Dialer
+	conn, err := nd.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	d.conn = &recordConn{Conn: conn}
+	return d.conn, nil
+}
+
+func TestServer_Chunked(t *testing.T) {
+	for _, tt := range []struct {
+		Name   string
+		Client [2]proto.Chunking // send, recv
+		Server [2]proto.Chunking // send, recv
+		Error  bool
+	}{
+		{
+			Name: "Default",
+		},
+		{
+			Name:   "Chunked",
+			Client: [2]proto.Chunking{proto.ChunkingChunkedOptional, proto.ChunkingChunkedOptional},
+			Server: [2]proto.Chunking{proto.ChunkingChunked, proto.ChunkingChunked},
+		},
+		{
+			Name:   "ClientSend",
+			Client: [2]proto.Chunking{proto.ChunkingChunked, proto.ChunkingNotChunked},
+			Server: [2]proto.Chunking{proto.ChunkingNotChunked, proto.ChunkingChunkedOptional},
+		},
+		{
+			Name:   "ServerSend",
+			Client: [2]proto.Chunking{proto.ChunkingNotChunkedOptional, proto.ChunkingChunkedOptional},
+			Server: [2]proto.Chunking{proto.ChunkingChunkedOptional, proto.ChunkingNotChunkedOptional},
+		},
+		{
+			Name:   "Incompatible",
+			Client: [2]proto.Chunking{proto.ChunkingChunked, proto.ChunkingNotChunked},
+			Server: [2]proto.Chunking{proto.ChunkingNotChunked, proto.ChunkingNotChunked},
+			Error:  true,
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = ln.Close() })
+
+			lg := ztest.NewLogger(t)
+			s := NewServer(ServerOptions{
+				Logger:      lg.Named("srv"),
+				ChunkedSend: tt.Server[0],
+				ChunkedRecv: tt.Server[1],
+				TablesStatus: func(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+					return []proto.TableStatus{{Table: tables[0], Replicated: true, AbsoluteDelay: 1}}, nil
+				},
+			})
+			go func() { _ = s.Serve(ln) }()
+
+			d := &recordDialer{}
+			c, err := Dial(ctx, Options{
+				Logger:      lg.Named("usr"),
+				Address:     ln.Addr().String(),
+				Dialer:      d,
+				ChunkedSend: tt.Client[0],
+				ChunkedRecv: tt.Client[1],
+			})
+			if tt.Error {
+				require.ErrorContains(t, err, "incompatible chunking")
+				return
+			}
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = c.Close() })
+
+			d.conn.Reset()
+			require.NoError(t, c.Ping(ctx))
+			status, err := c.TablesStatus(ctx, []proto.TableName{{Database: "default", Table: "t"}})
+			require.NoError(t, err)
+			require.Equal(t, []proto.TableStatus{
+				{Table: proto.TableName{Database: "default", Table: "t"}, Replicated: true, AbsoluteDelay: 1},
+			}, status)
+			gold.Bytes(t, d.conn.wrote.Bytes(), "chunked_"+tt.Name+"_client")
+			gold.Bytes(t, d.conn.read.Bytes(), "chunked_"+tt.Name+"_server")
+
+			require.NoError(t, c.Do(ctx, Query{Body: "HELLO"}))
+			require.NoError(t, c.Ping(ctx))
+		})
+	}
+}
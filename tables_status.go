@@ -0,0 +1,66 @@
+package ch
+
+import (
+	"context"
+
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ClickHouse/ch-go/otelch"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// TablesStatus requests status of tables on server, like Distributed
+// tables do to check replication delay of replica.
+//
+// Tables that are not found on server are omitted from result.
+//
+// Do not call concurrently with Do.
+func (c *Client) TablesStatus(ctx context.Context, tables []proto.TableName) (_ []proto.TableStatus, err error) {
+	if c.IsClosed() {
+		return nil, ErrClosed
+	}
+	if c.otel {
+		newCtx, span := c.tracer.Start(ctx, "TablesStatus",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				otelch.ProtocolVersion(c.protocolVersion),
+			),
+		)
+		ctx = newCtx
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "Failed")
+			} else {
+				span.SetStatus(codes.Ok, "")
+			}
+			span.End()
+		}()
+	}
+	c.encode(proto.TablesStatusRequest{Tables: tables})
+	if err := c.flush(ctx); err != nil {
+		return nil, errors.Wrap(err, "flush")
+	}
+	p, err := c.packet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+	switch p {
+	case proto.ServerCodeTablesStatus:
+		var resp proto.TablesStatusResponse
+		if err := c.decode(&resp); err != nil {
+			return nil, errors.Wrap(err, "decode")
+		}
+		return resp.Tables, nil
+	case proto.ServerCodeException:
+		e, err := c.exception()
+		if err != nil {
+			return nil, errors.Wrap(err, "decode exception")
+		}
+		return nil, errors.Wrap(e, "exception")
+	default:
+		return nil, errors.Errorf("unexpected packet %s", p)
+	}
+}
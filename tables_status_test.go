@@ -0,0 +1,68 @@
+package ch
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClickHouse/ch-go/internal/ztest"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+func TestClient_TablesStatus(t *testing.T) {
+	ctx := context.Background()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	lg := ztest.NewLogger(t)
+	s := NewServer(ServerOptions{
+		Logger: lg.Named("srv"),
+		TablesStatus: func(ctx context.Context, tables []proto.TableName) ([]proto.TableStatus, error) {
+			var result []proto.TableStatus
+			for _, table := range tables {
+				switch table.Table {
+				case "replicated":
+					result = append(result, proto.TableStatus{Table: table, Replicated: true, AbsoluteDelay: 10})
+				case "local":
+					result = append(result, proto.TableStatus{Table: table})
+				case "error":
+					return nil, &Exception{Code: proto.ErrTableIsReadOnly, Name: "DB::Exception", Message: "read only"}
+				}
+			}
+			return result, nil
+		},
+	})
+	go func() { _ = s.Serve(ln) }()
+
+	c, err := Dial(ctx, Options{
+		Logger:  lg.Named("usr"),
+		Address: ln.Addr().String(),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	status, err := c.TablesStatus(ctx, []proto.TableName{
+		{Database: "default", Table: "replicated"},
+		{Database: "default", Table: "missing"},
+		{Database: "default", Table: "local"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []proto.TableStatus{
+		{Table: proto.TableName{Database: "default", Table: "replicated"}, Replicated: true, AbsoluteDelay: 10},
+		{Table: proto.TableName{Database: "default", Table: "local"}},
+	}, status)
+
+	_, err = c.TablesStatus(ctx, []proto.TableName{{Database: "default", Table: "error"}})
+	require.True(t, IsErr(err, proto.ErrTableIsReadOnly))
+
+	// Connection is usable after exception.
+	require.NoError(t, c.Ping(ctx))
+
+	var e *Exception
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, "read only", e.Message)
+}